@@ -0,0 +1,97 @@
+package eventhub
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+	"pack.ag/amqp"
+)
+
+const (
+	// reservedAnnotationPrefix prefixes the message annotations reserved for use by the broker and this library, such
+	// as x-opt-partition-key
+	reservedAnnotationPrefix = "x-opt-"
+)
+
+// SetAnnotation sets a message annotation to be sent with the event. Strings, integers and time.Time values are
+// preserved through sending and receiving, though integers are received as int64. Annotations prefixed with x-opt-
+// are reserved by Event Hubs and return an error.
+func (e *Event) SetAnnotation(key string, val interface{}) error {
+	if isReservedAnnotation(key) {
+		return errors.Errorf("annotation %q is reserved", key)
+	}
+
+	if e.annotations == nil {
+		e.annotations = make(map[string]interface{})
+	}
+	e.annotations[key] = val
+	return nil
+}
+
+// Annotations returns the message annotations of the event other than those reserved by Event Hubs, which are
+// available through SystemProperties and the other fields of the event. For a received event, these are the
+// annotations set by the producer.
+func (e *Event) Annotations() map[string]interface{} {
+	annotations := make(map[string]interface{}, len(e.annotations))
+	for key, val := range e.annotations {
+		annotations[key] = val
+	}
+	return annotations
+}
+
+// applyAnnotations writes the annotations set on the event to the message
+func (e *Event) applyAnnotations(msg *amqp.Message) {
+	if len(e.annotations) == 0 {
+		return
+	}
+
+	if msg.Annotations == nil {
+		msg.Annotations = make(amqp.Annotations)
+	}
+	for key, val := range e.annotations {
+		msg.Annotations[key] = val
+	}
+}
+
+// annotationsFromMsg reads the annotations which are not reserved by Event Hubs from the message
+func annotationsFromMsg(msg *amqp.Message) map[string]interface{} {
+	var annotations map[string]interface{}
+	for key, val := range msg.Annotations {
+		name, ok := key.(string)
+		if !ok || isReservedAnnotation(name) {
+			continue
+		}
+
+		if annotations == nil {
+			annotations = make(map[string]interface{})
+		}
+		annotations[name] = val
+	}
+	return annotations
+}
+
+func isReservedAnnotation(key string) bool {
+	return strings.HasPrefix(strings.ToLower(key), reservedAnnotationPrefix)
+}
@@ -0,0 +1,66 @@
+package eventhub
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"pack.ag/amqp"
+)
+
+func TestEventSetAnnotation(t *testing.T) {
+	event := NewEventFromString("foo")
+	assert.Error(t, event.SetAnnotation("x-opt-partition-key", "bar"))
+	assert.Error(t, event.SetAnnotation("X-OPT-Offset", "1"))
+	assert.Empty(t, event.Annotations())
+
+	enqueued := time.Date(2018, 5, 1, 12, 0, 0, 0, time.UTC)
+	assert.NoError(t, event.SetAnnotation("x-route", "north"))
+	assert.NoError(t, event.SetAnnotation("x-hops", int64(3)))
+	assert.NoError(t, event.SetAnnotation("x-produced", enqueued))
+
+	msg := event.toMsg()
+	assert.Equal(t, "north", msg.Annotations["x-route"])
+	assert.Equal(t, int64(3), msg.Annotations["x-hops"])
+	assert.Equal(t, enqueued, msg.Annotations["x-produced"])
+
+	annotations := event.Annotations()
+	annotations["x-route"] = "south"
+	assert.Equal(t, "north", event.Annotations()["x-route"], "the returned annotations should be a copy")
+}
+
+func TestReceivedEventAnnotations(t *testing.T) {
+	msg := amqp.NewMessage([]byte("foo"))
+	msg.Annotations = amqp.Annotations{
+		"x-route":                  "north",
+		"x-hops":                   int64(3),
+		sequenceNumberName:         int64(42),
+		partitionKeyAnnotationName: "key",
+		uint64(7):                  "numeric keys are not exposed",
+	}
+
+	event := eventFromMsg(msg)
+	assert.Equal(t, map[string]interface{}{"x-route": "north", "x-hops": int64(3)}, event.Annotations())
+}
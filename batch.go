@@ -0,0 +1,211 @@
+package eventhub
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"context"
+	"fmt"
+)
+
+const (
+	// DefaultMaxMessageSize is the largest message, in bytes, accepted by the Basic and Standard tiers of Event Hubs
+	DefaultMaxMessageSize = 1024 * 1024
+
+	// dataSectionOverhead is the encoded size of the descriptor and 32 bit length prefix of a data section holding an
+	// event within a batch
+	dataSectionOverhead = 8
+
+	// batchEnvelopeReserve is reserved in each batch for the message ID, annotations and the properties added upon
+	// send such as tracing headers
+	batchEnvelopeReserve = 512
+)
+
+type (
+	// BatchOption provides a way to configure an EventBatch as a whole when sending it with SendBatchWithOptions
+	BatchOption func(batch *EventBatch) error
+)
+
+// BatchWithPartitionKey sets the partition key of the batch, which is sent once as the x-opt-partition-key annotation
+// of the batch message so the broker routes every event of the batch to the same partition
+func BatchWithPartitionKey(partitionKey string) BatchOption {
+	return func(batch *EventBatch) error {
+		batch.PartitionKey = &partitionKey
+		return nil
+	}
+}
+
+// SendBatchWithOptions sends an EventBatch to the Event Hub after applying the batch options. The broker routes a
+// batch by its partition key alone, so an error is returned if any event of the batch has a partition key other than
+// the one of the batch.
+func (h *Hub) SendBatchWithOptions(ctx context.Context, batch *EventBatch, opts ...BatchOption) error {
+	span, ctx := h.startSpanFromContext(ctx, "eventhub.Hub.SendBatchWithOptions")
+	defer span.Finish()
+
+	for _, opt := range opts {
+		if err := opt(batch); err != nil {
+			return err
+		}
+	}
+
+	if err := batch.validatePartitionKeys(); err != nil {
+		return err
+	}
+	return h.SendBatch(ctx, batch)
+}
+
+// validatePartitionKeys returns an error if an event of the batch has a partition key which conflicts with the
+// partition key of the batch
+func (b *EventBatch) validatePartitionKeys() error {
+	for idx, event := range b.Events {
+		if event.PartitionKey == nil || samePartitionKey(event.PartitionKey, b.PartitionKey) {
+			continue
+		}
+		if b.PartitionKey == nil {
+			return fmt.Errorf("event %d has partition key %q, but the batch has no partition key", idx, *event.PartitionKey)
+		}
+		return fmt.Errorf("event %d has partition key %q, which conflicts with partition key %q of the batch", idx, *event.PartitionKey, *b.PartitionKey)
+	}
+	return nil
+}
+
+// SendBatchChunked sends a slice of events to the Event Hub as one or more batches. Events are split into batches
+// which fit within the max message size negotiated on the sender link, opening the link if it is not open, and events
+// with differing partition keys are never placed in the same batch. Batches are sent in order and the first error encountered stops sending.
+//
+// If a single event can never fit within the max message size, an error identifying the event is returned before
+// anything is sent.
+func (h *Hub) SendBatchChunked(ctx context.Context, events []*Event, opts ...SendOption) error {
+	span, ctx := h.startSpanFromContext(ctx, "eventhub.Hub.SendBatchChunked")
+	defer span.Finish()
+
+	maxSize, err := h.sendMaxMessageSize(ctx)
+	if err != nil {
+		return err
+	}
+
+	batches, err := splitEvents(events, int(maxSize))
+	if err != nil {
+		return err
+	}
+
+	for _, batch := range batches {
+		if err := h.SendBatch(ctx, batch, opts...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MaxMessageSize returns the largest message, in bytes, the Hub sends, opening the sender link if it is not open. It is
+// the max message size negotiated on the sender link, so it reflects the tier of the namespace and any size configured
+// with HubWithMaxMessageSize.
+func (h *Hub) MaxMessageSize(ctx context.Context) (uint64, error) {
+	span, ctx := h.startSpanFromContext(ctx, "eventhub.Hub.MaxMessageSize")
+	defer span.Finish()
+
+	return h.sendMaxMessageSize(ctx)
+}
+
+// sendMaxMessageSize returns the max message size of the sender link, opening the link if it is not open
+func (h *Hub) sendMaxMessageSize(ctx context.Context) (uint64, error) {
+	sender, err := h.getSender(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return h.maxMessageSizeOf(sender), nil
+}
+
+// maxMessageSizeOf returns the max message size negotiated on the sender link. If the sender is not open or the broker
+// set no limit, it is the size configured with HubWithMaxMessageSize, or DefaultMaxMessageSize.
+func (h *Hub) maxMessageSizeOf(sender *sender) uint64 {
+	if sender != nil {
+		if size := sender.maxMessageSize(); size > 0 {
+			return size
+		}
+	}
+	if h.maxMessageSize > 0 {
+		return h.maxMessageSize
+	}
+	return DefaultMaxMessageSize
+}
+
+// splitEvents groups consecutive events sharing a partition key into batches whose encoded size does not exceed
+// maxSize
+func splitEvents(events []*Event, maxSize int) ([]*EventBatch, error) {
+	var batches []*EventBatch
+	var current *EventBatch
+	var currentSize int
+
+	for idx, event := range events {
+		bin, err := encodeBatchItem(event)
+		if err != nil {
+			return nil, err
+		}
+
+		envelope := batchEnvelopeReserve + len(partitionKeyValue(event.PartitionKey))
+		eventSize := len(bin) + dataSectionOverhead
+		if envelope+eventSize > maxSize {
+			return nil, fmt.Errorf("event at index %d with ID %q is %d bytes and will never fit within the max message size of %d bytes", idx, event.ID, eventSize, maxSize)
+		}
+
+		if current == nil || !samePartitionKey(current.PartitionKey, event.PartitionKey) || currentSize+eventSize > maxSize {
+			current = &EventBatch{PartitionKey: event.PartitionKey}
+			currentSize = envelope
+			batches = append(batches, current)
+		}
+
+		current.Events = append(current.Events, event)
+		currentSize += eventSize
+	}
+	return batches, nil
+}
+
+// encodeBatchItem encodes an event, including its application properties, as it will be represented within the data
+// section of a batch
+func encodeBatchItem(event *Event) ([]byte, error) {
+	msg := event.newMessage()
+	if len(event.Properties) > 0 {
+		msg.ApplicationProperties = make(map[string]interface{}, len(event.Properties))
+		for key, value := range event.Properties {
+			msg.ApplicationProperties[key] = value
+		}
+	}
+	event.applyAnnotations(msg)
+	event.applyTimeToLive(msg)
+	event.applyProperties(msg)
+	return msg.MarshalBinary()
+}
+
+func samePartitionKey(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func partitionKeyValue(key *string) string {
+	if key == nil {
+		return ""
+	}
+	return *key
+}
@@ -0,0 +1,133 @@
+package eventhub
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitEventsBySize(t *testing.T) {
+	data := bytes.Repeat([]byte("a"), 1000)
+	var events []*Event
+	for i := 0; i < 10; i++ {
+		events = append(events, NewEvent(data))
+	}
+
+	batches, err := splitEvents(events, batchEnvelopeReserve+3*1100)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var count int
+	for _, batch := range batches {
+		assert.True(t, len(batch.Events) <= 3)
+		count += len(batch.Events)
+	}
+	assert.Equal(t, 10, count)
+	assert.Len(t, batches, 4)
+	assert.Equal(t, events[0], batches[0].Events[0])
+	assert.Equal(t, events[9], batches[3].Events[0])
+}
+
+func TestSplitEventsByPartitionKey(t *testing.T) {
+	one, two := "one", "two"
+	events := []*Event{
+		{Data: []byte("1"), PartitionKey: &one},
+		{Data: []byte("2"), PartitionKey: &one},
+		{Data: []byte("3"), PartitionKey: &two},
+		{Data: []byte("4")},
+	}
+
+	batches, err := splitEvents(events, DefaultMaxMessageSize)
+	if !assert.NoError(t, err) {
+		return
+	}
+	if assert.Len(t, batches, 3) {
+		assert.Len(t, batches[0].Events, 2)
+		assert.Equal(t, "one", *batches[0].PartitionKey)
+		assert.Equal(t, "two", *batches[1].PartitionKey)
+		assert.Nil(t, batches[2].PartitionKey)
+	}
+}
+
+func TestSplitEventsTooLarge(t *testing.T) {
+	events := []*Event{
+		NewEventFromString("small"),
+		{Data: bytes.Repeat([]byte("a"), 2048), ID: "big"},
+	}
+
+	_, err := splitEvents(events, 1024)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), `index 1 with ID "big"`)
+}
+
+func TestBatchWithPartitionKey(t *testing.T) {
+	key, other := "key", "other"
+	keyed := NewEventFromString("keyed")
+	keyed.PartitionKey = &key
+	batch := NewEventBatch([]*Event{NewEventFromString("unkeyed"), keyed})
+
+	assert.Error(t, batch.validatePartitionKeys(), "an event key should conflict with a batch without a key")
+
+	assert.NoError(t, BatchWithPartitionKey(key)(batch))
+	assert.NoError(t, batch.validatePartitionKeys())
+	event, err := batch.toEvent()
+	if assert.NoError(t, err) {
+		assert.Equal(t, key, event.toMsg().Annotations[partitionKeyAnnotationName])
+	}
+
+	assert.NoError(t, BatchWithPartitionKey(other)(batch))
+	assert.Error(t, batch.validatePartitionKeys(), "an event key should conflict with a different batch key")
+}
+
+func TestMaxMessageSizeOf(t *testing.T) {
+	hub, err := NewHub("namespace", "hub", nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, uint64(DefaultMaxMessageSize), hub.maxMessageSizeOf(nil), "without a sender the default size should be used")
+	assert.Equal(t, uint64(DefaultMaxMessageSize), hub.maxMessageSizeOf(&sender{hub: hub}), "a link without a limit should use the default size")
+
+	negotiated := &sender{hub: hub, sender: newNegotiatedSender(t, 100*DefaultMaxMessageSize)}
+	assert.Equal(t, uint64(100*DefaultMaxMessageSize), hub.maxMessageSizeOf(negotiated), "the negotiated size should be used")
+
+	assert.NoError(t, HubWithMaxMessageSize(64*1024)(hub))
+	assert.Equal(t, uint64(64*1024), hub.maxMessageSizeOf(nil), "without a sender the configured size should be used")
+}
+
+func TestMaxMessageSize(t *testing.T) {
+	hub, err := NewHub("namespace", "hub", nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// an open sender link is reused rather than opened
+	hub.sender = &sender{hub: hub, sender: newNegotiatedSender(t, 100*DefaultMaxMessageSize)}
+	size, err := hub.MaxMessageSize(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(100*DefaultMaxMessageSize), size, "the size negotiated on the link should be returned")
+}
@@ -0,0 +1,123 @@
+package eventhub
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"fmt"
+
+	"pack.ag/amqp"
+)
+
+// BatchBuilder builds an EventBatch one event at a time, tracking the encoded size of the batch so that the batch never
+// exceeds the max message size of the Hub
+type BatchBuilder struct {
+	batch       *EventBatch
+	maxSize     int
+	currentSize int
+}
+
+// NewBatchBuilder returns a BatchBuilder for batches sized to the max message size negotiated on the sender link. If
+// the sender link is not open yet, batches are sized to the size configured with HubWithMaxMessageSize, or
+// DefaultMaxMessageSize. Batch options, such as BatchWithPartitionKey, are applied to the batch before any event is
+// added so their size is accounted for.
+func (h *Hub) NewBatchBuilder(opts ...BatchOption) (*BatchBuilder, error) {
+	h.senderMu.Lock()
+	sender := h.sender
+	h.senderMu.Unlock()
+	return newBatchBuilder(int(h.maxMessageSizeOf(sender)), opts...)
+}
+
+func newBatchBuilder(maxSize int, opts ...BatchOption) (*BatchBuilder, error) {
+	batch := new(EventBatch)
+	for _, opt := range opts {
+		if err := opt(batch); err != nil {
+			return nil, err
+		}
+	}
+
+	envelope := batchEnvelopeReserve + len(partitionKeyValue(batch.PartitionKey))
+	if len(batch.Properties) > 0 {
+		msg := amqp.NewMessage(nil)
+		msg.ApplicationProperties = batch.Properties
+		bin, err := msg.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		envelope += len(bin)
+	}
+
+	if envelope > maxSize {
+		return nil, fmt.Errorf("the batch envelope is %d bytes and exceeds the max message size of %d bytes", envelope, maxSize)
+	}
+
+	return &BatchBuilder{
+		batch:       batch,
+		maxSize:     maxSize,
+		currentSize: envelope,
+	}, nil
+}
+
+// TryAdd adds the event to the batch, returning false without adding it if the batch would then exceed the max
+// message size. An event which can't be encoded, or whose partition key differs from that of the batch, is never
+// added.
+func (b *BatchBuilder) TryAdd(event *Event) bool {
+	if event.PartitionKey != nil && !samePartitionKey(event.PartitionKey, b.batch.PartitionKey) {
+		return false
+	}
+
+	bin, err := encodeBatchItem(event)
+	if err != nil {
+		return false
+	}
+
+	eventSize := len(bin) + dataSectionOverhead
+	if b.currentSize+eventSize > b.maxSize {
+		return false
+	}
+
+	b.batch.Events = append(b.batch.Events, event)
+	b.currentSize += eventSize
+	return true
+}
+
+// CurrentSize returns the encoded size, in bytes, of the batch including its envelope
+func (b *BatchBuilder) CurrentSize() int {
+	return b.currentSize
+}
+
+// Count returns the number of events added to the batch
+func (b *BatchBuilder) Count() int {
+	return len(b.batch.Events)
+}
+
+// Build returns the batch of the events added so far
+func (b *BatchBuilder) Build() *EventBatch {
+	events := make([]*Event, len(b.batch.Events))
+	copy(events, b.batch.Events)
+	return &EventBatch{
+		Events:       events,
+		PartitionKey: b.batch.PartitionKey,
+		Properties:   b.batch.Properties,
+		ID:           b.batch.ID,
+	}
+}
@@ -0,0 +1,77 @@
+package eventhub
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBatchBuilderTracksSize(t *testing.T) {
+	data := bytes.Repeat([]byte("a"), 1000)
+	builder, err := newBatchBuilder(batchEnvelopeReserve + 3*1100)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, batchEnvelopeReserve, builder.CurrentSize())
+
+	for i := 0; i < 3; i++ {
+		assert.True(t, builder.TryAdd(NewEvent(data)))
+	}
+	assert.False(t, builder.TryAdd(NewEvent(data)), "the fourth event should exceed the max size")
+	assert.Equal(t, 3, builder.Count())
+	assert.True(t, builder.CurrentSize() <= batchEnvelopeReserve+3*1100)
+
+	batch := builder.Build()
+	assert.Len(t, batch.Events, 3)
+	batches, err := splitEvents(batch.Events, batchEnvelopeReserve+3*1100)
+	if assert.NoError(t, err) {
+		assert.Len(t, batches, 1, "a built batch should fit within one batch of the same max size")
+	}
+}
+
+func TestBatchBuilderPartitionKey(t *testing.T) {
+	one, two := "one", "two"
+	builder, err := newBatchBuilder(DefaultMaxMessageSize, BatchWithPartitionKey(one))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, batchEnvelopeReserve+len(one), builder.CurrentSize())
+
+	assert.True(t, builder.TryAdd(NewEventFromString("no key")))
+	assert.True(t, builder.TryAdd(&Event{Data: []byte("same key"), PartitionKey: &one}))
+	assert.False(t, builder.TryAdd(&Event{Data: []byte("other key"), PartitionKey: &two}))
+
+	batch := builder.Build()
+	assert.Len(t, batch.Events, 2)
+	if assert.NotNil(t, batch.PartitionKey) {
+		assert.Equal(t, one, *batch.PartitionKey)
+	}
+}
+
+func TestBatchBuilderEnvelopeTooLarge(t *testing.T) {
+	_, err := newBatchBuilder(batchEnvelopeReserve - 1)
+	assert.Error(t, err)
+}
@@ -0,0 +1,53 @@
+package eventhub
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSenderDrainWaitsForSendsInFlight(t *testing.T) {
+	s := new(sender)
+	assert.NoError(t, s.startSend())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, s.drain(ctx), "drain should wait for the send in flight")
+	assert.Error(t, s.startSend(), "no sends should start once the sender is closing")
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		s.endSend()
+	}()
+	assert.NoError(t, s.drain(context.Background()))
+}
+
+func TestHubCloseSenderAndReceiverWithoutLinks(t *testing.T) {
+	hub := &Hub{receivers: make(map[string]*receiver)}
+	assert.NoError(t, hub.CloseSender(context.Background()))
+	assert.NoError(t, hub.CloseReceiver(context.Background(), "0"))
+}
@@ -0,0 +1,207 @@
+package eventhub
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// CompressionPropertyName is the application property which names the codec used to compress the event data
+	CompressionPropertyName = "x-compression"
+)
+
+type (
+	// Codec compresses and decompresses event data. The name of the codec is set as the x-compression application
+	// property on sent events and is used to select the codec when decompressing received events.
+	Codec interface {
+		Name() string
+		Compress(data []byte) ([]byte, error)
+		Decompress(data []byte) ([]byte, error)
+	}
+
+	// GzipCodec compresses event data with gzip
+	GzipCodec struct{}
+
+	// DeflateCodec compresses event data with deflate
+	DeflateCodec struct{}
+)
+
+// HubWithPayloadCompression configures the Hub to compress the data of sent events with the codec and set the
+// x-compression application property to the name of the codec. Received events carrying the x-compression property
+// are decompressed with the configured codec, or the gzip and deflate codecs, while events without the property are
+// passed through untouched.
+func HubWithPayloadCompression(codec Codec) HubOption {
+	return func(h *Hub) error {
+		if codec == nil {
+			return errors.New("codec must not be nil")
+		}
+		h.compressionCodec = codec
+		return nil
+	}
+}
+
+// Name returns "gzip"
+func (GzipCodec) Name() string {
+	return "gzip"
+}
+
+// Compress compresses the data with gzip
+func (GzipCodec) Compress(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Decompress decompresses gzip compressed data
+func (GzipCodec) Decompress(data []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	return readAllAndClose(r)
+}
+
+// Name returns "deflate"
+func (DeflateCodec) Name() string {
+	return "deflate"
+}
+
+// Compress compresses the data with deflate
+func (DeflateCodec) Compress(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w, err := flate.NewWriter(&buf, flate.DefaultCompression)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Decompress decompresses deflate compressed data
+func (DeflateCodec) Decompress(data []byte) ([]byte, error) {
+	return readAllAndClose(flate.NewReader(bytes.NewReader(data)))
+}
+
+// compressEvent returns a copy of the event with compressed data if the Hub has been configured with a codec. Events
+// with an amqp-value body are sent uncompressed.
+func (h *Hub) compressEvent(event *Event) (*Event, error) {
+	if h.compressionCodec == nil || event.value != nil {
+		return event, nil
+	}
+
+	data, err := h.compressionCodec.Compress(event.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	compressed := *event
+	compressed.Data = data
+	// a received event holds the message it arrived in, which would be sent in place of the compressed data and
+	// modified while being sent
+	compressed.message = nil
+	compressed.Properties = make(map[string]interface{}, len(event.Properties)+1)
+	for key, value := range event.Properties {
+		compressed.Properties[key] = value
+	}
+	compressed.Properties[CompressionPropertyName] = h.compressionCodec.Name()
+	return &compressed, nil
+}
+
+// compressBatch returns a copy of the batch with each event compressed if the Hub has been configured with a codec
+func (h *Hub) compressBatch(batch *EventBatch) (*EventBatch, error) {
+	if h.compressionCodec == nil {
+		return batch, nil
+	}
+
+	compressed := *batch
+	compressed.Events = make([]*Event, len(batch.Events))
+	for idx, event := range batch.Events {
+		e, err := h.compressEvent(event)
+		if err != nil {
+			return nil, err
+		}
+		compressed.Events[idx] = e
+	}
+	return &compressed, nil
+}
+
+// decompressEvent decompresses the data of an event carrying the x-compression application property in place
+func (h *Hub) decompressEvent(event *Event) error {
+	val, ok := event.Properties[CompressionPropertyName]
+	if !ok {
+		return nil
+	}
+
+	name, ok := val.(string)
+	if !ok {
+		return errors.Errorf("%s application property must be a string, but was %T", CompressionPropertyName, val)
+	}
+
+	codec := h.codecByName(name)
+	if codec == nil {
+		return errors.Errorf("no codec is available to decompress event data compressed with %q", name)
+	}
+
+	data, err := codec.Decompress(event.Data)
+	if err != nil {
+		return err
+	}
+	event.Data = data
+	return nil
+}
+
+func (h *Hub) codecByName(name string) Codec {
+	switch {
+	case h.compressionCodec != nil && h.compressionCodec.Name() == name:
+		return h.compressionCodec
+	case name == GzipCodec{}.Name():
+		return GzipCodec{}
+	case name == DeflateCodec{}.Name():
+		return DeflateCodec{}
+	default:
+		return nil
+	}
+}
+
+func readAllAndClose(r io.ReadCloser) ([]byte, error) {
+	defer r.Close()
+	return ioutil.ReadAll(r)
+}
@@ -0,0 +1,93 @@
+package eventhub
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"pack.ag/amqp"
+)
+
+func TestPayloadCompressionRoundTrip(t *testing.T) {
+	codecs := []Codec{GzipCodec{}, DeflateCodec{}}
+	for _, codec := range codecs {
+		t.Run(codec.Name(), func(t *testing.T) {
+			hub, err := NewHub("ns", "hub", nil, HubWithPayloadCompression(codec))
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			data := bytes.Repeat([]byte(`{"hello": "world"}`), 100)
+			original := NewEvent(data)
+			compressed, err := hub.compressEvent(original)
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, codec.Name(), compressed.Properties[CompressionPropertyName])
+			assert.True(t, len(compressed.Data) < len(data))
+			assert.Equal(t, data, original.Data, "the original event should not be modified")
+
+			// any hub is able to decompress gzip and deflate without configuration
+			receivingHub, err := NewHub("ns", "hub", nil)
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.NoError(t, receivingHub.decompressEvent(compressed))
+			assert.Equal(t, data, compressed.Data)
+		})
+	}
+}
+
+func TestCompressReceivedEvent(t *testing.T) {
+	hub, err := NewHub("ns", "hub", nil, HubWithPayloadCompression(GzipCodec{}))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	data := bytes.Repeat([]byte(`{"hello": "world"}`), 100)
+	received := eventFromMsg(amqp.NewMessage(data))
+	compressed, err := hub.compressEvent(received)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	msg := compressed.toMsg()
+	if assert.Len(t, msg.Data, 1) {
+		assert.Equal(t, compressed.Data, msg.Data[0], "the compressed data should be sent rather than the received message")
+	}
+	assert.Equal(t, GzipCodec{}.Name(), msg.ApplicationProperties[CompressionPropertyName])
+	assert.Nil(t, received.message.ApplicationProperties, "the received message should not be modified")
+}
+
+func TestUncompressedEventPassesThrough(t *testing.T) {
+	hub, err := NewHub("ns", "hub", nil, HubWithPayloadCompression(GzipCodec{}))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	event := NewEventFromString("plain")
+	assert.NoError(t, hub.decompressEvent(event))
+	assert.Equal(t, "plain", string(event.Data))
+}
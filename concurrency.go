@@ -0,0 +1,122 @@
+package eventhub
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"context"
+	"sync"
+
+	"github.com/Azure/azure-amqp-common-go/persist"
+	"github.com/pkg/errors"
+	"pack.ag/amqp"
+)
+
+type (
+	// checkpointTracker tracks the events of a partition handled concurrently, in the order they were received, so
+	// the checkpoint only advances past an event once it and every event received before it have been handled
+	checkpointTracker struct {
+		mu      sync.Mutex
+		pending []*trackedCheckpoint
+	}
+
+	trackedCheckpoint struct {
+		checkpoint persist.Checkpoint
+		handled    bool
+	}
+)
+
+// ReceiveWithHandlerConcurrency configures the receiver to hand up to n events to the handler at once, rather than
+// one at a time. Events may be handled out of order, so this suits idempotent handlers. The checkpoint is only
+// advanced to the last event for which it and every earlier event have been handled, so events in flight when the
+// receiver stops are delivered again. When not set, events are handled one at a time.
+func ReceiveWithHandlerConcurrency(n int) ReceiveOption {
+	return func(receiver *receiver) error {
+		if n < 1 {
+			return errors.New("handler concurrency must be at least 1")
+		}
+		receiver.handlerConcurrency = n
+		return nil
+	}
+}
+
+// handleMessagesConcurrently hands up to the handler concurrency of messages to the handler at once
+func (r *receiver) handleMessagesConcurrently(ctx context.Context, messages chan *amqp.Message, handler Handler) {
+	slots := make(chan struct{}, r.handlerConcurrency)
+	tracker := new(checkpointTracker)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg := <-messages:
+			select {
+			case slots <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+
+			event := eventFromMsg(msg)
+			tracked := tracker.track(event.GetCheckpoint())
+			go func() {
+				defer func() { <-slots }()
+				r.handleEvent(ctx, msg, event, handler)
+				// as when handling events one at a time, a rejected event does not hold back the checkpoint
+				tracker.handled(tracked, func(checkpoint persist.Checkpoint) {
+					if err := r.storeLastReceivedOffset(checkpoint); err != nil {
+						r.logFor(ctx).Error(err)
+					}
+				})
+			}()
+		}
+	}
+}
+
+// track records a received event which has yet to be handled
+func (t *checkpointTracker) track(checkpoint persist.Checkpoint) *trackedCheckpoint {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	tracked := &trackedCheckpoint{checkpoint: checkpoint}
+	t.pending = append(t.pending, tracked)
+	return tracked
+}
+
+// handled marks the event as handled. If every event received before it has also been handled, advance is called,
+// while the tracker is locked so checkpoints are stored in order, with the checkpoint of the last event of the
+// handled run.
+func (t *checkpointTracker) handled(tracked *trackedCheckpoint, advance func(checkpoint persist.Checkpoint)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	tracked.handled = true
+	n := 0
+	for n < len(t.pending) && t.pending[n].handled {
+		n++
+	}
+	if n == 0 {
+		return
+	}
+
+	checkpoint := t.pending[n-1].checkpoint
+	t.pending = t.pending[n:]
+	advance(checkpoint)
+}
@@ -0,0 +1,88 @@
+package eventhub
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-amqp-common-go/persist"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReceiveWithHandlerConcurrency(t *testing.T) {
+	r := new(receiver)
+	assert.Error(t, ReceiveWithHandlerConcurrency(0)(r))
+	assert.NoError(t, ReceiveWithHandlerConcurrency(4)(r))
+	assert.Equal(t, 4, r.handlerConcurrency)
+}
+
+func TestCheckpointTrackerWaitsForGaps(t *testing.T) {
+	tracker := new(checkpointTracker)
+	var tracked []*trackedCheckpoint
+	for i := 0; i < 4; i++ {
+		tracked = append(tracked, tracker.track(persist.NewCheckpoint(strconv.Itoa(i), int64(i), time.Time{})))
+	}
+
+	var advanced []int64
+	advance := func(checkpoint persist.Checkpoint) {
+		advanced = append(advanced, checkpoint.SequenceNumber)
+	}
+
+	tracker.handled(tracked[1], advance)
+	tracker.handled(tracked[2], advance)
+	assert.Empty(t, advanced, "the checkpoint should not pass the unhandled first event")
+
+	tracker.handled(tracked[0], advance)
+	assert.Equal(t, []int64{2}, advanced, "the checkpoint should advance over the whole handled run")
+
+	tracker.handled(tracked[3], advance)
+	assert.Equal(t, []int64{2, 3}, advanced)
+	assert.Empty(t, tracker.pending)
+}
+
+func TestCheckpointTrackerAdvancesInOrder(t *testing.T) {
+	tracker := new(checkpointTracker)
+	var tracked []*trackedCheckpoint
+	for i := 0; i < 100; i++ {
+		tracked = append(tracked, tracker.track(persist.NewCheckpoint(strconv.Itoa(i), int64(i), time.Time{})))
+	}
+
+	var last int64 = -1
+	var wg sync.WaitGroup
+	for _, tc := range tracked {
+		wg.Add(1)
+		go func(tc *trackedCheckpoint) {
+			defer wg.Done()
+			tracker.handled(tc, func(checkpoint persist.Checkpoint) {
+				assert.True(t, checkpoint.SequenceNumber > last, "checkpoints should only move forward")
+				last = checkpoint.SequenceNumber
+			})
+		}(tc)
+	}
+	wg.Wait()
+	assert.Equal(t, int64(99), last)
+	assert.Empty(t, tracker.pending)
+}
@@ -0,0 +1,202 @@
+package eventhub
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"sync"
+
+	"github.com/Azure/azure-amqp-common-go/auth"
+	"github.com/Azure/go-autorest/autorest/azure"
+	"github.com/pkg/errors"
+	"pack.ag/amqp"
+)
+
+type (
+	// ConnectionPool shares AMQP connections to a namespace between the sender and receiver links of many Hubs. A
+	// connection is opened when every open connection of the pool carries the maximum number of links, and is closed
+	// once its last link is closed.
+	ConnectionPool struct {
+		namespace *namespace
+		maxLinks  int
+		dial      func() (*amqp.Client, error)
+		closeConn func(*amqp.Client) error
+		mu        sync.Mutex
+		conns     []*pooledConnection
+		closed    bool
+	}
+
+	// ConnectionPoolOption provides configuration options for a ConnectionPool
+	ConnectionPoolOption func(p *ConnectionPool) error
+
+	// pooledConnection is an AMQP connection of a ConnectionPool and the number of links using it
+	pooledConnection struct {
+		client *amqp.Client
+		links  int
+		closed bool
+	}
+)
+
+// NewConnectionPool creates a pool of AMQP connections to the namespace, authorized by the token provider, from which
+// Hubs are created with ConnectionPool.NewHub. Unless ConnectionPoolWithMaxLinks is set, all links share a single
+// connection.
+func NewConnectionPool(namespace string, tokenProvider auth.TokenProvider, opts ...ConnectionPoolOption) (*ConnectionPool, error) {
+	p := newConnectionPool(newNamespace(namespace, tokenProvider, azure.PublicCloud))
+	for _, opt := range opts {
+		if err := opt(p); err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+// ConnectionPoolWithMaxLinks configures the maximum number of sender and receiver links carried by each connection of
+// the pool, so the pool opens another connection rather than exceed the link limit of the broker
+func ConnectionPoolWithMaxLinks(maxLinks int) ConnectionPoolOption {
+	return func(p *ConnectionPool) error {
+		if maxLinks < 1 {
+			return errors.New("max links per connection must be greater than 0")
+		}
+		p.maxLinks = maxLinks
+		return nil
+	}
+}
+
+// ConnectionPoolWithEnvironment configures the pool to connect to the namespace in the Azure environment
+func ConnectionPoolWithEnvironment(env azure.Environment) ConnectionPoolOption {
+	return func(p *ConnectionPool) error {
+		p.namespace.environment = env
+		return nil
+	}
+}
+
+// NewHub creates a Hub for the Event Hub of the pool's namespace whose sender and receivers draw their connections
+// from the pool. As the pool opens the connections, an error is returned if the options configure how connections are
+// opened, such as HubWithWebSocketConnection, HubWithTLSConfig, HubWithWebSocketProxy, HubWithEndpointOverride or
+// HubWithIdleTimeout.
+func (p *ConnectionPool) NewHub(name string, opts ...HubOption) (*Hub, error) {
+	opts = append([]HubOption{HubWithEnvironment(p.namespace.environment)}, opts...)
+	opts = append(opts, func(h *Hub) error {
+		if h.namespace.hasTransportOptions() {
+			return errors.New("connection options such as WebSockets, TLS, proxies, endpoint overrides and idle timeouts can't be set on a Hub whose connections are opened by a connection pool")
+		}
+		h.connections = p
+		return nil
+	})
+	return NewHub(p.namespace.name, name, p.namespace.tokenProvider, opts...)
+}
+
+// Close closes every connection of the pool, failing the links which use them. Hubs created from the pool should be
+// closed first.
+func (p *ConnectionPool) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.closed = true
+	var lastErr error
+	for _, pc := range p.conns {
+		pc.closed = true
+		if err := p.closeConn(pc.client); err != nil {
+			lastErr = err
+		}
+	}
+	p.conns = nil
+	return lastErr
+}
+
+func newConnectionPool(ns *namespace) *ConnectionPool {
+	return &ConnectionPool{
+		namespace: ns,
+		dial:      ns.newConnection,
+		closeConn: (*amqp.Client).Close,
+	}
+}
+
+// hasTransportOptions returns true if the namespace is configured to open its connections other than with the defaults
+func (ns *namespace) hasTransportOptions() bool {
+	return ns.webSocket || ns.tlsConfig != nil || ns.proxyURL != nil || ns.endpoint != nil || ns.idleTimeout > 0
+}
+
+// acquire returns a connection of the pool for a new link, opening one if every connection carries the maximum
+// number of links
+func (p *ConnectionPool) acquire() (*pooledConnection, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return nil, errors.New("connection pool has been closed")
+	}
+
+	for _, pc := range p.conns {
+		if p.maxLinks == 0 || pc.links < p.maxLinks {
+			pc.links++
+			return pc, nil
+		}
+	}
+
+	client, err := p.dial()
+	if err != nil {
+		return nil, err
+	}
+
+	pc := &pooledConnection{client: client, links: 1}
+	p.conns = append(p.conns, pc)
+	return pc, nil
+}
+
+// release returns the connection of a closed link to the pool, closing the connection once no links use it
+func (p *ConnectionPool) release(pc *pooledConnection) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	pc.links--
+	if pc.links > 0 {
+		return nil
+	}
+
+	p.remove(pc)
+	if pc.closed {
+		return nil
+	}
+	pc.closed = true
+	return p.closeConn(pc.client)
+}
+
+// discard releases the connection of a failed link and removes the connection from the pool, so links recovering
+// from a failure of the connection open a new one rather than sharing the failed connection
+func (p *ConnectionPool) discard(pc *pooledConnection) error {
+	p.mu.Lock()
+	p.remove(pc)
+	p.mu.Unlock()
+
+	return p.release(pc)
+}
+
+// remove removes the connection from the pool while the pool is locked
+func (p *ConnectionPool) remove(pc *pooledConnection) {
+	for i, conn := range p.conns {
+		if conn == pc {
+			p.conns = append(p.conns[:i], p.conns[i+1:]...)
+			return
+		}
+	}
+}
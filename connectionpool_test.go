@@ -0,0 +1,147 @@
+package eventhub
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"context"
+	"crypto/tls"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"pack.ag/amqp"
+)
+
+func newTestConnectionPool() (*ConnectionPool, *int, *int) {
+	var dialed, closed int
+	pool := &ConnectionPool{
+		dial: func() (*amqp.Client, error) {
+			dialed++
+			return new(amqp.Client), nil
+		},
+		closeConn: func(*amqp.Client) error {
+			closed++
+			return nil
+		},
+	}
+	return pool, &dialed, &closed
+}
+
+func TestConnectionPoolSharesConnection(t *testing.T) {
+	pool, dialed, closed := newTestConnectionPool()
+
+	first, err := pool.acquire()
+	assert.NoError(t, err)
+	second, err := pool.acquire()
+	assert.NoError(t, err)
+	assert.True(t, first == second, "links should share the connection")
+	assert.Equal(t, 1, *dialed)
+
+	assert.NoError(t, pool.release(first))
+	assert.Equal(t, 0, *closed, "the connection should stay open while a link uses it")
+	assert.NoError(t, pool.release(second))
+	assert.Equal(t, 1, *closed)
+
+	_, err = pool.acquire()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, *dialed, "a new connection should be opened once the last one was closed")
+}
+
+func TestConnectionPoolDiscard(t *testing.T) {
+	pool, dialed, closed := newTestConnectionPool()
+
+	failed, err := pool.acquire()
+	assert.NoError(t, err)
+	healthy, err := pool.acquire()
+	assert.NoError(t, err)
+
+	assert.NoError(t, pool.discard(failed))
+	recovered, err := pool.acquire()
+	assert.NoError(t, err)
+	assert.False(t, recovered == healthy, "links recovering from a failure should not share the discarded connection")
+	assert.Equal(t, 2, *dialed)
+
+	assert.NoError(t, pool.release(healthy))
+	assert.Equal(t, 1, *closed, "the discarded connection should be closed once its last link is released")
+}
+
+func TestReceiveForConsumerGroupValidation(t *testing.T) {
+	hub, err := NewHub("namespace", "hub", nil)
+	if assert.NoError(t, err) {
+		_, err = hub.ReceiveForConsumerGroup(context.Background(), "", "0", nil)
+		assert.Error(t, err)
+	}
+}
+
+func TestConnectionPoolMaxLinks(t *testing.T) {
+	pool, dialed, closed := newTestConnectionPool()
+	assert.NoError(t, ConnectionPoolWithMaxLinks(2)(pool))
+
+	first, _ := pool.acquire()
+	second, _ := pool.acquire()
+	third, err := pool.acquire()
+	assert.NoError(t, err)
+	assert.True(t, first == second)
+	assert.False(t, third == first, "a connection should be opened once the link cap is reached")
+	assert.Equal(t, 2, *dialed)
+
+	assert.NoError(t, pool.release(second))
+	fourth, _ := pool.acquire()
+	assert.True(t, fourth == first, "links should reuse a connection below the link cap")
+	assert.Equal(t, 2, *dialed)
+
+	assert.NoError(t, pool.Close())
+	assert.Equal(t, 2, *closed)
+	_, err = pool.acquire()
+	assert.Error(t, err, "a closed pool should not open connections")
+	assert.NoError(t, pool.release(third))
+	assert.Equal(t, 2, *closed, "connections closed with the pool should not be closed again")
+}
+
+func TestNewConnectionPoolOptions(t *testing.T) {
+	_, err := NewConnectionPool("namespace", nil, ConnectionPoolWithMaxLinks(0))
+	assert.Error(t, err)
+
+	pool, err := NewConnectionPool("namespace", nil, ConnectionPoolWithMaxLinks(10))
+	if assert.NoError(t, err) {
+		hub, err := pool.NewHub("hub")
+		if assert.NoError(t, err) {
+			assert.True(t, hub.connections == pool)
+			assert.Equal(t, "namespace", hub.namespace.name)
+		}
+
+		// the pool opens the connections, so options changing how they are opened are rejected
+		for name, opt := range map[string]HubOption{
+			"WebSocket":   HubWithWebSocketConnection(),
+			"TLS":         HubWithTLSConfig(&tls.Config{}),
+			"Proxy":       HubWithWebSocketProxy("http://proxy:8080"),
+			"Endpoint":    HubWithEndpointOverride("gateway.example.com"),
+			"IdleTimeout": HubWithIdleTimeout(5 * time.Minute),
+		} {
+			_, err := pool.NewHub("hub", opt)
+			assert.Error(t, err, name)
+		}
+		_, err = pool.NewHub("hub", HubWithAudienceOverride("sb://namespace.servicebus.windows.net/"))
+		assert.NoError(t, err, "the audience of claims does not change how connections are opened")
+	}
+}
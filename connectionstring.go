@@ -0,0 +1,156 @@
+package eventhub
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/Azure/azure-amqp-common-go/sas"
+	"github.com/Azure/go-autorest/autorest/azure"
+	"github.com/pkg/errors"
+)
+
+type (
+	// connectionString holds the parts of an Event Hubs connection string
+	connectionString struct {
+		Namespace   string
+		Environment azure.Environment
+		KeyName     string
+		Key         string
+		EntityPath  string
+	}
+)
+
+// NewHubFromConnectionString creates a new Event Hub client from a connection string copied from the Azure portal,
+// such as "Endpoint=sb://namespace.servicebus.windows.net/;SharedAccessKeyName=name;SharedAccessKey=key;EntityPath=hub".
+// The namespace and Azure environment are derived from the endpoint and a SAS token provider is built from the
+// shared access key. The Event Hub is named by the EntityPath, unless overridden with HubWithName; if the connection
+// string has no EntityPath, HubWithName must be given.
+func NewHubFromConnectionString(connStr string, opts ...HubOption) (*Hub, error) {
+	parsed, err := parseConnectionString(connStr)
+	if err != nil {
+		return nil, err
+	}
+
+	provider, err := sas.NewTokenProvider(sas.TokenProviderWithKey(parsed.KeyName, parsed.Key))
+	if err != nil {
+		return nil, err
+	}
+
+	opts = append([]HubOption{HubWithEnvironment(parsed.Environment)}, opts...)
+	h, err := NewHub(parsed.Namespace, parsed.EntityPath, provider, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	if h.name == "" {
+		return nil, errors.New("connection string has no EntityPath, so the Event Hub must be named with HubWithName")
+	}
+	return h, nil
+}
+
+// HubWithName overrides the name of the Event Hub, such as the one given by the EntityPath of a connection string
+func HubWithName(name string) HubOption {
+	return func(h *Hub) error {
+		if name == "" {
+			return errors.New("the name of the Event Hub must not be empty")
+		}
+		h.name = name
+		return nil
+	}
+}
+
+// parseConnectionString parses an Event Hubs connection string of semicolon separated key=value pairs
+func parseConnectionString(connStr string) (*connectionString, error) {
+	parsed := new(connectionString)
+	var endpoint string
+	for _, part := range strings.Split(connStr, ";") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		// the key may end with base64 padding, so only the first '=' separates the name from the value
+		idx := strings.Index(part, "=")
+		if idx < 1 {
+			return nil, errors.Errorf("connection string segment %q is not of the form key=value", redactSegment(part))
+		}
+
+		key, value := part[:idx], part[idx+1:]
+		switch strings.ToLower(key) {
+		case "endpoint":
+			endpoint = value
+		case "sharedaccesskeyname":
+			parsed.KeyName = value
+		case "sharedaccesskey":
+			parsed.Key = value
+		case "entitypath":
+			parsed.EntityPath = value
+		}
+	}
+
+	if endpoint == "" {
+		return nil, errors.New("connection string has no Endpoint")
+	}
+	if parsed.KeyName == "" || parsed.Key == "" {
+		return nil, errors.New("connection string must have a SharedAccessKeyName and SharedAccessKey")
+	}
+
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, errors.Wrapf(err, "connection string Endpoint %q is not a valid URL", endpoint)
+	}
+
+	host := u.Hostname()
+	idx := strings.Index(host, ".")
+	if idx < 1 || idx == len(host)-1 {
+		return nil, errors.Errorf("connection string Endpoint %q should be of the form sb://namespace.servicebus.windows.net/", endpoint)
+	}
+	parsed.Namespace = host[:idx]
+	parsed.Environment = environmentFromSuffix(host[idx+1:])
+	return parsed, nil
+}
+
+// environmentFromSuffix returns the Azure environment with the Service Bus endpoint suffix. An unknown suffix, such as
+// the one of a local emulator, returns the public cloud with the suffix replaced.
+func environmentFromSuffix(suffix string) azure.Environment {
+	for _, env := range []azure.Environment{azure.PublicCloud, azure.USGovernmentCloud, azure.ChinaCloud, azure.GermanCloud} {
+		if strings.EqualFold(env.ServiceBusEndpointSuffix, suffix) {
+			return env
+		}
+	}
+
+	env := azure.PublicCloud
+	env.ServiceBusEndpointSuffix = suffix
+	return env
+}
+
+// redactSegment hides the value of a connection string segment in case it holds the key
+func redactSegment(segment string) string {
+	if len(segment) <= 4 {
+		return segment
+	}
+	return fmt.Sprintf("%s...", segment[:4])
+}
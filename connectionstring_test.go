@@ -0,0 +1,78 @@
+package eventhub
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"testing"
+
+	"github.com/Azure/go-autorest/autorest/azure"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseConnectionString(t *testing.T) {
+	parsed, err := parseConnectionString("Endpoint=sb://namespace.servicebus.usgovcloudapi.net/;SharedAccessKeyName=keyName;SharedAccessKey=secret==;EntityPath=hub")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "namespace", parsed.Namespace)
+		assert.Equal(t, azure.USGovernmentCloud.Name, parsed.Environment.Name)
+		assert.Equal(t, "keyName", parsed.KeyName)
+		assert.Equal(t, "secret==", parsed.Key, "base64 padding of the key should be kept")
+		assert.Equal(t, "hub", parsed.EntityPath)
+	}
+
+	parsed, err = parseConnectionString("endpoint=sb://namespace.localhost/;sharedaccesskeyname=keyName;sharedaccesskey=secret;")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "localhost", parsed.Environment.ServiceBusEndpointSuffix)
+		assert.Empty(t, parsed.EntityPath)
+	}
+
+	malformed := []string{
+		"",
+		"SharedAccessKeyName=keyName;SharedAccessKey=secret",
+		"Endpoint=sb://namespace.servicebus.windows.net/;SharedAccessKey=secret",
+		"Endpoint=sb://namespace.servicebus.windows.net/;SharedAccessKeyName=keyName",
+		"Endpoint=sb://namespace/;SharedAccessKeyName=keyName;SharedAccessKey=secret",
+		"Endpoint=sb://namespace.servicebus.windows.net/;SharedAccessKeyName;SharedAccessKey=secret",
+	}
+	for _, connStr := range malformed {
+		_, err := parseConnectionString(connStr)
+		assert.Error(t, err, connStr)
+	}
+}
+
+func TestNewHubFromConnectionString(t *testing.T) {
+	connStr := "Endpoint=sb://namespace.servicebus.windows.net/;SharedAccessKeyName=keyName;SharedAccessKey=secret"
+	_, err := NewHubFromConnectionString(connStr)
+	assert.Error(t, err, "a hub name is required without an EntityPath")
+
+	hub, err := NewHubFromConnectionString(connStr, HubWithName("hub"))
+	if assert.NoError(t, err) {
+		assert.Equal(t, "hub", hub.name)
+		assert.Equal(t, "namespace", hub.namespace.name)
+		assert.Equal(t, azure.PublicCloud.ServiceBusEndpointSuffix, hub.namespace.environment.ServiceBusEndpointSuffix)
+	}
+
+	hub, err = NewHubFromConnectionString(connStr+";EntityPath=path", HubWithName("override"))
+	if assert.NoError(t, err) {
+		assert.Equal(t, "override", hub.name)
+	}
+}
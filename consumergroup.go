@@ -0,0 +1,158 @@
+package eventhub
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"context"
+	"net/http"
+
+	ehmgmt "github.com/Azure/azure-sdk-for-go/services/eventhub/mgmt/2017-04-01/eventhub"
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/pkg/errors"
+)
+
+type (
+	// consumerGroupsClient is the subset of the Azure Resource Manager consumer groups client used by the Hub
+	consumerGroupsClient interface {
+		Get(ctx context.Context, resourceGroupName, namespaceName, eventHubName, consumerGroupName string) (ehmgmt.ConsumerGroup, error)
+		CreateOrUpdate(ctx context.Context, resourceGroupName, namespaceName, eventHubName, consumerGroupName string, parameters ehmgmt.ConsumerGroup) (ehmgmt.ConsumerGroup, error)
+	}
+)
+
+// HubWithConsumerGroupsClient configures the Hub to manage consumer groups through Azure Resource Manager using a
+// client authorized for the resource group of the namespace. This enables EnsureConsumerGroup and the validation of
+// the consumer group of a receiver before it is opened, so a missing consumer group is reported by name rather than
+// as a link failure.
+func HubWithConsumerGroupsClient(client ehmgmt.ConsumerGroupsClient, resourceGroup string) HubOption {
+	return func(h *Hub) error {
+		if resourceGroup == "" {
+			return errors.New("resource group must not be empty")
+		}
+		h.consumerGroups = client
+		h.resourceGroup = resourceGroup
+		return nil
+	}
+}
+
+// ReceiveForConsumerGroup subscribes to the partition under the consumer group, as Receive with
+// ReceiveWithConsumerGroup does, except the receivers opened by ReceiveForConsumerGroup share a single AMQP
+// connection of the Hub, so one Hub can receive under many consumer groups without a connection for each receiver.
+// The receivers of a Hub created by ConnectionPool.NewHub draw from that pool instead.
+func (h *Hub) ReceiveForConsumerGroup(ctx context.Context, consumerGroup, partitionID string, handler Handler, opts ...ReceiveOption) (*ListenerHandle, error) {
+	span, ctx := h.startSpanFromContext(ctx, "eventhub.Hub.ReceiveForConsumerGroup")
+	defer span.Finish()
+
+	if consumerGroup == "" {
+		return nil, errors.New("consumer group must not be empty")
+	}
+
+	pool := h.connections
+	if pool == nil {
+		h.receiverPoolOnce.Do(func() {
+			h.receiverPool = newConnectionPool(h.namespace)
+		})
+		pool = h.receiverPool
+	}
+	opts = append(opts, ReceiveWithConsumerGroup(consumerGroup), receiveWithConnectionPool(pool))
+	return h.Receive(ctx, partitionID, handler, opts...)
+}
+
+// receiveWithConnectionPool configures the receiver to acquire its connection from the pool
+func receiveWithConnectionPool(pool *ConnectionPool) ReceiveOption {
+	return func(receiver *receiver) error {
+		receiver.pool = pool
+		return nil
+	}
+}
+
+// EnsureConsumerGroup creates the consumer group on the Event Hub if it does not exist. The Hub must be configured
+// with HubWithConsumerGroupsClient.
+func (h *Hub) EnsureConsumerGroup(ctx context.Context, name string) error {
+	span, ctx := h.startSpanFromContext(ctx, "eventhub.Hub.EnsureConsumerGroup")
+	defer span.Finish()
+
+	if h.consumerGroups == nil {
+		return errors.New("managing consumer groups requires a Hub configured with HubWithConsumerGroupsClient")
+	}
+
+	exists, err := h.consumerGroupExists(ctx, name)
+	if err != nil || exists {
+		return err
+	}
+
+	_, err = h.consumerGroups.CreateOrUpdate(ctx, h.resourceGroup, h.namespace.name, h.name, name, ehmgmt.ConsumerGroup{})
+	if err != nil {
+		err = errors.Wrapf(err, "failed to create consumer group %q on Event Hub %q", name, h.name)
+		if busy := managementServerBusyError(err); busy != nil {
+			return busy
+		}
+		return err
+	}
+	return nil
+}
+
+// validateConsumerGroup returns an error naming the consumer group and Event Hub if the consumer group does not exist.
+// Consumer groups are only validated when the Hub is configured with HubWithConsumerGroupsClient, and a failure to
+// look up the consumer group is logged rather than failing the receiver.
+func (h *Hub) validateConsumerGroup(ctx context.Context, name string) error {
+	if h.consumerGroups == nil || name == DefaultConsumerGroup {
+		return nil
+	}
+
+	exists, err := h.consumerGroupExists(ctx, name)
+	if err != nil {
+		h.logFor(ctx).Error(err)
+		return nil
+	}
+	if !exists {
+		return &kindError{kind: ErrConsumerGroupNotFound, err: errors.Errorf("consumer group %q does not exist on Event Hub %q", name, h.name)}
+	}
+	return nil
+}
+
+func (h *Hub) consumerGroupExists(ctx context.Context, name string) (bool, error) {
+	group, err := h.consumerGroups.Get(ctx, h.resourceGroup, h.namespace.name, h.name, name)
+	if isNotFound(group.Response, err) {
+		return false, nil
+	}
+	if err != nil {
+		err = errors.Wrapf(err, "failed to get consumer group %q of Event Hub %q", name, h.name)
+		if busy := managementServerBusyError(err); busy != nil {
+			return false, busy
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func isNotFound(res autorest.Response, err error) bool {
+	if res.Response != nil && res.StatusCode == http.StatusNotFound {
+		return true
+	}
+	if detailed, ok := err.(autorest.DetailedError); ok {
+		if code, ok := detailed.StatusCode.(int); ok && code == http.StatusNotFound {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,69 @@
+package eventhub
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/Azure/azure-amqp-common-go/persist"
+	ehmgmt "github.com/Azure/azure-sdk-for-go/services/eventhub/mgmt/2017-04-01/eventhub"
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/azure"
+	"github.com/stretchr/testify/assert"
+)
+
+type memoryConsumerGroups map[string]bool
+
+func (m memoryConsumerGroups) Get(ctx context.Context, resourceGroupName, namespaceName, eventHubName, consumerGroupName string) (ehmgmt.ConsumerGroup, error) {
+	if !m[consumerGroupName] {
+		res := autorest.Response{Response: &http.Response{StatusCode: http.StatusNotFound}}
+		return ehmgmt.ConsumerGroup{Response: res}, autorest.NewError("eventhub.ConsumerGroupsClient", "Get", "not found")
+	}
+	return ehmgmt.ConsumerGroup{Name: &consumerGroupName}, nil
+}
+
+func (m memoryConsumerGroups) CreateOrUpdate(ctx context.Context, resourceGroupName, namespaceName, eventHubName, consumerGroupName string, parameters ehmgmt.ConsumerGroup) (ehmgmt.ConsumerGroup, error) {
+	m[consumerGroupName] = true
+	return ehmgmt.ConsumerGroup{Name: &consumerGroupName}, nil
+}
+
+func TestHubEnsureConsumerGroup(t *testing.T) {
+	ctx := context.Background()
+	groups := memoryConsumerGroups{}
+	hub := &Hub{name: "hub", namespace: newNamespace("ns", nil, azure.PublicCloud), consumerGroups: groups, resourceGroup: "rg"}
+
+	assert.EqualError(t, hub.validateConsumerGroup(ctx, "typo"), `consumer group "typo" does not exist on Event Hub "hub"`)
+	assert.NoError(t, hub.validateConsumerGroup(ctx, DefaultConsumerGroup), "the default consumer group always exists")
+
+	assert.NoError(t, hub.EnsureConsumerGroup(ctx, "typo"))
+	assert.True(t, groups["typo"])
+	assert.NoError(t, hub.validateConsumerGroup(ctx, "typo"))
+}
+
+func TestHubEnsureConsumerGroupRequiresClient(t *testing.T) {
+	hub := &Hub{name: "hub", namespace: newNamespace("ns", nil, azure.PublicCloud), offsetPersister: persist.NewMemoryPersister()}
+	assert.Error(t, hub.EnsureConsumerGroup(context.Background(), "group"))
+	assert.NoError(t, hub.validateConsumerGroup(context.Background(), "group"), "consumer groups are not validated without a client")
+}
@@ -0,0 +1,288 @@
+// Package cosmos provides an implementation of Checkpointer from package eph for persisting checkpoints for the
+// Event Processor Host in a Cosmos DB container.
+package cosmos
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-amqp-common-go/persist"
+	"github.com/Azure/azure-event-hubs-go"
+	"github.com/Azure/azure-event-hubs-go/eph"
+	"github.com/opentracing/opentracing-go"
+	tag "github.com/opentracing/opentracing-go/ext"
+	"github.com/pkg/errors"
+)
+
+const (
+	// PartitionKeyPath is the partition key path of the container holding the checkpoints
+	PartitionKeyPath = "/partitionId"
+)
+
+var (
+	// ErrNotFound is returned by a Client when the requested container or item does not exist
+	ErrNotFound = errors.New("cosmos: not found")
+
+	// ErrConflict is returned by a Client when creating an item which already exists
+	ErrConflict = errors.New("cosmos: conflict")
+
+	// ErrPreconditionFailed is returned by a Client when replacing an item whose ETag does not match the ETag provided
+	ErrPreconditionFailed = errors.New("cosmos: precondition failed")
+)
+
+type (
+	// Client provides the Cosmos DB SQL API operations used by the Checkpointer. Implementations adapt the Cosmos DB
+	// client in use and translate its errors into ErrNotFound, ErrConflict and ErrPreconditionFailed. Items are JSON
+	// documents and every operation on an item returns the ETag of the item. NewRESTClient provides a Client backed by
+	// the Cosmos DB REST API.
+	Client interface {
+		ContainerExists(ctx context.Context, database, container string) (bool, error)
+		CreateContainerIfNotExists(ctx context.Context, database, container, partitionKeyPath string) error
+		DeleteContainer(ctx context.Context, database, container string) error
+		ReadItem(ctx context.Context, database, container, partitionKey, id string) (item []byte, etag string, err error)
+		CreateItem(ctx context.Context, database, container, partitionKey string, item []byte) (etag string, err error)
+		ReplaceItem(ctx context.Context, database, container, partitionKey, id string, item []byte, ifMatch string) (etag string, err error)
+		DeleteItem(ctx context.Context, database, container, partitionKey, id string) error
+	}
+
+	// Checkpointer implements the eph.Checkpointer interface for Cosmos DB. Each partition's checkpoint is stored as
+	// an item with the partition ID as both its ID and partition key, and is updated with optimistic concurrency using
+	// the ETag of the item read by EnsureCheckpoint when the partition is acquired. Once another writer changes the
+	// checkpoint, updates fail until the partition is acquired again.
+	Checkpointer struct {
+		client    Client
+		database  string
+		container string
+		processor *eph.EventProcessorHost
+		etags     map[string]string
+		etagsMu   sync.Mutex
+	}
+
+	checkpointItem struct {
+		ID             string    `json:"id"`
+		PartitionID    string    `json:"partitionId"`
+		Offset         string    `json:"offset"`
+		SequenceNumber int64     `json:"sequenceNumber"`
+		EnqueueTime    time.Time `json:"enqueueTime"`
+	}
+)
+
+// NewCheckpointer builds a Cosmos DB Checkpointer which stores checkpoints in the container of the database using an
+// existing Cosmos DB client
+func NewCheckpointer(client Client, database, container string) (*Checkpointer, error) {
+	if client == nil {
+		return nil, errors.New("cosmos client must not be nil")
+	}
+	if database == "" || container == "" {
+		return nil, errors.New("database and container names must not be empty")
+	}
+
+	return &Checkpointer{
+		client:    client,
+		database:  database,
+		container: container,
+		etags:     make(map[string]string),
+	}, nil
+}
+
+// SetEventHostProcessor sets the EventHostProcessor on the instance of the Checkpointer
+func (c *Checkpointer) SetEventHostProcessor(eph *eph.EventProcessorHost) {
+	c.processor = eph
+}
+
+// StoreExists returns true if the container exists
+func (c *Checkpointer) StoreExists(ctx context.Context) (bool, error) {
+	span, ctx := startConsumerSpanFromContext(ctx, "eventhub.cosmos.Checkpointer.StoreExists")
+	defer span.Finish()
+
+	return c.client.ContainerExists(ctx, c.database, c.container)
+}
+
+// EnsureStore creates the container if it does not exist
+func (c *Checkpointer) EnsureStore(ctx context.Context) error {
+	span, ctx := startConsumerSpanFromContext(ctx, "eventhub.cosmos.Checkpointer.EnsureStore")
+	defer span.Finish()
+
+	return c.client.CreateContainerIfNotExists(ctx, c.database, c.container, PartitionKeyPath)
+}
+
+// DeleteStore deletes the container and all of the checkpoints within it
+func (c *Checkpointer) DeleteStore(ctx context.Context) error {
+	c.etagsMu.Lock()
+	defer c.etagsMu.Unlock()
+
+	span, ctx := startConsumerSpanFromContext(ctx, "eventhub.cosmos.Checkpointer.DeleteStore")
+	defer span.Finish()
+
+	if err := c.client.DeleteContainer(ctx, c.database, c.container); err != nil && err != ErrNotFound {
+		return err
+	}
+	c.etags = make(map[string]string)
+	return nil
+}
+
+// GetCheckpoint returns the latest checkpoint for the partitionID. Reading the checkpoint does not allow it to be
+// updated; only EnsureCheckpoint records the ETag used to update it.
+func (c *Checkpointer) GetCheckpoint(ctx context.Context, partitionID string) (persist.Checkpoint, bool) {
+	span, ctx := startConsumerSpanFromContext(ctx, "eventhub.cosmos.Checkpointer.GetCheckpoint")
+	defer span.Finish()
+
+	checkpoint, _, err := c.read(ctx, partitionID)
+	if err != nil {
+		return persist.NewCheckpointFromStartOfStream(), false
+	}
+	return checkpoint, true
+}
+
+// EnsureCheckpoint ensures a checkpoint exists for the partition, creating one at the start of the stream if needed.
+// The Event Processor Host calls it when it acquires the partition, so the ETag of the checkpoint read or created is
+// recorded as the only version of the checkpoint UpdateCheckpoint and DeleteCheckpoint will replace.
+func (c *Checkpointer) EnsureCheckpoint(ctx context.Context, partitionID string) (persist.Checkpoint, error) {
+	c.etagsMu.Lock()
+	defer c.etagsMu.Unlock()
+
+	span, ctx := startConsumerSpanFromContext(ctx, "eventhub.cosmos.Checkpointer.EnsureCheckpoint")
+	defer span.Finish()
+
+	checkpoint, err := c.acquire(ctx, partitionID)
+	if err != ErrNotFound {
+		return checkpoint, err
+	}
+
+	checkpoint = persist.NewCheckpointFromStartOfStream()
+	body, err := marshalCheckpoint(partitionID, checkpoint)
+	if err != nil {
+		return checkpoint, err
+	}
+
+	etag, err := c.client.CreateItem(ctx, c.database, c.container, partitionID, body)
+	if err == ErrConflict {
+		// another host created the checkpoint first
+		return c.acquire(ctx, partitionID)
+	}
+	if err != nil {
+		return checkpoint, err
+	}
+	c.etags[partitionID] = etag
+	return checkpoint, nil
+}
+
+// UpdateCheckpoint replaces the checkpoint for the partition if it has not been changed since the partition was
+// acquired with EnsureCheckpoint or the checkpoint was last written by this Checkpointer
+func (c *Checkpointer) UpdateCheckpoint(ctx context.Context, partitionID string, checkpoint persist.Checkpoint) error {
+	c.etagsMu.Lock()
+	defer c.etagsMu.Unlock()
+
+	span, ctx := startConsumerSpanFromContext(ctx, "eventhub.cosmos.Checkpointer.UpdateCheckpoint")
+	defer span.Finish()
+
+	return c.replace(ctx, partitionID, checkpoint)
+}
+
+// DeleteCheckpoint resets the checkpoint for the partition to the start of the stream
+func (c *Checkpointer) DeleteCheckpoint(ctx context.Context, partitionID string) error {
+	c.etagsMu.Lock()
+	defer c.etagsMu.Unlock()
+
+	span, ctx := startConsumerSpanFromContext(ctx, "eventhub.cosmos.Checkpointer.DeleteCheckpoint")
+	defer span.Finish()
+
+	return c.replace(ctx, partitionID, persist.NewCheckpointFromStartOfStream())
+}
+
+// Close is a no-op; the Cosmos DB client is owned by the caller and is not closed
+func (c *Checkpointer) Close() error {
+	return nil
+}
+
+// acquire reads the checkpoint for the partition and records its ETag as the version this Checkpointer may replace
+func (c *Checkpointer) acquire(ctx context.Context, partitionID string) (persist.Checkpoint, error) {
+	checkpoint, etag, err := c.read(ctx, partitionID)
+	if err != nil {
+		return checkpoint, err
+	}
+	c.etags[partitionID] = etag
+	return checkpoint, nil
+}
+
+// read fetches the checkpoint for the partition and its ETag
+func (c *Checkpointer) read(ctx context.Context, partitionID string) (persist.Checkpoint, string, error) {
+	body, etag, err := c.client.ReadItem(ctx, c.database, c.container, partitionID, partitionID)
+	if err != nil {
+		return persist.NewCheckpointFromStartOfStream(), "", err
+	}
+
+	var item checkpointItem
+	if err := json.Unmarshal(body, &item); err != nil {
+		return persist.NewCheckpointFromStartOfStream(), "", err
+	}
+	return persist.NewCheckpoint(item.Offset, item.SequenceNumber, item.EnqueueTime), etag, nil
+}
+
+// replace writes the checkpoint for the partition using the recorded ETag as a precondition. The ETag is never read
+// again here, so a writer which lost the partition keeps failing rather than overwriting the checkpoint of the new
+// owner.
+func (c *Checkpointer) replace(ctx context.Context, partitionID string, checkpoint persist.Checkpoint) error {
+	etag, ok := c.etags[partitionID]
+	if !ok {
+		return errors.Errorf("checkpoint for partition %q has not been acquired with EnsureCheckpoint", partitionID)
+	}
+
+	body, err := marshalCheckpoint(partitionID, checkpoint)
+	if err != nil {
+		return err
+	}
+
+	newETag, err := c.client.ReplaceItem(ctx, c.database, c.container, partitionID, partitionID, body, etag)
+	if err == ErrPreconditionFailed {
+		// the checkpoint was written by another owner; the stale ETag is kept so every later update fails as well
+		return errors.Errorf("checkpoint for partition %q was modified by another writer", partitionID)
+	}
+	if err != nil {
+		return err
+	}
+	c.etags[partitionID] = newETag
+	return nil
+}
+
+func marshalCheckpoint(partitionID string, checkpoint persist.Checkpoint) ([]byte, error) {
+	return json.Marshal(checkpointItem{
+		ID:             partitionID,
+		PartitionID:    partitionID,
+		Offset:         checkpoint.Offset,
+		SequenceNumber: checkpoint.SequenceNumber,
+		EnqueueTime:    checkpoint.EnqueueTime,
+	})
+}
+
+func startConsumerSpanFromContext(ctx context.Context, operationName string, opts ...opentracing.StartSpanOption) (opentracing.Span, context.Context) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, operationName, opts...)
+	eventhub.ApplyComponentInfo(span)
+	tag.SpanKindRPCClient.Set(span)
+	span.SetTag("eventhub.eventprocessorhost.kind", "cosmos")
+	return span, ctx
+}
@@ -0,0 +1,230 @@
+package cosmos
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-amqp-common-go/persist"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type (
+	memoryItem struct {
+		body []byte
+		etag string
+	}
+
+	memoryClient struct {
+		mu         sync.Mutex
+		containers map[string]map[string]memoryItem
+		version    int
+	}
+)
+
+func newMemoryClient() *memoryClient {
+	return &memoryClient{containers: make(map[string]map[string]memoryItem)}
+}
+
+func (m *memoryClient) ContainerExists(ctx context.Context, database, container string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, ok := m.containers[database+"/"+container]
+	return ok, nil
+}
+
+func (m *memoryClient) CreateContainerIfNotExists(ctx context.Context, database, container, partitionKeyPath string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.containers[database+"/"+container]; !ok {
+		m.containers[database+"/"+container] = make(map[string]memoryItem)
+	}
+	return nil
+}
+
+func (m *memoryClient) DeleteContainer(ctx context.Context, database, container string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.containers[database+"/"+container]; !ok {
+		return ErrNotFound
+	}
+	delete(m.containers, database+"/"+container)
+	return nil
+}
+
+func (m *memoryClient) ReadItem(ctx context.Context, database, container, partitionKey, id string) ([]byte, string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	item, ok := m.containers[database+"/"+container][id]
+	if !ok {
+		return nil, "", ErrNotFound
+	}
+	return item.body, item.etag, nil
+}
+
+func (m *memoryClient) CreateItem(ctx context.Context, database, container, partitionKey string, item []byte) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	items, ok := m.containers[database+"/"+container]
+	if !ok {
+		return "", ErrNotFound
+	}
+	if _, ok := items[partitionKey]; ok {
+		return "", ErrConflict
+	}
+	return m.put(items, partitionKey, item), nil
+}
+
+func (m *memoryClient) ReplaceItem(ctx context.Context, database, container, partitionKey, id string, item []byte, ifMatch string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	existing, ok := m.containers[database+"/"+container][id]
+	if !ok {
+		return "", ErrNotFound
+	}
+	if existing.etag != ifMatch {
+		return "", ErrPreconditionFailed
+	}
+	return m.put(m.containers[database+"/"+container], id, item), nil
+}
+
+func (m *memoryClient) DeleteItem(ctx context.Context, database, container, partitionKey, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.containers[database+"/"+container][id]; !ok {
+		return ErrNotFound
+	}
+	delete(m.containers[database+"/"+container], id)
+	return nil
+}
+
+func (m *memoryClient) put(items map[string]memoryItem, id string, body []byte) string {
+	m.version++
+	etag := fmt.Sprintf("etag-%d", m.version)
+	items[id] = memoryItem{body: body, etag: etag}
+	return etag
+}
+
+func TestCheckpointer(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	client := newMemoryClient()
+	checkpointer, err := NewCheckpointer(client, "db", "checkpoints")
+	require.NoError(t, err)
+
+	exists, err := checkpointer.StoreExists(ctx)
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	require.NoError(t, checkpointer.EnsureStore(ctx))
+	exists, err = checkpointer.StoreExists(ctx)
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	_, ok := checkpointer.GetCheckpoint(ctx, "0")
+	assert.False(t, ok)
+
+	checkpoint, err := checkpointer.EnsureCheckpoint(ctx, "0")
+	require.NoError(t, err)
+	assert.Equal(t, persist.StartOfStream, checkpoint.Offset)
+
+	enqueued := time.Date(2018, 6, 1, 0, 0, 0, 0, time.UTC)
+	require.NoError(t, checkpointer.UpdateCheckpoint(ctx, "0", persist.NewCheckpoint("100", 10, enqueued)))
+
+	checkpoint, ok = checkpointer.GetCheckpoint(ctx, "0")
+	assert.True(t, ok)
+	assert.Equal(t, "100", checkpoint.Offset)
+	assert.Equal(t, int64(10), checkpoint.SequenceNumber)
+	assert.True(t, enqueued.Equal(checkpoint.EnqueueTime))
+
+	require.NoError(t, checkpointer.DeleteCheckpoint(ctx, "0"))
+	checkpoint, _ = checkpointer.GetCheckpoint(ctx, "0")
+	assert.Equal(t, persist.StartOfStream, checkpoint.Offset)
+
+	require.NoError(t, checkpointer.DeleteStore(ctx))
+	exists, err = checkpointer.StoreExists(ctx)
+	require.NoError(t, err)
+	assert.False(t, exists)
+}
+
+func TestCheckpointerConcurrentUpdate(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	client := newMemoryClient()
+	first, err := NewCheckpointer(client, "db", "checkpoints")
+	require.NoError(t, err)
+	second, err := NewCheckpointer(client, "db", "checkpoints")
+	require.NoError(t, err)
+
+	require.NoError(t, first.EnsureStore(ctx))
+	_, err = first.EnsureCheckpoint(ctx, "0")
+	require.NoError(t, err)
+	_, err = second.EnsureCheckpoint(ctx, "0")
+	require.NoError(t, err)
+
+	require.NoError(t, second.UpdateCheckpoint(ctx, "0", persist.NewCheckpoint("200", 20, time.Now())))
+	assert.Error(t, first.UpdateCheckpoint(ctx, "0", persist.NewCheckpoint("100", 10, time.Now())))
+
+	// the stale writer keeps failing, even after reading the checkpoint, rather than overwriting the new owner
+	_, ok := first.GetCheckpoint(ctx, "0")
+	assert.True(t, ok)
+	assert.Error(t, first.UpdateCheckpoint(ctx, "0", persist.NewCheckpoint("300", 30, time.Now())))
+	assert.Error(t, first.DeleteCheckpoint(ctx, "0"))
+	checkpoint, ok := second.GetCheckpoint(ctx, "0")
+	assert.True(t, ok)
+	assert.Equal(t, "200", checkpoint.Offset)
+	require.NoError(t, second.UpdateCheckpoint(ctx, "0", persist.NewCheckpoint("400", 40, time.Now())))
+
+	// acquiring the partition again records the current ETag
+	_, err = first.EnsureCheckpoint(ctx, "0")
+	require.NoError(t, err)
+	require.NoError(t, first.UpdateCheckpoint(ctx, "0", persist.NewCheckpoint("500", 50, time.Now())))
+	checkpoint, _ = second.GetCheckpoint(ctx, "0")
+	assert.Equal(t, "500", checkpoint.Offset)
+}
+
+func TestCheckpointerUpdateRequiresAcquisition(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	client := newMemoryClient()
+	owner, err := NewCheckpointer(client, "db", "checkpoints")
+	require.NoError(t, err)
+	require.NoError(t, owner.EnsureStore(ctx))
+	_, err = owner.EnsureCheckpoint(ctx, "0")
+	require.NoError(t, err)
+
+	other, err := NewCheckpointer(client, "db", "checkpoints")
+	require.NoError(t, err)
+	assert.Error(t, other.UpdateCheckpoint(ctx, "0", persist.NewCheckpoint("100", 10, time.Now())),
+		"a partition which was not acquired should not be written")
+	checkpoint, _ := owner.GetCheckpoint(ctx, "0")
+	assert.Equal(t, persist.StartOfStream, checkpoint.Offset)
+}
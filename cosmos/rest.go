@@ -0,0 +1,226 @@
+package cosmos
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// restAPIVersion is the version of the Cosmos DB REST API used by the REST client
+	restAPIVersion = "2018-12-31"
+)
+
+type (
+	// restClient implements Client over the Cosmos DB SQL REST API, authorizing each request with the master key of
+	// the account
+	restClient struct {
+		endpoint   *url.URL
+		masterKey  []byte
+		httpClient *http.Client
+	}
+
+	containerDefinition struct {
+		ID           string                 `json:"id"`
+		PartitionKey partitionKeyDefinition `json:"partitionKey"`
+	}
+
+	partitionKeyDefinition struct {
+		Paths []string `json:"paths"`
+		Kind  string   `json:"kind"`
+	}
+)
+
+// NewRESTClient returns a Client which calls the Cosmos DB SQL REST API of the account at the endpoint, such as
+// https://myaccount.documents.azure.com:443/, authorizing requests with the base64 encoded master key of the account.
+// The database of the Checkpointer must exist; its container is created by EnsureStore.
+func NewRESTClient(endpoint, masterKey string) (Client, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return nil, errors.Errorf("endpoint %q must be an absolute URL", endpoint)
+	}
+
+	key, err := base64.StdEncoding.DecodeString(masterKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "master key must be base64 encoded")
+	}
+
+	return &restClient{
+		endpoint:   u,
+		masterKey:  key,
+		httpClient: http.DefaultClient,
+	}, nil
+}
+
+// ContainerExists returns true if the container exists in the database
+func (c *restClient) ContainerExists(ctx context.Context, database, container string) (bool, error) {
+	link := containerLink(database, container)
+	_, _, err := c.do(ctx, http.MethodGet, "colls", link, link, "", "", nil)
+	if err == ErrNotFound {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// CreateContainerIfNotExists creates the container in the database, partitioned by the partition key path
+func (c *restClient) CreateContainerIfNotExists(ctx context.Context, database, container, partitionKeyPath string) error {
+	body, err := json.Marshal(containerDefinition{
+		ID: container,
+		PartitionKey: partitionKeyDefinition{
+			Paths: []string{partitionKeyPath},
+			Kind:  "Hash",
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	link := "dbs/" + database
+	_, _, err = c.do(ctx, http.MethodPost, "colls", link, link+"/colls", "", "", body)
+	if err == ErrConflict {
+		return nil
+	}
+	return err
+}
+
+// DeleteContainer deletes the container and its items
+func (c *restClient) DeleteContainer(ctx context.Context, database, container string) error {
+	link := containerLink(database, container)
+	_, _, err := c.do(ctx, http.MethodDelete, "colls", link, link, "", "", nil)
+	return err
+}
+
+// ReadItem returns the item with the ID and its ETag
+func (c *restClient) ReadItem(ctx context.Context, database, container, partitionKey, id string) ([]byte, string, error) {
+	link := itemLink(database, container, id)
+	return c.do(ctx, http.MethodGet, "docs", link, link, partitionKey, "", nil)
+}
+
+// CreateItem creates the item, returning ErrConflict if an item with the same ID exists
+func (c *restClient) CreateItem(ctx context.Context, database, container, partitionKey string, item []byte) (string, error) {
+	link := containerLink(database, container)
+	_, etag, err := c.do(ctx, http.MethodPost, "docs", link, link+"/docs", partitionKey, "", item)
+	return etag, err
+}
+
+// ReplaceItem replaces the item, returning ErrPreconditionFailed if its ETag does not match ifMatch
+func (c *restClient) ReplaceItem(ctx context.Context, database, container, partitionKey, id string, item []byte, ifMatch string) (string, error) {
+	link := itemLink(database, container, id)
+	_, etag, err := c.do(ctx, http.MethodPut, "docs", link, link, partitionKey, ifMatch, item)
+	return etag, err
+}
+
+// DeleteItem deletes the item with the ID
+func (c *restClient) DeleteItem(ctx context.Context, database, container, partitionKey, id string) error {
+	link := itemLink(database, container, id)
+	_, _, err := c.do(ctx, http.MethodDelete, "docs", link, link, partitionKey, "", nil)
+	return err
+}
+
+// do sends a request for the resource at path, authorized for the resource type and link, and returns the response
+// body and ETag. Not found, conflict and precondition failed responses are returned as ErrNotFound, ErrConflict and
+// ErrPreconditionFailed.
+func (c *restClient) do(ctx context.Context, method, resourceType, resourceLink, path, partitionKey, ifMatch string, body []byte) ([]byte, string, error) {
+	u := *c.endpoint
+	u.Path = "/" + path
+	req, err := http.NewRequest(method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, "", err
+	}
+	req = req.WithContext(ctx)
+
+	date := time.Now().UTC().Format(http.TimeFormat)
+	req.Header.Set("x-ms-date", date)
+	req.Header.Set("x-ms-version", restAPIVersion)
+	req.Header.Set("Authorization", c.authorization(method, resourceType, resourceLink, date))
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if partitionKey != "" {
+		key, err := json.Marshal([]string{partitionKey})
+		if err != nil {
+			return nil, "", err
+		}
+		req.Header.Set("x-ms-documentdb-partitionkey", string(key))
+	}
+	if ifMatch != "" {
+		req.Header.Set("If-Match", ifMatch)
+	}
+
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer res.Body.Close()
+
+	resBody, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, "", err
+	}
+
+	switch {
+	case res.StatusCode == http.StatusNotFound:
+		return nil, "", ErrNotFound
+	case res.StatusCode == http.StatusConflict:
+		return nil, "", ErrConflict
+	case res.StatusCode == http.StatusPreconditionFailed:
+		return nil, "", ErrPreconditionFailed
+	case res.StatusCode < 200 || res.StatusCode > 299:
+		return nil, "", errors.Errorf("cosmos: %s %s returned %d: %s", method, u.Path, res.StatusCode, strings.TrimSpace(string(resBody)))
+	}
+	return resBody, res.Header.Get("Etag"), nil
+}
+
+// authorization returns the master key authorization of a request for the resource, signed over the verb, resource
+// type, resource link and date of the request
+func (c *restClient) authorization(method, resourceType, resourceLink, date string) string {
+	payload := fmt.Sprintf("%s\n%s\n%s\n%s\n\n", strings.ToLower(method), strings.ToLower(resourceType), resourceLink, strings.ToLower(date))
+	mac := hmac.New(sha256.New, c.masterKey)
+	mac.Write([]byte(payload))
+	signature := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+	return url.QueryEscape("type=master&ver=1.0&sig=" + signature)
+}
+
+func containerLink(database, container string) string {
+	return "dbs/" + database + "/colls/" + container
+}
+
+func itemLink(database, container, id string) string {
+	return containerLink(database, container) + "/docs/" + id
+}
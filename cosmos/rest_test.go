@@ -0,0 +1,212 @@
+package cosmos
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-amqp-common-go/persist"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	// testMasterKey is the example master key of the Cosmos DB access control documentation
+	testMasterKey = "dsZQi3KtZmCv1ljt3VNWNm7sQUF1y5rJfC6kv5JiwvW0EndXdDku/dkKBp8/ufDToSxLzR4y+O/0H/t4bQtVNw=="
+)
+
+type (
+	// restServer serves the subset of the Cosmos DB REST API used by the REST client, checking that each request is
+	// authorized and carries the headers the API requires
+	restServer struct {
+		t          *testing.T
+		client     *restClient
+		mu         sync.Mutex
+		containers map[string]map[string]memoryItem
+		version    int
+	}
+)
+
+func newRESTServer(t *testing.T) (*httptest.Server, *restClient) {
+	rs := &restServer{t: t, containers: make(map[string]map[string]memoryItem)}
+	server := httptest.NewServer(rs)
+	client, err := NewRESTClient(server.URL, testMasterKey)
+	require.NoError(t, err)
+	rs.client = client.(*restClient)
+	return server, rs.client
+}
+
+func (rs *restServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	segments := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	resourceType, link := segments[len(segments)-1], strings.Join(segments[:len(segments)-1], "/")
+	if len(segments)%2 == 0 {
+		resourceType, link = segments[len(segments)-2], strings.Join(segments, "/")
+	}
+	assert.Equal(rs.t, restAPIVersion, r.Header.Get("x-ms-version"))
+	if !assert.Equal(rs.t, rs.client.authorization(r.Method, resourceType, link, r.Header.Get("x-ms-date")), r.Header.Get("Authorization")) {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	if resourceType == "docs" {
+		assert.Equal(rs.t, `["0"]`, r.Header.Get("x-ms-documentdb-partitionkey"))
+	}
+
+	body, err := ioutil.ReadAll(r.Body)
+	require.NoError(rs.t, err)
+
+	var container string
+	if len(segments) >= 4 {
+		container = strings.Join(segments[:4], "/")
+	}
+	switch {
+	case resourceType == "colls" && r.Method == http.MethodPost:
+		var definition containerDefinition
+		require.NoError(rs.t, json.Unmarshal(body, &definition))
+		assert.Equal(rs.t, []string{PartitionKeyPath}, definition.PartitionKey.Paths)
+		container = link + "/colls/" + definition.ID
+		if _, ok := rs.containers[container]; ok {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		rs.containers[container] = make(map[string]memoryItem)
+		w.WriteHeader(http.StatusCreated)
+	case resourceType == "colls":
+		if _, ok := rs.containers[container]; !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.Method == http.MethodDelete {
+			delete(rs.containers, container)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	case r.Method == http.MethodPost:
+		var item checkpointItem
+		require.NoError(rs.t, json.Unmarshal(body, &item))
+		if _, ok := rs.containers[container][item.ID]; ok {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		rs.put(w, http.StatusCreated, container, item.ID, body)
+	default:
+		id := segments[len(segments)-1]
+		existing, ok := rs.containers[container][id]
+		switch {
+		case !ok:
+			w.WriteHeader(http.StatusNotFound)
+		case r.Method == http.MethodGet:
+			w.Header().Set("Etag", existing.etag)
+			w.Write(existing.body)
+		case r.Method == http.MethodPut && r.Header.Get("If-Match") != existing.etag:
+			w.WriteHeader(http.StatusPreconditionFailed)
+		case r.Method == http.MethodPut:
+			rs.put(w, http.StatusOK, container, id, body)
+		case r.Method == http.MethodDelete:
+			delete(rs.containers[container], id)
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}
+}
+
+func (rs *restServer) put(w http.ResponseWriter, status int, container, id string, body []byte) {
+	rs.version++
+	etag := fmt.Sprintf(`"%08d-0000"`, rs.version)
+	rs.containers[container][id] = memoryItem{body: body, etag: etag}
+	w.Header().Set("Etag", etag)
+	w.WriteHeader(status)
+	w.Write(body)
+}
+
+func TestRESTClientAuthorization(t *testing.T) {
+	client, err := NewRESTClient("https://account.documents.azure.com:443/", testMasterKey)
+	require.NoError(t, err)
+
+	// the example signature of the Cosmos DB access control documentation
+	auth := client.(*restClient).authorization(http.MethodGet, "dbs", "dbs/ToDoList", "Thu, 27 Apr 2017 00:51:12 GMT")
+	assert.Equal(t, "type%3Dmaster%26ver%3D1.0%26sig%3Dc09PEVJrgp2uQRkr934kFbTqhByc7TVr3OHyqlu%2Bc%2Bc%3D", auth)
+}
+
+func TestNewRESTClient(t *testing.T) {
+	_, err := NewRESTClient("account.documents.azure.com", testMasterKey)
+	assert.Error(t, err, "a relative endpoint should be an error")
+	_, err = NewRESTClient("https://account.documents.azure.com:443/", "not base64!")
+	assert.Error(t, err, "a key which is not base64 encoded should be an error")
+}
+
+func TestCheckpointerWithRESTClient(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	server, client := newRESTServer(t)
+	defer server.Close()
+
+	checkpointer, err := NewCheckpointer(client, "db", "checkpoints")
+	require.NoError(t, err)
+
+	exists, err := checkpointer.StoreExists(ctx)
+	require.NoError(t, err)
+	assert.False(t, exists)
+	require.NoError(t, checkpointer.EnsureStore(ctx))
+	require.NoError(t, checkpointer.EnsureStore(ctx), "creating an existing container should succeed")
+	exists, err = checkpointer.StoreExists(ctx)
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	_, ok := checkpointer.GetCheckpoint(ctx, "0")
+	assert.False(t, ok)
+	_, err = checkpointer.EnsureCheckpoint(ctx, "0")
+	require.NoError(t, err)
+
+	enqueued := time.Date(2018, 6, 1, 0, 0, 0, 0, time.UTC)
+	require.NoError(t, checkpointer.UpdateCheckpoint(ctx, "0", persist.NewCheckpoint("100", 10, enqueued)))
+	checkpoint, ok := checkpointer.GetCheckpoint(ctx, "0")
+	assert.True(t, ok)
+	assert.Equal(t, "100", checkpoint.Offset)
+
+	_, etag, err := client.ReadItem(ctx, "db", "checkpoints", "0", "0")
+	require.NoError(t, err)
+	_, err = client.ReplaceItem(ctx, "db", "checkpoints", "0", "0", []byte(`{"id":"0","partitionId":"0"}`), etag)
+	require.NoError(t, err)
+	assert.Error(t, checkpointer.UpdateCheckpoint(ctx, "0", persist.NewCheckpoint("200", 20, enqueued)),
+		"a checkpoint written by another writer should fail the precondition")
+
+	assert.Equal(t, ErrNotFound, client.DeleteItem(ctx, "db", "checkpoints", "0", "1"))
+	require.NoError(t, client.DeleteItem(ctx, "db", "checkpoints", "0", "0"))
+	require.NoError(t, checkpointer.DeleteStore(ctx))
+	exists, err = checkpointer.StoreExists(ctx)
+	require.NoError(t, err)
+	assert.False(t, exists)
+}
@@ -0,0 +1,131 @@
+package eventhub
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"github.com/pkg/errors"
+)
+
+const (
+	// EncryptionPropertyName is the application property which holds the ID of the key the event data was encrypted
+	// with
+	EncryptionPropertyName = "x-encryption"
+)
+
+type (
+	// Encryptor encrypts and decrypts event data, such as with envelope encryption. The key ID returned by Encrypt is
+	// set as the x-encryption application property on sent events and is passed to Decrypt for received events, so
+	// keys may be rotated while events encrypted with earlier keys are still being received.
+	Encryptor interface {
+		Encrypt(data []byte) (keyID string, encrypted []byte, err error)
+		Decrypt(keyID string, data []byte) ([]byte, error)
+	}
+)
+
+// HubWithPayloadEncryption configures the Hub to encrypt the data of sent events with the encryptor and set the
+// x-encryption application property to the ID of the key used. Received events carrying the x-encryption property are
+// decrypted with the encryptor, while events without the property are passed through untouched. When the Hub also
+// compresses payloads, data is compressed before it is encrypted and decrypted before it is decompressed.
+func HubWithPayloadEncryption(encryptor Encryptor) HubOption {
+	return func(h *Hub) error {
+		if encryptor == nil {
+			return errors.New("encryptor must not be nil")
+		}
+		h.encryptor = encryptor
+		return nil
+	}
+}
+
+// encryptEvent returns a copy of the event with encrypted data if the Hub has been configured with an encryptor.
+// Events with an amqp-value body are sent unencrypted.
+func (h *Hub) encryptEvent(event *Event) (*Event, error) {
+	if h.encryptor == nil || event.value != nil {
+		return event, nil
+	}
+
+	keyID, data, err := h.encryptor.Encrypt(event.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	encrypted := *event
+	encrypted.Data = data
+	// a received event holds the message it arrived in, which would be sent in place of the encrypted data and
+	// modified while being sent
+	encrypted.message = nil
+	encrypted.Properties = make(map[string]interface{}, len(event.Properties)+1)
+	for key, value := range event.Properties {
+		encrypted.Properties[key] = value
+	}
+	encrypted.Properties[EncryptionPropertyName] = keyID
+	return &encrypted, nil
+}
+
+// encryptBatch returns a copy of the batch with each event encrypted if the Hub has been configured with an encryptor
+func (h *Hub) encryptBatch(batch *EventBatch) (*EventBatch, error) {
+	if h.encryptor == nil {
+		return batch, nil
+	}
+
+	encrypted := *batch
+	encrypted.Events = make([]*Event, len(batch.Events))
+	for idx, event := range batch.Events {
+		e, err := h.encryptEvent(event)
+		if err != nil {
+			return nil, err
+		}
+		encrypted.Events[idx] = e
+	}
+	return &encrypted, nil
+}
+
+// decryptEvent decrypts the data of an event carrying the x-encryption application property in place
+func (h *Hub) decryptEvent(event *Event) error {
+	val, ok := event.Properties[EncryptionPropertyName]
+	if !ok {
+		return nil
+	}
+
+	keyID, ok := val.(string)
+	if !ok {
+		return errors.Errorf("%s application property must be a string, but was %T", EncryptionPropertyName, val)
+	}
+	if h.encryptor == nil {
+		return errors.Errorf("no encryptor is available to decrypt event data encrypted with key %q", keyID)
+	}
+
+	data, err := h.encryptor.Decrypt(keyID, event.Data)
+	if err != nil {
+		return err
+	}
+	event.Data = data
+	return nil
+}
+
+// decodeEvent decrypts and then decompresses the data of a received event in place
+func (h *Hub) decodeEvent(event *Event) error {
+	if err := h.decryptEvent(event); err != nil {
+		return err
+	}
+	return h.decompressEvent(event)
+}
@@ -0,0 +1,147 @@
+package eventhub
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"pack.ag/amqp"
+)
+
+type (
+	// xorEncryptor is a toy encryptor which XORs data with the key byte named by the key ID
+	xorEncryptor struct {
+		keyID string
+		keys  map[string]byte
+	}
+)
+
+func (e xorEncryptor) Encrypt(data []byte) (string, []byte, error) {
+	return e.keyID, xor(data, e.keys[e.keyID]), nil
+}
+
+func (e xorEncryptor) Decrypt(keyID string, data []byte) ([]byte, error) {
+	key, ok := e.keys[keyID]
+	if !ok {
+		return nil, errors.New("unknown key")
+	}
+	return xor(data, key), nil
+}
+
+func xor(data []byte, key byte) []byte {
+	out := make([]byte, len(data))
+	for i, b := range data {
+		out[i] = b ^ key
+	}
+	return out
+}
+
+func TestPayloadEncryptionRoundTrip(t *testing.T) {
+	assert.Error(t, HubWithPayloadEncryption(nil)(new(Hub)))
+
+	keys := map[string]byte{"v1": 0x2a, "v2": 0x17}
+	hub, err := NewHub("ns", "hub", nil, HubWithPayloadEncryption(xorEncryptor{keyID: "v2", keys: keys}))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	original := NewEventFromString("secret")
+	encrypted, err := hub.encryptEvent(original)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "v2", encrypted.Properties[EncryptionPropertyName])
+	assert.NotEqual(t, "secret", string(encrypted.Data))
+	assert.Equal(t, "secret", string(original.Data), "the original event should not be modified")
+	assert.NoError(t, hub.decodeEvent(encrypted))
+	assert.Equal(t, "secret", string(encrypted.Data))
+
+	// events encrypted with an earlier key are decrypted with that key
+	rotated := NewEvent(xor([]byte("older"), keys["v1"]))
+	rotated.Set(EncryptionPropertyName, "v1")
+	assert.NoError(t, hub.decodeEvent(rotated))
+	assert.Equal(t, "older", string(rotated.Data))
+}
+
+func TestEncryptReceivedEvent(t *testing.T) {
+	hub, err := NewHub("ns", "hub", nil, HubWithPayloadEncryption(xorEncryptor{keyID: "v1", keys: map[string]byte{"v1": 0x2a}}))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	received := eventFromMsg(amqp.NewMessage([]byte("secret")))
+	encrypted, err := hub.encryptEvent(received)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	msg := encrypted.toMsg()
+	if assert.Len(t, msg.Data, 1) {
+		assert.Equal(t, encrypted.Data, msg.Data[0], "the encrypted data should be sent rather than the received message")
+	}
+	assert.Equal(t, "v1", msg.ApplicationProperties[EncryptionPropertyName])
+	assert.Nil(t, received.message.ApplicationProperties, "the received message should not be modified")
+}
+
+func TestPayloadEncryptionWithCompression(t *testing.T) {
+	hub, err := NewHub("ns", "hub", nil,
+		HubWithPayloadCompression(GzipCodec{}),
+		HubWithPayloadEncryption(xorEncryptor{keyID: "v1", keys: map[string]byte{"v1": 0x2a}}))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	data := bytes.Repeat([]byte("compressible "), 100)
+	batch, err := hub.compressBatch(NewEventBatch([]*Event{NewEvent(data)}))
+	if !assert.NoError(t, err) {
+		return
+	}
+	batch, err = hub.encryptBatch(batch)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	sent := batch.Events[0]
+	assert.Equal(t, "gzip", sent.Properties[CompressionPropertyName])
+	assert.Equal(t, "v1", sent.Properties[EncryptionPropertyName])
+	assert.NoError(t, hub.decodeEvent(sent))
+	assert.Equal(t, data, sent.Data)
+}
+
+func TestUnencryptedEventPassesThrough(t *testing.T) {
+	hub, err := NewHub("ns", "hub", nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	event := NewEventFromString("plain")
+	assert.NoError(t, hub.decodeEvent(event))
+	assert.Equal(t, "plain", string(event.Data))
+
+	// a Hub without an encryptor can't decrypt events which are encrypted
+	event.Set(EncryptionPropertyName, "v1")
+	assert.Error(t, hub.decodeEvent(event))
+}
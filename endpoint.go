@@ -0,0 +1,85 @@
+package eventhub
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	amqpScheme  = "amqp"
+	amqpsScheme = "amqps"
+)
+
+// HubWithEndpointOverride connects the Hub to the endpoint rather than the host of its namespace, such as to use a
+// local emulator or a custom gateway. The endpoint is a host, a host and port, or a URL such as amqp://localhost:5672;
+// the amqps scheme is assumed when none is given, and the amqp scheme connects without TLS. The audience of the tokens
+// sent to the endpoint is still computed from the namespace and Event Hub names unless HubWithAudienceOverride is also
+// given.
+func HubWithEndpointOverride(endpoint string) HubOption {
+	return func(h *Hub) error {
+		u, err := parseEndpoint(endpoint)
+		if err != nil {
+			return err
+		}
+		h.namespace.endpoint = u
+		return nil
+	}
+}
+
+// HubWithAudienceOverride sets the base of the audience of the tokens used to authorize the Hub's links, such as
+// sb://gateway.example.com, in place of the URI of the namespace. The path of each entity is appended to the audience.
+func HubWithAudienceOverride(audience string) HubOption {
+	return func(h *Hub) error {
+		if audience == "" {
+			return errors.New("audience must not be empty")
+		}
+		h.namespace.audience = audience
+		return nil
+	}
+}
+
+// parseEndpoint parses an endpoint given as a host, a host and port, or an amqp or amqps URL
+func parseEndpoint(endpoint string) (*url.URL, error) {
+	if endpoint == "" {
+		return nil, errors.New("endpoint must not be empty")
+	}
+	if !strings.Contains(endpoint, "://") {
+		endpoint = amqpsScheme + "://" + endpoint
+	}
+
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid endpoint")
+	}
+	if u.Scheme != amqpScheme && u.Scheme != amqpsScheme {
+		return nil, errors.Errorf("endpoint %q must have the amqp or amqps scheme", endpoint)
+	}
+	if u.Hostname() == "" {
+		return nil, errors.Errorf("endpoint %q must have a host", endpoint)
+	}
+	return &url.URL{Scheme: u.Scheme, Host: u.Host}, nil
+}
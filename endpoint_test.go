@@ -0,0 +1,74 @@
+package eventhub
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHubWithEndpointOverride(t *testing.T) {
+	for _, endpoint := range []string{"", "http://localhost:5672", "amqp://", "amqps://:5671"} {
+		_, err := NewHub("namespace", "hub", nil, HubWithEndpointOverride(endpoint))
+		assert.Error(t, err, endpoint)
+	}
+
+	cases := []struct {
+		endpoint string
+		uri      string
+		hostname string
+	}{
+		{endpoint: "gateway.example.com", uri: "amqps://gateway.example.com/", hostname: "gateway.example.com"},
+		{endpoint: "gateway.example.com:5700", uri: "amqps://gateway.example.com:5700/", hostname: "gateway.example.com"},
+		{endpoint: "amqp://localhost:5672", uri: "amqp://localhost:5672/", hostname: "localhost"},
+		{endpoint: "amqps://localhost/ignored", uri: "amqps://localhost/", hostname: "localhost"},
+	}
+	for _, c := range cases {
+		hub, err := NewHub("namespace", "hub", nil, HubWithEndpointOverride(c.endpoint))
+		if !assert.NoError(t, err, c.endpoint) {
+			continue
+		}
+		assert.Equal(t, c.uri, hub.namespace.getAmqpHostURI(), c.endpoint)
+		assert.Equal(t, c.hostname, hub.namespace.getHostname(), c.endpoint)
+		assert.Equal(t, "amqps://namespace.servicebus.windows.net/hub", hub.namespace.getEntityAudience("hub"),
+			"the audience should be computed from the namespace")
+	}
+}
+
+func TestHubWithAudienceOverride(t *testing.T) {
+	_, err := NewHub("namespace", "hub", nil, HubWithAudienceOverride(""))
+	assert.Error(t, err)
+
+	hub, err := NewHub("namespace", "hub", nil, HubWithEndpointOverride("localhost:5671"), HubWithAudienceOverride("sb://gateway.example.com/"))
+	require.NoError(t, err)
+	assert.Equal(t, "sb://gateway.example.com/hub/$management", hub.namespace.getEntityAudience("hub/$management"))
+}
+
+func TestPlainEndpointRequiresDirectConnection(t *testing.T) {
+	hub, err := NewHub("namespace", "hub", nil, HubWithEndpointOverride("amqp://localhost:5672"), HubWithWebSocketConnection())
+	require.NoError(t, err)
+	_, err = hub.namespace.newConnection()
+	assert.Error(t, err)
+}
@@ -0,0 +1,77 @@
+package eph
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+type (
+	// BalanceCycle describes one cycle of the balancing loop, in which the host acquires expired leases or, if there
+	// are none, may steal a lease from another host
+	BalanceCycle struct {
+		// Acquired is the number of expired leases acquired
+		Acquired int
+		// Stolen is the number of leases stolen from other hosts
+		Stolen int
+		// Released is the number of leases acquired or stolen in the cycle which were released again because the
+		// receiver of the partition was disconnected by another host
+		Released int
+		// Duration is how long the cycle took, including starting the receivers of the partitions it leased
+		Duration time.Duration
+		// Err is the Leaser error which ended the cycle early, if any
+		Err error
+	}
+
+	// BalanceMetricsRecorder records the outcome of each cycle of the balancing loop of an EventProcessorHost
+	BalanceMetricsRecorder interface {
+		// RecordBalanceCycle is called as each cycle of the balancing loop completes
+		RecordBalanceCycle(cycle BalanceCycle)
+	}
+)
+
+// WithBalanceMetrics configures the EventProcessorHost to record how many leases each cycle of the balancing loop
+// acquired, stole and released, and how long it took. Frequent cycles which steal leases indicate partitions moving
+// back and forth between hosts. The recorder is called synchronously, so it should return quickly.
+func WithBalanceMetrics(recorder BalanceMetricsRecorder) EventProcessorHostOption {
+	return func(host *EventProcessorHost) error {
+		if recorder == nil {
+			return errors.New("balance metrics recorder must not be nil")
+		}
+		host.balanceMetrics = recorder
+		return nil
+	}
+}
+
+// recordBalanceCycle records the cycle, which started at start on the clock of the host, if the host is configured
+// with a recorder
+func (h *EventProcessorHost) recordBalanceCycle(cycle BalanceCycle, start time.Time, err error) {
+	if h.balanceMetrics == nil {
+		return
+	}
+	cycle.Duration = h.getClock().Now().Sub(start)
+	cycle.Err = err
+	h.balanceMetrics.RecordBalanceCycle(cycle)
+}
@@ -0,0 +1,126 @@
+package eph
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type (
+	recordingBalanceMetrics struct {
+		cycles []BalanceCycle
+	}
+
+	// advancingLeaser advances the clock each time the leases are fetched, as if fetching them took that long
+	advancingLeaser struct {
+		Leaser
+		clock *manualClock
+		by    time.Duration
+	}
+)
+
+func (r *recordingBalanceMetrics) RecordBalanceCycle(cycle BalanceCycle) {
+	r.cycles = append(r.cycles, cycle)
+}
+
+func (l *advancingLeaser) GetLeases(ctx context.Context) ([]LeaseMarker, error) {
+	l.clock.Advance(l.by)
+	return l.Leaser.GetLeases(ctx)
+}
+
+func TestWithBalanceMetrics(t *testing.T) {
+	host := new(EventProcessorHost)
+	assert.Error(t, WithBalanceMetrics(nil)(host))
+	recorder := new(recordingBalanceMetrics)
+	assert.NoError(t, WithBalanceMetrics(recorder)(host))
+	assert.Equal(t, recorder, host.balanceMetrics)
+}
+
+func TestScanRecordsBalanceCycle(t *testing.T) {
+	store := newMemoryLeaserCheckpointer(DefaultLeaseDuration, new(sharedStore))
+	recorder := new(recordingBalanceMetrics)
+	host := &EventProcessorHost{
+		name:            "host",
+		leaser:          store,
+		checkpointer:    store,
+		partitionIDs:    []string{"0", "1"},
+		balancer:        GreedyLeaseBalancer{},
+		partitionFilter: func(partitionID string) bool { return false },
+		balanceMetrics:  recorder,
+	}
+	store.SetEventHostProcessor(host)
+
+	ctx := context.Background()
+	require.NoError(t, store.EnsureStore(ctx))
+	for _, partitionID := range host.partitionIDs {
+		_, err := store.EnsureLease(ctx, partitionID)
+		require.NoError(t, err)
+	}
+
+	assert.NoError(t, newScheduler(host).scan(ctx))
+	if assert.Len(t, recorder.cycles, 1) {
+		cycle := recorder.cycles[0]
+		assert.Equal(t, 0, cycle.Acquired+cycle.Stolen+cycle.Released, "no partition passes the filter")
+		assert.True(t, cycle.Duration > 0)
+		assert.NoError(t, cycle.Err)
+	}
+
+	leaserErr := errors.New("store unavailable")
+	host.leaser = &failingLeaser{Leaser: store, err: leaserErr}
+	assert.Equal(t, leaserErr, newScheduler(host).scan(ctx))
+	if assert.Len(t, recorder.cycles, 2) {
+		assert.Equal(t, leaserErr, recorder.cycles[1].Err)
+	}
+}
+
+func TestScanMeasuresBalanceCycleOnClock(t *testing.T) {
+	clock := newManualClock()
+	store := newMemoryLeaserCheckpointer(DefaultLeaseDuration, &sharedStore{clock: clock})
+	recorder := new(recordingBalanceMetrics)
+	host := &EventProcessorHost{
+		name:           "host",
+		leaser:         &advancingLeaser{Leaser: store, clock: clock, by: 5 * time.Second},
+		partitionIDs:   []string{"0"},
+		balancer:       GreedyLeaseBalancer{},
+		balanceMetrics: recorder,
+		clock:          clock,
+		// no partition passes the filter, so the scan doesn't open receivers
+		partitionFilter: func(partitionID string) bool { return false },
+	}
+	store.SetEventHostProcessor(host)
+	ctx := context.Background()
+	require.NoError(t, store.EnsureStore(ctx))
+	_, err := store.EnsureLease(ctx, "0")
+	require.NoError(t, err)
+
+	assert.NoError(t, newScheduler(host).scan(ctx))
+	if assert.Len(t, recorder.cycles, 1) {
+		assert.Equal(t, 5*time.Second, recorder.cycles[0].Duration, "the cycle should be measured on the clock of the host")
+	}
+}
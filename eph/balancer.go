@@ -0,0 +1,83 @@
+package eph
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+
+	"github.com/Azure/azure-amqp-common-go/log"
+	"github.com/pkg/errors"
+)
+
+type (
+	// LeaseBalancer decides which partition lease, if any, an EventProcessorHost should attempt to steal from another
+	// host in order to balance the partitions across hosts. LeaseToSteal is given all of the leases for the Event Hub
+	// and the subset of those leases owned by the host.
+	LeaseBalancer interface {
+		LeaseToSteal(ctx context.Context, leases []LeaseMarker, owned []LeaseMarker) (LeaseMarker, bool)
+	}
+
+	// GreedyLeaseBalancer steals a random lease from the host owning the most leases whenever that host owns at least
+	// two more leases than the local host
+	GreedyLeaseBalancer struct{}
+)
+
+// WithLeaseBalancer configures an EventProcessorHost to use the LeaseBalancer to choose which leases to steal from
+// other hosts. The GreedyLeaseBalancer is used by default.
+func WithLeaseBalancer(balancer LeaseBalancer) EventProcessorHostOption {
+	return func(host *EventProcessorHost) error {
+		if balancer == nil {
+			return errors.New("lease balancer must not be nil")
+		}
+		host.balancer = balancer
+		return nil
+	}
+}
+
+// LeaseToSteal returns a lease owned by the host with the most leases if work has become imbalanced
+func (GreedyLeaseBalancer) LeaseToSteal(ctx context.Context, leases []LeaseMarker, owned []LeaseMarker) (LeaseMarker, bool) {
+	span, ctx := startConsumerSpanFromContext(ctx, "eventhub.eph.GreedyLeaseBalancer.LeaseToSteal")
+	defer span.Finish()
+
+	ownedIDs := make(map[string]bool, len(owned))
+	for _, lease := range owned {
+		ownedIDs[lease.GetPartitionID()] = true
+	}
+
+	var candidates []LeaseMarker
+	for _, lease := range leases {
+		if !ownedIDs[lease.GetPartitionID()] {
+			candidates = append(candidates, lease)
+		}
+	}
+
+	biggestOwner := ownerWithMostLeases(candidates)
+	if biggestOwner != nil && (len(biggestOwner.Leases)-len(owned)) >= 2 {
+		log.For(ctx).Debug(fmt.Sprintf("the biggest owner is %v and leases by owner: %v", biggestOwner.Owner, leasesByOwner(candidates)))
+		selection := rand.Intn(len(biggestOwner.Leases))
+		return biggestOwner.Leases[selection], true
+	}
+	return nil, false
+}
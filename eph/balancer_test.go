@@ -0,0 +1,68 @@
+package eph
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newOwnedLeases(owner string, partitionIDs ...int) []LeaseMarker {
+	leases := make([]LeaseMarker, len(partitionIDs))
+	for idx, partitionID := range partitionIDs {
+		lease := newMemoryLease(strconv.Itoa(partitionID))
+		lease.Owner = owner
+		leases[idx] = lease
+	}
+	return leases
+}
+
+func TestGreedyLeaseBalancer(t *testing.T) {
+	tests := map[string]struct {
+		others    []LeaseMarker
+		owned     []LeaseMarker
+		stealFrom string
+	}{
+		"Balanced":             {others: newOwnedLeases("other", 0, 1), owned: newOwnedLeases("me", 2, 3)},
+		"OffByOne":             {others: newOwnedLeases("other", 0, 1), owned: newOwnedLeases("me", 2)},
+		"Imbalanced":           {others: newOwnedLeases("other", 0, 1, 2), owned: newOwnedLeases("me"), stealFrom: "other"},
+		"StealFromBiggestHost": {others: append(newOwnedLeases("small", 0), newOwnedLeases("big", 1, 2, 3)...), owned: newOwnedLeases("me", 4), stealFrom: "big"},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			leases := append(append([]LeaseMarker{}, tt.others...), tt.owned...)
+			lease, ok := GreedyLeaseBalancer{}.LeaseToSteal(context.Background(), leases, tt.owned)
+			if tt.stealFrom == "" {
+				assert.False(t, ok)
+				return
+			}
+			if assert.True(t, ok) {
+				assert.Equal(t, tt.stealFrom, lease.GetOwner())
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package eph
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// DefaultBalancingBackoffMaxDelay is the longest the balancing loop waits between scans after consecutive scans
+	// failed with a Leaser error
+	DefaultBalancingBackoffMaxDelay = 5 * time.Minute
+)
+
+// WithBalancingBackoff configures how long the balancing loop waits to scan again after a scan fails with a Leaser
+// error, such as during a store outage. The first failed scan is followed by minDelay, which doubles after each
+// consecutive failure up to maxDelay. Once a scan succeeds the loop returns to scanning every lease renewal interval.
+// By default the delay doubles from the lease renewal interval up to DefaultBalancingBackoffMaxDelay.
+func WithBalancingBackoff(minDelay, maxDelay time.Duration) EventProcessorHostOption {
+	return func(host *EventProcessorHost) error {
+		if minDelay <= 0 {
+			return errors.New("balancing backoff min delay must be greater than 0")
+		}
+		if maxDelay < minDelay {
+			return errors.New("balancing backoff max delay must not be less than the min delay")
+		}
+		host.balancingBackoffMin = minDelay
+		host.balancingBackoffMax = maxDelay
+		return nil
+	}
+}
+
+// scanDelay returns how long to wait before the next scan after the number of consecutive failed scans
+func (s *scheduler) scanDelay(failures int) time.Duration {
+	if failures == 0 {
+		return s.leaseRenewalInterval
+	}
+
+	minDelay, maxDelay := s.processor.balancingBackoffMin, s.processor.balancingBackoffMax
+	if minDelay <= 0 {
+		minDelay = s.leaseRenewalInterval
+	}
+	if maxDelay <= 0 {
+		maxDelay = DefaultBalancingBackoffMaxDelay
+	}
+
+	delay := minDelay
+	for i := 1; i < failures && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxDelay {
+		delay = maxDelay
+	}
+	return delay
+}
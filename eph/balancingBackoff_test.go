@@ -0,0 +1,94 @@
+package eph
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type failingLeaser struct {
+	Leaser
+	err error
+}
+
+func (l *failingLeaser) GetLeases(ctx context.Context) ([]LeaseMarker, error) {
+	return nil, l.err
+}
+
+func TestWithBalancingBackoff(t *testing.T) {
+	host := new(EventProcessorHost)
+	assert.Error(t, WithBalancingBackoff(0, time.Second)(host))
+	assert.Error(t, WithBalancingBackoff(time.Second, time.Millisecond)(host))
+	assert.NoError(t, WithBalancingBackoff(time.Second, time.Minute)(host))
+	assert.Equal(t, time.Second, host.balancingBackoffMin)
+	assert.Equal(t, time.Minute, host.balancingBackoffMax)
+}
+
+func TestSchedulerScanDelay(t *testing.T) {
+	host := new(EventProcessorHost)
+	s := newScheduler(host)
+	assert.Equal(t, DefaultLeaseRenewalInterval, s.scanDelay(0))
+	assert.Equal(t, DefaultLeaseRenewalInterval, s.scanDelay(1))
+	assert.Equal(t, 2*DefaultLeaseRenewalInterval, s.scanDelay(2))
+	assert.Equal(t, DefaultBalancingBackoffMaxDelay, s.scanDelay(100))
+
+	require.NoError(t, WithBalancingBackoff(time.Second, 5*time.Second)(host))
+	assert.Equal(t, DefaultLeaseRenewalInterval, s.scanDelay(0), "scans which succeed should not be backed off")
+	assert.Equal(t, time.Second, s.scanDelay(1))
+	assert.Equal(t, 4*time.Second, s.scanDelay(3))
+	assert.Equal(t, 5*time.Second, s.scanDelay(4))
+}
+
+func TestSchedulerScanReturnsLeaserError(t *testing.T) {
+	leaserErr := errors.New("store unavailable")
+	host := &EventProcessorHost{name: "host", leaser: &failingLeaser{err: leaserErr}, balancer: GreedyLeaseBalancer{}}
+	assert.Equal(t, leaserErr, newScheduler(host).scan(context.Background()))
+}
+
+func TestSchedulerBacksOffAfterLeaserErrors(t *testing.T) {
+	clock := newManualClock()
+	host := &EventProcessorHost{name: "host", leaser: &failingLeaser{err: errors.New("store unavailable")}, balancer: GreedyLeaseBalancer{}, clock: clock}
+	require.NoError(t, WithBalancingBackoff(time.Minute, time.Hour)(host))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s := newScheduler(host)
+	go s.Run(ctx)
+
+	for clock.waiting() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	// a scan which succeeded would be followed by the next scan after the lease renewal interval
+	clock.Advance(DefaultLeaseRenewalInterval + time.Second)
+	assert.Equal(t, 1, clock.waiting(), "the scan after a leaser error should be backed off")
+	clock.Advance(time.Minute)
+	for clock.waiting() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,346 @@
+package eph
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-amqp-common-go/uuid"
+	"github.com/Azure/azure-event-hubs-go"
+	"github.com/pkg/errors"
+)
+
+const (
+	// batchRetryBackoff is the amount of time to wait before retrying a batch which failed its BatchHandler
+	batchRetryBackoff = 1 * time.Second
+)
+
+type (
+	// BatchHandler handles a batch of events received from a single partition. The events are in the order they were
+	// received.
+	BatchHandler func(ctx context.Context, events []*eventhub.Event) error
+
+	batchRegistration struct {
+		handler  BatchHandler
+		maxBatch int
+		maxWait  time.Duration
+	}
+
+	// partitionBatchers holds the eventBatcher of each registered BatchHandler for a partition
+	partitionBatchers struct {
+		host        *EventProcessorHost
+		partitionID string
+		batchers    map[string]*eventBatcher
+		// tracker awaits batches handled after their max wait when the receiver drains; it may be nil
+		tracker handlingTracker
+		// position numbers the events of the partition in the order they are added to the batchers
+		position int64
+		mu       sync.Mutex
+	}
+
+	// handlingTracker tracks handler invocations made outside of the receive loop, so they are awaited when the
+	// receiver drains
+	handlingTracker interface {
+		beginHandling() bool
+		endHandling()
+	}
+
+	// eventBatcher accumulates the events of a partition and hands them to a BatchHandler once the batch is full or
+	// the oldest event has waited for the max wait
+	eventBatcher struct {
+		host         *EventProcessorHost
+		partitionID  string
+		batchers     *partitionBatchers
+		registration *batchRegistration
+		events       []*eventhub.Event
+		positions    []int64
+		// handlingFrom is the position of the first event of the batch being handled, or 0 if none is
+		handlingFrom int64
+		ctx          context.Context
+		timer        *time.Timer
+		mu           sync.Mutex
+		flushMu      sync.Mutex
+	}
+)
+
+// ReceiveBatch registers a handler which is invoked with batches of up to maxBatch events from a partition. A batch
+// is handed to the handler once it is full or maxWait has passed since its first event was received. The last event
+// of a batch is checkpointed only after the handler succeeds; if the handler returns an error, the whole batch is
+// retried until it succeeds or the partition is closed. With several BatchHandlers registered, the checkpoint only
+// advances past events every BatchHandler has handled.
+//
+// While a BatchHandler holds events of a partition it has yet to handle, the EventProcessorHost does not checkpoint the
+// partition after each event, so the checkpoint never passes an event of an incomplete batch. Events pending in an
+// incomplete batch when a partition is closed are not checkpointed and will be received again by the next owner of the
+// partition.
+func (h *EventProcessorHost) ReceiveBatch(handler BatchHandler, maxBatch int, maxWait time.Duration) (close func() error, err error) {
+	if handler == nil {
+		return nil, errors.New("batch handler must not be nil")
+	}
+	if maxBatch < 1 {
+		return nil, errors.New("max batch must be at least 1")
+	}
+	if maxWait <= 0 {
+		return nil, errors.New("max wait must be greater than 0")
+	}
+
+	h.handlersMu.Lock()
+	defer h.handlersMu.Unlock()
+
+	receiverID, err := uuid.NewV4()
+	if err != nil {
+		return nil, err
+	}
+
+	h.batchHandlers[receiverID.String()] = &batchRegistration{
+		handler:  handler,
+		maxBatch: maxBatch,
+		maxWait:  maxWait,
+	}
+	close = func() error {
+		h.handlersMu.Lock()
+		defer h.handlersMu.Unlock()
+
+		delete(h.batchHandlers, receiverID.String())
+		return nil
+	}
+	return close, nil
+}
+
+func newPartitionBatchers(host *EventProcessorHost, partitionID string, tracker handlingTracker) *partitionBatchers {
+	return &partitionBatchers{
+		host:        host,
+		partitionID: partitionID,
+		batchers:    make(map[string]*eventBatcher),
+		tracker:     tracker,
+	}
+}
+
+// add appends the event to the pending batch of each registration, then handles the batches which are full. The event
+// is appended to every batch before any is handled, so a batch is never checkpointed past an event another
+// registration has yet to receive.
+func (pb *partitionBatchers) add(ctx context.Context, event *eventhub.Event, registrations map[string]*batchRegistration) error {
+	pb.mu.Lock()
+	pb.position++
+	var full []*eventBatcher
+	for id, registration := range registrations {
+		batcher := pb.get(id, registration)
+		if batcher.append(ctx, event, pb.position) {
+			full = append(full, batcher)
+		}
+	}
+	pb.mu.Unlock()
+
+	var wg sync.WaitGroup
+	var errMu sync.Mutex
+	var lastErr error
+	for _, batcher := range full {
+		wg.Add(1)
+		go func(batcher *eventBatcher) {
+			defer wg.Done()
+			if err := batcher.flush(ctx); err != nil {
+				errMu.Lock()
+				lastErr = err
+				errMu.Unlock()
+			}
+		}(batcher)
+	}
+	wg.Wait()
+	return lastErr
+}
+
+// get returns the eventBatcher for the registration, creating it if this is the first event for the registration. The
+// caller must hold the lock of the partitionBatchers.
+func (pb *partitionBatchers) get(id string, registration *batchRegistration) *eventBatcher {
+	if batcher, ok := pb.batchers[id]; ok {
+		return batcher
+	}
+
+	batcher := &eventBatcher{
+		host:         pb.host,
+		partitionID:  pb.partitionID,
+		batchers:     pb,
+		registration: registration,
+	}
+	pb.batchers[id] = batcher
+	return batcher
+}
+
+// checkpointable returns the index of the last of the positions which precedes every event the other batchers have
+// yet to handle, or false if there is none
+func (pb *partitionBatchers) checkpointable(handled *eventBatcher, positions []int64) (int, bool) {
+	pb.mu.Lock()
+	defer pb.mu.Unlock()
+
+	var limit int64
+	for _, batcher := range pb.batchers {
+		if batcher == handled {
+			continue
+		}
+		if from, ok := batcher.unhandledFrom(); ok && (limit == 0 || from < limit) {
+			limit = from
+		}
+	}
+
+	for i := len(positions) - 1; i >= 0; i-- {
+		if limit == 0 || positions[i] < limit {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// unhandled returns true if any batcher has an event which is pending or being handled
+func (pb *partitionBatchers) unhandled() bool {
+	pb.mu.Lock()
+	defer pb.mu.Unlock()
+
+	for _, batcher := range pb.batchers {
+		if _, ok := batcher.unhandledFrom(); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// append adds the event at the position to the pending batch, returning true if the batch is full
+func (b *eventBatcher) append(ctx context.Context, event *eventhub.Event, position int64) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.events = append(b.events, event)
+	b.positions = append(b.positions, position)
+	if len(b.events) == 1 {
+		b.ctx = ctx
+		b.timer = time.AfterFunc(b.registration.maxWait, b.flushExpired)
+	}
+	return len(b.events) >= b.registration.maxBatch
+}
+
+// unhandledFrom returns the position of the oldest event which is pending or being handled, or false if there is none
+func (b *eventBatcher) unhandledFrom() (int64, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch {
+	case b.handlingFrom != 0:
+		return b.handlingFrom, true
+	case len(b.positions) > 0:
+		return b.positions[0], true
+	default:
+		return 0, false
+	}
+}
+
+// flush hands the pending batch, if any, to the BatchHandler
+func (b *eventBatcher) flush(ctx context.Context) error {
+	b.flushMu.Lock()
+	defer b.flushMu.Unlock()
+
+	events, positions, _ := b.take()
+	if len(events) == 0 {
+		return nil
+	}
+	return b.handle(ctx, events, positions)
+}
+
+// flushExpired hands the pending batch to the BatchHandler once the max wait has passed. The batch is handled as an
+// in-flight handler of the receiver, so it is awaited when the receiver drains.
+func (b *eventBatcher) flushExpired() {
+	if tracker := b.batchers.tracker; tracker != nil {
+		if !tracker.beginHandling() {
+			// the receiver is draining, so the batch is left to be received again by the next owner of the partition
+			return
+		}
+		defer tracker.endHandling()
+	}
+
+	b.flushMu.Lock()
+	defer b.flushMu.Unlock()
+
+	events, positions, ctx := b.take()
+	if len(events) == 0 {
+		return
+	}
+	if err := b.handle(ctx, events, positions); err != nil {
+		b.host.logFor(ctx, "partitionID", b.partitionID).Error(err)
+	}
+}
+
+// take removes and returns the pending batch along with the positions of its events and the context of its first
+// event, marking the batch as being handled
+func (b *eventBatcher) take() ([]*eventhub.Event, []int64, context.Context) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.timer != nil {
+		b.timer.Stop()
+		b.timer = nil
+	}
+	events, positions, ctx := b.events, b.positions, b.ctx
+	b.events, b.positions, b.ctx = nil, nil, nil
+	if len(positions) > 0 {
+		b.handlingFrom = positions[0]
+	}
+	return events, positions, ctx
+}
+
+// handle invokes the BatchHandler until it succeeds or the context is done, then checkpoints the last event which
+// every other registration has handled. A batch which is not handled keeps holding back the checkpoint, so its events
+// are received again once the partition is reopened.
+func (b *eventBatcher) handle(ctx context.Context, events []*eventhub.Event, positions []int64) error {
+	span, ctx := startConsumerSpanFromContext(ctx, "eventhub.eph.eventBatcher.handle")
+	defer span.Finish()
+
+	span.SetTag(partitionIDTag, b.partitionID)
+	span.SetTag("eventhub.batch-size", len(events))
+	for {
+		err := b.registration.handler(ctx, events)
+		if err == nil {
+			break
+		}
+
+		b.host.logFor(ctx, "partitionID", b.partitionID).Error(err)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(batchRetryBackoff):
+		}
+	}
+
+	b.mu.Lock()
+	b.handlingFrom = 0
+	b.mu.Unlock()
+
+	if b.host.manualCheckpointing {
+		return nil
+	}
+	last, ok := b.batchers.checkpointable(b, positions)
+	if !ok {
+		// an older event is held by another registration, which checkpoints once it has handled it
+		return nil
+	}
+	return b.host.persister.checkpoint(ctx, b.partitionID, events[last].GetCheckpoint())
+}
@@ -0,0 +1,209 @@
+package eph
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-amqp-common-go/persist"
+	"github.com/Azure/azure-event-hubs-go"
+	"github.com/stretchr/testify/assert"
+)
+
+// testBatcher adds events to the batchers of a partition with a single registration
+type testBatcher struct {
+	batchers      *partitionBatchers
+	registrations map[string]*batchRegistration
+}
+
+func (b *testBatcher) add(ctx context.Context, event *eventhub.Event) error {
+	return b.batchers.add(ctx, event, b.registrations)
+}
+
+func newTestBatcher(handler BatchHandler, maxBatch int, maxWait time.Duration) (*testBatcher, *checkpointBatcher) {
+	checkpoints := newCheckpointBatcher(nil, time.Hour)
+	host := &EventProcessorHost{persister: &checkpointPersister{batcher: checkpoints}}
+	registration := &batchRegistration{handler: handler, maxBatch: maxBatch, maxWait: maxWait}
+	return &testBatcher{
+		batchers:      newPartitionBatchers(host, "0", nil),
+		registrations: map[string]*batchRegistration{"id": registration},
+	}, checkpoints
+}
+
+func TestEventBatcherFlushesFullBatch(t *testing.T) {
+	var batches [][]*eventhub.Event
+	batcher, checkpoints := newTestBatcher(func(ctx context.Context, events []*eventhub.Event) error {
+		batches = append(batches, events)
+		return nil
+	}, 2, time.Hour)
+
+	ctx := context.Background()
+	assert.NoError(t, batcher.add(ctx, eventhub.NewEventFromString("1")))
+	assert.Len(t, batches, 0)
+	_, ok := checkpoints.get("0")
+	assert.False(t, ok, "nothing should be checkpointed before the batch is handled")
+
+	assert.NoError(t, batcher.add(ctx, eventhub.NewEventFromString("2")))
+	if assert.Len(t, batches, 1) {
+		assert.Equal(t, "1", string(batches[0][0].Data))
+		assert.Equal(t, "2", string(batches[0][1].Data))
+	}
+	_, ok = checkpoints.get("0")
+	assert.True(t, ok, "the batch should be checkpointed once handled")
+}
+
+func TestEventBatcherFlushesAfterMaxWait(t *testing.T) {
+	handled := make(chan []*eventhub.Event, 1)
+	batcher, _ := newTestBatcher(func(ctx context.Context, events []*eventhub.Event) error {
+		handled <- events
+		return nil
+	}, 10, 10*time.Millisecond)
+
+	assert.NoError(t, batcher.add(context.Background(), eventhub.NewEventFromString("1")))
+	select {
+	case events := <-handled:
+		assert.Len(t, events, 1)
+	case <-time.After(time.Second):
+		assert.Fail(t, "batch should be handled once max wait has passed")
+	}
+}
+
+func TestEventBatcherRetriesFailedBatch(t *testing.T) {
+	var mu sync.Mutex
+	var attempts int
+	batcher, checkpoints := newTestBatcher(func(ctx context.Context, events []*eventhub.Event) error {
+		mu.Lock()
+		defer mu.Unlock()
+		attempts++
+		return errors.New("boom")
+	}, 1, time.Hour)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, batcher.add(ctx, eventhub.NewEventFromString("1")))
+	_, ok := checkpoints.get("0")
+	assert.False(t, ok, "a failed batch should not be checkpointed")
+	mu.Lock()
+	assert.Equal(t, 1, attempts)
+	mu.Unlock()
+}
+
+func TestReceiveBatchValidation(t *testing.T) {
+	host := &EventProcessorHost{batchHandlers: make(map[string]*batchRegistration)}
+	handler := func(ctx context.Context, events []*eventhub.Event) error { return nil }
+
+	_, err := host.ReceiveBatch(handler, 0, time.Second)
+	assert.Error(t, err)
+	_, err = host.ReceiveBatch(handler, 1, 0)
+	assert.Error(t, err)
+
+	closer, err := host.ReceiveBatch(handler, 1, time.Second)
+	if assert.NoError(t, err) {
+		assert.False(t, host.hasCheckpointHandlers(), "batch handlers should not disable automatic checkpointing")
+		assert.NoError(t, closer())
+	}
+}
+
+func TestHandlerCheckpointsAlongsideBatchHandler(t *testing.T) {
+	checkpoints := newCheckpointBatcher(nil, time.Hour)
+	host := &EventProcessorHost{
+		handlers: map[string]eventhub.Handler{
+			"plain": func(ctx context.Context, event *eventhub.Event) error { return nil },
+		},
+		batchHandlers: make(map[string]*batchRegistration),
+	}
+	host.persister = &checkpointPersister{host: host, batcher: checkpoints}
+	_, err := host.ReceiveBatch(func(ctx context.Context, events []*eventhub.Event) error { return nil }, 2, time.Hour)
+	assert.NoError(t, err)
+
+	ctx := context.Background()
+	handle := host.compositeHandlers("0", nil)
+	otherHandle := host.compositeHandlers("1", nil)
+	write := func(partitionID, offset string) string {
+		assert.NoError(t, host.persister.Write("", "", "", partitionID, persist.NewCheckpoint(offset, 0, time.Now())))
+		checkpoint, _ := checkpoints.get(partitionID)
+		return checkpoint.Offset
+	}
+
+	assert.NoError(t, handle(ctx, eventhub.NewEventFromString("1")))
+	assert.Equal(t, "", write("0", "1"), "the checkpoint should not pass an event of an incomplete batch")
+
+	assert.NoError(t, otherHandle(ctx, eventhub.NewEventFromString("1")))
+	assert.NoError(t, otherHandle(ctx, eventhub.NewEventFromString("2")))
+	assert.Equal(t, "2", write("1", "2"), "a partition without incomplete batches should be checkpointed")
+
+	assert.NoError(t, handle(ctx, eventhub.NewEventFromString("2")))
+	assert.Equal(t, "2", write("0", "2"), "the checkpoint should advance once the batch is handled")
+}
+
+func TestBatchCheckpointWaitsForEveryRegistration(t *testing.T) {
+	checkpoints := newCheckpointBatcher(nil, time.Hour)
+	host := &EventProcessorHost{persister: &checkpointPersister{batcher: checkpoints}}
+	handler := func(ctx context.Context, events []*eventhub.Event) error { return nil }
+	registrations := map[string]*batchRegistration{
+		"fast": {handler: handler, maxBatch: 1, maxWait: time.Hour},
+		"slow": {handler: handler, maxBatch: 2, maxWait: time.Hour},
+	}
+	batchers := newPartitionBatchers(host, "0", nil)
+
+	ctx := context.Background()
+	assert.NoError(t, batchers.add(ctx, eventhub.NewEventFromString("1"), registrations))
+	_, ok := checkpoints.get("0")
+	assert.False(t, ok, "the checkpoint should not pass an event another registration has yet to handle")
+
+	assert.NoError(t, batchers.add(ctx, eventhub.NewEventFromString("2"), registrations))
+	_, ok = checkpoints.get("0")
+	assert.True(t, ok, "the checkpoint should advance once every registration has handled the events")
+}
+
+func TestDrainAwaitsExpiredBatch(t *testing.T) {
+	lr := newLeasedReceiver(&EventProcessorHost{name: "host"}, newMemoryLease("0"))
+	started := make(chan struct{})
+	release := make(chan struct{})
+	registrations := map[string]*batchRegistration{
+		"id": {handler: func(ctx context.Context, events []*eventhub.Event) error {
+			close(started)
+			<-release
+			return nil
+		}, maxBatch: 10, maxWait: 10 * time.Millisecond},
+	}
+	host := &EventProcessorHost{manualCheckpointing: true}
+	batchers := newPartitionBatchers(host, "0", lr)
+
+	assert.NoError(t, batchers.add(context.Background(), eventhub.NewEventFromString("1"), registrations))
+	select {
+	case <-started:
+	case <-time.After(time.Second):
+		assert.FailNow(t, "batch should be handled once max wait has passed")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, lr.Drain(ctx), "drain should wait for the expired batch")
+	close(release)
+	assert.NoError(t, lr.Drain(context.Background()))
+}
@@ -0,0 +1,161 @@
+package eph
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-amqp-common-go/log"
+	"github.com/Azure/azure-amqp-common-go/persist"
+)
+
+type (
+	// checkpointBatcher coalesces checkpoint updates per partition and flushes the latest checkpoint for each
+	// partition to the Checkpointer at most once per interval
+	checkpointBatcher struct {
+		checkpointer Checkpointer
+		interval     time.Duration
+		pending      map[string]persist.Checkpoint
+		errs         map[string]error
+		mu           sync.Mutex
+		flushMu      sync.Mutex
+		// stopped is closed by Stop to end Run, even if Stop is called before Run has started
+		stopped  chan struct{}
+		stopOnce sync.Once
+	}
+)
+
+func newCheckpointBatcher(checkpointer Checkpointer, interval time.Duration) *checkpointBatcher {
+	return &checkpointBatcher{
+		checkpointer: checkpointer,
+		interval:     interval,
+		pending:      make(map[string]persist.Checkpoint),
+		errs:         make(map[string]error),
+		stopped:      make(chan struct{}),
+	}
+}
+
+// Run flushes pending checkpoints every interval until the context is canceled or Stop is called
+func (b *checkpointBatcher) Run(ctx context.Context) {
+	span, ctx := startConsumerSpanFromContext(ctx, "eventhub.eph.checkpointBatcher.Run")
+	defer span.Finish()
+
+	ticker := time.NewTicker(b.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-b.stopped:
+			return
+		case <-ticker.C:
+			_ = b.flush(ctx)
+		}
+	}
+}
+
+// Stop ends the periodic flush and forces a final flush of all pending checkpoints
+func (b *checkpointBatcher) Stop(ctx context.Context) error {
+	b.stopOnce.Do(func() {
+		close(b.stopped)
+	})
+	return b.flush(ctx)
+}
+
+// add records the checkpoint as the latest for the partition. If the previous flush for the partition failed, the
+// error is returned so the caller is made aware that progress has not been persisted.
+func (b *checkpointBatcher) add(partitionID string, checkpoint persist.Checkpoint) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.pending[partitionID] = checkpoint
+	err := b.errs[partitionID]
+	delete(b.errs, partitionID)
+	return err
+}
+
+// get returns the pending checkpoint for the partition if one has not yet been flushed
+func (b *checkpointBatcher) get(partitionID string) (persist.Checkpoint, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	checkpoint, ok := b.pending[partitionID]
+	return checkpoint, ok
+}
+
+// flush writes all pending checkpoints to the Checkpointer, returning the last error encountered
+func (b *checkpointBatcher) flush(ctx context.Context) error {
+	b.flushMu.Lock()
+	defer b.flushMu.Unlock()
+
+	span, ctx := startConsumerSpanFromContext(ctx, "eventhub.eph.checkpointBatcher.flush")
+	defer span.Finish()
+
+	b.mu.Lock()
+	pending := b.pending
+	b.pending = make(map[string]persist.Checkpoint)
+	b.mu.Unlock()
+
+	var lastErr error
+	for partitionID, checkpoint := range pending {
+		if err := b.write(ctx, partitionID, checkpoint); err != nil {
+			lastErr = err
+		}
+	}
+	return lastErr
+}
+
+// flushPartition writes the pending checkpoint for a single partition to the Checkpointer
+func (b *checkpointBatcher) flushPartition(ctx context.Context, partitionID string) error {
+	b.flushMu.Lock()
+	defer b.flushMu.Unlock()
+
+	span, ctx := startConsumerSpanFromContext(ctx, "eventhub.eph.checkpointBatcher.flushPartition")
+	defer span.Finish()
+
+	b.mu.Lock()
+	checkpoint, ok := b.pending[partitionID]
+	delete(b.pending, partitionID)
+	b.mu.Unlock()
+
+	if !ok {
+		return nil
+	}
+	return b.write(ctx, partitionID, checkpoint)
+}
+
+func (b *checkpointBatcher) write(ctx context.Context, partitionID string, checkpoint persist.Checkpoint) error {
+	writeCtx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	if err := b.checkpointer.UpdateCheckpoint(writeCtx, partitionID, checkpoint); err != nil {
+		log.For(ctx).Error(err)
+		b.mu.Lock()
+		b.errs[partitionID] = err
+		b.mu.Unlock()
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package eph
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-amqp-common-go/persist"
+	"github.com/stretchr/testify/assert"
+)
+
+type (
+	// writeLogCheckpointer records every checkpoint written to it, failing writes while err is set
+	writeLogCheckpointer struct {
+		Checkpointer
+		mu     sync.Mutex
+		writes []persist.Checkpoint
+		err    error
+	}
+)
+
+func (c *writeLogCheckpointer) UpdateCheckpoint(ctx context.Context, partitionID string, checkpoint persist.Checkpoint) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.err != nil {
+		return c.err
+	}
+	c.writes = append(c.writes, checkpoint)
+	return nil
+}
+
+func (c *writeLogCheckpointer) written() []persist.Checkpoint {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return append([]persist.Checkpoint(nil), c.writes...)
+}
+
+func TestCheckpointBatcherLastCheckpointWins(t *testing.T) {
+	writes := new(writeLogCheckpointer)
+	b := newCheckpointBatcher(writes, time.Hour)
+
+	first := persist.NewCheckpoint("1", 1, time.Now())
+	last := persist.NewCheckpoint("2", 2, time.Now())
+	assert.NoError(t, b.add("0", first))
+	assert.NoError(t, b.add("0", last))
+	pending, ok := b.get("0")
+	assert.True(t, ok)
+	assert.Equal(t, last, pending)
+
+	assert.NoError(t, b.flush(context.Background()))
+	assert.Equal(t, []persist.Checkpoint{last}, writes.written(), "only the latest checkpoint should be written")
+	_, ok = b.get("0")
+	assert.False(t, ok)
+}
+
+func TestCheckpointBatcherFlushesEveryInterval(t *testing.T) {
+	writes := new(writeLogCheckpointer)
+	b := newCheckpointBatcher(writes, 10*time.Millisecond)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go b.Run(ctx)
+
+	checkpoint := persist.NewCheckpoint("1", 1, time.Now())
+	assert.NoError(t, b.add("0", checkpoint))
+	deadline := time.Now().Add(time.Second)
+	for len(writes.written()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	assert.Equal(t, []persist.Checkpoint{checkpoint}, writes.written())
+}
+
+func TestCheckpointBatcherStopFlushes(t *testing.T) {
+	writes := new(writeLogCheckpointer)
+	b := newCheckpointBatcher(writes, time.Hour)
+
+	checkpoint := persist.NewCheckpoint("1", 1, time.Now())
+	assert.NoError(t, b.add("0", checkpoint))
+	assert.NoError(t, b.Stop(context.Background()))
+	assert.Equal(t, []persist.Checkpoint{checkpoint}, writes.written(), "stopping should force pending checkpoints out")
+
+	// Run must end even if the batcher was stopped before it started
+	ran := make(chan struct{})
+	go func() {
+		b.Run(context.Background())
+		close(ran)
+	}()
+	select {
+	case <-ran:
+	case <-time.After(time.Second):
+		assert.Fail(t, "Run should return once the batcher is stopped")
+	}
+	assert.NoError(t, b.Stop(context.Background()), "stopping twice should be harmless")
+}
+
+func TestCheckpointBatcherReturnsFlushErrorFromNextAdd(t *testing.T) {
+	failed := errors.New("store unavailable")
+	writes := &writeLogCheckpointer{err: failed}
+	b := newCheckpointBatcher(writes, time.Hour)
+
+	assert.NoError(t, b.add("0", persist.NewCheckpoint("1", 1, time.Now())))
+	assert.Equal(t, failed, b.flushPartition(context.Background(), "0"))
+
+	assert.NoError(t, b.add("1", persist.NewCheckpoint("1", 1, time.Now())), "errors are reported for their own partition")
+	assert.Equal(t, failed, b.add("0", persist.NewCheckpoint("2", 2, time.Now())))
+	assert.NoError(t, b.add("0", persist.NewCheckpoint("3", 3, time.Now())), "the error should be reported once")
+}
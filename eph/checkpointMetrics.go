@@ -0,0 +1,103 @@
+package eph
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"context"
+	"time"
+
+	"github.com/Azure/azure-amqp-common-go/persist"
+	"github.com/pkg/errors"
+)
+
+const (
+	// CheckpointOperationGet is the operation recorded for calls to Checkpointer.GetCheckpoint
+	CheckpointOperationGet = "GetCheckpoint"
+	// CheckpointOperationEnsure is the operation recorded for calls to Checkpointer.EnsureCheckpoint
+	CheckpointOperationEnsure = "EnsureCheckpoint"
+	// CheckpointOperationUpdate is the operation recorded for calls to Checkpointer.UpdateCheckpoint
+	CheckpointOperationUpdate = "UpdateCheckpoint"
+	// CheckpointOperationDelete is the operation recorded for calls to Checkpointer.DeleteCheckpoint
+	CheckpointOperationDelete = "DeleteCheckpoint"
+)
+
+type (
+	// CheckpointMetricsRecorder records the latency of each call the EventProcessorHost makes to its Checkpointer
+	CheckpointMetricsRecorder interface {
+		// RecordCheckpointOperation is called as each call to the Checkpointer returns with the operation, such as
+		// CheckpointOperationUpdate, the partition, how long the call took and the error of the call, if any. Each
+		// attempt of a retried checkpoint write is recorded separately.
+		RecordCheckpointOperation(operation, partitionID string, duration time.Duration, err error)
+	}
+
+	// measuringCheckpointer records the latency of the calls to the Checkpointer it wraps
+	measuringCheckpointer struct {
+		Checkpointer
+		recorder CheckpointMetricsRecorder
+	}
+)
+
+// WithCheckpointMetrics configures the EventProcessorHost to record the duration of every GetCheckpoint,
+// EnsureCheckpoint, UpdateCheckpoint and DeleteCheckpoint call made to the Checkpointer, whichever implementation it
+// is. The recorder is called synchronously, so it should return quickly, such as after observing a histogram.
+func WithCheckpointMetrics(recorder CheckpointMetricsRecorder) EventProcessorHostOption {
+	return func(host *EventProcessorHost) error {
+		if recorder == nil {
+			return errors.New("checkpoint metrics recorder must not be nil")
+		}
+		host.checkpointMetrics = recorder
+		return nil
+	}
+}
+
+// GetCheckpoint gets the checkpoint of the partition and records how long it took
+func (c *measuringCheckpointer) GetCheckpoint(ctx context.Context, partitionID string) (persist.Checkpoint, bool) {
+	start := time.Now()
+	checkpoint, ok := c.Checkpointer.GetCheckpoint(ctx, partitionID)
+	c.recorder.RecordCheckpointOperation(CheckpointOperationGet, partitionID, time.Since(start), nil)
+	return checkpoint, ok
+}
+
+// EnsureCheckpoint ensures the checkpoint of the partition exists and records how long it took
+func (c *measuringCheckpointer) EnsureCheckpoint(ctx context.Context, partitionID string) (persist.Checkpoint, error) {
+	start := time.Now()
+	checkpoint, err := c.Checkpointer.EnsureCheckpoint(ctx, partitionID)
+	c.recorder.RecordCheckpointOperation(CheckpointOperationEnsure, partitionID, time.Since(start), err)
+	return checkpoint, err
+}
+
+// UpdateCheckpoint writes the checkpoint of the partition and records how long it took
+func (c *measuringCheckpointer) UpdateCheckpoint(ctx context.Context, partitionID string, checkpoint persist.Checkpoint) error {
+	start := time.Now()
+	err := c.Checkpointer.UpdateCheckpoint(ctx, partitionID, checkpoint)
+	c.recorder.RecordCheckpointOperation(CheckpointOperationUpdate, partitionID, time.Since(start), err)
+	return err
+}
+
+// DeleteCheckpoint deletes the checkpoint of the partition and records how long it took
+func (c *measuringCheckpointer) DeleteCheckpoint(ctx context.Context, partitionID string) error {
+	start := time.Now()
+	err := c.Checkpointer.DeleteCheckpoint(ctx, partitionID)
+	c.recorder.RecordCheckpointOperation(CheckpointOperationDelete, partitionID, time.Since(start), err)
+	return err
+}
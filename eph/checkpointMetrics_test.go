@@ -0,0 +1,84 @@
+package eph
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-amqp-common-go/persist"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type (
+	checkpointOperation struct {
+		operation   string
+		partitionID string
+		err         error
+	}
+
+	recordingCheckpointMetrics struct {
+		mu         sync.Mutex
+		operations []checkpointOperation
+	}
+)
+
+func (r *recordingCheckpointMetrics) RecordCheckpointOperation(operation, partitionID string, duration time.Duration, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.operations = append(r.operations, checkpointOperation{operation: operation, partitionID: partitionID, err: err})
+}
+
+func TestWithCheckpointMetrics(t *testing.T) {
+	host := new(EventProcessorHost)
+	assert.Error(t, WithCheckpointMetrics(nil)(host))
+
+	recorder := new(recordingCheckpointMetrics)
+	assert.NoError(t, WithCheckpointMetrics(recorder)(host))
+	assert.Equal(t, recorder, host.checkpointMetrics)
+}
+
+func TestMeasuringCheckpointerRecordsEachAttempt(t *testing.T) {
+	boom := errors.New("boom")
+	recorder := new(recordingCheckpointMetrics)
+	measured := &measuringCheckpointer{
+		Checkpointer: &flakyCheckpointer{errs: []error{temporaryError{}, boom}},
+		recorder:     recorder,
+	}
+	host := &EventProcessorHost{
+		name:                  "host",
+		checkpointRetryPolicy: &CheckpointRetryPolicy{MaxAttempts: 3},
+	}
+	retrying := &retryingCheckpointer{Checkpointer: measured, host: host}
+
+	err := retrying.UpdateCheckpoint(context.Background(), "0", persist.NewCheckpointFromStartOfStream())
+	assert.Equal(t, boom, err)
+
+	require.Len(t, recorder.operations, 2, "each attempt of a retried write should be recorded")
+	assert.Equal(t, checkpointOperation{operation: CheckpointOperationUpdate, partitionID: "0", err: temporaryError{}}, recorder.operations[0])
+	assert.Equal(t, checkpointOperation{operation: CheckpointOperationUpdate, partitionID: "0", err: boom}, recorder.operations[1])
+}
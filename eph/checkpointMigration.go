@@ -0,0 +1,152 @@
+package eph
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"context"
+	"sort"
+
+	"github.com/Azure/azure-amqp-common-go/persist"
+	"github.com/pkg/errors"
+)
+
+const (
+	// migrationHostName is the name of the host set on a Checkpointer while its checkpoints are exported or imported
+	migrationHostName = "checkpoint-migration"
+)
+
+// ExportCheckpoints reads the checkpoint of each of the partitions from the Checkpointer, so they can be imported into
+// another store or consumer group with ImportCheckpoints. Partitions without a checkpoint are exported at the start of
+// the stream.
+//
+// Checkpointers which are also Leasers, such as the in-memory and Azure Storage implementations, only read and write the
+// checkpoints of partitions they hold the lease of. For those, the lease of each partition is acquired while its
+// checkpoint is read and released afterwards, so no EventProcessorHost should be processing the consumer group during
+// the export. The host of the Checkpointer is set to one used only for the export.
+func ExportCheckpoints(ctx context.Context, c Checkpointer, partitionIDs []string) (map[string]persist.Checkpoint, error) {
+	span, ctx := startConsumerSpanFromContext(ctx, "eventhub.eph.ExportCheckpoints")
+	defer span.Finish()
+
+	c.SetEventHostProcessor(&EventProcessorHost{name: migrationHostName, partitionIDs: partitionIDs})
+	checkpoints := make(map[string]persist.Checkpoint, len(partitionIDs))
+	for _, partitionID := range partitionIDs {
+		err := withMigrationLease(ctx, c, partitionID, func() error {
+			checkpoint, err := c.EnsureCheckpoint(ctx, partitionID)
+			if err != nil {
+				return err
+			}
+			checkpoints[partitionID] = checkpoint
+			return nil
+		})
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to export the checkpoint of partition %q", partitionID)
+		}
+	}
+	return checkpoints, nil
+}
+
+// ImportCheckpoints writes the checkpoints, such as those returned by ExportCheckpoints, to the Checkpointer. The
+// checkpoints must be keyed by exactly the partition IDs of the target Event Hub, which can be fetched with
+// Hub.GetRuntimeInformation, otherwise an error is returned before anything is written.
+//
+// As with ExportCheckpoints, the lease of each partition is acquired while its checkpoint is written if the
+// Checkpointer is also a Leaser, so no EventProcessorHost should be processing the consumer group during the import.
+func ImportCheckpoints(ctx context.Context, c Checkpointer, partitionIDs []string, checkpoints map[string]persist.Checkpoint) error {
+	span, ctx := startConsumerSpanFromContext(ctx, "eventhub.eph.ImportCheckpoints")
+	defer span.Finish()
+
+	if err := validateCheckpointPartitions(partitionIDs, checkpoints); err != nil {
+		return err
+	}
+
+	c.SetEventHostProcessor(&EventProcessorHost{name: migrationHostName, partitionIDs: partitionIDs})
+	if err := c.EnsureStore(ctx); err != nil {
+		return err
+	}
+
+	for _, partitionID := range partitionIDs {
+		err := withMigrationLease(ctx, c, partitionID, func() error {
+			if _, err := c.EnsureCheckpoint(ctx, partitionID); err != nil {
+				return err
+			}
+			if err := c.UpdateCheckpoint(ctx, partitionID, checkpoints[partitionID]); err != nil {
+				return err
+			}
+			if leaser, ok := c.(Leaser); ok {
+				// persist the lease holding the checkpoint before it is released
+				if _, _, err := leaser.UpdateLease(ctx, partitionID); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return errors.Wrapf(err, "failed to import the checkpoint of partition %q", partitionID)
+		}
+	}
+	return nil
+}
+
+// withMigrationLease runs fn while holding the lease of the partition if the Checkpointer is also a Leaser
+func withMigrationLease(ctx context.Context, c Checkpointer, partitionID string, fn func() error) error {
+	leaser, ok := c.(Leaser)
+	if !ok {
+		return fn()
+	}
+
+	if _, err := leaser.EnsureLease(ctx, partitionID); err != nil {
+		return err
+	}
+	_, acquired, err := leaser.AcquireLease(ctx, partitionID)
+	if err != nil {
+		return err
+	}
+	if !acquired {
+		return errors.New("the lease could not be acquired")
+	}
+
+	fnErr := fn()
+	if _, err := leaser.ReleaseLease(ctx, partitionID); err != nil && fnErr == nil {
+		return err
+	}
+	return fnErr
+}
+
+// validateCheckpointPartitions checks the checkpoints are keyed by exactly the partition IDs
+func validateCheckpointPartitions(partitionIDs []string, checkpoints map[string]persist.Checkpoint) error {
+	if len(checkpoints) != len(partitionIDs) {
+		return errors.Errorf("%d checkpoints can't be imported into an Event Hub with %d partitions", len(checkpoints), len(partitionIDs))
+	}
+
+	var missing []string
+	for _, partitionID := range partitionIDs {
+		if _, ok := checkpoints[partitionID]; !ok {
+			missing = append(missing, partitionID)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return errors.Errorf("checkpoints are missing for partitions %v of the Event Hub", missing)
+	}
+	return nil
+}
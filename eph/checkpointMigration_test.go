@@ -0,0 +1,82 @@
+package eph
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-amqp-common-go/persist"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExportImportCheckpoints(t *testing.T) {
+	ctx := context.Background()
+	partitionIDs := []string{"0", "1"}
+	checkpoint := persist.NewCheckpoint("100", 10, time.Date(2018, 6, 1, 0, 0, 0, 0, time.UTC))
+
+	// a host of the source consumer group checkpoints partition 0, then stops
+	source := new(sharedStore)
+	host := newMemoryLeaserCheckpointer(time.Minute, source)
+	host.SetEventHostProcessor(&EventProcessorHost{name: "host", partitionIDs: partitionIDs})
+	assert.NoError(t, host.EnsureStore(ctx))
+	for _, partitionID := range partitionIDs {
+		_, err := host.EnsureLease(ctx, partitionID)
+		assert.NoError(t, err)
+	}
+	_, ok, err := host.AcquireLease(ctx, "0")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.NoError(t, host.UpdateCheckpoint(ctx, "0", checkpoint))
+	_, err = host.ReleaseLease(ctx, "0")
+	assert.NoError(t, err)
+
+	exported, err := ExportCheckpoints(ctx, newMemoryLeaserCheckpointer(time.Minute, source), partitionIDs)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, map[string]persist.Checkpoint{
+		"0": checkpoint,
+		"1": persist.NewCheckpointFromStartOfStream(),
+	}, exported)
+
+	target := new(sharedStore)
+	assert.NoError(t, ImportCheckpoints(ctx, newMemoryLeaserCheckpointer(time.Minute, target), partitionIDs, exported))
+	imported, err := ExportCheckpoints(ctx, newMemoryLeaserCheckpointer(time.Minute, target), partitionIDs)
+	assert.NoError(t, err)
+	assert.Equal(t, exported, imported)
+}
+
+func TestImportCheckpointsValidatesPartitions(t *testing.T) {
+	ctx := context.Background()
+	checkpoints := map[string]persist.Checkpoint{
+		"0": persist.NewCheckpointFromStartOfStream(),
+		"1": persist.NewCheckpointFromStartOfStream(),
+	}
+
+	c := newMemoryLeaserCheckpointer(time.Minute, new(sharedStore))
+	assert.Error(t, ImportCheckpoints(ctx, c, []string{"0", "1", "2"}, checkpoints), "the partition counts should match")
+	assert.Error(t, ImportCheckpoints(ctx, c, []string{"0", "2"}, checkpoints), "the partition IDs should match")
+	assert.NoError(t, ImportCheckpoints(ctx, c, []string{"0", "1"}, checkpoints))
+}
@@ -0,0 +1,208 @@
+package eph
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"time"
+
+	"github.com/Azure/azure-amqp-common-go/persist"
+	"github.com/Azure/azure-event-hubs-go"
+	"github.com/pkg/errors"
+)
+
+const (
+	// DefaultCheckpointRetryMaxAttempts is the number of attempts made to write a checkpoint by the default checkpoint
+	// retry policy
+	DefaultCheckpointRetryMaxAttempts = 3
+	// DefaultCheckpointRetryMinDelay is the delay before the first retry of a checkpoint write by the default policy
+	DefaultCheckpointRetryMinDelay = 200 * time.Millisecond
+	// DefaultCheckpointRetryMaxDelay is the longest delay between retries of a checkpoint write by the default policy
+	DefaultCheckpointRetryMaxDelay = 2 * time.Second
+
+	// checkpointAttemptTimeout bounds each attempt to write a checkpoint
+	checkpointAttemptTimeout = 3 * time.Second
+)
+
+type (
+	// CheckpointRetryPolicy retries checkpoint writes which fail with a transient store error, as reported by
+	// IsTransientStoreError, up to MaxAttempts times, doubling the delay after each attempt from MinDelay up to
+	// MaxDelay. Each delay is jittered between half and the full computed delay.
+	CheckpointRetryPolicy struct {
+		MaxAttempts int
+		MinDelay    time.Duration
+		MaxDelay    time.Duration
+	}
+
+	// retryingCheckpointer retries failed checkpoint writes according to the retry policy of the host, and closes the
+	// partition of a checkpoint which could not be written once the retries are exhausted
+	retryingCheckpointer struct {
+		Checkpointer
+		host *EventProcessorHost
+	}
+)
+
+// NewCheckpointRetryPolicy creates a CheckpointRetryPolicy with the default attempts and delays
+func NewCheckpointRetryPolicy() *CheckpointRetryPolicy {
+	return &CheckpointRetryPolicy{
+		MaxAttempts: DefaultCheckpointRetryMaxAttempts,
+		MinDelay:    DefaultCheckpointRetryMinDelay,
+		MaxDelay:    DefaultCheckpointRetryMaxDelay,
+	}
+}
+
+// WithCheckpointRetryPolicy configures the EventProcessorHost to retry failed checkpoint writes according to the
+// policy rather than NewCheckpointRetryPolicy. A partition whose checkpoint can't be written once the policy stops
+// retrying is closed with CloseReasonCheckpointError, releasing its lease, while other partitions keep processing.
+func WithCheckpointRetryPolicy(policy eventhub.RetryPolicy) EventProcessorHostOption {
+	return func(host *EventProcessorHost) error {
+		if policy == nil {
+			return errors.New("checkpoint retry policy must not be nil")
+		}
+		host.checkpointRetryPolicy = policy
+		return nil
+	}
+}
+
+// ShouldRetry returns true with a jittered exponential delay if the error is a transient store error and fewer than
+// MaxAttempts attempts have been made
+func (p *CheckpointRetryPolicy) ShouldRetry(err error, attempt int) (bool, time.Duration) {
+	if attempt >= p.MaxAttempts || !IsTransientStoreError(err) {
+		return false, 0
+	}
+
+	delay := p.MinDelay
+	for i := 1; i < attempt && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+
+	half := delay / 2
+	if half > 0 {
+		delay = half + time.Duration(rand.Int63n(int64(half)+1))
+	}
+	return true, delay
+}
+
+// IsTransientStoreError returns true if the error of a Leaser or Checkpointer is likely to be resolved by retrying,
+// such as a timeout, a throttled or unavailable store, or an error reporting itself as temporary, as Azure Storage
+// errors do
+func IsTransientStoreError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	cause := errors.Cause(err)
+	if cause == context.DeadlineExceeded {
+		return true
+	}
+	if temporary, ok := cause.(interface{ Temporary() bool }); ok && temporary.Temporary() {
+		return true
+	}
+	if timeout, ok := cause.(interface{ Timeout() bool }); ok && timeout.Timeout() {
+		return true
+	}
+	if responder, ok := cause.(interface{ Response() *http.Response }); ok {
+		if res := responder.Response(); res != nil {
+			switch {
+			case res.StatusCode == http.StatusRequestTimeout, res.StatusCode == http.StatusTooManyRequests,
+				res.StatusCode >= http.StatusInternalServerError:
+				return true
+			}
+		}
+	}
+	return eventhub.IsTransient(err)
+}
+
+// UpdateCheckpoint writes the checkpoint, retrying transient failures, and closes the partition if it can't be written
+func (c *retryingCheckpointer) UpdateCheckpoint(ctx context.Context, partitionID string, checkpoint persist.Checkpoint) error {
+	err := c.retry(ctx, func(ctx context.Context) error {
+		return c.Checkpointer.UpdateCheckpoint(ctx, partitionID, checkpoint)
+	})
+	if err != nil && c.host != nil {
+		c.host.closePartitionAfterCheckpointFailure(partitionID, err)
+	}
+	return err
+}
+
+// retry attempts the operation, bounding each attempt by checkpointAttemptTimeout, until it succeeds, the retry policy
+// of the host stops retrying or the context is done
+func (c *retryingCheckpointer) retry(ctx context.Context, operation func(ctx context.Context) error) error {
+	var policy eventhub.RetryPolicy = NewCheckpointRetryPolicy()
+	var clock Clock = realClock{}
+	if c.host != nil {
+		clock = c.host.getClock()
+		if c.host.checkpointRetryPolicy != nil {
+			policy = c.host.checkpointRetryPolicy
+		}
+	}
+
+	for attempt := 1; ; attempt++ {
+		attemptCtx, cancel := context.WithTimeout(ctx, checkpointAttemptTimeout)
+		err := operation(attemptCtx)
+		cancel()
+		if err == nil {
+			return nil
+		}
+
+		retry, delay := policy.ShouldRetry(err, attempt)
+		if !retry || ctx.Err() != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-clock.After(delay):
+		}
+	}
+}
+
+// closePartitionAfterCheckpointFailure closes the partition, if it is owned, as its checkpoint could not be written
+func (h *EventProcessorHost) closePartitionAfterCheckpointFailure(partitionID string, err error) {
+	if h.scheduler == nil {
+		return
+	}
+
+	// the receiver is looked up and closed asynchronously, as checkpoints may be written from its own receive loop or
+	// flushed by the scheduler while it holds its receivers
+	go func() {
+		lr := h.scheduler.receiver(partitionID)
+		if lr == nil {
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		lr.logFor(ctx).Error(fmt.Errorf("closing partition after failing to write its checkpoint: %v", err))
+		if stopErr := h.scheduler.stopReceiver(ctx, lr.lease, CloseReasonCheckpointError, err); stopErr != nil {
+			lr.logFor(ctx).Error(stopErr)
+		}
+	}()
+}
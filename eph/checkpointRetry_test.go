@@ -0,0 +1,191 @@
+package eph
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-amqp-common-go/persist"
+	"github.com/stretchr/testify/assert"
+)
+
+type (
+	statusError struct {
+		status int
+	}
+
+	temporaryError struct{}
+
+	flakyCheckpointer struct {
+		Checkpointer
+		errs     []error
+		attempts int
+	}
+)
+
+func (e statusError) Error() string {
+	return http.StatusText(e.status)
+}
+
+func (e statusError) Response() *http.Response {
+	return &http.Response{StatusCode: e.status}
+}
+
+func (temporaryError) Error() string   { return "temporary" }
+func (temporaryError) Temporary() bool { return true }
+
+func (c *flakyCheckpointer) UpdateCheckpoint(ctx context.Context, partitionID string, checkpoint persist.Checkpoint) error {
+	c.attempts++
+	if len(c.errs) == 0 {
+		return nil
+	}
+	err := c.errs[0]
+	c.errs = c.errs[1:]
+	return err
+}
+
+func TestIsTransientStoreError(t *testing.T) {
+	tests := map[string]struct {
+		err       error
+		transient bool
+	}{
+		"Nil":                {err: nil},
+		"Other":              {err: errors.New("boom")},
+		"NotFound":           {err: statusError{status: http.StatusNotFound}},
+		"PreconditionFailed": {err: statusError{status: http.StatusPreconditionFailed}},
+		"Throttled":          {err: statusError{status: http.StatusTooManyRequests}, transient: true},
+		"Unavailable":        {err: statusError{status: http.StatusServiceUnavailable}, transient: true},
+		"RequestTimeout":     {err: statusError{status: http.StatusRequestTimeout}, transient: true},
+		"Temporary":          {err: temporaryError{}, transient: true},
+		"DeadlineExceeded":   {err: context.DeadlineExceeded, transient: true},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tt.transient, IsTransientStoreError(tt.err))
+		})
+	}
+}
+
+func TestCheckpointRetryPolicy(t *testing.T) {
+	policy := &CheckpointRetryPolicy{MaxAttempts: 3, MinDelay: 100 * time.Millisecond, MaxDelay: 150 * time.Millisecond}
+	retry, delay := policy.ShouldRetry(temporaryError{}, 1)
+	assert.True(t, retry)
+	assert.True(t, delay >= 50*time.Millisecond && delay <= 100*time.Millisecond)
+
+	retry, delay = policy.ShouldRetry(temporaryError{}, 2)
+	assert.True(t, retry)
+	assert.True(t, delay >= 75*time.Millisecond && delay <= 150*time.Millisecond, "delays should be capped by the max delay")
+
+	retry, _ = policy.ShouldRetry(temporaryError{}, 3)
+	assert.False(t, retry, "retries should stop after max attempts")
+	retry, _ = policy.ShouldRetry(errors.New("boom"), 1)
+	assert.False(t, retry, "permanent errors should not be retried")
+
+	assert.Error(t, WithCheckpointRetryPolicy(nil)(new(EventProcessorHost)))
+}
+
+func TestRetryingCheckpointer(t *testing.T) {
+	host := &EventProcessorHost{
+		name:                  "host",
+		checkpointRetryPolicy: &CheckpointRetryPolicy{MaxAttempts: 3},
+	}
+
+	flaky := &flakyCheckpointer{errs: []error{temporaryError{}, statusError{status: http.StatusServiceUnavailable}}}
+	c := &retryingCheckpointer{Checkpointer: flaky, host: host}
+	assert.NoError(t, c.UpdateCheckpoint(context.Background(), "0", persist.NewCheckpointFromStartOfStream()))
+	assert.Equal(t, 3, flaky.attempts, "transient errors should be retried")
+
+	permanent := errors.New("boom")
+	flaky = &flakyCheckpointer{errs: []error{permanent}}
+	c = &retryingCheckpointer{Checkpointer: flaky, host: host}
+	assert.Equal(t, permanent, c.UpdateCheckpoint(context.Background(), "0", persist.NewCheckpointFromStartOfStream()))
+	assert.Equal(t, 1, flaky.attempts, "permanent errors should not be retried")
+}
+
+func TestCheckpointFailureClosesPartition(t *testing.T) {
+	host := &EventProcessorHost{
+		name:                  "host",
+		leaser:                newMemoryLeaserCheckpointer(time.Minute, new(sharedStore)),
+		persister:             &checkpointPersister{},
+		closeHandlers:         make(map[string]PartitionCloseHandler),
+		checkpointRetryPolicy: &CheckpointRetryPolicy{MaxAttempts: 2},
+	}
+	host.scheduler = newScheduler(host)
+	host.scheduler.receivers["0"] = newLeasedReceiver(host, newMemoryLease("0"))
+	host.scheduler.receivers["1"] = newLeasedReceiver(host, newMemoryLease("1"))
+
+	closes := make(chan CloseReason, 2)
+	_, err := host.OnPartitionClose(func(partitionID string, reason CloseReason, err error) {
+		assert.Equal(t, "0", partitionID)
+		closes <- reason
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	unavailable := statusError{status: http.StatusServiceUnavailable}
+	flaky := &flakyCheckpointer{errs: []error{unavailable, unavailable}}
+	c := &retryingCheckpointer{Checkpointer: flaky, host: host}
+	assert.Equal(t, unavailable, c.UpdateCheckpoint(context.Background(), "0", persist.NewCheckpointFromStartOfStream()))
+	assert.Equal(t, 2, flaky.attempts)
+
+	select {
+	case reason := <-closes:
+		assert.Equal(t, CloseReasonCheckpointError, reason)
+		assert.Equal(t, "CheckpointError", reason.String())
+	case <-time.After(time.Second):
+		assert.Fail(t, "the partition should be closed once retries are exhausted")
+	}
+	assert.NotNil(t, host.scheduler.receiver("1"), "other partitions should keep processing")
+}
+
+func TestCheckpointFailureWhileFlushingOnStop(t *testing.T) {
+	host, lease := newRestartTestHost(t)
+	host.checkpointRetryPolicy = &CheckpointRetryPolicy{MaxAttempts: 1}
+	unavailable := statusError{status: http.StatusServiceUnavailable}
+	c := &retryingCheckpointer{Checkpointer: &flakyCheckpointer{errs: []error{unavailable}}, host: host}
+	host.persister.checkpointer = c
+	host.persister.batcher = newCheckpointBatcher(c, time.Hour)
+	host.scheduler = newScheduler(host)
+	host.scheduler.receivers["0"] = newLeasedReceiver(host, lease)
+	assert.NoError(t, host.persister.checkpoint(context.Background(), "0", persist.NewCheckpointFromStartOfStream()))
+
+	// the failed flush of the pending checkpoint must not wait on the scheduler which is flushing it
+	stopped := make(chan error)
+	go func() {
+		_, err := host.scheduler.stop(context.Background(), true)
+		stopped <- err
+	}()
+	select {
+	case err := <-stopped:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		assert.Fail(t, "stopping the scheduler deadlocked on the failed checkpoint flush")
+	}
+	assert.Nil(t, host.scheduler.receiver("0"))
+}
@@ -30,7 +30,10 @@ import (
 )
 
 type (
-	// Checkpointer interface provides the ability to persist durable checkpoints for event processors
+	// Checkpointer interface provides the ability to persist durable checkpoints for event processors. The host never
+	// reaches a Leaser through its Checkpointer or the reverse, so the Checkpointer may be backed by a different store
+	// than the Leaser. A Checkpointer paired with another Leaser must not rely on holding leases of its own; the host
+	// only updates the checkpoints of partitions it has leased through its Leaser.
 	Checkpointer interface {
 		io.Closer
 		StoreProvisioner
@@ -0,0 +1,141 @@
+package eph
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-amqp-common-go/persist"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mapCheckpointer stores checkpoints without leases, as a checkpointer backed by a store other than the leaser's would
+type mapCheckpointer struct {
+	host        *EventProcessorHost
+	stored      bool
+	checkpoints map[string]persist.Checkpoint
+	mu          sync.Mutex
+}
+
+func newMapCheckpointer() *mapCheckpointer {
+	return &mapCheckpointer{checkpoints: make(map[string]persist.Checkpoint)}
+}
+
+func (c *mapCheckpointer) SetEventHostProcessor(host *EventProcessorHost) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.host = host
+}
+
+func (c *mapCheckpointer) StoreExists(ctx context.Context) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.stored, nil
+}
+
+func (c *mapCheckpointer) EnsureStore(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stored = true
+	return nil
+}
+
+func (c *mapCheckpointer) DeleteStore(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stored = false
+	c.checkpoints = make(map[string]persist.Checkpoint)
+	return nil
+}
+
+func (c *mapCheckpointer) GetCheckpoint(ctx context.Context, partitionID string) (persist.Checkpoint, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	checkpoint, ok := c.checkpoints[partitionID]
+	if !ok {
+		return persist.NewCheckpointFromStartOfStream(), false
+	}
+	return checkpoint, true
+}
+
+func (c *mapCheckpointer) EnsureCheckpoint(ctx context.Context, partitionID string) (persist.Checkpoint, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	checkpoint, ok := c.checkpoints[partitionID]
+	if !ok {
+		checkpoint = persist.NewCheckpointFromStartOfStream()
+		c.checkpoints[partitionID] = checkpoint
+	}
+	return checkpoint, nil
+}
+
+func (c *mapCheckpointer) UpdateCheckpoint(ctx context.Context, partitionID string, checkpoint persist.Checkpoint) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checkpoints[partitionID] = checkpoint
+	return nil
+}
+
+func (c *mapCheckpointer) DeleteCheckpoint(ctx context.Context, partitionID string) error {
+	return c.UpdateCheckpoint(ctx, partitionID, persist.NewCheckpointFromStartOfStream())
+}
+
+func (c *mapCheckpointer) Close() error {
+	return nil
+}
+
+func TestSetupWithIndependentLeaserAndCheckpointer(t *testing.T) {
+	leaser := newMemoryLeaserCheckpointer(DefaultLeaseDuration, new(sharedStore))
+	checkpointer := newMapCheckpointer()
+	host := &EventProcessorHost{
+		name:         "host",
+		leaser:       leaser,
+		checkpointer: checkpointer,
+		persister:    &checkpointPersister{checkpointer: checkpointer},
+		partitionIDs: []string{"0", "1"},
+	}
+
+	ctx := context.Background()
+	require.NoError(t, host.setup(ctx))
+	assert.Equal(t, host, leaser.processor, "the host should be set on the leaser")
+	assert.Equal(t, host, checkpointer.host, "the host should be set on the checkpointer")
+	exists, err := checkpointer.StoreExists(ctx)
+	assert.NoError(t, err)
+	assert.True(t, exists)
+
+	// the lease is held only by the leaser, while the checkpoint is written only to the checkpointer
+	_, ok, err := leaser.AcquireLease(ctx, "0")
+	require.NoError(t, err)
+	require.True(t, ok)
+	checkpoint := persist.NewCheckpoint("100", 10, time.Now())
+	require.NoError(t, host.persister.Write("namespace", "hub", "$Default", "0", checkpoint))
+
+	stored, ok := checkpointer.GetCheckpoint(ctx, "0")
+	assert.True(t, ok)
+	assert.Equal(t, "100", stored.Offset)
+	assert.Nil(t, leaser.leases["0"].Checkpoint, "no checkpoint should be written through the leaser")
+}
@@ -0,0 +1,71 @@
+package eph
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+type (
+	// Clock tells the time and waits for durations to pass, so tests can advance the time by which leases expire and
+	// the EPH balances and renews leases rather than waiting on the wall clock
+	Clock interface {
+		Now() time.Time
+		After(d time.Duration) <-chan time.Time
+	}
+
+	// realClock is the wall clock
+	realClock struct{}
+)
+
+// WithClock configures the EventProcessorHost to balance partitions and renew leases on the clock rather than the wall
+// clock
+func WithClock(clock Clock) EventProcessorHostOption {
+	return func(host *EventProcessorHost) error {
+		if clock == nil {
+			return errors.New("clock must not be nil")
+		}
+		host.clock = clock
+		return nil
+	}
+}
+
+// Now returns the current time
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+// After waits for the duration to pass and then sends the current time on the returned channel
+func (realClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+// getClock returns the clock of the host, defaulting to the wall clock
+func (h *EventProcessorHost) getClock() Clock {
+	if h.clock == nil {
+		return realClock{}
+	}
+	return h.clock
+}
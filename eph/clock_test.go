@@ -0,0 +1,154 @@
+package eph
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type (
+	// manualClock only moves forward when advanced
+	manualClock struct {
+		mu      sync.Mutex
+		now     time.Time
+		waiters []clockWaiter
+	}
+
+	clockWaiter struct {
+		at time.Time
+		ch chan time.Time
+	}
+
+	countingLeaser struct {
+		Leaser
+		scans chan struct{}
+	}
+)
+
+func newManualClock() *manualClock {
+	return &manualClock{now: time.Now()}
+}
+
+func (c *manualClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *manualClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ch := make(chan time.Time, 1)
+	c.waiters = append(c.waiters, clockWaiter{at: c.now.Add(d), ch: ch})
+	return ch
+}
+
+// Advance moves the clock forward, firing the waiters which are due
+func (c *manualClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+	var pending []clockWaiter
+	for _, w := range c.waiters {
+		if c.now.Before(w.at) {
+			pending = append(pending, w)
+			continue
+		}
+		w.ch <- c.now
+	}
+	c.waiters = pending
+}
+
+func (c *manualClock) waiting() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.waiters)
+}
+
+func (l *countingLeaser) GetLeases(ctx context.Context) ([]LeaseMarker, error) {
+	l.scans <- struct{}{}
+	return l.Leaser.GetLeases(ctx)
+}
+
+func TestWithClock(t *testing.T) {
+	host := new(EventProcessorHost)
+	assert.Equal(t, realClock{}, host.getClock())
+	assert.Error(t, WithClock(nil)(host))
+
+	clock := newManualClock()
+	assert.NoError(t, WithClock(clock)(host))
+	assert.Equal(t, clock, host.getClock())
+}
+
+func TestSchedulerScansOnClock(t *testing.T) {
+	clock := newManualClock()
+	store := newMemoryLeaserCheckpointer(DefaultLeaseDuration, &sharedStore{clock: clock})
+	leaser := &countingLeaser{Leaser: store, scans: make(chan struct{}, 10)}
+	host := &EventProcessorHost{name: "host", leaser: leaser, balancer: GreedyLeaseBalancer{}, clock: clock}
+	store.SetEventHostProcessor(host)
+	assert.NoError(t, store.EnsureStore(context.Background()))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s := newScheduler(host)
+	done := make(chan struct{})
+	go func() {
+		s.Run(ctx)
+		close(done)
+	}()
+
+	waitForScan := func() {
+		select {
+		case <-leaser.scans:
+		case <-time.After(time.Second):
+			assert.FailNow(t, "the scheduler should scan")
+		}
+	}
+
+	waitForScan()
+	for clock.waiting() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	select {
+	case <-leaser.scans:
+		assert.FailNow(t, "the scheduler should wait for the clock before scanning again")
+	default:
+	}
+
+	clock.Advance(s.leaseRenewalInterval + time.Second)
+	waitForScan()
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		assert.Fail(t, "the scheduler should stop once the context is cancelled")
+	}
+}
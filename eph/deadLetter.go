@@ -0,0 +1,146 @@
+package eph
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/Azure/azure-event-hubs-go"
+	"github.com/pkg/errors"
+)
+
+const (
+	// DefaultDeadLetterMaxAttempts is the number of times a handler is invoked for an event before the event is dead
+	// lettered
+	DefaultDeadLetterMaxAttempts = 3
+
+	// DefaultDeadLetterBackoff is the amount of time to wait between invocations of a failing handler
+	DefaultDeadLetterBackoff = 1 * time.Second
+
+	deadLetterReasonProperty         = "x-opt-dead-letter-reason"
+	deadLetterPartitionIDProperty    = "x-opt-dead-letter-partition-id"
+	deadLetterSequenceNumberProperty = "x-opt-dead-letter-sequence-number"
+)
+
+type (
+	// DeadLetterHandler is invoked with an event which has failed a handler for the configured number of consecutive
+	// attempts. If the DeadLetterHandler returns nil, the event is considered handled and the checkpoint advances past
+	// it.
+	DeadLetterHandler func(ctx context.Context, partitionID string, event *eventhub.Event, handlerErr error) error
+)
+
+// WithDeadLetterHandler configures an EventProcessorHost to invoke the DeadLetterHandler for events which fail a
+// handler repeatedly rather than stalling the partition. The number of attempts and the backoff between them are
+// configured with WithDeadLetterRetries.
+func WithDeadLetterHandler(handler DeadLetterHandler) EventProcessorHostOption {
+	return func(host *EventProcessorHost) error {
+		if handler == nil {
+			return errors.New("dead letter handler must not be nil")
+		}
+		host.deadLetterHandler = handler
+		return nil
+	}
+}
+
+// WithDeadLetterHub configures an EventProcessorHost to forward events which fail a handler repeatedly to another
+// Event Hub. The forwarded event carries the handler error, source partition and sequence number as properties.
+func WithDeadLetterHub(hub *eventhub.Hub) EventProcessorHostOption {
+	return func(host *EventProcessorHost) error {
+		if hub == nil {
+			return errors.New("dead letter hub must not be nil")
+		}
+		host.deadLetterHandler = func(ctx context.Context, partitionID string, event *eventhub.Event, handlerErr error) error {
+			return hub.Send(ctx, newDeadLetterEvent(partitionID, event, handlerErr))
+		}
+		return nil
+	}
+}
+
+// WithDeadLetterRetries configures the number of consecutive attempts made to handle an event, and the backoff
+// between attempts, before the event is dead lettered
+func WithDeadLetterRetries(maxAttempts int, backoff time.Duration) EventProcessorHostOption {
+	return func(host *EventProcessorHost) error {
+		if maxAttempts < 1 {
+			return errors.New("max attempts must be at least 1")
+		}
+		if backoff < 0 {
+			return errors.New("backoff must not be negative")
+		}
+		host.deadLetterMaxAttempts = maxAttempts
+		host.deadLetterBackoff = backoff
+		return nil
+	}
+}
+
+// handleWithDeadLetter invokes the handler until it succeeds or has failed for the configured number of attempts, at
+// which point the event is handed to the DeadLetterHandler
+func (h *EventProcessorHost) handleWithDeadLetter(ctx context.Context, partitionID string, event *eventhub.Event, handle eventhub.Handler) error {
+	var err error
+	for attempt := 1; attempt <= h.deadLetterMaxAttempts; attempt++ {
+		if err = handle(ctx, event); err == nil {
+			return nil
+		}
+
+		h.logFor(ctx, "partitionID", partitionID).Error(err)
+		if attempt < h.deadLetterMaxAttempts {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(h.deadLetterBackoff):
+			}
+		}
+	}
+
+	span, ctx := startConsumerSpanFromContext(ctx, "eventhub.eph.EventProcessorHost.deadLetter")
+	defer span.Finish()
+
+	checkpoint := event.GetCheckpoint()
+	span.SetTag(partitionIDTag, partitionID)
+	span.SetTag("eventhub.sequence-number", checkpoint.SequenceNumber)
+	h.logFor(ctx, "partitionID", partitionID).Debug(fmt.Sprintf("dead lettering event with sequence number %d on partition %q after %d attempts", checkpoint.SequenceNumber, partitionID, h.deadLetterMaxAttempts))
+
+	if dlErr := h.deadLetterHandler(ctx, partitionID, event, err); dlErr != nil {
+		h.logFor(ctx, "partitionID", partitionID).Error(dlErr)
+		return dlErr
+	}
+	return nil
+}
+
+func newDeadLetterEvent(partitionID string, event *eventhub.Event, handlerErr error) *eventhub.Event {
+	forward := eventhub.NewEvent(event.Data)
+	forward.PartitionKey = event.PartitionKey
+	forward.Properties = make(map[string]interface{}, len(event.Properties)+3)
+	for key, value := range event.Properties {
+		forward.Properties[key] = value
+	}
+
+	if handlerErr != nil {
+		forward.Properties[deadLetterReasonProperty] = handlerErr.Error()
+	}
+	forward.Properties[deadLetterPartitionIDProperty] = partitionID
+	forward.Properties[deadLetterSequenceNumberProperty] = strconv.FormatInt(event.GetCheckpoint().SequenceNumber, 10)
+	return forward
+}
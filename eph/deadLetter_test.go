@@ -0,0 +1,158 @@
+package eph
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-event-hubs-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func newDeadLetterTestHost(handler DeadLetterHandler, maxAttempts int, backoff time.Duration) *EventProcessorHost {
+	return &EventProcessorHost{
+		name:                  "host",
+		handlers:              make(map[string]eventhub.Handler),
+		persister:             &checkpointPersister{},
+		deadLetterHandler:     handler,
+		deadLetterMaxAttempts: maxAttempts,
+		deadLetterBackoff:     backoff,
+	}
+}
+
+func TestWithDeadLetterRetries(t *testing.T) {
+	host := new(EventProcessorHost)
+	assert.NoError(t, WithDeadLetterRetries(5, time.Second)(host))
+	assert.Equal(t, 5, host.deadLetterMaxAttempts)
+	assert.Equal(t, time.Second, host.deadLetterBackoff)
+	assert.NoError(t, WithDeadLetterRetries(1, 0)(host), "a single attempt without backoff is allowed")
+
+	assert.Error(t, WithDeadLetterRetries(0, time.Second)(host))
+	assert.Error(t, WithDeadLetterRetries(3, -time.Second)(host))
+	assert.Error(t, WithDeadLetterHandler(nil)(host))
+	assert.Error(t, WithDeadLetterHub(nil)(host))
+}
+
+func TestHandleWithDeadLetterRetriesThenDeadLetters(t *testing.T) {
+	var deadLettered []error
+	host := newDeadLetterTestHost(func(ctx context.Context, partitionID string, event *eventhub.Event, handlerErr error) error {
+		assert.Equal(t, "0", partitionID)
+		deadLettered = append(deadLettered, handlerErr)
+		return nil
+	}, 3, 20*time.Millisecond)
+
+	attempts := 0
+	start := time.Now()
+	err := host.handleWithDeadLetter(context.Background(), "0", eventhub.NewEventFromString("foo"), func(ctx context.Context, event *eventhub.Event) error {
+		attempts++
+		return errors.New("attempt " + strconv.Itoa(attempts))
+	})
+	assert.NoError(t, err, "a dead lettered event is handled")
+	assert.Equal(t, 3, attempts)
+	assert.True(t, time.Since(start) >= 40*time.Millisecond, "the handler should be backed off between attempts")
+	if assert.Len(t, deadLettered, 1) {
+		assert.EqualError(t, deadLettered[0], "attempt 3", "the dead letter handler should receive the last error")
+	}
+}
+
+func TestHandleWithDeadLetterStopsOnSuccess(t *testing.T) {
+	host := newDeadLetterTestHost(func(ctx context.Context, partitionID string, event *eventhub.Event, handlerErr error) error {
+		assert.Fail(t, "an event which is eventually handled should not be dead lettered")
+		return nil
+	}, 3, 0)
+
+	attempts := 0
+	err := host.handleWithDeadLetter(context.Background(), "0", eventhub.NewEventFromString("foo"), func(ctx context.Context, event *eventhub.Event) error {
+		attempts++
+		if attempts < 2 {
+			return errors.New("transient")
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, attempts)
+}
+
+func TestHandleWithDeadLetterStopsBackingOffWhenCanceled(t *testing.T) {
+	host := newDeadLetterTestHost(func(ctx context.Context, partitionID string, event *eventhub.Event, handlerErr error) error {
+		assert.Fail(t, "a canceled event should not be dead lettered")
+		return nil
+	}, 3, time.Hour)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	err := host.handleWithDeadLetter(ctx, "0", eventhub.NewEventFromString("foo"), func(ctx context.Context, event *eventhub.Event) error {
+		cancel()
+		return errors.New("boom")
+	})
+	assert.Equal(t, context.Canceled, err)
+}
+
+func TestFailedDeadLetterBlocksCheckpoint(t *testing.T) {
+	sendErr := errors.New("dead letter hub unavailable")
+	var mu sync.Mutex
+	fail := true
+	host := newDeadLetterTestHost(func(ctx context.Context, partitionID string, event *eventhub.Event, handlerErr error) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if fail {
+			return sendErr
+		}
+		return nil
+	}, 1, 0)
+	host.handlers["failing"] = func(ctx context.Context, event *eventhub.Event) error {
+		return errors.New("boom")
+	}
+
+	// the receiver only checkpoints events whose handler returns nil
+	handler := host.compositeHandlers("0", nil)
+	assert.Equal(t, sendErr, handler(context.Background(), eventhub.NewEventFromString("foo")))
+
+	mu.Lock()
+	fail = false
+	mu.Unlock()
+	assert.NoError(t, handler(context.Background(), eventhub.NewEventFromString("foo")))
+}
+
+func TestNewDeadLetterEvent(t *testing.T) {
+	event := eventhub.NewEventFromString("foo")
+	key := "key"
+	event.PartitionKey = &key
+	event.Properties = map[string]interface{}{"app": "value"}
+
+	forward := newDeadLetterEvent("3", event, errors.New("boom"))
+	assert.Equal(t, event.Data, forward.Data)
+	assert.Equal(t, &key, forward.PartitionKey)
+	assert.Equal(t, "value", forward.Properties["app"])
+	assert.Equal(t, "boom", forward.Properties[deadLetterReasonProperty])
+	assert.Equal(t, "3", forward.Properties[deadLetterPartitionIDProperty])
+	assert.Equal(t, strconv.FormatInt(event.GetCheckpoint().SequenceNumber, 10), forward.Properties[deadLetterSequenceNumberProperty])
+	assert.Len(t, event.Properties, 1, "the properties of the original event should not be modified")
+
+	_, ok := newDeadLetterEvent("3", event, nil).Properties[deadLetterReasonProperty]
+	assert.False(t, ok, "no reason is recorded without a handler error")
+}
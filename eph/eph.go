@@ -29,16 +29,19 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
+	"strings"
 	"sync"
+	"syscall"
 	"time"
 
 	"github.com/Azure/azure-amqp-common-go/auth"
-	"github.com/Azure/azure-amqp-common-go/log"
 	"github.com/Azure/azure-amqp-common-go/persist"
 	"github.com/Azure/azure-amqp-common-go/uuid"
 	"github.com/Azure/azure-event-hubs-go"
+	"github.com/Azure/azure-event-hubs-go/mgmt"
 	"github.com/opentracing/opentracing-go"
 	tag "github.com/opentracing/opentracing-go/ext"
+	"github.com/pkg/errors"
 )
 
 const (
@@ -66,10 +69,86 @@ type (
 		checkpointer  Checkpointer
 		scheduler     *scheduler
 		handlers      map[string]eventhub.Handler
-		hostMu        sync.Mutex
-		handlersMu    sync.Mutex
-		partitionIDs  []string
-		noBanner      bool
+		// checkpointHandlers are handlers which control checkpointing explicitly through an EventCheckpointer
+		checkpointHandlers map[string]CheckpointHandler
+		// batchHandlers are handlers which are invoked with batches of events and checkpoint once a batch is handled
+		batchHandlers map[string]*batchRegistration
+		// partitionBatchers hold the events of each partition which the batchHandlers have yet to handle
+		partitionBatchers map[string]*partitionBatchers
+		// leaseChangeHandlers are notified when this host acquires or loses a partition lease
+		leaseChangeHandlers map[string]LeaseChangeHandler
+		hostMu              sync.Mutex
+		handlersMu          sync.Mutex
+		partitionIDs        []string
+		noBanner            bool
+		persister           *checkpointPersister
+		// checkpointInterval when greater than 0 enables coalescing of checkpoints, which are then flushed at most
+		// once per interval
+		checkpointInterval    time.Duration
+		deadLetterHandler     DeadLetterHandler
+		deadLetterMaxAttempts int
+		deadLetterBackoff     time.Duration
+		balancer              LeaseBalancer
+		// partitionInfoInterval when greater than 0 enables setting the partition runtime information on events
+		partitionInfoInterval time.Duration
+		// prefetchCount when greater than 0 overrides the link credit of the partition receivers
+		prefetchCount uint32
+		// startPosition is where partitions without a checkpoint start receiving
+		startPosition StartPosition
+		// closeHandlers are notified when this host stops processing a partition
+		closeHandlers map[string]PartitionCloseHandler
+		// partitionConcurrency when greater than 1 allows the events of a partition to be handled concurrently
+		partitionConcurrency int
+		// receiveBufferSize when greater than 0 bounds the events of each partition held ahead of the handlers
+		receiveBufferSize   int
+		receiveBufferPolicy eventhub.BufferPolicy
+		// partitionErrors holds the most recent error of each partition for diagnostics
+		partitionErrors partitionErrors
+		// manualCheckpointing disables the checkpoints the host writes after handlers and batches are handled
+		manualCheckpointing bool
+		// logger receives the log messages of the host and its Hub, if set
+		logger eventhub.Logger
+		// leaseRenewalJitter is the fraction of the renewal interval by which each lease renewal is randomly brought
+		// forward, and random returns the random fraction, defaulting to rand.Float64
+		leaseRenewalJitter float64
+		random             func() float64
+		// clock drives the balancing and lease renewal loops, defaulting to the wall clock
+		clock Clock
+		// partitionRefreshInterval is how often the Event Hub is checked for added partitions using runtimeInformation
+		partitionRefreshInterval time.Duration
+		runtimeInformation       func(ctx context.Context) (*mgmt.HubRuntimeInformation, error)
+		partitionIDsMu           sync.RWMutex
+		// checkpointRetryPolicy retries failed checkpoint writes, defaulting to NewCheckpointRetryPolicy
+		checkpointRetryPolicy eventhub.RetryPolicy
+		// maxReplayAge when greater than 0 caps how far before now a partition starts receiving from its checkpoint
+		maxReplayAge time.Duration
+		// partitionFilter when set restricts the partitions the host leases to those for which it returns true
+		partitionFilter func(partitionID string) bool
+		// releaseOnIdle when greater than 0 releases the lease of a partition which has handled no events for the duration
+		releaseOnIdle time.Duration
+		// checkpointMetrics when set records the latency of each call to the checkpointer
+		checkpointMetrics CheckpointMetricsRecorder
+		// keepLeasesOnClose leaves the leases of owned partitions to expire when the host is closed rather than
+		// releasing them
+		keepLeasesOnClose bool
+		// balancingBackoffMin and balancingBackoffMax bound the delay between scans after scans fail with a Leaser
+		// error, defaulting to the lease renewal interval and DefaultBalancingBackoffMaxDelay
+		balancingBackoffMin time.Duration
+		balancingBackoffMax time.Duration
+		// balanceMetrics when set records the outcome of each cycle of the balancing loop
+		balanceMetrics BalanceMetricsRecorder
+		// receiverRestartPolicy reopens the receivers of partitions whose link failed, defaulting to
+		// eventhub.NewExponentialBackoffRetryPolicy
+		receiverRestartPolicy eventhub.RetryPolicy
+		// receiveInterceptors are invoked in order with each event before it is handed to the handlers
+		receiveInterceptors []eventhub.ReceiveInterceptor
+		// encryptor when set decrypts the data of received events carrying the x-encryption property
+		encryptor eventhub.Encryptor
+		// ownershipChanged is closed, and replaced, each time a receiver is started for a partition
+		ownershipChanged chan struct{}
+		ownershipMu      sync.Mutex
+		// linkNamePrefix when set names the receiver link of each partition for broker-side diagnostics
+		linkNamePrefix string
 	}
 
 	// EventProcessorHostOption provides configuration options for an EventProcessorHost
@@ -80,8 +159,26 @@ type (
 		Receive(ctx context.Context, handler eventhub.Handler) (close func() error, err error)
 	}
 
+	// CheckpointHandler handles Event Hub events and decides when the progress of a partition is checkpointed. Events
+	// are not checkpointed automatically; call Checkpoint on the EventCheckpointer once the event has been durably
+	// handled, for example after a downstream transaction has committed.
+	CheckpointHandler func(ctx context.Context, event *eventhub.Event, checkpointer EventCheckpointer) error
+
+	// EventCheckpointer records the position of a received event as the checkpoint for its partition
+	EventCheckpointer interface {
+		Checkpoint(ctx context.Context) error
+	}
+
 	checkpointPersister struct {
 		checkpointer Checkpointer
+		batcher      *checkpointBatcher
+		host         *EventProcessorHost
+	}
+
+	eventCheckpointer struct {
+		persister   *checkpointPersister
+		partitionID string
+		checkpoint  persist.Checkpoint
 	}
 )
 
@@ -93,12 +190,86 @@ func WithNoBanner() EventProcessorHostOption {
 	}
 }
 
-// New constructs a new instance of an EventHostProcessor
+// WithNoLeaseRelease configures an EventProcessorHost to stop renewing the leases of the partitions it owns when it is
+// closed, leaving them to expire after the lease duration, rather than releasing them so other hosts may acquire them
+// immediately. Checkpoints are still flushed before the host stops.
+func WithNoLeaseRelease() EventProcessorHostOption {
+	return func(host *EventProcessorHost) error {
+		host.keepLeasesOnClose = true
+		return nil
+	}
+}
+
+// WithCheckpointInterval configures an EventProcessorHost to coalesce checkpoint updates per partition and flush the
+// latest checkpoint for each partition to the Checkpointer at most once per interval. Pending checkpoints are flushed
+// before a partition's lease is released and when the EventProcessorHost is closed.
+func WithCheckpointInterval(interval time.Duration) EventProcessorHostOption {
+	return func(host *EventProcessorHost) error {
+		if interval < 0 {
+			return errors.New("checkpoint interval must not be negative")
+		}
+		host.checkpointInterval = interval
+		return nil
+	}
+}
+
+// WithPrefetchCount configures the number of unsettled events the broker may push to each partition receiver ahead of
+// the handlers. Unless WithPartitionConcurrency is set, events of a partition are handed to the handlers one at a time.
+// Events are checkpointed and settled after the handlers return, so the prefetch count bounds the events of a partition
+// buffered in memory but not yet checkpointed. When not set, the default prefetch count of the receiver is used.
+func WithPrefetchCount(prefetch uint32) EventProcessorHostOption {
+	return func(host *EventProcessorHost) error {
+		if prefetch == 0 {
+			return errors.New("prefetch count must be greater than 0")
+		}
+		host.prefetchCount = prefetch
+		return nil
+	}
+}
+
+// WithReceiveBuffer bounds the events of each partition received ahead of the handlers by size, following the
+// policy. eventhub.BufferPolicyBlock buffers up to size events in addition to the prefetch count. When not set, events
+// are held only by the link credit of the prefetch count; use WithPrefetchCount to hold fewer events in memory.
+func WithReceiveBuffer(size int, policy eventhub.BufferPolicy) EventProcessorHostOption {
+	return func(host *EventProcessorHost) error {
+		if size < 1 {
+			return errors.New("receive buffer size must be greater than 0")
+		}
+		if policy != eventhub.BufferPolicyBlock {
+			return errors.Errorf("unknown receive buffer policy %d", policy)
+		}
+		host.receiveBufferSize = size
+		host.receiveBufferPolicy = policy
+		return nil
+	}
+}
+
+// WithPartitionConcurrency configures up to n events of each partition to be handled at once. Events of a partition
+// may then be handled out of order, so this suits idempotent handlers. The checkpoint of a partition only advances to
+// the last event for which it and every earlier event have been handled, so events in flight when the partition
+// closes are delivered again to the next owner. When not set, or set to 1, the events of a partition are handled one
+// at a time.
+func WithPartitionConcurrency(n int) EventProcessorHostOption {
+	return func(host *EventProcessorHost) error {
+		if n < 1 {
+			return errors.New("partition concurrency must be at least 1")
+		}
+		host.partitionConcurrency = n
+		return nil
+	}
+}
+
+// New constructs a new instance of an EventHostProcessor. The leaser and checkpointer may be the same instance, or
+// independent implementations backed by different stores.
 func New(ctx context.Context, namespace, hubName string, tokenProvider auth.TokenProvider, leaser Leaser, checkpointer Checkpointer, opts ...EventProcessorHostOption) (*EventProcessorHost, error) {
 	span, ctx := startConsumerSpanFromContext(ctx, "eventhub.eph.New")
 	defer span.Finish()
 
-	persister := checkpointPersister{checkpointer: checkpointer}
+	// checkpoints are written through a recorder so Health can report when the last one was written, and failed writes
+	// are retried before the recorder sees them
+	retrying := &retryingCheckpointer{Checkpointer: checkpointer}
+	writes := &recordingCheckpointer{Checkpointer: retrying}
+	persister := &checkpointPersister{checkpointer: writes}
 	client, err := eventhub.NewHub(namespace, hubName, tokenProvider, eventhub.HubWithOffsetPersistence(persister))
 	if err != nil {
 		return nil, err
@@ -115,16 +286,29 @@ func New(ctx context.Context, namespace, hubName string, tokenProvider auth.Toke
 	}
 
 	host := &EventProcessorHost{
-		namespace:     namespace,
-		name:          hostName.String(),
-		hubName:       hubName,
-		tokenProvider: tokenProvider,
-		client:        client,
-		handlers:      make(map[string]eventhub.Handler),
-		leaser:        leaser,
-		checkpointer:  checkpointer,
-		partitionIDs:  runtimeInfo.PartitionIDs,
-		noBanner:      false,
+		namespace:             namespace,
+		name:                  hostName.String(),
+		hubName:               hubName,
+		tokenProvider:         tokenProvider,
+		client:                client,
+		handlers:              make(map[string]eventhub.Handler),
+		checkpointHandlers:    make(map[string]CheckpointHandler),
+		batchHandlers:         make(map[string]*batchRegistration),
+		partitionBatchers:     make(map[string]*partitionBatchers),
+		leaseChangeHandlers:   make(map[string]LeaseChangeHandler),
+		closeHandlers:         make(map[string]PartitionCloseHandler),
+		leaser:                leaser,
+		checkpointer:          checkpointer,
+		partitionIDs:          runtimeInfo.PartitionIDs,
+		noBanner:              false,
+		persister:             persister,
+		deadLetterMaxAttempts: DefaultDeadLetterMaxAttempts,
+		deadLetterBackoff:     DefaultDeadLetterBackoff,
+		leaseRenewalJitter:    DefaultLeaseRenewalJitter,
+		balancer:              GreedyLeaseBalancer{},
+		// partitions added to the Event Hub are discovered from its runtime information
+		partitionRefreshInterval: DefaultPartitionRefreshInterval,
+		runtimeInformation:       client.GetRuntimeInformation,
 	}
 
 	for _, opt := range opts {
@@ -134,6 +318,30 @@ func New(ctx context.Context, namespace, hubName string, tokenProvider auth.Toke
 		}
 	}
 
+	if host.logger != nil {
+		if err := eventhub.HubWithLogger(host.logger)(client); err != nil {
+			return nil, err
+		}
+	}
+
+	if host.encryptor != nil {
+		if err := eventhub.HubWithPayloadEncryption(host.encryptor)(client); err != nil {
+			return nil, err
+		}
+	}
+
+	if host.checkpointMetrics != nil {
+		host.checkpointer = &measuringCheckpointer{Checkpointer: checkpointer, recorder: host.checkpointMetrics}
+		retrying.Checkpointer = host.checkpointer
+	}
+
+	persister.host = host
+	writes.host = host
+	retrying.host = host
+	if host.checkpointInterval > 0 {
+		persister.batcher = newCheckpointBatcher(writes, host.checkpointInterval)
+	}
+
 	return host, nil
 }
 
@@ -158,15 +366,35 @@ func (h *EventProcessorHost) Receive(handler eventhub.Handler) (close func() err
 	return close, nil
 }
 
+// ReceiveWithCheckpointHandler registers a handler which controls when events are checkpointed. While any
+// CheckpointHandler is registered, the EventProcessorHost no longer checkpoints after each event and progress is only
+// recorded when a handler calls Checkpoint on the EventCheckpointer it was given.
+func (h *EventProcessorHost) ReceiveWithCheckpointHandler(handler CheckpointHandler) (close func() error, err error) {
+	h.handlersMu.Lock()
+	defer h.handlersMu.Unlock()
+
+	receiverID, err := uuid.NewV4()
+	if err != nil {
+		return nil, err
+	}
+
+	h.checkpointHandlers[receiverID.String()] = handler
+	close = func() error {
+		h.handlersMu.Lock()
+		defer h.handlersMu.Unlock()
+
+		delete(h.checkpointHandlers, receiverID.String())
+		return nil
+	}
+	return close, nil
+}
+
 // Start begins processing of messages for registered handlers on the EventHostProcessor. The call is blocking.
 func (h *EventProcessorHost) Start(ctx context.Context) error {
 	span, ctx := startConsumerSpanFromContext(ctx, "eventhub.eph.EventProcessorHost.Start")
 	defer span.Finish()
 
-	if !h.noBanner {
-		fmt.Print(banner)
-		fmt.Println(exitPrompt)
-	}
+	h.printBanner(ctx, true)
 
 	if err := h.setup(ctx); err != nil {
 		return err
@@ -180,7 +408,7 @@ func (h *EventProcessorHost) Start(ctx context.Context) error {
 
 	// Wait for a signal to quit:
 	signalChan := make(chan os.Signal, 1)
-	signal.Notify(signalChan, os.Interrupt, os.Kill)
+	signal.Notify(signalChan, os.Interrupt, os.Kill, syscall.SIGTERM)
 	<-signalChan
 	return h.Close(ctx)
 }
@@ -190,9 +418,7 @@ func (h *EventProcessorHost) StartNonBlocking(ctx context.Context) error {
 	span, ctx := startConsumerSpanFromContext(ctx, "eventhub.eph.EventProcessorHost.StartNonBlocking")
 	defer span.Finish()
 
-	if !h.noBanner {
-		fmt.Print(banner)
-	}
+	h.printBanner(ctx, false)
 
 	if err := h.setup(ctx); err != nil {
 		return err
@@ -207,6 +433,20 @@ func (h *EventProcessorHost) StartNonBlocking(ctx context.Context) error {
 	return nil
 }
 
+// printBanner prints the banner, and the exit prompt if blocking, unless disabled. Hosts configured with WithLogger
+// log that they have started instead.
+func (h *EventProcessorHost) printBanner(ctx context.Context, blocking bool) {
+	switch {
+	case h.logger != nil:
+		h.logFor(ctx).Info("processing events")
+	case !h.noBanner:
+		fmt.Print(banner)
+		if blocking {
+			fmt.Println(exitPrompt)
+		}
+	}
+}
+
 // GetName returns the name of the EventProcessorHost
 func (h *EventProcessorHost) GetName() string {
 	return h.name
@@ -214,7 +454,10 @@ func (h *EventProcessorHost) GetName() string {
 
 // GetPartitionIDs fetches the partition IDs for the Event Hub
 func (h *EventProcessorHost) GetPartitionIDs() []string {
-	return h.partitionIDs
+	h.partitionIDsMu.RLock()
+	defer h.partitionIDsMu.RUnlock()
+
+	return append([]string(nil), h.partitionIDs...)
 }
 
 // PartitionIDsBeingProcessed returns the partition IDs currently receiving messages
@@ -228,29 +471,73 @@ func (h *EventProcessorHost) PartitionIDsBeingProcessed() []string {
 	return ids
 }
 
-// Close stops the EventHostProcessor from processing messages
+// Close stops the EventHostProcessor from processing messages. Receivers stop handing new events to the handlers and
+// in-flight handlers are given until the context deadline to complete. Pending checkpoints are then flushed and leases
+// released, even if the deadline passed; in that case an error naming the force closed partitions is returned. The
+// Leaser, Checkpointer and Hub are always closed, and the errors of every step are combined into the error returned.
+// Releasing the leases lets other hosts take over the partitions without waiting for the leases to expire, unless the
+// host was configured with WithNoLeaseRelease.
 func (h *EventProcessorHost) Close(ctx context.Context) error {
-	if !h.noBanner {
+	switch {
+	case h.logger != nil:
+		h.logFor(ctx).Info("shutting down")
+	case !h.noBanner:
 		fmt.Println("shutting down...")
 	}
+
+	// everything is closed even if a step fails, and the errors of every step are returned
+	var errs []error
 	if h.scheduler != nil {
-		if err := h.scheduler.Stop(ctx); err != nil {
-			if h.client != nil {
-				_ = h.client.Close(ctx)
-			}
-			return err
+		errs = append(errs, h.scheduler.Stop(ctx))
+	}
+	errs = append(errs, h.flushCheckpoints(ctx))
+	if h.leaser != nil {
+		errs = append(errs, h.leaser.Close())
+	}
+	if h.checkpointer != nil {
+		errs = append(errs, h.checkpointer.Close())
+	}
+	if h.client != nil {
+		errs = append(errs, h.client.Close(ctx))
+	}
+	return combineErrors(errs)
+}
+
+// combineErrors returns nil if none of the errors is non-nil, the error if only one is, or an error joining the
+// messages of all of them
+func combineErrors(errs []error) error {
+	var failed []error
+	for _, err := range errs {
+		if err != nil {
+			failed = append(failed, err)
 		}
 	}
 
-	if h.leaser != nil {
-		_ = h.leaser.Close()
+	switch len(failed) {
+	case 0:
+		return nil
+	case 1:
+		return failed[0]
+	default:
+		msgs := make([]string, len(failed))
+		for i, err := range failed {
+			msgs[i] = err.Error()
+		}
+		return errors.New(strings.Join(msgs, "; "))
 	}
+}
 
-	if h.checkpointer != nil {
-		_ = h.checkpointer.Close()
+// flushCheckpoints forces any coalesced checkpoints to be written to the Checkpointer
+func (h *EventProcessorHost) flushCheckpoints(ctx context.Context) error {
+	if h.persister == nil || h.persister.batcher == nil {
+		return nil
 	}
 
-	return h.client.Close(ctx)
+	err := h.persister.batcher.Stop(ctx)
+	if err != nil {
+		h.logFor(ctx).Error(err)
+	}
+	return err
 }
 
 func (h *EventProcessorHost) setup(ctx context.Context) error {
@@ -272,43 +559,165 @@ func (h *EventProcessorHost) setup(ctx context.Context) error {
 
 		scheduler := newScheduler(h)
 
-		for _, partitionID := range h.partitionIDs {
+		for _, partitionID := range h.GetPartitionIDs() {
 			h.leaser.EnsureLease(ctx, partitionID)
 			h.checkpointer.EnsureCheckpoint(ctx, partitionID)
 		}
 
 		h.scheduler = scheduler
+
+		if h.persister.batcher != nil {
+			go func() {
+				span := opentracing.SpanFromContext(ctx)
+				ctx := opentracing.ContextWithSpan(context.Background(), span)
+				h.persister.batcher.Run(ctx)
+			}()
+		}
 	}
 	return nil
 }
 
-func (h *EventProcessorHost) compositeHandlers() eventhub.Handler {
+func (h *EventProcessorHost) compositeHandlers(partitionID string, tracker handlingTracker) eventhub.Handler {
+	batchers := newPartitionBatchers(h, partitionID, tracker)
+	h.handlersMu.Lock()
+	if h.partitionBatchers == nil {
+		h.partitionBatchers = make(map[string]*partitionBatchers)
+	}
+	h.partitionBatchers[partitionID] = batchers
+	h.handlersMu.Unlock()
+
 	return func(ctx context.Context, event *eventhub.Event) error {
-		var wg sync.WaitGroup
+		h.handlersMu.Lock()
+		batchRegistrations := make(map[string]*batchRegistration, len(h.batchHandlers))
+		for id, registration := range h.batchHandlers {
+			batchRegistrations[id] = registration
+		}
+		handlers := make([]eventhub.Handler, 0, len(h.handlers)+len(h.checkpointHandlers))
 		for _, handle := range h.handlers {
+			handlers = append(handlers, handle)
+		}
+		if len(h.checkpointHandlers) > 0 {
+			checkpointer := &eventCheckpointer{
+				persister:   h.persister,
+				partitionID: partitionID,
+				checkpoint:  event.GetCheckpoint(),
+			}
+			for _, handle := range h.checkpointHandlers {
+				boundHandle := handle
+				handlers = append(handlers, func(ctx context.Context, event *eventhub.Event) error {
+					return boundHandle(ctx, event, checkpointer)
+				})
+			}
+		}
+		h.handlersMu.Unlock()
+
+		var wg sync.WaitGroup
+		var errMu sync.Mutex
+		var lastErr error
+		for _, handle := range handlers {
 			wg.Add(1)
 			go func(boundHandle eventhub.Handler) {
+				defer wg.Done()
+				if h.deadLetterHandler != nil {
+					// only an event which could not be dead lettered is reported so it is not checkpointed
+					if err := h.handleWithDeadLetter(ctx, partitionID, event, boundHandle); err != nil {
+						errMu.Lock()
+						lastErr = err
+						errMu.Unlock()
+					}
+					return
+				}
+
 				if err := boundHandle(ctx, event); err != nil {
-					log.For(ctx).Error(err)
+					h.logFor(ctx).Error(err)
 				}
-				wg.Done()
 			}(handle)
 		}
+		if len(batchRegistrations) > 0 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				// batches are retried by the batcher, so they are not subject to dead lettering
+				if err := batchers.add(ctx, event, batchRegistrations); err != nil {
+					h.logFor(ctx).Error(err)
+				}
+			}()
+		}
 		wg.Wait()
+		return lastErr
+	}
+}
+
+func (h *EventProcessorHost) hasCheckpointHandlers() bool {
+	h.handlersMu.Lock()
+	defer h.handlersMu.Unlock()
+
+	return len(h.checkpointHandlers) > 0
+}
+
+// hasUnhandledBatches returns true if a BatchHandler has yet to handle an event it received from the partition
+func (h *EventProcessorHost) hasUnhandledBatches(partitionID string) bool {
+	h.handlersMu.Lock()
+	batchers, ok := h.partitionBatchers[partitionID]
+	h.handlersMu.Unlock()
+
+	return ok && batchers.unhandled()
+}
+
+// Checkpoint records the position of the event as the checkpoint for its partition
+func (ec *eventCheckpointer) Checkpoint(ctx context.Context) error {
+	span, ctx := startConsumerSpanFromContext(ctx, "eventhub.eph.eventCheckpointer.Checkpoint")
+	defer span.Finish()
+
+	return ec.persister.checkpoint(ctx, ec.partitionID, ec.checkpoint)
+}
+
+func (c *checkpointPersister) Write(namespace, name, consumerGroup, partitionID string, checkpoint persist.Checkpoint) error {
+	if c.host != nil && (c.host.manualCheckpointing || c.host.hasCheckpointHandlers()) {
+		// progress is recorded explicitly by CheckpointHandlers
 		return nil
 	}
+	if c.host != nil && c.host.hasUnhandledBatches(partitionID) {
+		// the BatchHandlers checkpoint once they have handled the events they hold
+		return nil
+	}
+
+	// each attempt to write the checkpoint is bounded by checkpointAttemptTimeout
+	return c.checkpoint(context.Background(), partitionID, checkpoint)
 }
 
-func (c checkpointPersister) Write(namespace, name, consumerGroup, partitionID string, checkpoint persist.Checkpoint) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-	defer cancel()
+func (c *checkpointPersister) checkpoint(ctx context.Context, partitionID string, checkpoint persist.Checkpoint) error {
+	if c.batcher != nil {
+		return c.batcher.add(partitionID, checkpoint)
+	}
 	return c.checkpointer.UpdateCheckpoint(ctx, partitionID, checkpoint)
 }
 
-func (c checkpointPersister) Read(namespace, name, consumerGroup, partitionID string) (persist.Checkpoint, error) {
+func (c *checkpointPersister) Read(namespace, name, consumerGroup, partitionID string) (persist.Checkpoint, error) {
+	checkpoint, err := c.read(partitionID)
+	if err == nil && c.host != nil {
+		// receivers start from the checkpoint read, so the replay is capped here
+		checkpoint = c.host.capReplayAge(checkpoint)
+	}
+	return checkpoint, err
+}
+
+// read returns the latest checkpoint of the partition, or the checkpoint of the start position if none was recorded
+func (c *checkpointPersister) read(partitionID string) (persist.Checkpoint, error) {
+	if c.batcher != nil {
+		if checkpoint, ok := c.batcher.get(partitionID); ok {
+			return checkpoint, nil
+		}
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
-	return c.checkpointer.EnsureCheckpoint(ctx, partitionID)
+	checkpoint, err := c.checkpointer.EnsureCheckpoint(ctx, partitionID)
+	if err == nil && c.host != nil && checkpoint.Offset == persist.StartOfStream {
+		// no progress has been checkpointed for the partition
+		return c.host.startPosition.checkpoint(), nil
+	}
+	return checkpoint, err
 }
 
 func startConsumerSpanFromContext(ctx context.Context, operationName string, opts ...opentracing.StartSpanOption) (opentracing.Span, context.Context) {
@@ -0,0 +1,51 @@
+package eph
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"context"
+
+	"github.com/Azure/azure-event-hubs-go"
+)
+
+type (
+	epochContextKey struct{}
+)
+
+// EpochFromContext returns the epoch of the lease under which the event being handled was received. The epoch is
+// incremented each time the lease of a partition is acquired, so it can be used as a fencing token: tag writes made by
+// a handler with the epoch and reject writes carrying an epoch lower than one already seen for the partition, such as
+// late writes from a host which has lost the partition. The context of every handler registered with the
+// EventProcessorHost carries the epoch.
+func EpochFromContext(ctx context.Context) (int64, bool) {
+	epoch, ok := ctx.Value(epochContextKey{}).(int64)
+	return epoch, ok
+}
+
+// withEpoch wraps the handler so the context it is given carries the epoch of the lease the receiver was started with
+func (lr *leasedReceiver) withEpoch(handler eventhub.Handler) eventhub.Handler {
+	epoch := lr.lease.GetEpoch()
+	return func(ctx context.Context, event *eventhub.Event) error {
+		return handler(context.WithValue(ctx, epochContextKey{}, epoch), event)
+	}
+}
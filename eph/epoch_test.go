@@ -0,0 +1,74 @@
+package eph
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-event-hubs-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEpochFromContext(t *testing.T) {
+	_, ok := EpochFromContext(context.Background())
+	assert.False(t, ok)
+
+	lease := newMemoryLease("0")
+	lease.Epoch = 3
+	lr := newLeasedReceiver(&EventProcessorHost{name: "host"}, lease)
+
+	var epoch int64
+	handler := lr.withEpoch(func(ctx context.Context, event *eventhub.Event) error {
+		epoch, ok = EpochFromContext(ctx)
+		return nil
+	})
+	assert.NoError(t, handler(context.Background(), eventhub.NewEventFromString("foo")))
+	assert.True(t, ok)
+	assert.Equal(t, int64(3), epoch)
+}
+
+func TestMemoryLeaserEpochIncreasesAcrossHosts(t *testing.T) {
+	ctx := context.Background()
+	store := new(sharedStore)
+	var leasers []*memoryLeaserCheckpointer
+	for _, name := range []string{"a", "b"} {
+		ml := newMemoryLeaserCheckpointer(time.Minute, store)
+		ml.SetEventHostProcessor(&EventProcessorHost{name: name, partitionIDs: []string{"0"}})
+		assert.NoError(t, ml.EnsureStore(ctx))
+		_, err := ml.EnsureLease(ctx, "0")
+		assert.NoError(t, err)
+		leasers = append(leasers, ml)
+	}
+
+	var last int64
+	for i := 0; i < 4; i++ {
+		lease, ok, err := leasers[i%2].AcquireLease(ctx, "0")
+		if !assert.NoError(t, err) || !assert.True(t, ok) {
+			return
+		}
+		assert.True(t, lease.GetEpoch() > last, "each acquisition should be fenced by a higher epoch")
+		last = lease.GetEpoch()
+	}
+}
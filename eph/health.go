@@ -0,0 +1,173 @@
+package eph
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-amqp-common-go/persist"
+	"github.com/pkg/errors"
+)
+
+type (
+	// HealthStatus reports whether an EventProcessorHost is processing its partitions
+	HealthStatus struct {
+		// Healthy is true if the host is running, the checkpoint store is reachable and the receiver link of every
+		// owned partition is attached
+		Healthy bool
+		// Partitions holds the state of each partition owned by the host, ordered by partition ID
+		Partitions []PartitionHealth
+		// LastCheckpointWrite is when a checkpoint was last written to the Checkpointer, or the zero time if no
+		// checkpoint has been written by the host
+		LastCheckpointWrite time.Time
+	}
+
+	// PartitionHealth reports the state of a partition owned by an EventProcessorHost
+	PartitionHealth struct {
+		PartitionID string
+		// Epoch is the epoch of the lease held on the partition
+		Epoch int64
+		// LinkAttached is true while the receiver link of the partition is open
+		LinkAttached bool
+		// Err is the error which detached the receiver link, if any
+		Err error
+	}
+
+	// recordingCheckpointer records when checkpoints are written to the Checkpointer it wraps and records failed
+	// writes as errors of their partition
+	recordingCheckpointer struct {
+		Checkpointer
+		host      *EventProcessorHost
+		mu        sync.Mutex
+		lastWrite time.Time
+		// partitionWrites holds when the checkpoint of each partition was last written
+		partitionWrites map[string]time.Time
+	}
+)
+
+// Health reports the state of the partitions owned by the host and when a checkpoint was last written. The
+// checkpoint store is pinged by checking it exists, rather than by reading every lease or checkpoint, so Health is
+// suitable for frequent readiness probes. An error is returned if the checkpoint store cannot be reached.
+func (h *EventProcessorHost) Health(ctx context.Context) (HealthStatus, error) {
+	span, ctx := startConsumerSpanFromContext(ctx, "eventhub.eph.EventProcessorHost.Health")
+	defer span.Finish()
+
+	var status HealthStatus
+	if h.persister != nil {
+		status.LastCheckpointWrite = h.persister.lastWrite()
+	}
+	if h.scheduler != nil {
+		status.Partitions = h.scheduler.partitionHealth()
+	}
+
+	exists, err := h.checkpointer.StoreExists(ctx)
+	if err != nil {
+		return status, errors.Wrap(err, "checkpoint store is unreachable")
+	}
+	if !exists {
+		return status, errors.New("checkpoint store does not exist")
+	}
+
+	status.Healthy = h.scheduler != nil
+	for _, partition := range status.Partitions {
+		if !partition.LinkAttached {
+			status.Healthy = false
+		}
+	}
+	return status, nil
+}
+
+// partitionHealth returns the state of the partitions owned by the scheduler, ordered by partition ID
+func (s *scheduler) partitionHealth() []PartitionHealth {
+	s.receiverMu.Lock()
+	defer s.receiverMu.Unlock()
+
+	partitions := make([]PartitionHealth, 0, len(s.receivers))
+	for partitionID, lr := range s.receivers {
+		partition := PartitionHealth{
+			PartitionID: partitionID,
+			Epoch:       lr.lease.GetEpoch(),
+		}
+		if lr.handle != nil {
+			select {
+			case <-lr.handle.Done():
+				partition.Err = lr.handle.Err()
+			default:
+				partition.LinkAttached = true
+			}
+		}
+		partitions = append(partitions, partition)
+	}
+
+	sort.Slice(partitions, func(i, j int) bool {
+		return partitions[i].PartitionID < partitions[j].PartitionID
+	})
+	return partitions
+}
+
+// UpdateCheckpoint writes the checkpoint and records when it was written, or the error if it could not be written
+func (c *recordingCheckpointer) UpdateCheckpoint(ctx context.Context, partitionID string, checkpoint persist.Checkpoint) error {
+	if err := c.Checkpointer.UpdateCheckpoint(ctx, partitionID, checkpoint); err != nil {
+		if c.host != nil {
+			c.host.recordPartitionError(partitionID, err)
+		}
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lastWrite = time.Now()
+	if c.partitionWrites == nil {
+		c.partitionWrites = make(map[string]time.Time)
+	}
+	c.partitionWrites[partitionID] = c.lastWrite
+	return nil
+}
+
+// lastWrite returns when a checkpoint was last written through the persister, or the zero time
+func (c *checkpointPersister) lastWrite() time.Time {
+	recorder, ok := c.checkpointer.(*recordingCheckpointer)
+	if !ok {
+		return time.Time{}
+	}
+
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	return recorder.lastWrite
+}
+
+// lastPartitionWrite returns when the checkpoint of the partition was last written through the persister, or the zero
+// time
+func (c *checkpointPersister) lastPartitionWrite(partitionID string) time.Time {
+	recorder, ok := c.checkpointer.(*recordingCheckpointer)
+	if !ok {
+		return time.Time{}
+	}
+
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	return recorder.partitionWrites[partitionID]
+}
@@ -0,0 +1,80 @@
+package eph
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-amqp-common-go/persist"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHealth(t *testing.T) {
+	store := newMemoryLeaserCheckpointer(time.Minute, new(sharedStore))
+	host := &EventProcessorHost{
+		name:         "host",
+		leaser:       store,
+		checkpointer: store,
+		persister:    &checkpointPersister{checkpointer: &recordingCheckpointer{Checkpointer: store}},
+		partitionIDs: []string{"0"},
+	}
+	store.SetEventHostProcessor(host)
+
+	ctx := context.Background()
+	_, err := host.Health(ctx)
+	assert.Error(t, err, "the checkpoint store has not been provisioned")
+
+	assert.NoError(t, store.EnsureStore(ctx))
+	status, err := host.Health(ctx)
+	assert.NoError(t, err)
+	assert.False(t, status.Healthy, "a host which has not started is not healthy")
+	assert.True(t, status.LastCheckpointWrite.IsZero())
+
+	host.scheduler = newScheduler(host)
+	status, err = host.Health(ctx)
+	assert.NoError(t, err)
+	assert.True(t, status.Healthy, "a running host owning no partitions is healthy")
+
+	_, err = store.EnsureLease(ctx, "0")
+	assert.NoError(t, err)
+	lease, ok, err := store.AcquireLease(ctx, "0")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	host.scheduler.receivers["0"] = newLeasedReceiver(host, lease)
+
+	_, err = store.EnsureCheckpoint(ctx, "0")
+	assert.NoError(t, err)
+	assert.NoError(t, host.persister.checkpoint(ctx, "0", persist.NewCheckpoint("1", 1, time.Now())))
+
+	status, err = host.Health(ctx)
+	assert.NoError(t, err)
+	assert.False(t, status.Healthy, "a partition without an attached receiver link is not healthy")
+	assert.False(t, status.LastCheckpointWrite.IsZero())
+	if assert.Len(t, status.Partitions, 1) {
+		assert.Equal(t, "0", status.Partitions[0].PartitionID)
+		assert.Equal(t, lease.GetEpoch(), status.Partitions[0].Epoch)
+		assert.False(t, status.Partitions[0].LinkAttached)
+	}
+}
@@ -0,0 +1,145 @@
+package eph
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"context"
+	"time"
+
+	"github.com/Azure/azure-event-hubs-go"
+	"github.com/opentracing/opentracing-go"
+	"github.com/pkg/errors"
+)
+
+// WithReleaseOnIdle configures the EventProcessorHost to release the lease of a partition, closing its receiver, once
+// no events of the partition have been handled for the idle duration. The checkpoint of the partition is flushed before
+// the lease is released, and the host waits for the idle duration again before attempting to reacquire the partition,
+// so partitions with sparse traffic don't hold links open while quiet.
+func WithReleaseOnIdle(idle time.Duration) EventProcessorHostOption {
+	return func(host *EventProcessorHost) error {
+		if idle <= 0 {
+			return errors.New("idle duration must be greater than 0")
+		}
+		host.releaseOnIdle = idle
+		return nil
+	}
+}
+
+// trackActivity wraps the handler to record when the partition last handled an event and whether a handler is running
+func (lr *leasedReceiver) trackActivity(handler eventhub.Handler) eventhub.Handler {
+	return func(ctx context.Context, event *eventhub.Event) error {
+		lr.activityMu.Lock()
+		lr.active++
+		lr.activityMu.Unlock()
+
+		defer func() {
+			lr.activityMu.Lock()
+			lr.active--
+			lr.lastActivity = lr.processor.getClock().Now()
+			lr.activityMu.Unlock()
+		}()
+		return handler(ctx, event)
+	}
+}
+
+// idleFor returns how long the partition has been idle at now, or 0 while a handler is running
+func (lr *leasedReceiver) idleFor(now time.Time) time.Duration {
+	lr.activityMu.Lock()
+	defer lr.activityMu.Unlock()
+
+	if lr.active > 0 {
+		return 0
+	}
+	return now.Sub(lr.lastActivity)
+}
+
+// releaseWhenIdle releases the partition once it has been idle for the idle duration of the host, returning when the
+// context is done or the partition is released
+func (lr *leasedReceiver) releaseWhenIdle(ctx context.Context) {
+	idle := lr.processor.releaseOnIdle
+	if idle <= 0 {
+		return
+	}
+
+	clock := lr.processor.getClock()
+	wait := idle
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-clock.After(wait):
+		}
+
+		idleFor := lr.idleFor(clock.Now())
+		if idleFor < idle {
+			wait = idle - idleFor
+			continue
+		}
+
+		// releasing closes the receiver, which cancels ctx, so a fresh context is used
+		span := opentracing.SpanFromContext(ctx)
+		releaseCtx, cancel := context.WithTimeout(opentracing.ContextWithSpan(context.Background(), span), timeout)
+		lr.dlog(releaseCtx, "releasing idle partition")
+		if err := lr.processor.scheduler.releaseIdle(releaseCtx, lr.lease, idle); err != nil {
+			lr.logFor(releaseCtx).Error(err)
+		}
+		cancel()
+		return
+	}
+}
+
+// releaseIdle stops the receiver of an idle partition, releasing its lease, and keeps the scheduler from reacquiring
+// the partition until the cooldown has passed
+func (s *scheduler) releaseIdle(ctx context.Context, lease LeaseMarker, cooldown time.Duration) error {
+	s.idleMu.Lock()
+	if s.idleUntil == nil {
+		s.idleUntil = make(map[string]time.Time)
+	}
+	s.idleUntil[lease.GetPartitionID()] = s.processor.getClock().Now().Add(cooldown)
+	s.idleMu.Unlock()
+
+	return s.stopReceiver(ctx, lease, CloseReasonIdle, nil)
+}
+
+// withoutCoolingDown returns the leases of the partitions which are not cooling down after being released when idle
+func (s *scheduler) withoutCoolingDown(leases []LeaseMarker) []LeaseMarker {
+	s.idleMu.Lock()
+	defer s.idleMu.Unlock()
+
+	if len(s.idleUntil) == 0 {
+		return leases
+	}
+
+	now := s.processor.getClock().Now()
+	var filtered []LeaseMarker
+	for _, lease := range leases {
+		if until, ok := s.idleUntil[lease.GetPartitionID()]; ok {
+			if now.Before(until) {
+				continue
+			}
+			delete(s.idleUntil, lease.GetPartitionID())
+		}
+		filtered = append(filtered, lease)
+	}
+	return filtered
+}
@@ -0,0 +1,109 @@
+package eph
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-event-hubs-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithReleaseOnIdle(t *testing.T) {
+	host := new(EventProcessorHost)
+	assert.Error(t, WithReleaseOnIdle(0)(host))
+	assert.NoError(t, WithReleaseOnIdle(time.Minute)(host))
+	assert.Equal(t, time.Minute, host.releaseOnIdle)
+}
+
+func TestReleaseWhenIdle(t *testing.T) {
+	clock := newManualClock()
+	store := newMemoryLeaserCheckpointer(DefaultLeaseDuration, &sharedStore{clock: clock})
+	host := &EventProcessorHost{
+		name:          "host",
+		leaser:        store,
+		checkpointer:  store,
+		partitionIDs:  []string{"0"},
+		balancer:      GreedyLeaseBalancer{},
+		clock:         clock,
+		releaseOnIdle: time.Minute,
+		persister:     new(checkpointPersister),
+		closeHandlers: make(map[string]PartitionCloseHandler),
+	}
+	store.SetEventHostProcessor(host)
+	host.scheduler = newScheduler(host)
+
+	ctx := context.Background()
+	require.NoError(t, store.EnsureStore(ctx))
+	_, err := store.EnsureLease(ctx, "0")
+	require.NoError(t, err)
+	lease, ok, err := store.AcquireLease(ctx, "0")
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	closed := make(chan CloseReason, 1)
+	_, err = host.OnPartitionClose(func(partitionID string, reason CloseReason, err error) {
+		closed <- reason
+	})
+	require.NoError(t, err)
+
+	lr := newLeasedReceiver(host, lease)
+	lr.lastActivity = clock.Now()
+	host.scheduler.receivers[lease.GetPartitionID()] = lr
+	handler := lr.trackActivity(func(ctx context.Context, event *eventhub.Event) error { return nil })
+
+	done := make(chan struct{})
+	go func() {
+		lr.releaseWhenIdle(ctx)
+		close(done)
+	}()
+
+	for clock.waiting() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	clock.Advance(30 * time.Second)
+	require.NoError(t, handler(ctx, eventhub.NewEventFromString("event")))
+	clock.Advance(30 * time.Second)
+	for clock.waiting() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	assert.NotNil(t, host.scheduler.receiver("0"), "a partition which handled an event should not be released")
+
+	clock.Advance(30 * time.Second)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		require.FailNow(t, "the idle partition should be released")
+	}
+	assert.Nil(t, host.scheduler.receiver("0"))
+	assert.Equal(t, CloseReasonIdle, <-closed)
+
+	leases, err := store.GetLeases(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, host.scheduler.withoutCoolingDown(leases), "the partition should not be reacquired during the cooldown")
+	clock.Advance(time.Minute)
+	assert.Len(t, host.scheduler.withoutCoolingDown(leases), 1, "the partition should be reacquired after the cooldown")
+}
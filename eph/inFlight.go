@@ -0,0 +1,44 @@
+package eph
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+// InFlight returns the number of events of the partition which have been handed to the handlers and whose handlers
+// have yet to return, counting each event being handled concurrently when the host is configured with
+// WithPartitionConcurrency. As events are checkpointed only once their handlers return, these events are also not yet
+// checkpointed; a count which stays above 0 indicates a handler which is stuck. 0 is returned for partitions the host
+// does not own.
+func (h *EventProcessorHost) InFlight(partitionID string) int {
+	lr := h.currentReceiver(partitionID)
+	if lr == nil {
+		return 0
+	}
+	return lr.inFlight()
+}
+
+// inFlight returns the number of handler invocations of the receiver which have yet to return
+func (lr *leasedReceiver) inFlight() int {
+	lr.activityMu.Lock()
+	defer lr.activityMu.Unlock()
+
+	return lr.active
+}
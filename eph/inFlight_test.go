@@ -0,0 +1,69 @@
+package eph
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-event-hubs-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInFlight(t *testing.T) {
+	host, lease := newRestartTestHost(t)
+	assert.Equal(t, 0, host.InFlight("0"), "a host which has not started has no events in flight")
+
+	s := newScheduler(host)
+	host.scheduler = s
+	lr := newLeasedReceiver(host, lease)
+	s.receivers["0"] = lr
+
+	release := make(chan struct{})
+	handler := lr.trackActivity(func(ctx context.Context, event *eventhub.Event) error {
+		<-release
+		return nil
+	})
+
+	// events handled concurrently are each counted
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, handler(context.Background(), eventhub.NewEventFromString("foo")))
+		}()
+	}
+	deadline := time.Now().Add(time.Second)
+	for host.InFlight("0") < 3 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	assert.Equal(t, 3, host.InFlight("0"))
+	assert.Equal(t, 0, host.InFlight("1"), "events are counted per partition")
+
+	close(release)
+	wg.Wait()
+	assert.Equal(t, 0, host.InFlight("0"))
+}
@@ -25,8 +25,11 @@ package eph
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"io"
 	"sync/atomic"
+
+	"github.com/Azure/azure-event-hubs-go"
 )
 
 type (
@@ -37,7 +40,8 @@ type (
 		DeleteStore(ctx context.Context) error
 	}
 
-	// EventProcessHostSetter provides the ability to set an EventHostProcessor on the implementor
+	// EventProcessHostSetter provides the ability to set an EventHostProcessor on the implementor. The host sets itself
+	// on both its Leaser and its Checkpointer before using either, even when they are the same instance.
 	EventProcessHostSetter interface {
 		SetEventHostProcessor(eph *EventProcessorHost)
 	}
@@ -67,19 +71,36 @@ type (
 	LeaseMarker interface {
 		GetPartitionID() string
 		IsExpired(context.Context) bool
+		// GetOwner returns the name of the host holding the lease, or an empty string once the lease is released. A
+		// lease which expired without being released still names its last owner, so check IsExpired as well.
 		GetOwner() string
 		IncrementEpoch() int64
 		GetEpoch() int64
 		String() string
 	}
+
+	// notOwnedError is returned by operations on a partition the host does not own, and is matched by errors.Is to
+	// eventhub.ErrLeaseLost
+	notOwnedError struct {
+		partitionID string
+		host        string
+	}
 )
 
+func (e *notOwnedError) Error() string {
+	return fmt.Sprintf("partition %q is not owned by event processor host %q", e.partitionID, e.host)
+}
+
+func (e *notOwnedError) Is(target error) bool {
+	return target == eventhub.ErrLeaseLost
+}
+
 // GetPartitionID returns the partition which belongs to this lease
 func (l *Lease) GetPartitionID() string {
 	return l.PartitionID
 }
 
-// GetOwner returns the owner of the lease
+// GetOwner returns the name of the host which owns the lease
 func (l *Lease) GetOwner() string {
 	return l.Owner
 }
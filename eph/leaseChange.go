@@ -0,0 +1,65 @@
+package eph
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"github.com/Azure/azure-amqp-common-go/uuid"
+)
+
+type (
+	// LeaseChangeHandler is notified when the EventProcessorHost acquires or loses the lease of a partition.
+	// previousOwner is the name of the host whose ownership of the partition ended: the prior owner when a lease is
+	// acquired, or this host when a lease is lost.
+	LeaseChangeHandler func(partitionID string, acquired bool, previousOwner string)
+)
+
+// OnLeaseChange registers a handler which is invoked whenever the EventProcessorHost acquires or loses a partition
+// lease. Handlers are invoked asynchronously after the Leaser has persisted the change, so they never block balancing.
+func (h *EventProcessorHost) OnLeaseChange(handler LeaseChangeHandler) (close func() error, err error) {
+	h.handlersMu.Lock()
+	defer h.handlersMu.Unlock()
+
+	handlerID, err := uuid.NewV4()
+	if err != nil {
+		return nil, err
+	}
+
+	h.leaseChangeHandlers[handlerID.String()] = handler
+	close = func() error {
+		h.handlersMu.Lock()
+		defer h.handlersMu.Unlock()
+
+		delete(h.leaseChangeHandlers, handlerID.String())
+		return nil
+	}
+	return close, nil
+}
+
+func (h *EventProcessorHost) notifyLeaseChange(partitionID string, acquired bool, previousOwner string) {
+	h.handlersMu.Lock()
+	defer h.handlersMu.Unlock()
+
+	for _, handler := range h.leaseChangeHandlers {
+		go handler(partitionID, acquired, previousOwner)
+	}
+}
@@ -0,0 +1,63 @@
+package eph
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventProcessorHostOnLeaseChange(t *testing.T) {
+	host := &EventProcessorHost{name: "host", leaseChangeHandlers: make(map[string]LeaseChangeHandler)}
+
+	type change struct {
+		partitionID   string
+		acquired      bool
+		previousOwner string
+	}
+	changes := make(chan change, 1)
+	closer, err := host.OnLeaseChange(func(partitionID string, acquired bool, previousOwner string) {
+		changes <- change{partitionID: partitionID, acquired: acquired, previousOwner: previousOwner}
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	host.notifyLeaseChange("1", true, "other")
+	select {
+	case c := <-changes:
+		assert.Equal(t, change{partitionID: "1", acquired: true, previousOwner: "other"}, c)
+	case <-time.After(time.Second):
+		assert.Fail(t, "lease change handler was not invoked")
+	}
+
+	assert.NoError(t, closer())
+	host.notifyLeaseChange("1", false, "host")
+	select {
+	case c := <-changes:
+		assert.Fail(t, "lease change handler invoked after close", "%v", c)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
@@ -0,0 +1,61 @@
+package eph
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"math/rand"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// DefaultLeaseRenewalJitter is the default fraction of the lease renewal interval by which each renewal is randomly
+	// brought forward, so leases are renewed within the first half of DefaultLeaseDuration
+	DefaultLeaseRenewalJitter = 0.5
+)
+
+// WithLeaseRenewalJitter configures the fraction of the lease renewal interval by which each renewal is randomly
+// brought forward, spreading the renewals of many hosts rather than having them arrive at the lease store together.
+// A fraction of 0 renews on a fixed interval; the fraction must be less than 1.
+func WithLeaseRenewalJitter(fraction float64) EventProcessorHostOption {
+	return func(host *EventProcessorHost) error {
+		if fraction < 0 || fraction >= 1 {
+			return errors.New("lease renewal jitter must be at least 0 and less than 1")
+		}
+		host.leaseRenewalJitter = fraction
+		return nil
+	}
+}
+
+// leaseRenewalDelay returns how long to wait before the next lease renewal, which is a random point between the
+// renewal interval less the jitter and the renewal interval
+func (h *EventProcessorHost) leaseRenewalDelay() time.Duration {
+	random := h.random
+	if random == nil {
+		random = rand.Float64
+	}
+
+	jitter := time.Duration(h.leaseRenewalJitter * random() * float64(DefaultLeaseRenewalInterval))
+	return DefaultLeaseRenewalInterval - jitter
+}
@@ -0,0 +1,75 @@
+package eph
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithLeaseRenewalJitter(t *testing.T) {
+	host := new(EventProcessorHost)
+	assert.Error(t, WithLeaseRenewalJitter(-0.1)(host))
+	assert.Error(t, WithLeaseRenewalJitter(1)(host))
+	assert.NoError(t, WithLeaseRenewalJitter(0)(host))
+	assert.Equal(t, DefaultLeaseRenewalInterval, host.leaseRenewalDelay(), "no jitter should renew on a fixed interval")
+
+	assert.NoError(t, WithLeaseRenewalJitter(0.5)(host))
+	host.random = func() float64 { return 0 }
+	assert.Equal(t, DefaultLeaseRenewalInterval, host.leaseRenewalDelay())
+	host.random = func() float64 { return 0.99 }
+	assert.Equal(t, DefaultLeaseRenewalInterval-time.Duration(0.5*0.99*float64(DefaultLeaseRenewalInterval)), host.leaseRenewalDelay())
+	assert.True(t, host.leaseRenewalDelay() > DefaultLeaseRenewalInterval/2, "renewals should be brought forward by at most the jitter fraction")
+}
+
+func TestJitteredRenewalKeepsMemoryLease(t *testing.T) {
+	clock := newManualClock()
+	store := &sharedStore{clock: clock}
+	ml := newMemoryLeaserCheckpointer(DefaultLeaseDuration, store)
+	host := &EventProcessorHost{name: "host", leaser: ml, leaseRenewalJitter: DefaultLeaseRenewalJitter}
+	ml.SetEventHostProcessor(host)
+
+	ctx := context.Background()
+	assert.NoError(t, ml.EnsureStore(ctx))
+	_, err := ml.EnsureLease(ctx, "0")
+	assert.NoError(t, err)
+	_, ok, err := ml.AcquireLease(ctx, "0")
+	if !assert.NoError(t, err) || !assert.True(t, ok) {
+		return
+	}
+
+	for _, random := range []float64{0, 0.5, 0.99} {
+		host.random = func() float64 { return random }
+		clock.Advance(host.leaseRenewalDelay())
+		assert.True(t, store.isLeased("0"), "the lease should not expire before a jittered renewal")
+		_, ok, err = ml.RenewLease(ctx, "0")
+		assert.NoError(t, err)
+		assert.True(t, ok)
+	}
+
+	clock.Advance(DefaultLeaseDuration + time.Second)
+	assert.False(t, store.isLeased("0"), "the lease should expire once the clock passes the lease duration without renewal")
+}
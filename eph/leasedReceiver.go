@@ -25,10 +25,9 @@ package eph
 import (
 	"context"
 	"fmt"
-	"math/rand"
+	"sync"
 	"time"
 
-	"github.com/Azure/azure-amqp-common-go/log"
 	"github.com/Azure/azure-event-hubs-go"
 	"github.com/opentracing/opentracing-go"
 	"github.com/pkg/errors"
@@ -36,10 +35,22 @@ import (
 
 type (
 	leasedReceiver struct {
-		handle    *eventhub.ListenerHandle
-		processor *EventProcessorHost
-		lease     LeaseMarker
-		done      func()
+		handle     *eventhub.ListenerHandle
+		processor  *EventProcessorHost
+		lease      LeaseMarker
+		done       func()
+		inflight   sync.WaitGroup
+		inflightMu sync.Mutex
+		closing    bool
+		// closed is set once the receiver is closed, after which its handlers no longer own the partition
+		closed bool
+		// partitionInfo caches the runtime information of the partition when the host is configured to set it on events
+		partitionInfo partitionInformationCache
+		// lastActivity is when a handler last returned, and active the number of running handlers, for releasing idle
+		// partitions
+		lastActivity time.Time
+		active       int
+		activityMu   sync.Mutex
 	}
 )
 
@@ -57,16 +68,32 @@ func (lr *leasedReceiver) Run(ctx context.Context) error {
 	partitionID := lr.lease.GetPartitionID()
 	epoch := lr.lease.GetEpoch()
 	lr.dlog(ctx, "running...")
+	lr.lastActivity = lr.processor.getClock().Now()
 
 	go func() {
 		ctx, done := context.WithCancel(context.Background())
 		lr.done = done
 		span := opentracing.StartSpan("eventhub.eph.leasedReceiver.Run.startLeaseRenew", opentracing.FollowsFrom(span.Context()))
 		ctx = opentracing.ContextWithSpan(ctx, span)
+		go lr.releaseWhenIdle(ctx)
 		lr.periodicallyRenewLease(ctx)
 	}()
 
-	handle, err := lr.processor.client.Receive(ctx, partitionID, lr.processor.compositeHandlers(), eventhub.ReceiveWithEpoch(epoch))
+	handler := lr.trackActivity(lr.trackInflight(lr.withPartitionID(lr.withEpoch(lr.withPartitionInformation(lr.withErrorRecording(lr.withReceiveInterceptors(lr.processor.compositeHandlers(partitionID, lr))))))))
+	opts := []eventhub.ReceiveOption{eventhub.ReceiveWithEpoch(epoch)}
+	if lr.processor.prefetchCount > 0 {
+		opts = append(opts, eventhub.ReceiveWithPrefetchCount(lr.processor.prefetchCount))
+	}
+	if lr.processor.linkNamePrefix != "" {
+		opts = append(opts, eventhub.ReceiveWithLinkName(receiverLinkName(lr.processor.linkNamePrefix, lr.processor.name, partitionID)))
+	}
+	if lr.processor.partitionConcurrency > 1 {
+		opts = append(opts, eventhub.ReceiveWithHandlerConcurrency(lr.processor.partitionConcurrency))
+	}
+	if lr.processor.receiveBufferSize > 0 {
+		opts = append(opts, eventhub.ReceiveWithBuffer(lr.processor.receiveBufferSize, lr.processor.receiveBufferPolicy))
+	}
+	handle, err := lr.processor.client.Receive(ctx, partitionID, handler, opts...)
 	if err != nil {
 		return err
 	}
@@ -83,6 +110,10 @@ func (lr *leasedReceiver) Close(ctx context.Context) error {
 		lr.done()
 	}
 
+	lr.inflightMu.Lock()
+	lr.closed = true
+	lr.inflightMu.Unlock()
+
 	if lr.handle != nil {
 		return lr.handle.Close(ctx)
 	}
@@ -90,6 +121,67 @@ func (lr *leasedReceiver) Close(ctx context.Context) error {
 	return nil
 }
 
+// Drain stops the receiver from handing new events to the handlers and waits for in-flight handlers to return. If the
+// context expires before the handlers have returned, the context error is returned.
+func (lr *leasedReceiver) Drain(ctx context.Context) error {
+	span, ctx := lr.startConsumerSpanFromContext(ctx, "eventhub.eph.leasedReceiver.Drain")
+	defer span.Finish()
+
+	lr.inflightMu.Lock()
+	lr.closing = true
+	lr.inflightMu.Unlock()
+
+	drained := make(chan struct{})
+	go func() {
+		lr.inflight.Wait()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// trackInflight wraps the handler so in-flight invocations can be awaited when draining. Events arriving after the
+// receiver has begun closing are returned with an error so they are not checkpointed.
+func (lr *leasedReceiver) trackInflight(handler eventhub.Handler) eventhub.Handler {
+	return func(ctx context.Context, event *eventhub.Event) error {
+		if !lr.beginHandling() {
+			return errors.New("receiver is closing; event will be redelivered to the next owner of the partition")
+		}
+		defer lr.endHandling()
+		return handler(ctx, event)
+	}
+}
+
+// beginHandling records a handler invocation as in flight, returning false once the receiver has begun closing
+func (lr *leasedReceiver) beginHandling() bool {
+	lr.inflightMu.Lock()
+	defer lr.inflightMu.Unlock()
+
+	if lr.closing {
+		return false
+	}
+	lr.inflight.Add(1)
+	return true
+}
+
+// endHandling records the return of a handler invocation begun with beginHandling
+func (lr *leasedReceiver) endHandling() {
+	lr.inflight.Done()
+}
+
+// isClosed returns true once the receiver has been closed
+func (lr *leasedReceiver) isClosed() bool {
+	lr.inflightMu.Lock()
+	defer lr.inflightMu.Unlock()
+
+	return lr.closed
+}
+
 func (lr *leasedReceiver) listenForClose() {
 	go func() {
 		<-lr.handle.Done()
@@ -97,9 +189,27 @@ func (lr *leasedReceiver) listenForClose() {
 		defer cancel()
 		span, ctx := lr.startConsumerSpanFromContext(ctx, "eventhub.eph.leasedReceiver.listenForClose")
 		defer span.Finish()
-		err := lr.processor.scheduler.stopReceiver(ctx, lr.lease)
+		reason, cause := CloseReasonReceiverError, lr.handle.Err()
+		if cause != nil && cause != context.Canceled {
+			lr.processor.recordPartitionError(lr.lease.GetPartitionID(), cause)
+		}
+		if eventhub.IsReceiverDisconnected(cause) {
+			// another host has taken ownership of the partition with a higher epoch
+			lr.dlog(ctx, fmt.Sprintf("lost ownership: %v", cause))
+			reason = CloseReasonLeaseLost
+		}
+		if lr.processor.scheduler.receiver(lr.lease.GetPartitionID()) != lr {
+			// the receiver was replaced, such as when its checkpoint was reset, so the partition is still processed
+			return
+		}
+		if reason == CloseReasonReceiverError && cause != nil && cause != context.Canceled {
+			// the lease is still held, so the receiver is reopened rather than the partition given up
+			lr.processor.scheduler.restartReceiver(lr, cause)
+			return
+		}
+		err := lr.processor.scheduler.stopReceiver(ctx, lr.lease, reason, cause)
 		if err != nil {
-			log.For(ctx).Error(err)
+			lr.logFor(ctx).Error(err)
 		}
 	}()
 }
@@ -112,12 +222,10 @@ func (lr *leasedReceiver) periodicallyRenewLease(ctx context.Context) {
 		select {
 		case <-ctx.Done():
 			return
-		default:
-			skew := time.Duration(rand.Intn(1000)-500) * time.Millisecond
-			time.Sleep(DefaultLeaseRenewalInterval + skew)
+		case <-lr.processor.getClock().After(lr.processor.leaseRenewalDelay()):
 			err := lr.tryRenew(ctx)
 			if err != nil {
-				lr.processor.scheduler.stopReceiver(ctx, lr.lease)
+				lr.processor.scheduler.stopReceiver(ctx, lr.lease, CloseReasonLeaseLost, err)
 			}
 		}
 	}
@@ -129,12 +237,12 @@ func (lr *leasedReceiver) tryRenew(ctx context.Context) error {
 
 	lease, ok, err := lr.processor.leaser.RenewLease(ctx, lr.lease.GetPartitionID())
 	if err != nil {
-		log.For(ctx).Error(err)
+		lr.logFor(ctx).Error(err)
 		return err
 	}
 	if !ok {
 		err = errors.New("can't renew lease")
-		log.For(ctx).Error(err)
+		lr.logFor(ctx).Error(err)
 		return err
 	}
 	lr.dlog(ctx, "lease renewed")
@@ -146,7 +254,7 @@ func (lr *leasedReceiver) dlog(ctx context.Context, msg string) {
 	name := lr.processor.name
 	partitionID := lr.lease.GetPartitionID()
 	epoch := lr.lease.GetEpoch()
-	log.For(ctx).Debug(fmt.Sprintf("eph %q, partition %q, epoch %d: "+msg, name, partitionID, epoch))
+	lr.logFor(ctx).Debug(fmt.Sprintf("eph %q, partition %q, epoch %d: "+msg, name, partitionID, epoch))
 }
 
 func (lr *leasedReceiver) startConsumerSpanFromContext(ctx context.Context, operationName string, opts ...opentracing.StartSpanOption) (opentracing.Span, context.Context) {
@@ -0,0 +1,112 @@
+package eph
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-event-hubs-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLeasedReceiverDrain(t *testing.T) {
+	lr := newLeasedReceiver(&EventProcessorHost{name: "host"}, newMemoryLease("0"))
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	handler := lr.trackInflight(func(ctx context.Context, event *eventhub.Event) error {
+		close(started)
+		<-release
+		return nil
+	})
+
+	handled := make(chan error)
+	go func() {
+		handled <- handler(context.Background(), eventhub.NewEventFromString("foo"))
+	}()
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, lr.Drain(ctx), "drain should give up on a handler which outlives the deadline")
+	assert.Error(t, handler(context.Background(), eventhub.NewEventFromString("bar")), "events should not be handled once draining")
+
+	close(release)
+	assert.NoError(t, <-handled)
+	assert.NoError(t, lr.Drain(context.Background()))
+}
+
+func TestWithReceiveBuffer(t *testing.T) {
+	host := new(EventProcessorHost)
+	assert.NoError(t, WithReceiveBuffer(10, eventhub.BufferPolicyBlock)(host))
+	assert.Equal(t, 10, host.receiveBufferSize)
+	assert.Equal(t, eventhub.BufferPolicyBlock, host.receiveBufferPolicy)
+
+	assert.Error(t, WithReceiveBuffer(0, eventhub.BufferPolicyBlock)(host))
+	assert.Error(t, WithReceiveBuffer(10, eventhub.BufferPolicy(42))(host))
+}
+
+func TestSchedulerStopDrainsHandlersCallingBackIntoHost(t *testing.T) {
+	host, lease := newRestartTestHost(t)
+	s := newScheduler(host)
+	host.scheduler = s
+	lr := newLeasedReceiver(host, lease)
+	s.receivers["0"] = lr
+
+	started := make(chan struct{})
+	stopping := make(chan struct{})
+	handler := lr.trackInflight(func(ctx context.Context, event *eventhub.Event) error {
+		close(started)
+		<-stopping
+		// the handler must be able to reach the host while the scheduler waits for it to return
+		host.InFlight("0")
+		return nil
+	})
+
+	handled := make(chan error)
+	go func() {
+		handled <- handler(context.Background(), eventhub.NewEventFromString("foo"))
+	}()
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	stopped := make(chan error)
+	go func() {
+		_, err := s.stop(ctx, true)
+		stopped <- err
+	}()
+	for draining := false; !draining; {
+		time.Sleep(time.Millisecond)
+		lr.inflightMu.Lock()
+		draining = lr.closing
+		lr.inflightMu.Unlock()
+	}
+	close(stopping)
+
+	assert.NoError(t, <-handled)
+	assert.NoError(t, <-stopped, "the partition should not be force closed")
+	assert.Nil(t, s.receiver("0"))
+}
@@ -0,0 +1,54 @@
+package eph
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"fmt"
+
+	"github.com/Azure/azure-event-hubs-go"
+	"github.com/pkg/errors"
+)
+
+// WithReceiverLinkNamePrefix names the receiver link of each partition <prefix>-<host name>-<partition ID>, with
+// eventhub.ReceiveWithLinkName, so the links of the host can be found in broker-side diagnostics. The name of a
+// partition's link is kept when its receiver is recovered or restarted by the host. The prefix must leave the names
+// of all partitions within eventhub.MaxLinkNameLength.
+func WithReceiverLinkNamePrefix(prefix string) EventProcessorHostOption {
+	return func(host *EventProcessorHost) error {
+		if prefix == "" {
+			return errors.New("receiver link name prefix must not be empty")
+		}
+		for _, partitionID := range host.partitionIDs {
+			if name := receiverLinkName(prefix, host.name, partitionID); len(name) > eventhub.MaxLinkNameLength {
+				return errors.Errorf("receiver link name %q must be at most %d characters long", name, eventhub.MaxLinkNameLength)
+			}
+		}
+		host.linkNamePrefix = prefix
+		return nil
+	}
+}
+
+// receiverLinkName returns the name of the receiver link of the partition
+func receiverLinkName(prefix, hostName, partitionID string) string {
+	return fmt.Sprintf("%s-%s-%s", prefix, hostName, partitionID)
+}
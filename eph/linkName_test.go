@@ -0,0 +1,39 @@
+package eph
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithReceiverLinkNamePrefix(t *testing.T) {
+	host := &EventProcessorHost{name: "6b1e9f3a-3c1f-4a5e-9c4e-2f7d1e8a9b0c", partitionIDs: []string{"0", "31"}}
+	assert.Error(t, WithReceiverLinkNamePrefix("")(host))
+	assert.Error(t, WithReceiverLinkNamePrefix(strings.Repeat("a", 30))(host), "the name of partition 31 would be too long")
+
+	assert.NoError(t, WithReceiverLinkNamePrefix("orders")(host))
+	assert.Equal(t, "orders-6b1e9f3a-3c1f-4a5e-9c4e-2f7d1e8a9b0c-31", receiverLinkName(host.linkNamePrefix, host.name, "31"))
+}
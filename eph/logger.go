@@ -0,0 +1,90 @@
+package eph
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"context"
+
+	"github.com/Azure/azure-amqp-common-go/log"
+	"github.com/Azure/azure-event-hubs-go"
+	"github.com/pkg/errors"
+)
+
+type (
+	// hostLogger writes to the span of a context, as log.For does, and to the Logger of the host with the keys and
+	// values identifying the host and partition
+	hostLogger struct {
+		ctx           context.Context
+		logger        eventhub.Logger
+		keysAndValues []interface{}
+	}
+)
+
+// WithLogger configures the EventProcessorHost and its Hub to write to the logger as partitions are balanced,
+// received and checkpointed, in place of the banner printed to stdout
+func WithLogger(logger eventhub.Logger) EventProcessorHostOption {
+	return func(host *EventProcessorHost) error {
+		if logger == nil {
+			return errors.New("logger must not be nil")
+		}
+		host.logger = logger
+		return nil
+	}
+}
+
+// Debug writes a debug message
+func (l *hostLogger) Debug(msg string) {
+	log.For(l.ctx).Debug(msg)
+	if l.logger != nil {
+		l.logger.Debug(msg, l.keysAndValues...)
+	}
+}
+
+// Info writes an informational message
+func (l *hostLogger) Info(msg string) {
+	log.For(l.ctx).Info(msg)
+	if l.logger != nil {
+		l.logger.Info(msg, l.keysAndValues...)
+	}
+}
+
+// Error writes an error
+func (l *hostLogger) Error(err error) {
+	log.For(l.ctx).Error(err)
+	if l.logger != nil {
+		l.logger.Error(err.Error(), l.keysAndValues...)
+	}
+}
+
+// logFor returns a logger of the host with any further keys and values identifying the writer
+func (h *EventProcessorHost) logFor(ctx context.Context, keysAndValues ...interface{}) *hostLogger {
+	return &hostLogger{
+		ctx:           ctx,
+		logger:        h.logger,
+		keysAndValues: append([]interface{}{"eph", h.name}, keysAndValues...),
+	}
+}
+
+func (lr *leasedReceiver) logFor(ctx context.Context) *hostLogger {
+	return lr.processor.logFor(ctx, "partitionID", lr.lease.GetPartitionID(), "epoch", lr.lease.GetEpoch())
+}
@@ -0,0 +1,87 @@
+package eph
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"context"
+
+	"github.com/Azure/azure-event-hubs-go"
+	"github.com/pkg/errors"
+)
+
+type (
+	partitionIDContextKey    struct{}
+	leasedReceiverContextKey struct{}
+)
+
+// WithManualCheckpointing configures the host to never checkpoint on its own, neither after handlers return nor after
+// batches are handled. Progress is only recorded when the application calls EventProcessorHost.Checkpoint or
+// checkpoints through an EventCheckpointer, so a partition resumes from the last checkpoint the application wrote.
+func WithManualCheckpointing() EventProcessorHostOption {
+	return func(host *EventProcessorHost) error {
+		host.manualCheckpointing = true
+		return nil
+	}
+}
+
+// PartitionIDFromContext returns the ID of the partition from which the event being handled was received. The context
+// of every handler registered with the EventProcessorHost carries the partition ID.
+func PartitionIDFromContext(ctx context.Context) (string, bool) {
+	partitionID, ok := ctx.Value(partitionIDContextKey{}).(string)
+	return partitionID, ok
+}
+
+// Checkpoint records the position of the event as the checkpoint of the partition, so the partition resumes after the
+// event. The partition must be owned by the host, so checkpoints are not written for partitions another host has
+// taken over.
+func (h *EventProcessorHost) Checkpoint(ctx context.Context, partitionID string, event *eventhub.Event) error {
+	span, ctx := startConsumerSpanFromContext(ctx, "eventhub.eph.EventProcessorHost.Checkpoint")
+	defer span.Finish()
+
+	span.SetTag(partitionIDTag, partitionID)
+	if event == nil {
+		return errors.New("event must not be nil")
+	}
+	if !h.owns(ctx, partitionID) {
+		return &notOwnedError{partitionID: partitionID, host: h.name}
+	}
+	return h.persister.checkpoint(ctx, partitionID, event.GetCheckpoint())
+}
+
+// owns returns true if the partition is owned by the host. Handlers of the partition check the receiver which invoked
+// them rather than looking it up in the scheduler, so they don't wait on the scheduler while it drains them.
+func (h *EventProcessorHost) owns(ctx context.Context, partitionID string) bool {
+	if lr, ok := ctx.Value(leasedReceiverContextKey{}).(*leasedReceiver); ok && lr.processor == h && lr.lease.GetPartitionID() == partitionID {
+		return !lr.isClosed()
+	}
+	return h.scheduler != nil && h.scheduler.receiver(partitionID) != nil
+}
+
+// withPartitionID wraps the handler so the context it is given carries the ID of the partition and the receiver
+func (lr *leasedReceiver) withPartitionID(handler eventhub.Handler) eventhub.Handler {
+	partitionID := lr.lease.GetPartitionID()
+	return func(ctx context.Context, event *eventhub.Event) error {
+		ctx = context.WithValue(ctx, partitionIDContextKey{}, partitionID)
+		return handler(context.WithValue(ctx, leasedReceiverContextKey{}, lr), event)
+	}
+}
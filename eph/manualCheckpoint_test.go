@@ -0,0 +1,142 @@
+package eph
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-amqp-common-go/persist"
+	"github.com/Azure/azure-event-hubs-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManualCheckpointing(t *testing.T) {
+	ctx := context.Background()
+	store := newMemoryLeaserCheckpointer(time.Minute, new(sharedStore))
+	host := &EventProcessorHost{name: "host", leaser: store, checkpointer: store}
+	require.NoError(t, WithManualCheckpointing()(host))
+	host.persister = &checkpointPersister{checkpointer: store, host: host}
+	store.SetEventHostProcessor(host)
+
+	require.NoError(t, store.EnsureStore(ctx))
+	_, err := store.EnsureLease(ctx, "0")
+	require.NoError(t, err)
+	lease, ok, err := store.AcquireLease(ctx, "0")
+	require.NoError(t, err)
+	require.True(t, ok)
+	_, err = store.EnsureCheckpoint(ctx, "0")
+	require.NoError(t, err)
+
+	// the receiver records progress through the persister, which is ignored with manual checkpointing
+	require.NoError(t, host.persister.Write("ns", "hub", "$Default", "0", persist.NewCheckpoint("1", 1, time.Now())))
+	checkpoint, ok := store.GetCheckpoint(ctx, "0")
+	assert.True(t, ok)
+	assert.Equal(t, persist.StartOfStream, checkpoint.Offset)
+
+	event := eventhub.NewEventFromString("foo")
+	err = host.Checkpoint(ctx, "0", event)
+	if notOwned, ok := err.(*notOwnedError); assert.True(t, ok, "the partition is not owned by a host which has not started") {
+		assert.True(t, notOwned.Is(eventhub.ErrLeaseLost))
+	}
+
+	host.scheduler = newScheduler(host)
+	host.scheduler.receivers["0"] = newLeasedReceiver(host, lease)
+	assert.Error(t, host.Checkpoint(ctx, "0", nil))
+	assert.NoError(t, host.Checkpoint(ctx, "0", event))
+	checkpoint, ok = store.GetCheckpoint(ctx, "0")
+	assert.True(t, ok)
+	assert.Equal(t, event.GetCheckpoint(), checkpoint)
+}
+
+func TestPartitionIDFromContext(t *testing.T) {
+	_, ok := PartitionIDFromContext(context.Background())
+	assert.False(t, ok)
+
+	lr := newLeasedReceiver(&EventProcessorHost{name: "host"}, newMemoryLease("3"))
+	handler := lr.withPartitionID(func(ctx context.Context, event *eventhub.Event) error {
+		partitionID, ok := PartitionIDFromContext(ctx)
+		assert.True(t, ok)
+		assert.Equal(t, "3", partitionID)
+		return nil
+	})
+	assert.NoError(t, handler(context.Background(), eventhub.NewEventFromString("foo")))
+}
+
+func TestCheckpointWhileHostCloses(t *testing.T) {
+	host, lease := newRestartTestHost(t)
+	store := host.leaser.(*memoryLeaserCheckpointer)
+	host.checkpointer = store
+	host.persister = &checkpointPersister{checkpointer: store, host: host}
+	host.client = &eventhub.Hub{}
+	host.noBanner = true
+	_, err := store.EnsureCheckpoint(context.Background(), "0")
+	require.NoError(t, err)
+
+	s := newScheduler(host)
+	host.scheduler = s
+	lr := newLeasedReceiver(host, lease)
+	s.receivers["0"] = lr
+
+	event := eventhub.NewEventFromString("foo")
+	started := make(chan struct{})
+	closing := make(chan struct{})
+	handler := lr.trackInflight(lr.withPartitionID(func(ctx context.Context, event *eventhub.Event) error {
+		close(started)
+		<-closing
+		return host.Checkpoint(ctx, "0", event)
+	}))
+
+	handled := make(chan error)
+	go func() {
+		handled <- handler(context.Background(), event)
+	}()
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	closed := make(chan error)
+	go func() {
+		closed <- host.Close(ctx)
+	}()
+	for draining := false; !draining; {
+		time.Sleep(time.Millisecond)
+		lr.inflightMu.Lock()
+		draining = lr.closing
+		lr.inflightMu.Unlock()
+	}
+	close(closing)
+
+	assert.NoError(t, <-handled, "the partition is owned until its in-flight handlers are drained")
+	assert.NoError(t, <-closed)
+	checkpoint, ok := store.GetCheckpoint(context.Background(), "0")
+	assert.True(t, ok)
+	assert.Equal(t, event.GetCheckpoint(), checkpoint)
+
+	// the handler context of a closed receiver no longer owns the partition
+	assert.Error(t, lr.withPartitionID(func(ctx context.Context, event *eventhub.Event) error {
+		return host.Checkpoint(ctx, "0", event)
+	})(context.Background(), event))
+}
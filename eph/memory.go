@@ -38,7 +38,7 @@ type (
 		store         *sharedStore
 		processor     *EventProcessorHost
 		leaseDuration time.Duration
-		memMu         sync.Mutex
+		memMu         sync.RWMutex
 		leases        map[string]*memoryLease
 	}
 
@@ -52,7 +52,9 @@ type (
 
 	sharedStore struct {
 		leases  map[string]*storeLease
-		storeMu sync.Mutex
+		storeMu sync.RWMutex
+		// clock tells the time leases expire by, defaulting to the wall clock, so tests can control expiry
+		clock Clock
 	}
 
 	storeLease struct {
@@ -68,9 +70,16 @@ func newMemoryLease(partitionID string) *memoryLease {
 	return lease
 }
 
+func (s *sharedStore) now() time.Time {
+	if s.clock != nil {
+		return s.clock.Now()
+	}
+	return time.Now()
+}
+
 func (s *sharedStore) exists() bool {
-	s.storeMu.Lock()
-	defer s.storeMu.Unlock()
+	s.storeMu.RLock()
+	defer s.storeMu.RUnlock()
 
 	return s.leases != nil
 }
@@ -86,12 +95,22 @@ func (s *sharedStore) ensure() bool {
 }
 
 func (s *sharedStore) getLease(partitionID string) memoryLease {
-	s.storeMu.Lock()
-	defer s.storeMu.Unlock()
+	s.storeMu.RLock()
+	defer s.storeMu.RUnlock()
 
 	return *s.leases[partitionID].ml
 }
 
+func (s *sharedStore) getCheckpoint(partitionID string) (persist.Checkpoint, bool) {
+	s.storeMu.RLock()
+	defer s.storeMu.RUnlock()
+
+	if l, ok := s.leases[partitionID]; ok && l.ml != nil && l.ml.Checkpoint != nil {
+		return *l.ml.Checkpoint, true
+	}
+	return persist.Checkpoint{}, false
+}
+
 func (s *sharedStore) deleteLease(partitionID string) {
 	s.storeMu.Lock()
 	defer s.storeMu.Unlock()
@@ -121,7 +140,7 @@ func (s *sharedStore) changeLease(partitionID, newToken, oldToken string, durati
 
 	if l, ok := s.leases[partitionID]; ok && l.token == oldToken {
 		l.token = newToken
-		l.expiration = time.Now().Add(duration)
+		l.expiration = s.now().Add(duration)
 		return true
 	}
 	return false
@@ -133,7 +152,10 @@ func (s *sharedStore) releaseLease(partitionID, token string) bool {
 
 	if l, ok := s.leases[partitionID]; ok && l.token == token {
 		l.token = ""
-		l.expiration = time.Now().Add(-1 * time.Second)
+		l.expiration = s.now().Add(-1 * time.Second)
+		if l.ml != nil {
+			l.ml.Owner = ""
+		}
 		return true
 	}
 	return false
@@ -144,7 +166,7 @@ func (s *sharedStore) renewLease(partitionID, token string, duration time.Durati
 	defer s.storeMu.Unlock()
 
 	if l, ok := s.leases[partitionID]; ok && l.token == token {
-		l.expiration = time.Now().Add(duration)
+		l.expiration = s.now().Add(duration)
 		return true
 	}
 	return false
@@ -154,9 +176,9 @@ func (s *sharedStore) acquireLease(partitionID, newToken string, duration time.D
 	s.storeMu.Lock()
 	defer s.storeMu.Unlock()
 
-	if l, ok := s.leases[partitionID]; ok && (time.Now().After(l.expiration) || l.token == "") {
+	if l, ok := s.leases[partitionID]; ok && (s.now().After(l.expiration) || l.token == "") {
 		l.token = newToken
-		l.expiration = time.Now().Add(duration)
+		l.expiration = s.now().Add(duration)
 		return true
 	}
 	return false
@@ -174,11 +196,11 @@ func (s *sharedStore) storeLease(partitionID, token string, ml memoryLease) bool
 }
 
 func (s *sharedStore) isLeased(partitionID string) bool {
-	s.storeMu.Lock()
-	defer s.storeMu.Unlock()
+	s.storeMu.RLock()
+	defer s.storeMu.RUnlock()
 
 	if l, ok := s.leases[partitionID]; ok {
-		if time.Now().After(l.expiration) || l.token == "" {
+		if s.now().After(l.expiration) || l.token == "" {
 			return false
 		}
 		return true
@@ -197,7 +219,11 @@ func (l *memoryLease) IsExpired(_ context.Context) bool {
 }
 
 func (l *memoryLease) expireAfter(d time.Duration) {
-	l.expirationTime = time.Now().Add(d)
+	now := time.Now()
+	if l.leaser != nil {
+		now = l.leaser.store.now()
+	}
+	l.expirationTime = now.Add(d)
 }
 
 func newMemoryLeaserCheckpointer(leaseDuration time.Duration, store *sharedStore) *memoryLeaserCheckpointer {
@@ -213,6 +239,10 @@ func (ml *memoryLeaserCheckpointer) SetEventHostProcessor(eph *EventProcessorHos
 }
 
 func (ml *memoryLeaserCheckpointer) StoreExists(ctx context.Context) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
 	span, ctx := startConsumerSpanFromContext(ctx, "eventhub.eph.memoryLeaserCheckpointer.StoreExists")
 	defer span.Finish()
 
@@ -220,6 +250,10 @@ func (ml *memoryLeaserCheckpointer) StoreExists(ctx context.Context) (bool, erro
 }
 
 func (ml *memoryLeaserCheckpointer) EnsureStore(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	span, ctx := startConsumerSpanFromContext(ctx, "eventhub.eph.memoryLeaserCheckpointer.EnsureStore")
 	defer span.Finish()
 
@@ -228,6 +262,10 @@ func (ml *memoryLeaserCheckpointer) EnsureStore(ctx context.Context) error {
 }
 
 func (ml *memoryLeaserCheckpointer) DeleteStore(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	span, ctx := startConsumerSpanFromContext(ctx, "eventhub.eph.memoryLeaserCheckpointer.DeleteStore")
 	defer span.Finish()
 
@@ -235,6 +273,10 @@ func (ml *memoryLeaserCheckpointer) DeleteStore(ctx context.Context) error {
 }
 
 func (ml *memoryLeaserCheckpointer) GetLeases(ctx context.Context) ([]LeaseMarker, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	ml.memMu.Lock()
 	defer ml.memMu.Unlock()
 
@@ -252,6 +294,10 @@ func (ml *memoryLeaserCheckpointer) GetLeases(ctx context.Context) ([]LeaseMarke
 }
 
 func (ml *memoryLeaserCheckpointer) EnsureLease(ctx context.Context, partitionID string) (LeaseMarker, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	ml.memMu.Lock()
 	defer ml.memMu.Unlock()
 
@@ -264,6 +310,10 @@ func (ml *memoryLeaserCheckpointer) EnsureLease(ctx context.Context, partitionID
 }
 
 func (ml *memoryLeaserCheckpointer) DeleteLease(ctx context.Context, partitionID string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	ml.memMu.Lock()
 	defer ml.memMu.Unlock()
 
@@ -275,6 +325,10 @@ func (ml *memoryLeaserCheckpointer) DeleteLease(ctx context.Context, partitionID
 }
 
 func (ml *memoryLeaserCheckpointer) AcquireLease(ctx context.Context, partitionID string) (LeaseMarker, bool, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, false, err
+	}
+
 	ml.memMu.Lock()
 	defer ml.memMu.Unlock()
 
@@ -301,6 +355,10 @@ func (ml *memoryLeaserCheckpointer) AcquireLease(ctx context.Context, partitionI
 		}
 	}
 
+	// read the lease again now it is held, so the epoch is incremented from the last one stored rather than from one
+	// which may have been superseded while acquiring
+	lease = ml.store.getLease(partitionID)
+	lease.leaser = ml
 	lease.Token = newToken
 	lease.Owner = ml.processor.GetName()
 	lease.IncrementEpoch()
@@ -312,8 +370,12 @@ func (ml *memoryLeaserCheckpointer) AcquireLease(ctx context.Context, partitionI
 }
 
 func (ml *memoryLeaserCheckpointer) RenewLease(ctx context.Context, partitionID string) (LeaseMarker, bool, error) {
-	ml.memMu.Lock()
-	defer ml.memMu.Unlock()
+	if err := ctx.Err(); err != nil {
+		return nil, false, err
+	}
+
+	ml.memMu.RLock()
+	defer ml.memMu.RUnlock()
 
 	span, ctx := startConsumerSpanFromContext(ctx, "eventhub.eph.memoryLeaserCheckpointer.RenewLease")
 	defer span.Finish()
@@ -330,6 +392,10 @@ func (ml *memoryLeaserCheckpointer) RenewLease(ctx context.Context, partitionID
 }
 
 func (ml *memoryLeaserCheckpointer) ReleaseLease(ctx context.Context, partitionID string) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
 	ml.memMu.Lock()
 	defer ml.memMu.Unlock()
 
@@ -349,6 +415,13 @@ func (ml *memoryLeaserCheckpointer) ReleaseLease(ctx context.Context, partitionI
 }
 
 func (ml *memoryLeaserCheckpointer) UpdateLease(ctx context.Context, partitionID string) (LeaseMarker, bool, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, false, err
+	}
+
+	ml.memMu.RLock()
+	defer ml.memMu.RUnlock()
+
 	span, ctx := startConsumerSpanFromContext(ctx, "eventhub.eph.memoryLeaserCheckpointer.UpdateLease")
 	defer span.Finish()
 
@@ -369,8 +442,12 @@ func (ml *memoryLeaserCheckpointer) UpdateLease(ctx context.Context, partitionID
 }
 
 func (ml *memoryLeaserCheckpointer) GetCheckpoint(ctx context.Context, partitionID string) (persist.Checkpoint, bool) {
-	ml.memMu.Lock()
-	defer ml.memMu.Unlock()
+	if ctx.Err() != nil {
+		return persist.NewCheckpointFromStartOfStream(), false
+	}
+
+	ml.memMu.RLock()
+	defer ml.memMu.RUnlock()
 
 	span, ctx := startConsumerSpanFromContext(ctx, "eventhub.eph.memoryCheckpointer.GetCheckpoint")
 	defer span.Finish()
@@ -379,10 +456,19 @@ func (ml *memoryLeaserCheckpointer) GetCheckpoint(ctx context.Context, partition
 	if ok {
 		return *lease.Checkpoint, ok
 	}
-	return persist.NewCheckpointFromStartOfStream(), ok
+
+	// as with durable stores, checkpoints of partitions owned by other hosts are read from the shared store
+	if checkpoint, ok := ml.store.getCheckpoint(partitionID); ok {
+		return checkpoint, ok
+	}
+	return persist.NewCheckpointFromStartOfStream(), false
 }
 
 func (ml *memoryLeaserCheckpointer) EnsureCheckpoint(ctx context.Context, partitionID string) (persist.Checkpoint, error) {
+	if err := ctx.Err(); err != nil {
+		return persist.NewCheckpointFromStartOfStream(), err
+	}
+
 	ml.memMu.Lock()
 	defer ml.memMu.Unlock()
 
@@ -401,6 +487,10 @@ func (ml *memoryLeaserCheckpointer) EnsureCheckpoint(ctx context.Context, partit
 }
 
 func (ml *memoryLeaserCheckpointer) UpdateCheckpoint(ctx context.Context, partitionID string, checkpoint persist.Checkpoint) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	ml.memMu.Lock()
 	defer ml.memMu.Unlock()
 
@@ -420,6 +510,10 @@ func (ml *memoryLeaserCheckpointer) UpdateCheckpoint(ctx context.Context, partit
 }
 
 func (ml *memoryLeaserCheckpointer) DeleteCheckpoint(ctx context.Context, partitionID string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	ml.memMu.Lock()
 	defer ml.memMu.Unlock()
 
@@ -0,0 +1,193 @@
+package eph
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-amqp-common-go/persist"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryLeaserCheckpointerHonorsCancellation(t *testing.T) {
+	ml := newMemoryLeaserCheckpointer(time.Minute, new(sharedStore))
+	ml.SetEventHostProcessor(&EventProcessorHost{name: "host", partitionIDs: []string{"0"}})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := ml.StoreExists(ctx)
+	assert.Equal(t, context.Canceled, err)
+	assert.Equal(t, context.Canceled, ml.EnsureStore(ctx))
+	assert.Equal(t, context.Canceled, ml.DeleteStore(ctx))
+	_, err = ml.GetLeases(ctx)
+	assert.Equal(t, context.Canceled, err)
+	_, err = ml.EnsureLease(ctx, "0")
+	assert.Equal(t, context.Canceled, err)
+	assert.Equal(t, context.Canceled, ml.DeleteLease(ctx, "0"))
+	_, _, err = ml.AcquireLease(ctx, "0")
+	assert.Equal(t, context.Canceled, err)
+	_, _, err = ml.RenewLease(ctx, "0")
+	assert.Equal(t, context.Canceled, err)
+	_, err = ml.ReleaseLease(ctx, "0")
+	assert.Equal(t, context.Canceled, err)
+	_, _, err = ml.UpdateLease(ctx, "0")
+	assert.Equal(t, context.Canceled, err)
+	_, ok := ml.GetCheckpoint(ctx, "0")
+	assert.False(t, ok)
+	_, err = ml.EnsureCheckpoint(ctx, "0")
+	assert.Equal(t, context.Canceled, err)
+	assert.Equal(t, context.Canceled, ml.UpdateCheckpoint(ctx, "0", persist.NewCheckpointFromStartOfStream()))
+	assert.Equal(t, context.Canceled, ml.DeleteCheckpoint(ctx, "0"))
+}
+
+func TestMemoryLeaserCheckpointerDeadlineExceeded(t *testing.T) {
+	ml := newMemoryLeaserCheckpointer(time.Minute, new(sharedStore))
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+
+	assert.Equal(t, context.DeadlineExceeded, ml.EnsureStore(ctx))
+	_, _, err := ml.AcquireLease(ctx, "0")
+	assert.Equal(t, context.DeadlineExceeded, err)
+}
+
+func TestMemoryLeaserCheckpointerStoreExists(t *testing.T) {
+	ctx := context.Background()
+	ml := newMemoryLeaserCheckpointer(time.Minute, new(sharedStore))
+
+	exists, err := ml.StoreExists(ctx)
+	assert.NoError(t, err)
+	assert.False(t, exists, "the store should not exist before EnsureStore")
+
+	assert.NoError(t, ml.EnsureStore(ctx))
+	exists, err = ml.StoreExists(ctx)
+	assert.NoError(t, err)
+	assert.True(t, exists, "the store should exist after EnsureStore")
+}
+
+func TestMemoryLeaserCheckpointerUpdateCheckpoint(t *testing.T) {
+	ctx := context.Background()
+	ml := newMemoryLeaserCheckpointer(time.Minute, new(sharedStore))
+	ml.SetEventHostProcessor(&EventProcessorHost{name: "host", partitionIDs: []string{"0"}})
+	assert.NoError(t, ml.EnsureStore(ctx))
+
+	checkpoint := persist.NewCheckpoint("100", 10, time.Date(2018, 6, 1, 0, 0, 0, 0, time.UTC))
+	assert.Error(t, ml.UpdateCheckpoint(ctx, "0", checkpoint), "a partition which isn't owned can't be checkpointed")
+
+	_, err := ml.EnsureLease(ctx, "0")
+	assert.NoError(t, err)
+	_, ok, err := ml.AcquireLease(ctx, "0")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	_, err = ml.EnsureCheckpoint(ctx, "0")
+	assert.NoError(t, err)
+
+	assert.NoError(t, ml.UpdateCheckpoint(ctx, "0", checkpoint))
+	stored, ok := ml.GetCheckpoint(ctx, "0")
+	assert.True(t, ok)
+	assert.Equal(t, checkpoint, stored)
+}
+
+func TestMemoryLeaserGetLeasesOwners(t *testing.T) {
+	ctx := context.Background()
+	store := new(sharedStore)
+	partitionIDs := []string{"0", "1"}
+	hosts := make(map[string]*memoryLeaserCheckpointer)
+	for _, name := range []string{"a", "b"} {
+		ml := newMemoryLeaserCheckpointer(time.Minute, store)
+		ml.SetEventHostProcessor(&EventProcessorHost{name: name, partitionIDs: partitionIDs})
+		assert.NoError(t, ml.EnsureStore(ctx))
+		for _, partitionID := range partitionIDs {
+			_, err := ml.EnsureLease(ctx, partitionID)
+			assert.NoError(t, err)
+		}
+		hosts[name] = ml
+	}
+
+	owners := func() map[string]string {
+		leases, err := hosts["a"].GetLeases(ctx)
+		assert.NoError(t, err)
+		byPartition := make(map[string]string)
+		for _, lease := range leases {
+			byPartition[lease.GetPartitionID()] = lease.GetOwner()
+		}
+		return byPartition
+	}
+	assert.Equal(t, map[string]string{"0": "", "1": ""}, owners())
+
+	for name, partitionID := range map[string]string{"a": "0", "b": "1"} {
+		_, ok, err := hosts[name].AcquireLease(ctx, partitionID)
+		assert.NoError(t, err)
+		assert.True(t, ok)
+	}
+	assert.Equal(t, map[string]string{"0": "a", "1": "b"}, owners())
+
+	ok, err := hosts["b"].ReleaseLease(ctx, "1")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, map[string]string{"0": "a", "1": ""}, owners(), "a released lease should have no owner")
+}
+
+func TestMemoryLeaserCheckpointerConcurrentHosts(t *testing.T) {
+	ctx := context.Background()
+	store := new(sharedStore)
+	partitionIDs := []string{"0", "1", "2", "3"}
+
+	// each host is used concurrently, as the scheduler and lease renewal of an EventProcessorHost do
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		ml := newMemoryLeaserCheckpointer(time.Minute, store)
+		ml.SetEventHostProcessor(&EventProcessorHost{name: fmt.Sprintf("host%d", i), partitionIDs: partitionIDs})
+		if !assert.NoError(t, ml.EnsureStore(ctx)) {
+			return
+		}
+		for _, partitionID := range partitionIDs {
+			if _, err := ml.EnsureLease(ctx, partitionID); !assert.NoError(t, err) {
+				return
+			}
+		}
+
+		for _, partitionID := range partitionIDs {
+			wg.Add(1)
+			go func(ml *memoryLeaserCheckpointer, partitionID string) {
+				defer wg.Done()
+				for j := 0; j < 50; j++ {
+					_, _ = ml.GetLeases(ctx)
+					if _, ok, _ := ml.AcquireLease(ctx, partitionID); !ok {
+						continue
+					}
+					_, _ = ml.EnsureCheckpoint(ctx, partitionID)
+					_ = ml.UpdateCheckpoint(ctx, partitionID, persist.NewCheckpoint(fmt.Sprint(j), int64(j), time.Now()))
+					_, _, _ = ml.RenewLease(ctx, partitionID)
+					_, _, _ = ml.UpdateLease(ctx, partitionID)
+					_, _ = ml.GetCheckpoint(ctx, partitionID)
+					_, _ = ml.ReleaseLease(ctx, partitionID)
+				}
+			}(ml, partitionID)
+		}
+	}
+	wg.Wait()
+}
@@ -0,0 +1,106 @@
+package eph
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"github.com/Azure/azure-amqp-common-go/uuid"
+)
+
+const (
+	// CloseReasonShutdown indicates the partition was closed because the EventProcessorHost was closed
+	CloseReasonShutdown CloseReason = iota
+	// CloseReasonLeaseLost indicates the partition was closed because its lease could not be renewed or another host
+	// took ownership of the partition
+	CloseReasonLeaseLost
+	// CloseReasonReceiverError indicates the partition was closed because its receiver failed and could not recover
+	// within the receiver restart policy
+	CloseReasonReceiverError
+	// CloseReasonCheckpointError indicates the partition was closed because its checkpoint could not be written
+	CloseReasonCheckpointError
+	// CloseReasonIdle indicates the partition was closed because it handled no events for the idle duration configured
+	// with WithReleaseOnIdle
+	CloseReasonIdle
+	// CloseReasonReceiverRestart indicates the receiver of the partition failed and is reopened from the last checkpoint
+	// after a delay, while the host keeps the lease of the partition
+	CloseReasonReceiverRestart
+)
+
+type (
+	// CloseReason describes why the EventProcessorHost stopped processing a partition
+	CloseReason int
+
+	// PartitionCloseHandler is notified when the EventProcessorHost stops processing a partition. err is the error
+	// which caused the partition to close, and is nil when the partition was closed by a shutdown.
+	PartitionCloseHandler func(partitionID string, reason CloseReason, err error)
+)
+
+func (r CloseReason) String() string {
+	switch r {
+	case CloseReasonShutdown:
+		return "Shutdown"
+	case CloseReasonLeaseLost:
+		return "LeaseLost"
+	case CloseReasonReceiverError:
+		return "ReceiverError"
+	case CloseReasonCheckpointError:
+		return "CheckpointError"
+	case CloseReasonIdle:
+		return "Idle"
+	case CloseReasonReceiverRestart:
+		return "ReceiverRestart"
+	default:
+		return "Unknown"
+	}
+}
+
+// OnPartitionClose registers a handler which is invoked whenever the EventProcessorHost stops processing a partition,
+// with the reason the partition was closed. Handlers are invoked asynchronously after the partition's receiver has
+// been closed.
+func (h *EventProcessorHost) OnPartitionClose(handler PartitionCloseHandler) (close func() error, err error) {
+	h.handlersMu.Lock()
+	defer h.handlersMu.Unlock()
+
+	handlerID, err := uuid.NewV4()
+	if err != nil {
+		return nil, err
+	}
+
+	h.closeHandlers[handlerID.String()] = handler
+	close = func() error {
+		h.handlersMu.Lock()
+		defer h.handlersMu.Unlock()
+
+		delete(h.closeHandlers, handlerID.String())
+		return nil
+	}
+	return close, nil
+}
+
+func (h *EventProcessorHost) notifyPartitionClose(partitionID string, reason CloseReason, err error) {
+	h.handlersMu.Lock()
+	defer h.handlersMu.Unlock()
+
+	for _, handler := range h.closeHandlers {
+		go handler(partitionID, reason, err)
+	}
+}
@@ -0,0 +1,103 @@
+package eph
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSchedulerStopReceiverNotifiesPartitionClose(t *testing.T) {
+	host := &EventProcessorHost{
+		name:          "host",
+		leaser:        newMemoryLeaserCheckpointer(time.Minute, new(sharedStore)),
+		persister:     &checkpointPersister{},
+		closeHandlers: make(map[string]PartitionCloseHandler),
+	}
+	s := newScheduler(host)
+	lease := newMemoryLease("0")
+	s.receivers["0"] = newLeasedReceiver(host, lease)
+
+	type closed struct {
+		partitionID string
+		reason      CloseReason
+		err         error
+	}
+	closes := make(chan closed, 2)
+	_, err := host.OnPartitionClose(func(partitionID string, reason CloseReason, err error) {
+		closes <- closed{partitionID: partitionID, reason: reason, err: err}
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	renewErr := errors.New("can't renew lease")
+	assert.NoError(t, s.stopReceiver(context.Background(), lease, CloseReasonLeaseLost, renewErr))
+	select {
+	case c := <-closes:
+		assert.Equal(t, closed{partitionID: "0", reason: CloseReasonLeaseLost, err: renewErr}, c)
+		assert.Equal(t, "LeaseLost", c.reason.String())
+	case <-time.After(time.Second):
+		assert.Fail(t, "partition close handler was not invoked")
+	}
+
+	// the partition is no longer processed, so stopping it again is not reported
+	assert.NoError(t, s.stopReceiver(context.Background(), lease, CloseReasonReceiverError, nil))
+	select {
+	case c := <-closes:
+		assert.Fail(t, "partition close handler invoked for a partition which was not processed", "%v", c)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestSchedulerReleaseLostLeaseNotifiesPartitionClose(t *testing.T) {
+	host, lease := newRestartTestHost(t)
+	host.closeHandlers = make(map[string]PartitionCloseHandler)
+	s := newScheduler(host)
+
+	reasons := make(chan CloseReason, 1)
+	_, err := host.OnPartitionClose(func(partitionID string, reason CloseReason, err error) {
+		reasons <- reason
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	ctx := context.Background()
+	s.releaseLostLease(ctx, newLeasedReceiver(host, lease), errors.New("receiver disconnected"))
+	select {
+	case reason := <-reasons:
+		assert.Equal(t, CloseReasonLeaseLost, reason)
+	case <-time.After(time.Second):
+		assert.Fail(t, "partition close handler was not invoked")
+	}
+
+	leases, err := host.leaser.GetLeases(ctx)
+	if assert.NoError(t, err) {
+		assert.Empty(t, resumableLeases(ctx, host.name, leases, []string{"0"}), "the lease should be released")
+	}
+}
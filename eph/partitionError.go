@@ -0,0 +1,78 @@
+package eph
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-event-hubs-go"
+)
+
+type (
+	// partitionErrors holds the most recent error of each partition processed by a host
+	partitionErrors struct {
+		mu     sync.Mutex
+		errors map[string]partitionError
+	}
+
+	partitionError struct {
+		err error
+		at  time.Time
+	}
+)
+
+// LastPartitionError returns the most recent error of the partition and when it occurred, or a nil error and the zero
+// time if the partition has had none. Errors of the receiver link, of handlers and of checkpoint writes are recorded,
+// including those from which the host recovered, and are kept after the partition is no longer owned by the host.
+func (h *EventProcessorHost) LastPartitionError(partitionID string) (error, time.Time) {
+	h.partitionErrors.mu.Lock()
+	defer h.partitionErrors.mu.Unlock()
+
+	last := h.partitionErrors.errors[partitionID]
+	return last.err, last.at
+}
+
+// recordPartitionError records the error as the most recent error of the partition
+func (h *EventProcessorHost) recordPartitionError(partitionID string, err error) {
+	h.partitionErrors.mu.Lock()
+	defer h.partitionErrors.mu.Unlock()
+
+	if h.partitionErrors.errors == nil {
+		h.partitionErrors.errors = make(map[string]partitionError)
+	}
+	h.partitionErrors.errors[partitionID] = partitionError{err: err, at: time.Now()}
+}
+
+// withErrorRecording wraps the handler so the errors it returns are recorded as errors of the partition
+func (lr *leasedReceiver) withErrorRecording(handler eventhub.Handler) eventhub.Handler {
+	partitionID := lr.lease.GetPartitionID()
+	return func(ctx context.Context, event *eventhub.Event) error {
+		err := handler(ctx, event)
+		if err != nil {
+			lr.processor.recordPartitionError(partitionID, err)
+		}
+		return err
+	}
+}
@@ -0,0 +1,71 @@
+package eph
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-amqp-common-go/persist"
+	"github.com/Azure/azure-event-hubs-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLastPartitionError(t *testing.T) {
+	host := new(EventProcessorHost)
+	err, at := host.LastPartitionError("0")
+	assert.NoError(t, err)
+	assert.True(t, at.IsZero())
+
+	lr := newLeasedReceiver(host, newMemoryLease("0"))
+	handlerErr := errors.New("boom")
+	handler := lr.withErrorRecording(func(ctx context.Context, event *eventhub.Event) error {
+		return handlerErr
+	})
+	before := time.Now()
+	assert.Equal(t, handlerErr, handler(context.Background(), eventhub.NewEventFromString("foo")))
+	err, at = host.LastPartitionError("0")
+	assert.Equal(t, handlerErr, err)
+	assert.False(t, at.Before(before))
+
+	err, _ = host.LastPartitionError("1")
+	assert.NoError(t, err, "errors should be recorded per partition")
+}
+
+func TestLastPartitionErrorRecordsCheckpointFailures(t *testing.T) {
+	store := newMemoryLeaserCheckpointer(time.Minute, new(sharedStore))
+	host := new(EventProcessorHost)
+	writes := &recordingCheckpointer{Checkpointer: store, host: host}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	writeErr := writes.UpdateCheckpoint(ctx, "0", persist.NewCheckpoint("1", 1, time.Now()))
+	assert.Error(t, writeErr)
+
+	err, at := host.LastPartitionError("0")
+	assert.Equal(t, writeErr, err)
+	assert.False(t, at.IsZero())
+	assert.True(t, (&checkpointPersister{checkpointer: writes}).lastWrite().IsZero(), "a failed write is not a checkpoint write")
+}
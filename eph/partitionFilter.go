@@ -0,0 +1,61 @@
+package eph
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"github.com/pkg/errors"
+)
+
+// WithPartitionFilter configures the EventProcessorHost to lease only the partitions for which the filter returns true,
+// such as when partitions are assigned to hosts by an external scheduler. Partitions outside the filter are neither
+// acquired when their lease expires nor stolen from other hosts, and leases are balanced only among the partitions
+// passing the filter.
+func WithPartitionFilter(filter func(partitionID string) bool) EventProcessorHostOption {
+	return func(host *EventProcessorHost) error {
+		if filter == nil {
+			return errors.New("partition filter must not be nil")
+		}
+		host.partitionFilter = filter
+		return nil
+	}
+}
+
+// leasable returns true if the host may lease the partition
+func (h *EventProcessorHost) leasable(partitionID string) bool {
+	return h.partitionFilter == nil || h.partitionFilter(partitionID)
+}
+
+// filterLeases returns the leases of the partitions the host may lease
+func (h *EventProcessorHost) filterLeases(leases []LeaseMarker) []LeaseMarker {
+	if h.partitionFilter == nil {
+		return leases
+	}
+
+	var filtered []LeaseMarker
+	for _, lease := range leases {
+		if h.leasable(lease.GetPartitionID()) {
+			filtered = append(filtered, lease)
+		}
+	}
+	return filtered
+}
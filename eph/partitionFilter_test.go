@@ -0,0 +1,73 @@
+package eph
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithPartitionFilter(t *testing.T) {
+	host := new(EventProcessorHost)
+	assert.Error(t, WithPartitionFilter(nil)(host))
+	assert.True(t, host.leasable("0"), "every partition should be leasable without a filter")
+
+	require.NoError(t, WithPartitionFilter(func(partitionID string) bool { return partitionID == "1" })(host))
+	assert.False(t, host.leasable("0"))
+	assert.True(t, host.leasable("1"))
+}
+
+func TestScanSkipsFilteredPartitions(t *testing.T) {
+	store := newMemoryLeaserCheckpointer(DefaultLeaseDuration, new(sharedStore))
+	host := &EventProcessorHost{
+		name:            "host",
+		leaser:          store,
+		checkpointer:    store,
+		partitionIDs:    []string{"0", "1"},
+		balancer:        GreedyLeaseBalancer{},
+		partitionFilter: func(partitionID string) bool { return false },
+	}
+	store.SetEventHostProcessor(host)
+
+	ctx := context.Background()
+	require.NoError(t, store.EnsureStore(ctx))
+	for _, partitionID := range host.partitionIDs {
+		_, err := store.EnsureLease(ctx, partitionID)
+		require.NoError(t, err)
+	}
+
+	leases, err := store.GetLeases(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, host.filterLeases(leases))
+
+	newScheduler(host).scan(ctx)
+
+	leases, err = store.GetLeases(ctx)
+	require.NoError(t, err)
+	for _, lease := range leases {
+		assert.Empty(t, lease.GetOwner(), "partitions outside the filter should never be leased")
+	}
+}
@@ -0,0 +1,160 @@
+package eph
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-amqp-common-go/persist"
+	"github.com/Azure/azure-event-hubs-go"
+	"github.com/Azure/azure-event-hubs-go/mgmt"
+	"github.com/pkg/errors"
+)
+
+const (
+	// DefaultPartitionInformationRefreshInterval is the default amount of time the runtime information of a partition
+	// is cached before it is retrieved again from the management node
+	DefaultPartitionInformationRefreshInterval = 30 * time.Second
+)
+
+type (
+	// partitionInformationCache holds the most recently retrieved runtime information of a partition
+	partitionInformationCache struct {
+		info      *mgmt.HubPartitionRuntimeInformation
+		fetchedAt time.Time
+		mu        sync.Mutex
+	}
+)
+
+// WithPartitionInformation configures an EventProcessorHost to set the runtime information of the partition, such as
+// the last enqueued sequence number, on each received event. The information is retrieved from the Event Hub
+// management node at most once per refresh interval per partition, which is useful for computing consumer lag.
+func WithPartitionInformation(refreshInterval time.Duration) EventProcessorHostOption {
+	return func(host *EventProcessorHost) error {
+		if refreshInterval <= 0 {
+			return errors.New("partition information refresh interval must be greater than 0")
+		}
+		host.partitionInfoInterval = refreshInterval
+		return nil
+	}
+}
+
+// withPartitionInformation wraps the handler so each event carries the runtime information of the partition when the
+// EventProcessorHost has been configured to retrieve it
+func (lr *leasedReceiver) withPartitionInformation(handler eventhub.Handler) eventhub.Handler {
+	if lr.processor.partitionInfoInterval <= 0 {
+		return handler
+	}
+
+	return func(ctx context.Context, event *eventhub.Event) error {
+		event.PartitionInformation = lr.partitionInformation(ctx)
+		return handler(ctx, event)
+	}
+}
+
+// PartitionLag returns the number of events enqueued in the partition after the last checkpoint, which is the
+// difference between the last enqueued sequence number and the checkpointed sequence number. The runtime information
+// of the partition is retrieved at most once per refresh interval configured by WithPartitionInformation, or
+// DefaultPartitionInformationRefreshInterval. An error is returned if the partition is not owned by this host.
+func (h *EventProcessorHost) PartitionLag(ctx context.Context, partitionID string) (int64, error) {
+	span, ctx := startConsumerSpanFromContext(ctx, "eventhub.eph.EventProcessorHost.PartitionLag")
+	defer span.Finish()
+
+	span.SetTag(partitionIDTag, partitionID)
+	var lr *leasedReceiver
+	if h.scheduler != nil {
+		lr = h.scheduler.receiver(partitionID)
+	}
+	if lr == nil {
+		return 0, &notOwnedError{partitionID: partitionID, host: h.name}
+	}
+
+	info, err := lr.refreshPartitionInformation(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	checkpoint, err := h.persister.read(partitionID)
+	if err != nil {
+		return 0, err
+	}
+	return partitionLag(info, checkpoint), nil
+}
+
+// partitionInformation returns the runtime information of the partition for setting on events. If a refresh fails,
+// the previously retrieved information is returned.
+func (lr *leasedReceiver) partitionInformation(ctx context.Context) *mgmt.HubPartitionRuntimeInformation {
+	info, err := lr.refreshPartitionInformation(ctx)
+	if err != nil {
+		lr.logFor(ctx).Error(err)
+		lr.partitionInfo.mu.Lock()
+		defer lr.partitionInfo.mu.Unlock()
+		return lr.partitionInfo.info
+	}
+	return info
+}
+
+// refreshPartitionInformation returns the cached runtime information of the partition, retrieving it from the
+// management node once it is older than the refresh interval
+func (lr *leasedReceiver) refreshPartitionInformation(ctx context.Context) (*mgmt.HubPartitionRuntimeInformation, error) {
+	cache := &lr.partitionInfo
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	interval := lr.processor.partitionInfoInterval
+	if interval <= 0 {
+		interval = DefaultPartitionInformationRefreshInterval
+	}
+	if cache.info != nil && time.Since(cache.fetchedAt) < interval {
+		return cache.info, nil
+	}
+
+	span, ctx := lr.startConsumerSpanFromContext(ctx, "eventhub.eph.leasedReceiver.refreshPartitionInformation")
+	defer span.Finish()
+
+	info, err := lr.processor.client.GetPartitionInformation(ctx, lr.lease.GetPartitionID())
+	if err != nil {
+		return nil, err
+	}
+	cache.info = info
+	cache.fetchedAt = time.Now()
+	return info, nil
+}
+
+// partitionLag calculates the number of events enqueued after the checkpoint
+func partitionLag(info *mgmt.HubPartitionRuntimeInformation, checkpoint persist.Checkpoint) int64 {
+	var lag int64
+	if checkpoint.Offset == persist.StartOfStream {
+		// nothing has been checkpointed, so every retained event is outstanding
+		lag = info.LastSequenceNumber - info.BeginningSequenceNumber + 1
+	} else {
+		lag = info.LastSequenceNumber - checkpoint.SequenceNumber
+	}
+
+	if lag < 0 {
+		return 0
+	}
+	return lag
+}
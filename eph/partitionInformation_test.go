@@ -0,0 +1,59 @@
+package eph
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-amqp-common-go/persist"
+	"github.com/Azure/azure-event-hubs-go/mgmt"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPartitionLag(t *testing.T) {
+	info := &mgmt.HubPartitionRuntimeInformation{BeginningSequenceNumber: 10, LastSequenceNumber: 49}
+	tests := map[string]struct {
+		checkpoint persist.Checkpoint
+		lag        int64
+	}{
+		"NoCheckpoint":   {checkpoint: persist.NewCheckpointFromStartOfStream(), lag: 40},
+		"Checkpointed":   {checkpoint: persist.NewCheckpoint("1024", 45, time.Now()), lag: 4},
+		"CaughtUp":       {checkpoint: persist.NewCheckpoint("2048", 49, time.Now()), lag: 0},
+		"AheadOfRuntime": {checkpoint: persist.NewCheckpoint("4096", 50, time.Now()), lag: 0},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tt.lag, partitionLag(info, tt.checkpoint))
+		})
+	}
+}
+
+func TestPartitionLagNotOwned(t *testing.T) {
+	host := &EventProcessorHost{name: "host"}
+	host.scheduler = newScheduler(host)
+	_, err := host.PartitionLag(context.Background(), "0")
+	assert.Error(t, err)
+}
@@ -0,0 +1,107 @@
+package eph
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// DefaultPartitionRefreshInterval is the default amount of time between checks of the Event Hub for partitions
+	// added since the EventProcessorHost started
+	DefaultPartitionRefreshInterval = time.Minute
+)
+
+// WithPartitionRefreshInterval configures how often the EventProcessorHost checks the runtime information of the Event
+// Hub for partitions added since it started. Leases are created for added partitions, which are then balanced across
+// hosts and processed without a restart.
+func WithPartitionRefreshInterval(interval time.Duration) EventProcessorHostOption {
+	return func(host *EventProcessorHost) error {
+		if interval <= 0 {
+			return errors.New("partition refresh interval must be greater than 0")
+		}
+		host.partitionRefreshInterval = interval
+		return nil
+	}
+}
+
+// refreshPartitions adds the partitions of the Event Hub which are unknown to the host once the refresh interval has
+// passed since the last refresh
+func (s *scheduler) refreshPartitions(ctx context.Context) {
+	h := s.processor
+	if h.partitionRefreshInterval <= 0 || h.runtimeInformation == nil {
+		return
+	}
+
+	now := h.getClock().Now()
+	if now.Sub(s.lastPartitionRefresh) < h.partitionRefreshInterval {
+		return
+	}
+	s.lastPartitionRefresh = now
+
+	info, err := h.runtimeInformation(ctx)
+	if err != nil {
+		h.logFor(ctx).Error(err)
+		return
+	}
+
+	known := make(map[string]bool)
+	for _, partitionID := range h.GetPartitionIDs() {
+		known[partitionID] = true
+	}
+
+	var added []string
+	for _, partitionID := range info.PartitionIDs {
+		if known[partitionID] {
+			continue
+		}
+
+		// leases must exist before the partition is listed, as leasers get the lease of each listed partition
+		if _, err := h.leaser.EnsureLease(ctx, partitionID); err != nil {
+			h.logFor(ctx, "partitionID", partitionID).Error(err)
+			continue
+		}
+		if _, err := h.checkpointer.EnsureCheckpoint(ctx, partitionID); err != nil {
+			h.logFor(ctx, "partitionID", partitionID).Error(err)
+			continue
+		}
+		added = append(added, partitionID)
+	}
+
+	if len(added) > 0 {
+		h.addPartitionIDs(added)
+		h.logFor(ctx).Info(fmt.Sprintf("added partitions %v", added))
+	}
+}
+
+// addPartitionIDs appends partitions added to the Event Hub to the partitions of the host
+func (h *EventProcessorHost) addPartitionIDs(partitionIDs []string) {
+	h.partitionIDsMu.Lock()
+	defer h.partitionIDsMu.Unlock()
+
+	h.partitionIDs = append(h.partitionIDs, partitionIDs...)
+}
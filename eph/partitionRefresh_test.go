@@ -0,0 +1,89 @@
+package eph
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-event-hubs-go/mgmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithPartitionRefreshInterval(t *testing.T) {
+	host := new(EventProcessorHost)
+	assert.Error(t, WithPartitionRefreshInterval(0)(host))
+	assert.NoError(t, WithPartitionRefreshInterval(time.Second)(host))
+	assert.Equal(t, time.Second, host.partitionRefreshInterval)
+}
+
+func TestRefreshPartitionsAddsPartitions(t *testing.T) {
+	clock := newManualClock()
+	store := newMemoryLeaserCheckpointer(DefaultLeaseDuration, &sharedStore{clock: clock})
+	partitionIDs := []string{"0", "1"}
+	var infoErr error
+	host := &EventProcessorHost{
+		name:                     "host",
+		leaser:                   store,
+		checkpointer:             store,
+		partitionIDs:             []string{"0", "1"},
+		clock:                    clock,
+		partitionRefreshInterval: time.Minute,
+		runtimeInformation: func(ctx context.Context) (*mgmt.HubRuntimeInformation, error) {
+			return &mgmt.HubRuntimeInformation{PartitionIDs: partitionIDs}, infoErr
+		},
+	}
+	store.SetEventHostProcessor(host)
+
+	ctx := context.Background()
+	require.NoError(t, store.EnsureStore(ctx))
+	for _, partitionID := range host.partitionIDs {
+		_, err := store.EnsureLease(ctx, partitionID)
+		require.NoError(t, err)
+	}
+
+	s := newScheduler(host)
+	partitionIDs = []string{"0", "1", "2", "3"}
+	s.refreshPartitions(ctx)
+	assert.Equal(t, []string{"0", "1"}, host.GetPartitionIDs(), "partitions should not be refreshed before the interval passes")
+
+	clock.Advance(time.Minute)
+	infoErr = errors.New("boom")
+	s.refreshPartitions(ctx)
+	assert.Equal(t, []string{"0", "1"}, host.GetPartitionIDs(), "partitions should be kept when the runtime information can't be retrieved")
+
+	clock.Advance(time.Minute)
+	infoErr = nil
+	s.refreshPartitions(ctx)
+	assert.Equal(t, []string{"0", "1", "2", "3"}, host.GetPartitionIDs())
+
+	leases, err := store.GetLeases(ctx)
+	require.NoError(t, err)
+	assert.Len(t, leases, 4, "leases should be created for added partitions")
+	_, ok, err := store.AcquireLease(ctx, "3")
+	assert.NoError(t, err)
+	assert.True(t, ok, "added partitions should be leasable")
+}
@@ -0,0 +1,95 @@
+package eph
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+type (
+	// PartitionState is the lease and checkpoint of a partition as stored, whichever host owns the partition
+	PartitionState struct {
+		PartitionID string
+		// Owner is the name of the host holding the lease, or empty if the lease was released
+		Owner string
+		// Expired is true if the lease has expired, in which case Owner is its last owner
+		Expired bool
+		Epoch   int64
+		// HasCheckpoint is false if no checkpoint has been stored for the partition, in which case the sequence number
+		// and offset are zero values
+		HasCheckpoint  bool
+		SequenceNumber int64
+		Offset         string
+		// EnqueueTime is when the checkpointed event was enqueued
+		EnqueueTime time.Time
+		// LastUpdated is when this host last wrote the checkpoint, or the zero time if it has not. Stores do not record
+		// when checkpoints are written, so checkpoints written by other hosts have no LastUpdated.
+		LastUpdated time.Time
+	}
+)
+
+// PartitionStates returns the lease and stored checkpoint of every partition of the Event Hub, ordered by partition ID,
+// regardless of which partitions the host owns. Leases are read in one call and checkpoints are then read for each
+// partition, so a checkpoint may be more recent than its lease. The host does not need to be started.
+func (h *EventProcessorHost) PartitionStates(ctx context.Context) ([]PartitionState, error) {
+	span, ctx := startConsumerSpanFromContext(ctx, "eventhub.eph.EventProcessorHost.PartitionStates")
+	defer span.Finish()
+
+	// stores list the partitions of the host they are set on, which Start would otherwise do
+	h.leaser.SetEventHostProcessor(h)
+	h.checkpointer.SetEventHostProcessor(h)
+
+	leases, err := h.leaser.GetLeases(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	states := make([]PartitionState, 0, len(leases))
+	for _, lease := range leases {
+		partitionID := lease.GetPartitionID()
+		state := PartitionState{
+			PartitionID: partitionID,
+			Owner:       lease.GetOwner(),
+			Expired:     lease.IsExpired(ctx),
+			Epoch:       lease.GetEpoch(),
+		}
+
+		if checkpoint, ok := h.checkpointer.GetCheckpoint(ctx, partitionID); ok {
+			state.HasCheckpoint = true
+			state.SequenceNumber = checkpoint.SequenceNumber
+			state.Offset = checkpoint.Offset
+			state.EnqueueTime = checkpoint.EnqueueTime
+		}
+		if h.persister != nil {
+			state.LastUpdated = h.persister.lastPartitionWrite(partitionID)
+		}
+		states = append(states, state)
+	}
+
+	sort.Slice(states, func(i, j int) bool {
+		return states[i].PartitionID < states[j].PartitionID
+	})
+	return states, nil
+}
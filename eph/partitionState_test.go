@@ -0,0 +1,83 @@
+package eph
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-amqp-common-go/persist"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPartitionStates(t *testing.T) {
+	ctx := context.Background()
+	shared := new(sharedStore)
+	store := newMemoryLeaserCheckpointer(time.Minute, shared)
+	host := &EventProcessorHost{name: "host", leaser: store, checkpointer: store, partitionIDs: []string{"1", "0"}}
+	writes := &recordingCheckpointer{Checkpointer: store, host: host}
+	host.persister = &checkpointPersister{checkpointer: writes, host: host}
+	store.SetEventHostProcessor(host)
+
+	require.NoError(t, store.EnsureStore(ctx))
+	for _, partitionID := range host.partitionIDs {
+		_, err := store.EnsureLease(ctx, partitionID)
+		require.NoError(t, err)
+	}
+
+	// partition 0 is owned and checkpointed by another host sharing the store
+	other := &EventProcessorHost{name: "other", partitionIDs: host.partitionIDs}
+	otherStore := newMemoryLeaserCheckpointer(time.Minute, shared)
+	otherStore.SetEventHostProcessor(other)
+	_, ok, err := otherStore.AcquireLease(ctx, "0")
+	require.NoError(t, err)
+	require.True(t, ok)
+	enqueued := time.Now().Add(-time.Minute)
+	require.NoError(t, otherStore.UpdateCheckpoint(ctx, "0", persist.NewCheckpoint("10", 5, enqueued)))
+
+	// partition 1 is owned and checkpointed by this host
+	_, ok, err = store.AcquireLease(ctx, "1")
+	require.NoError(t, err)
+	require.True(t, ok)
+	before := time.Now()
+	require.NoError(t, writes.UpdateCheckpoint(ctx, "1", persist.NewCheckpoint("20", 7, enqueued)))
+
+	states, err := host.PartitionStates(ctx)
+	require.NoError(t, err)
+	require.Len(t, states, 2)
+
+	assert.Equal(t, "0", states[0].PartitionID, "states should be ordered by partition ID")
+	assert.Equal(t, "other", states[0].Owner)
+	assert.False(t, states[0].Expired)
+	assert.True(t, states[0].HasCheckpoint)
+	assert.Equal(t, int64(5), states[0].SequenceNumber)
+	assert.Equal(t, "10", states[0].Offset)
+	assert.True(t, states[0].LastUpdated.IsZero(), "checkpoints written by other hosts have no last updated time")
+
+	assert.Equal(t, "1", states[1].PartitionID)
+	assert.Equal(t, "host", states[1].Owner)
+	assert.Equal(t, int64(7), states[1].SequenceNumber)
+	assert.False(t, states[1].LastUpdated.Before(before))
+}
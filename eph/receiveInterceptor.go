@@ -0,0 +1,61 @@
+package eph
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"context"
+
+	"github.com/Azure/azure-event-hubs-go"
+	"github.com/pkg/errors"
+)
+
+// WithReceiveInterceptor configures the EventProcessorHost to invoke the interceptor with every event of its
+// partitions before the event is handed to the handlers, such as to decrypt the event or record metrics. An error
+// returned by the interceptor skips the handlers and is treated as a handler error, so the event is not checkpointed
+// and the error is reported by LastPartitionError. The option may be given more than once, in which case the
+// interceptors are invoked in the order they were given.
+func WithReceiveInterceptor(interceptor eventhub.ReceiveInterceptor) EventProcessorHostOption {
+	return func(host *EventProcessorHost) error {
+		if interceptor == nil {
+			return errors.New("receive interceptor must not be nil")
+		}
+		host.receiveInterceptors = append(host.receiveInterceptors, interceptor)
+		return nil
+	}
+}
+
+// withReceiveInterceptors wraps the handler so each event is passed through the receive interceptors of the host first
+func (lr *leasedReceiver) withReceiveInterceptors(handler eventhub.Handler) eventhub.Handler {
+	interceptors := lr.processor.receiveInterceptors
+	if len(interceptors) == 0 {
+		return handler
+	}
+
+	return func(ctx context.Context, event *eventhub.Event) error {
+		event, err := eventhub.InterceptReceive(ctx, event, interceptors...)
+		if err != nil {
+			return err
+		}
+		return handler(ctx, event)
+	}
+}
@@ -0,0 +1,67 @@
+package eph
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Azure/azure-event-hubs-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReceiveInterceptorRunsBeforeHandlers(t *testing.T) {
+	host := new(EventProcessorHost)
+	assert.Error(t, WithReceiveInterceptor(nil)(host))
+	require.NoError(t, WithReceiveInterceptor(func(ctx context.Context, event *eventhub.Event) (*eventhub.Event, error) {
+		return eventhub.NewEventFromString("decrypted " + string(event.Data)), nil
+	})(host))
+
+	var handled []string
+	lr := newLeasedReceiver(host, newMemoryLease("0"))
+	handler := lr.withErrorRecording(lr.withReceiveInterceptors(func(ctx context.Context, event *eventhub.Event) error {
+		handled = append(handled, string(event.Data))
+		return nil
+	}))
+	assert.NoError(t, handler(context.Background(), eventhub.NewEventFromString("foo")))
+	assert.Equal(t, []string{"decrypted foo"}, handled)
+}
+
+func TestReceiveInterceptorErrorSkipsHandlers(t *testing.T) {
+	host := new(EventProcessorHost)
+	interceptErr := errors.New("can't decrypt")
+	require.NoError(t, WithReceiveInterceptor(func(ctx context.Context, event *eventhub.Event) (*eventhub.Event, error) {
+		return nil, interceptErr
+	})(host))
+
+	lr := newLeasedReceiver(host, newMemoryLease("0"))
+	handler := lr.withErrorRecording(lr.withReceiveInterceptors(func(ctx context.Context, event *eventhub.Event) error {
+		assert.Fail(t, "the handler should be skipped")
+		return nil
+	}))
+	assert.Equal(t, interceptErr, handler(context.Background(), eventhub.NewEventFromString("foo")))
+	err, _ := host.LastPartitionError("0")
+	assert.Equal(t, interceptErr, err, "the interceptor error should be recorded as an error of the partition")
+}
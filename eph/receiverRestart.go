@@ -0,0 +1,142 @@
+package eph
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Azure/azure-event-hubs-go"
+	"github.com/pkg/errors"
+)
+
+// WithReceiverRestartPolicy configures how the EventProcessorHost reopens the receiver of a partition after its link
+// fails, rather than eventhub.NewExponentialBackoffRetryPolicy. While the policy retries the receiver error, the host
+// keeps the lease of the partition, waits the delay returned by the policy and reopens the receiver from the last
+// checkpoint, notifying partition close handlers with CloseReasonReceiverRestart before each delay. Once the policy
+// stops retrying, the partition is closed with CloseReasonReceiverError and its lease is released.
+func WithReceiverRestartPolicy(policy eventhub.RetryPolicy) EventProcessorHostOption {
+	return func(host *EventProcessorHost) error {
+		if policy == nil {
+			return errors.New("receiver restart policy must not be nil")
+		}
+		host.receiverRestartPolicy = policy
+		return nil
+	}
+}
+
+// restartReceiver reopens the receiver of the partition after it failed with cause, backing off according to the
+// receiver restart policy of the host, until a receiver is opened, the policy stops retrying or the receiver is no
+// longer the receiver of the partition, such as when the partition is stopped during a backoff
+func (s *scheduler) restartReceiver(failed *leasedReceiver, cause error) {
+	var policy eventhub.RetryPolicy = eventhub.NewExponentialBackoffRetryPolicy()
+	if s.processor.receiverRestartPolicy != nil {
+		policy = s.processor.receiverRestartPolicy
+	}
+
+	partitionID := failed.lease.GetPartitionID()
+	current := failed
+	for attempt := 1; ; attempt++ {
+		retry, delay := policy.ShouldRetry(cause, attempt)
+		if !retry {
+			s.closeFailedReceiver(current, CloseReasonReceiverError, cause)
+			return
+		}
+
+		s.dlog(context.Background(), fmt.Sprintf("restarting receiver for partitionID %q in %v after: %v", partitionID, delay, cause))
+		s.processor.notifyPartitionClose(partitionID, CloseReasonReceiverRestart, cause)
+		<-s.processor.getClock().After(delay)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		reopened, err := s.reopenReceiver(ctx, current)
+		cancel()
+		if reopened == nil || err == nil {
+			return
+		}
+
+		s.processor.recordPartitionError(partitionID, err)
+		current, cause = reopened, err
+		if eventhub.IsReceiverDisconnected(err) {
+			// another host has taken ownership of the partition with a higher epoch
+			s.closeFailedReceiver(current, CloseReasonLeaseLost, cause)
+			return
+		}
+	}
+}
+
+// reopenReceiver drains and closes the failed receiver of the partition, persists any coalesced checkpoint and opens
+// a receiver of the same lease in its place, which resumes from the checkpoint. If the failed receiver is no longer the
+// receiver of the partition, nil is returned and nothing is done. The reopened receiver is returned along with the
+// error opening it, so it holds the lease until the next attempt.
+func (s *scheduler) reopenReceiver(ctx context.Context, failed *leasedReceiver) (*leasedReceiver, error) {
+	span, ctx := s.startConsumerSpanFromContext(ctx, "eventhub.eph.scheduler.reopenReceiver")
+	defer span.Finish()
+
+	partitionID := failed.lease.GetPartitionID()
+	span.SetTag(partitionIDTag, partitionID)
+	if s.receiver(partitionID) != failed {
+		return nil, nil
+	}
+
+	// the failed receiver is drained without holding the lock, so handlers calling back into the scheduler can return
+	if err := failed.Drain(ctx); err != nil {
+		s.processor.logFor(ctx, "partitionID", partitionID).Error(err)
+	}
+
+	s.receiverMu.Lock()
+	defer s.receiverMu.Unlock()
+
+	if s.receivers[partitionID] != failed {
+		return nil, nil
+	}
+	if err := failed.Close(ctx); err != nil {
+		s.processor.logFor(ctx, "partitionID", partitionID).Error(err)
+	}
+	if batcher := s.processor.persister.batcher; batcher != nil {
+		if err := batcher.flushPartition(ctx, partitionID); err != nil {
+			s.processor.logFor(ctx, "partitionID", partitionID).Error(err)
+		}
+	}
+
+	reopened := newLeasedReceiver(s.processor, failed.lease)
+	s.receivers[partitionID] = reopened
+	err := reopened.Run(ctx)
+	if err == nil {
+		s.processor.notifyOwnershipChange()
+	}
+	return reopened, err
+}
+
+// closeFailedReceiver stops the receiver of the partition for the reason, unless it has been replaced or stopped
+func (s *scheduler) closeFailedReceiver(failed *leasedReceiver, reason CloseReason, cause error) {
+	if s.receiver(failed.lease.GetPartitionID()) != failed {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := s.stopReceiver(ctx, failed.lease, reason, cause); err != nil {
+		s.processor.logFor(ctx, "partitionID", failed.lease.GetPartitionID()).Error(err)
+	}
+}
@@ -0,0 +1,120 @@
+package eph
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-event-hubs-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type (
+	// retryForeverPolicy retries every error after a fixed delay
+	retryForeverPolicy struct {
+		delay time.Duration
+	}
+)
+
+func (p retryForeverPolicy) ShouldRetry(err error, attempt int) (bool, time.Duration) {
+	return true, p.delay
+}
+
+func newReceiverRestartTestHost(t *testing.T, policy eventhub.RetryPolicy) (*EventProcessorHost, *scheduler, *leasedReceiver, chan CloseReason) {
+	host, lease := newRestartTestHost(t)
+	host.closeHandlers = make(map[string]PartitionCloseHandler)
+	require.NoError(t, WithReceiverRestartPolicy(policy)(host))
+	s := newScheduler(host)
+	host.scheduler = s
+	lr := newLeasedReceiver(host, lease)
+	s.receivers["0"] = lr
+
+	reasons := make(chan CloseReason, 4)
+	_, err := host.OnPartitionClose(func(partitionID string, reason CloseReason, err error) {
+		reasons <- reason
+	})
+	require.NoError(t, err)
+	return host, s, lr, reasons
+}
+
+func TestWithReceiverRestartPolicyRequiresPolicy(t *testing.T) {
+	assert.Error(t, WithReceiverRestartPolicy(nil)(&EventProcessorHost{}))
+}
+
+func TestReceiverRestartReleasesLeaseOncePolicyDeclines(t *testing.T) {
+	host, s, lr, reasons := newReceiverRestartTestHost(t, &eventhub.ExponentialBackoffRetryPolicy{MaxAttempts: 1})
+
+	s.restartReceiver(lr, errors.New("link detached"))
+	select {
+	case reason := <-reasons:
+		assert.Equal(t, CloseReasonReceiverError, reason)
+	case <-time.After(time.Second):
+		assert.Fail(t, "partition close handler was not invoked")
+	}
+	assert.Nil(t, s.receiver("0"))
+
+	ctx := context.Background()
+	leases, err := host.leaser.GetLeases(ctx)
+	if assert.NoError(t, err) {
+		assert.Empty(t, resumableLeases(ctx, host.name, leases, []string{"0"}), "the lease should be released")
+	}
+}
+
+func TestReceiverRestartAbandonedWhenPartitionStopped(t *testing.T) {
+	clock := newManualClock()
+	host, s, lr, reasons := newReceiverRestartTestHost(t, retryForeverPolicy{delay: time.Minute})
+	host.clock = clock
+
+	restarted := make(chan struct{})
+	go func() {
+		s.restartReceiver(lr, errors.New("link detached"))
+		close(restarted)
+	}()
+
+	select {
+	case reason := <-reasons:
+		assert.Equal(t, CloseReasonReceiverRestart, reason)
+		assert.Equal(t, "ReceiverRestart", reason.String())
+	case <-time.After(time.Second):
+		assert.Fail(t, "partition close handler was not notified of the restart")
+	}
+	for clock.waiting() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	assert.Equal(t, lr, s.receiver("0"), "the failed receiver should hold the partition during the backoff")
+
+	// suspending the scheduler during the backoff stops the partition, so it must not be reopened
+	_, err := s.Suspend(context.Background())
+	require.NoError(t, err)
+	clock.Advance(time.Minute)
+	select {
+	case <-restarted:
+	case <-time.After(time.Second):
+		assert.Fail(t, "restart was not abandoned")
+	}
+	assert.Nil(t, s.receiver("0"))
+}
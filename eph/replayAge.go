@@ -0,0 +1,59 @@
+package eph
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"time"
+
+	"github.com/Azure/azure-amqp-common-go/persist"
+	"github.com/pkg/errors"
+)
+
+// WithMaxReplayAge configures the EventProcessorHost to skip events enqueued more than maxAge ago when it resumes a
+// partition, such as after the host has been down for a long time. If the checkpoint of a partition was enqueued
+// before now less maxAge, the partition starts receiving with the events enqueued after now less maxAge instead.
+// Checkpoints are then updated from the events received as usual. Partitions without a checkpoint start from the
+// position configured with WithStartPosition.
+func WithMaxReplayAge(maxAge time.Duration) EventProcessorHostOption {
+	return func(host *EventProcessorHost) error {
+		if maxAge <= 0 {
+			return errors.New("max replay age must be greater than 0")
+		}
+		host.maxReplayAge = maxAge
+		return nil
+	}
+}
+
+// capReplayAge returns a checkpoint of now less the max replay age if the checkpoint was enqueued before then, or the
+// checkpoint unchanged. Checkpoints without an enqueued time, such as the start of the stream, are not capped.
+func (h *EventProcessorHost) capReplayAge(checkpoint persist.Checkpoint) persist.Checkpoint {
+	if h.maxReplayAge <= 0 || checkpoint.EnqueueTime.IsZero() {
+		return checkpoint
+	}
+
+	earliest := h.getClock().Now().Add(-h.maxReplayAge)
+	if checkpoint.EnqueueTime.Before(earliest) {
+		return persist.NewCheckpoint("", 0, earliest)
+	}
+	return checkpoint
+}
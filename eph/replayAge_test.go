@@ -0,0 +1,91 @@
+package eph
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-amqp-common-go/persist"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithMaxReplayAge(t *testing.T) {
+	host := new(EventProcessorHost)
+	assert.Error(t, WithMaxReplayAge(0)(host))
+	assert.NoError(t, WithMaxReplayAge(time.Hour)(host))
+	assert.Equal(t, time.Hour, host.maxReplayAge)
+}
+
+func TestCapReplayAge(t *testing.T) {
+	clock := newManualClock()
+	now := clock.Now()
+	host := &EventProcessorHost{clock: clock, maxReplayAge: time.Hour}
+
+	recent := persist.NewCheckpoint("10", 5, now.Add(-time.Minute))
+	assert.Equal(t, recent, host.capReplayAge(recent))
+
+	stale := persist.NewCheckpoint("10", 5, now.Add(-2*time.Hour))
+	assert.Equal(t, persist.NewCheckpoint("", 0, now.Add(-time.Hour)), host.capReplayAge(stale))
+
+	start := persist.NewCheckpointFromStartOfStream()
+	assert.Equal(t, start, host.capReplayAge(start), "checkpoints without an enqueued time should not be capped")
+
+	host.maxReplayAge = 0
+	assert.Equal(t, stale, host.capReplayAge(stale))
+}
+
+func TestPersisterReadCapsReplayAge(t *testing.T) {
+	ctx := context.Background()
+	clock := newManualClock()
+	store := newMemoryLeaserCheckpointer(time.Minute, new(sharedStore))
+	host := &EventProcessorHost{name: "host", leaser: store, checkpointer: store, clock: clock, maxReplayAge: time.Hour}
+	host.persister = &checkpointPersister{checkpointer: store, host: host}
+	store.SetEventHostProcessor(host)
+
+	require.NoError(t, store.EnsureStore(ctx))
+	_, err := store.EnsureLease(ctx, "0")
+	require.NoError(t, err)
+	_, ok, err := store.AcquireLease(ctx, "0")
+	require.NoError(t, err)
+	require.True(t, ok)
+	stale := persist.NewCheckpoint("10", 5, clock.Now().Add(-24*time.Hour))
+	require.NoError(t, store.UpdateCheckpoint(ctx, "0", stale))
+
+	checkpoint, err := host.persister.Read("ns", "hub", "$Default", "0")
+	require.NoError(t, err)
+	assert.Equal(t, persist.NewCheckpoint("", 0, clock.Now().Add(-time.Hour)), checkpoint, "receivers should start from the max replay age")
+
+	stored, err := host.persister.read("0")
+	require.NoError(t, err)
+	assert.Equal(t, stale, stored, "the stored checkpoint should be unchanged until events are received")
+
+	// once an event is checkpointed the receiver resumes from it
+	received := persist.NewCheckpoint("20", 6, clock.Now())
+	require.NoError(t, host.persister.Write("ns", "hub", "$Default", "0", received))
+	checkpoint, err = host.persister.Read("ns", "hub", "$Default", "0")
+	require.NoError(t, err)
+	assert.Equal(t, received, checkpoint)
+}
@@ -0,0 +1,155 @@
+package eph
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"context"
+
+	"github.com/Azure/azure-amqp-common-go/persist"
+	"github.com/pkg/errors"
+)
+
+// ResetCheckpoint writes the checkpoint of the partition so it resumes from the position, such as to rewind or fast
+// forward the consumer group, and is safe to call while the host is running. If the host owns the partition, its
+// receiver is drained, any coalesced checkpoint is discarded and the receiver is reopened from the position. Otherwise
+// the lease of the partition is acquired while the checkpoint is written, so an error is returned if another host
+// owns the partition.
+//
+// Resetting to StartPositionLatest records the latest position rather than an offset, so the partition resumes from
+// events enqueued after each receiver is opened until a received event is checkpointed.
+func (h *EventProcessorHost) ResetCheckpoint(ctx context.Context, partitionID string, position StartPosition) error {
+	span, ctx := startConsumerSpanFromContext(ctx, "eventhub.eph.EventProcessorHost.ResetCheckpoint")
+	defer span.Finish()
+
+	span.SetTag(partitionIDTag, partitionID)
+	if !h.hasPartition(partitionID) {
+		return errors.Errorf("partition %q is not a partition of the Event Hub", partitionID)
+	}
+
+	checkpoint := position.resetCheckpoint()
+	if h.scheduler != nil {
+		if reset, err := h.scheduler.reseek(ctx, partitionID, checkpoint); reset {
+			return err
+		}
+	}
+
+	// acquiring a lease takes it over, so leases held by other hosts are left alone
+	leases, err := h.leaser.GetLeases(ctx)
+	if err != nil {
+		return err
+	}
+	for _, lease := range leases {
+		if lease.GetPartitionID() == partitionID && lease.GetOwner() != "" && lease.GetOwner() != h.name && !lease.IsExpired(ctx) {
+			return errors.Errorf("partition %q is owned by event processor host %q", partitionID, lease.GetOwner())
+		}
+	}
+
+	if _, ok, err := h.leaser.AcquireLease(ctx, partitionID); err != nil {
+		return err
+	} else if !ok {
+		return errors.Errorf("partition %q is owned by another event processor host", partitionID)
+	}
+
+	writeErr := h.persister.checkpointer.UpdateCheckpoint(ctx, partitionID, checkpoint)
+	if _, err := h.leaser.ReleaseLease(ctx, partitionID); err != nil && writeErr == nil {
+		return err
+	}
+	return writeErr
+}
+
+// reseek drains and closes the receiver of the partition, writes the checkpoint and reopens the receiver from it. If
+// the partition is not owned by the scheduler, false is returned and nothing is done.
+func (s *scheduler) reseek(ctx context.Context, partitionID string, checkpoint persist.Checkpoint) (bool, error) {
+	lr := s.receiver(partitionID)
+	if lr == nil {
+		return false, nil
+	}
+
+	// handlers still running could otherwise checkpoint over the reset checkpoint. They are drained without holding the
+	// lock, so handlers calling back into the scheduler can return.
+	if err := lr.Drain(ctx); err != nil {
+		return true, err
+	}
+
+	s.receiverMu.Lock()
+	defer s.receiverMu.Unlock()
+
+	if s.receivers[partitionID] != lr {
+		return true, errors.Errorf("receiver of partition %q was closed while resetting its checkpoint", partitionID)
+	}
+	if err := lr.Close(ctx); err != nil {
+		s.processor.logFor(ctx, "partitionID", partitionID).Error(err)
+	}
+	if batcher := s.processor.persister.batcher; batcher != nil {
+		batcher.discard(partitionID)
+	}
+
+	writeErr := s.processor.persister.checkpointer.UpdateCheckpoint(ctx, partitionID, checkpoint)
+	if writeErr != nil {
+		// the receiver is reopened regardless, resuming from the checkpoint last written
+		s.processor.logFor(ctx, "partitionID", partitionID).Error(writeErr)
+	}
+
+	reopened := newLeasedReceiver(s.processor, lr.lease)
+	if err := reopened.Run(ctx); err != nil {
+		delete(s.receivers, partitionID)
+		_, _ = s.processor.leaser.ReleaseLease(ctx, partitionID)
+		s.processor.notifyLeaseChange(partitionID, false, s.processor.name)
+		s.processor.notifyPartitionClose(partitionID, CloseReasonReceiverError, err)
+		return true, err
+	}
+	s.receivers[partitionID] = reopened
+	s.processor.notifyOwnershipChange()
+	return true, writeErr
+}
+
+// hasPartition returns true if the partition is one of the partitions of the Event Hub
+func (h *EventProcessorHost) hasPartition(partitionID string) bool {
+	for _, id := range h.GetPartitionIDs() {
+		if id == partitionID {
+			return true
+		}
+	}
+	return false
+}
+
+// resetCheckpoint returns the checkpoint written to reset a partition to the position. Unlike checkpoint, the beginning
+// of the stream is expressed as a sequence number so it is not mistaken for a partition without a checkpoint.
+func (p StartPosition) resetCheckpoint() persist.Checkpoint {
+	if !p.latest && p.enqueuedTime.IsZero() {
+		return persist.Checkpoint{SequenceNumber: -1}
+	}
+	return p.checkpoint()
+}
+
+// discard drops the pending checkpoint and the error of the last flush of the partition, waiting for a flush in
+// progress to complete
+func (b *checkpointBatcher) discard(partitionID string) {
+	b.flushMu.Lock()
+	defer b.flushMu.Unlock()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.pending, partitionID)
+	delete(b.errs, partitionID)
+}
@@ -0,0 +1,92 @@
+package eph
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-amqp-common-go/persist"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStartPositionResetCheckpoint(t *testing.T) {
+	assert.Equal(t, persist.Checkpoint{SequenceNumber: -1}, StartPositionBeginning().resetCheckpoint(), "the beginning should not be mistaken for a missing checkpoint")
+	assert.Equal(t, persist.NewCheckpointFromEndOfStream(), StartPositionLatest().resetCheckpoint())
+
+	at := time.Now().Add(-time.Hour)
+	assert.Equal(t, at, StartPositionFromEnqueuedTime(at).resetCheckpoint().EnqueueTime)
+}
+
+func TestResetCheckpointOfUnownedPartition(t *testing.T) {
+	shared := new(sharedStore)
+	store := newMemoryLeaserCheckpointer(DefaultLeaseDuration, shared)
+	host := &EventProcessorHost{
+		name:         "host",
+		leaser:       store,
+		checkpointer: store,
+		partitionIDs: []string{"0", "1"},
+		persister:    &checkpointPersister{checkpointer: store},
+	}
+	store.SetEventHostProcessor(host)
+
+	ctx := context.Background()
+	require.NoError(t, store.EnsureStore(ctx))
+	for _, partitionID := range host.partitionIDs {
+		_, err := store.EnsureLease(ctx, partitionID)
+		require.NoError(t, err)
+		_, err = store.EnsureCheckpoint(ctx, partitionID)
+		require.NoError(t, err)
+	}
+
+	assert.Error(t, host.ResetCheckpoint(ctx, "2", StartPositionLatest()), "unknown partitions should not be reset")
+
+	require.NoError(t, host.ResetCheckpoint(ctx, "0", StartPositionLatest()))
+	checkpoint, ok := store.GetCheckpoint(ctx, "0")
+	require.True(t, ok)
+	assert.Equal(t, persist.EndOfStream, checkpoint.Offset)
+
+	leases, err := store.GetLeases(ctx)
+	require.NoError(t, err)
+	for _, lease := range leases {
+		assert.Empty(t, lease.GetOwner(), "the lease taken to reset the checkpoint should be released")
+	}
+
+	other := &EventProcessorHost{name: "other", partitionIDs: host.partitionIDs}
+	otherStore := newMemoryLeaserCheckpointer(DefaultLeaseDuration, shared)
+	otherStore.SetEventHostProcessor(other)
+	_, ok, err = otherStore.AcquireLease(ctx, "1")
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Error(t, host.ResetCheckpoint(ctx, "1", StartPositionBeginning()), "partitions owned by other hosts should not be reset")
+}
+
+func TestCheckpointBatcherDiscard(t *testing.T) {
+	batcher := newCheckpointBatcher(nil, time.Hour)
+	require.NoError(t, batcher.add("0", persist.NewCheckpoint("10", 10, time.Now())))
+	batcher.discard("0")
+	_, ok := batcher.get("0")
+	assert.False(t, ok, "the pending checkpoint should be discarded")
+}
@@ -0,0 +1,114 @@
+package eph
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/pkg/errors"
+)
+
+// Restart stops processing and starts again under the same host name, keeping the client, Leaser and Checkpointer
+// open. In-flight handlers are drained and checkpoints flushed as in Close, but the leases of the partitions being
+// processed are kept. Those still owned by this host when processing starts again are re-acquired and resumed straight
+// away, rather than being left to expire and be balanced across the hosts again, so other hosts do not see them become
+// free. Partitions which were taken by another host in the meantime are left to the usual balancing.
+func (h *EventProcessorHost) Restart(ctx context.Context) error {
+	span, ctx := startConsumerSpanFromContext(ctx, "eventhub.eph.EventProcessorHost.Restart")
+	defer span.Finish()
+
+	h.hostMu.Lock()
+	defer h.hostMu.Unlock()
+
+	if h.scheduler == nil {
+		return errors.New("event processor host must be started before it can be restarted")
+	}
+
+	owned, suspendErr := h.scheduler.Suspend(ctx)
+	if suspendErr != nil {
+		h.logFor(ctx).Error(suspendErr)
+	}
+
+	scheduler := newScheduler(h)
+	resumed := scheduler.resume(ctx, owned)
+	h.logFor(ctx).Debug(fmt.Sprintf("eph %q: restarted, resuming partitions %v of %v", h.name, resumed, owned))
+	h.scheduler = scheduler
+
+	go func() {
+		span := opentracing.SpanFromContext(ctx)
+		ctx := opentracing.ContextWithSpan(context.Background(), span)
+		scheduler.Run(ctx)
+	}()
+	return suspendErr
+}
+
+// resume re-acquires the leases of the partitions which are still owned by this host and starts receiving from them.
+// The IDs of the partitions which were resumed are returned.
+func (s *scheduler) resume(ctx context.Context, partitionIDs []string) []string {
+	span, ctx := s.startConsumerSpanFromContext(ctx, "eventhub.eph.scheduler.resume")
+	defer span.Finish()
+
+	leaseCtx, cancel := context.WithTimeout(ctx, timeout)
+	allLeases, err := s.processor.leaser.GetLeases(leaseCtx)
+	cancel()
+	if err != nil {
+		s.processor.logFor(ctx).Error(err)
+		return nil
+	}
+
+	var resumed []string
+	for _, lease := range resumableLeases(ctx, s.processor.name, allLeases, partitionIDs) {
+		acquireCtx, cancel := context.WithTimeout(ctx, timeout)
+		acquired, ok, err := s.processor.leaser.AcquireLease(acquireCtx, lease.GetPartitionID())
+		cancel()
+		if err != nil || !ok {
+			s.dlog(ctx, fmt.Sprintf("failed to re-acquire partitionID %q: %v", lease.GetPartitionID(), err))
+			continue
+		}
+
+		if err := s.startReceiver(ctx, acquired); err != nil {
+			s.processor.logFor(ctx).Error(err)
+			continue
+		}
+		resumed = append(resumed, acquired.GetPartitionID())
+	}
+	return resumed
+}
+
+// resumableLeases returns the leases of the partitions which are still held by the owner
+func resumableLeases(ctx context.Context, owner string, leases []LeaseMarker, partitionIDs []string) []LeaseMarker {
+	wanted := make(map[string]bool, len(partitionIDs))
+	for _, partitionID := range partitionIDs {
+		wanted[partitionID] = true
+	}
+
+	var resumable []LeaseMarker
+	for _, lease := range leases {
+		if wanted[lease.GetPartitionID()] && lease.GetOwner() == owner && !lease.IsExpired(ctx) {
+			resumable = append(resumable, lease)
+		}
+	}
+	return resumable
+}
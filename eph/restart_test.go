@@ -0,0 +1,163 @@
+package eph
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-event-hubs-go"
+	"github.com/stretchr/testify/assert"
+)
+
+type (
+	closeRecordingLeaser struct {
+		Leaser
+		closed bool
+	}
+
+	closeRecordingCheckpointer struct {
+		Checkpointer
+		closed bool
+	}
+)
+
+func (l *closeRecordingLeaser) Close() error {
+	l.closed = true
+	return errors.New("leaser close failed")
+}
+
+func (c *closeRecordingCheckpointer) Close() error {
+	c.closed = true
+	return nil
+}
+
+func newRestartTestHost(t *testing.T) (*EventProcessorHost, LeaseMarker) {
+	host := &EventProcessorHost{
+		name:         "host",
+		leaser:       newMemoryLeaserCheckpointer(time.Minute, new(sharedStore)),
+		persister:    &checkpointPersister{},
+		partitionIDs: []string{"0", "1"},
+	}
+	host.leaser.SetEventHostProcessor(host)
+
+	ctx := context.Background()
+	assert.NoError(t, host.leaser.EnsureStore(ctx))
+	for _, partitionID := range host.partitionIDs {
+		_, err := host.leaser.EnsureLease(ctx, partitionID)
+		assert.NoError(t, err)
+	}
+	lease, ok, err := host.leaser.AcquireLease(ctx, "0")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	return host, lease
+}
+
+func TestSchedulerSuspendKeepsLeases(t *testing.T) {
+	host, lease := newRestartTestHost(t)
+	s := newScheduler(host)
+	s.receivers["0"] = newLeasedReceiver(host, lease)
+
+	ctx := context.Background()
+	owned, err := s.Suspend(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"0"}, owned)
+	assert.Empty(t, s.receivers)
+
+	leases, err := host.leaser.GetLeases(ctx)
+	if assert.NoError(t, err) {
+		resumable := resumableLeases(ctx, host.name, leases, []string{"0", "1"})
+		if assert.Len(t, resumable, 1, "only the partition still owned by the host should be resumed") {
+			assert.Equal(t, "0", resumable[0].GetPartitionID())
+		}
+		assert.Empty(t, resumableLeases(ctx, "other", leases, owned), "leases owned by another host should not be resumed")
+	}
+}
+
+func TestSchedulerStopReleasesLeases(t *testing.T) {
+	host, lease := newRestartTestHost(t)
+	s := newScheduler(host)
+	s.receivers["0"] = newLeasedReceiver(host, lease)
+
+	ctx := context.Background()
+	assert.NoError(t, s.Stop(ctx))
+	leases, err := host.leaser.GetLeases(ctx)
+	if assert.NoError(t, err) {
+		assert.Empty(t, resumableLeases(ctx, host.name, leases, []string{"0"}))
+	}
+}
+
+func TestSchedulerStopWithNoLeaseRelease(t *testing.T) {
+	host, lease := newRestartTestHost(t)
+	assert.NoError(t, WithNoLeaseRelease()(host))
+	s := newScheduler(host)
+	s.receivers["0"] = newLeasedReceiver(host, lease)
+
+	ctx := context.Background()
+	assert.NoError(t, s.Stop(ctx))
+	leases, err := host.leaser.GetLeases(ctx)
+	if assert.NoError(t, err) {
+		assert.Len(t, resumableLeases(ctx, host.name, leases, []string{"0"}), 1, "the lease should be left to expire")
+	}
+}
+
+func TestCloseClosesStoresWhenStopFails(t *testing.T) {
+	host, lease := newRestartTestHost(t)
+	host.noBanner = true
+	s := newScheduler(host)
+	host.scheduler = s
+	lr := newLeasedReceiver(host, lease)
+	s.receivers["0"] = lr
+
+	release := make(chan struct{})
+	defer close(release)
+	started := make(chan struct{})
+	handler := lr.trackInflight(func(ctx context.Context, event *eventhub.Event) error {
+		close(started)
+		<-release
+		return nil
+	})
+	go handler(context.Background(), eventhub.NewEventFromString("foo"))
+	<-started
+
+	leaser := &closeRecordingLeaser{Leaser: host.leaser}
+	checkpointer := &closeRecordingCheckpointer{Checkpointer: host.leaser.(*memoryLeaserCheckpointer)}
+	host.leaser, host.checkpointer = leaser, checkpointer
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err := host.Close(ctx)
+	if assert.Error(t, err, "the scheduler should fail to stop with a handler in flight") {
+		assert.Contains(t, err.Error(), "force closed")
+		assert.Contains(t, err.Error(), "leaser close failed")
+	}
+	assert.True(t, leaser.closed, "the leaser should be closed even if the scheduler failed to stop")
+	assert.True(t, checkpointer.closed, "the checkpointer should be closed even if the scheduler failed to stop")
+}
+
+func TestRestartBeforeStart(t *testing.T) {
+	host := &EventProcessorHost{name: "host"}
+	assert.Error(t, host.Restart(context.Background()))
+}
@@ -26,11 +26,13 @@ import (
 	"context"
 	"fmt"
 	"math/rand"
+	"sort"
 	"sync"
 	"time"
 
-	"github.com/Azure/azure-amqp-common-go/log"
+	"github.com/Azure/azure-event-hubs-go"
 	"github.com/opentracing/opentracing-go"
+	"github.com/pkg/errors"
 )
 
 var (
@@ -55,6 +57,11 @@ type (
 		done                 func()
 		leaseRenewalInterval time.Duration
 		receiverMu           sync.Mutex
+		// lastPartitionRefresh is when the Event Hub was last checked for added partitions
+		lastPartitionRefresh time.Time
+		// idleUntil holds when each partition released while idle may be reacquired
+		idleUntil map[string]time.Time
+		idleMu    sync.Mutex
 	}
 
 	ownerCount struct {
@@ -68,6 +75,7 @@ func newScheduler(eventHostProcessor *EventProcessorHost) *scheduler {
 		processor:            eventHostProcessor,
 		receivers:            make(map[string]*leasedReceiver),
 		leaseRenewalInterval: DefaultLeaseRenewalInterval,
+		lastPartitionRefresh: eventHostProcessor.getClock().Now(),
 	}
 }
 
@@ -77,111 +85,212 @@ func (s *scheduler) Run(ctx context.Context) {
 	span, ctx := s.startConsumerSpanFromContext(ctx, "eventhub.eph.scheduler.Run")
 	defer span.Finish()
 
+	// failures counts the consecutive scans which failed with a Leaser error, backing off the next scan
+	failures := 0
 	for {
 		select {
 		case <-ctx.Done():
 			s.dlog(ctx, "shutting down scan")
 			return
 		default:
-			s.scan(ctx)
+			if err := s.scan(ctx); err != nil {
+				failures++
+				s.processor.logFor(ctx, "failures", failures).Info(fmt.Sprintf("backing off scanning for %v after a leaser error", s.scanDelay(failures)))
+			} else {
+				failures = 0
+			}
 			skew := time.Duration(rand.Intn(1000)-500) * time.Millisecond
-			time.Sleep(s.leaseRenewalInterval + skew)
+			select {
+			case <-ctx.Done():
+			case <-s.processor.getClock().After(s.scanDelay(failures) + skew):
+			}
 		}
 	}
 }
 
-func (s *scheduler) scan(ctx context.Context) {
+// scan acquires expired leases and steals a lease to balance partitions, returning the error of the Leaser if it fails
+func (s *scheduler) scan(ctx context.Context) (err error) {
 	span, ctx := s.startConsumerSpanFromContext(ctx, "eventhub.eph.scheduler.scan")
 	defer span.Finish()
 
+	var cycle BalanceCycle
+	start := s.processor.getClock().Now()
+	defer func() {
+		s.processor.recordBalanceCycle(cycle, start, err)
+	}()
+
 	s.dlog(ctx, "running scan")
+	s.refreshPartitions(ctx)
 
 	// fetch updated view of all leases
 	leaseCtx, cancel := context.WithTimeout(ctx, timeout)
 	allLeases, err := s.processor.leaser.GetLeases(leaseCtx)
 	cancel()
 	if err != nil {
-		log.For(ctx).Error(err)
-		return
+		s.processor.logFor(ctx).Error(err)
+		return err
 	}
+	allLeases = s.withoutCoolingDown(s.processor.filterLeases(allLeases))
 
 	// try to acquire any leases that have expired
 	acquired, notAcquired, err := s.acquireExpiredLeases(ctx, allLeases)
 	s.dlog(ctx, fmt.Sprintf("acquired: %v, not acquired: %v", acquired, notAcquired))
 	if err != nil {
-		log.For(ctx).Error(err)
-		return
+		s.processor.logFor(ctx).Error(err)
+		return err
 	}
 
 	// start receiving message from newly acquired partitions
 	for _, lease := range acquired {
+		cycle.Acquired++
 		if err := s.startReceiver(ctx, lease); err != nil {
-			log.For(ctx).Error(err)
-			return
+			s.processor.logFor(ctx).Error(err)
+			return nil
+		}
+		if s.receiver(lease.GetPartitionID()) == nil {
+			cycle.Released++
 		}
 	}
 
 	if len(acquired) > 0 {
 		// don't be too greedy
-		return
-	}
-
-	// calculate the number of leases we own including the newly acquired partitions
-	byOwner := leasesByOwner(notAcquired)
-	var countOwnedByMe int
-	if val, ok := byOwner[s.processor.name]; ok {
-		countOwnedByMe = len(val)
+		return nil
 	}
-	countOwnedByMe += len(acquired)
 
-	// gather all of the leases owned by others
-	var leasesOwnedByOthers []LeaseMarker
-	for key, value := range byOwner {
-		if key != s.processor.name {
-			leasesOwnedByOthers = append(leasesOwnedByOthers, value...)
+	// gather all of the leases owned by me
+	var owned []LeaseMarker
+	for _, lease := range allLeases {
+		if lease.GetOwner() == s.processor.name {
+			owned = append(owned, lease)
 		}
 	}
 
 	// try to steal work away from others if work has become imbalanced
-	if candidate, ok := s.leaseToSteal(ctx, leasesOwnedByOthers, countOwnedByMe); ok {
+	if candidate, ok := s.processor.balancer.LeaseToSteal(ctx, allLeases, owned); ok && candidate.GetOwner() != s.processor.name && s.processor.leasable(candidate.GetPartitionID()) {
 		s.dlog(ctx, fmt.Sprintf("attempting to steal: %v", candidate))
 		acquireCtx, cancel := context.WithTimeout(ctx, timeout)
 		stolen, ok, err := s.processor.leaser.AcquireLease(acquireCtx, candidate.GetPartitionID())
 		cancel()
 		switch {
 		case err != nil:
-			log.For(ctx).Error(err)
-			break
+			s.processor.logFor(ctx).Error(err)
+			return err
 		case !ok:
 			s.dlog(ctx, fmt.Sprintf("failed to steal: %v", candidate))
 			break
 		default:
-			s.dlog(ctx, fmt.Sprintf("stole: %v", stolen))
+			s.processor.logFor(ctx, "partitionID", stolen.GetPartitionID(), "previousOwner", candidate.GetOwner()).Info("stole lease to balance partitions")
+			s.processor.notifyLeaseChange(stolen.GetPartitionID(), true, candidate.GetOwner())
+			cycle.Stolen++
 			if err := s.startReceiver(ctx, stolen); err != nil {
-				log.For(ctx).Error(err)
-				return
+				s.processor.logFor(ctx).Error(err)
+				return nil
+			}
+			if s.receiver(stolen.GetPartitionID()) == nil {
+				cycle.Released++
 			}
 		}
 	}
+	return nil
 }
 
 func (s *scheduler) Stop(ctx context.Context) error {
 	span, ctx := s.startConsumerSpanFromContext(ctx, "eventhub.eph.scheduler.Stop")
 	defer span.Finish()
 
+	_, err := s.stop(ctx, !s.processor.keepLeasesOnClose)
+	return err
+}
+
+// Suspend stops the scheduler like Stop, but keeps the leases of the partitions being processed so another scheduler
+// of the same host can resume them. The IDs of those partitions are returned.
+func (s *scheduler) Suspend(ctx context.Context) ([]string, error) {
+	span, ctx := s.startConsumerSpanFromContext(ctx, "eventhub.eph.scheduler.Suspend")
+	defer span.Finish()
+
+	return s.stop(ctx, false)
+}
+
+// stop stops scanning, drains and closes the receivers and flushes their checkpoints, releasing their leases if
+// release is true. The IDs of the partitions which were being processed are returned.
+func (s *scheduler) stop(ctx context.Context, release bool) ([]string, error) {
 	if s.done != nil {
 		s.done()
 	}
 
+	// the receivers are drained without holding the lock, so handlers calling back into the scheduler can return
+	s.receiverMu.Lock()
+	draining := make(map[string]*leasedReceiver, len(s.receivers))
+	for partitionID, lr := range s.receivers {
+		draining[partitionID] = lr
+	}
+	s.receiverMu.Unlock()
+
+	// drain all receivers concurrently so they share the deadline of the context
+	var wg sync.WaitGroup
+	var drainMu sync.Mutex
+	var forceClosed []string
+	for partitionID, lr := range draining {
+		wg.Add(1)
+		go func(partitionID string, lr *leasedReceiver) {
+			defer wg.Done()
+			if err := lr.Drain(ctx); err != nil {
+				drainMu.Lock()
+				forceClosed = append(forceClosed, partitionID)
+				drainMu.Unlock()
+			}
+		}(partitionID, lr)
+	}
+	wg.Wait()
+
+	s.receiverMu.Lock()
+	defer s.receiverMu.Unlock()
+
 	// close all receivers even if errors occur reporting only the last error, but logging all
 	var lastErr error
-	for _, lr := range s.receivers {
+	var stopped []string
+	for partitionID, lr := range s.receivers {
+		stopped = append(stopped, partitionID)
 		if err := lr.Close(ctx); err != nil {
+			s.processor.logFor(ctx).Error(err)
 			lastErr = err
 		}
+
+		// persist any coalesced checkpoint while the lease is still held, then release the lease. A fresh context is used
+		// so leases are released even if the drain exhausted the caller's deadline.
+		releaseCtx, cancel := context.WithTimeout(context.Background(), timeout)
+		if batcher := s.processor.persister.batcher; batcher != nil {
+			if err := batcher.flushPartition(releaseCtx, partitionID); err != nil {
+				s.processor.logFor(ctx).Error(err)
+			}
+		}
+		if release {
+			if _, err := s.processor.leaser.ReleaseLease(releaseCtx, partitionID); err != nil {
+				s.processor.logFor(ctx).Error(err)
+			}
+		}
+		cancel()
+		delete(s.receivers, partitionID)
+		if release {
+			s.processor.notifyLeaseChange(partitionID, false, s.processor.name)
+		}
+		s.processor.notifyPartitionClose(partitionID, CloseReasonShutdown, nil)
+	}
+
+	sort.Strings(stopped)
+	if len(forceClosed) > 0 {
+		sort.Strings(forceClosed)
+		return stopped, errors.Errorf("partitions %v were force closed before in-flight handlers completed: %v", forceClosed, ctx.Err())
 	}
+	return stopped, lastErr
+}
+
+// receiver returns the leased receiver for the partition, or nil if the partition is not owned
+func (s *scheduler) receiver(partitionID string) *leasedReceiver {
+	s.receiverMu.Lock()
+	defer s.receiverMu.Unlock()
 
-	return lastErr
+	return s.receivers[partitionID]
 }
 
 func (s *scheduler) startReceiver(ctx context.Context, lease LeaseMarker) error {
@@ -193,7 +302,7 @@ func (s *scheduler) startReceiver(ctx context.Context, lease LeaseMarker) error
 	if receiver, ok := s.receivers[lease.GetPartitionID()]; ok {
 		// receiver thinks it's already running... this is probably a bug if it happens
 		if err := receiver.Close(ctx); err != nil {
-			log.For(ctx).Error(err)
+			s.processor.logFor(ctx).Error(err)
 		}
 		delete(s.receivers, lease.GetPartitionID())
 	}
@@ -201,14 +310,34 @@ func (s *scheduler) startReceiver(ctx context.Context, lease LeaseMarker) error
 	span.SetTag(epochTag, lease.GetEpoch())
 	lr := newLeasedReceiver(s.processor, lease)
 	if err := lr.Run(ctx); err != nil {
-		log.For(ctx).Error(err)
+		if eventhub.IsReceiverDisconnected(err) {
+			// another host has taken ownership of the partition with a higher epoch, so the lease is lost rather
+			// than the scheduler having failed
+			s.releaseLostLease(ctx, lr, err)
+			return nil
+		}
+		s.processor.logFor(ctx).Error(err)
 		return err
 	}
 	s.receivers[lease.GetPartitionID()] = lr
+	s.processor.notifyOwnershipChange()
 	return nil
 }
 
-func (s *scheduler) stopReceiver(ctx context.Context, lease LeaseMarker) error {
+// releaseLostLease closes a receiver which was disconnected by the receiver of another host when opening, releasing
+// its lease and notifying the lease change and partition close handlers that the lease was lost
+func (s *scheduler) releaseLostLease(ctx context.Context, lr *leasedReceiver, cause error) {
+	partitionID := lr.lease.GetPartitionID()
+	s.dlog(ctx, fmt.Sprintf("lost ownership of partitionID %q: %v", partitionID, cause))
+	_ = lr.Close(ctx)
+	_, _ = s.processor.leaser.ReleaseLease(ctx, partitionID)
+	s.processor.notifyLeaseChange(partitionID, false, s.processor.name)
+	s.processor.notifyPartitionClose(partitionID, CloseReasonLeaseLost, cause)
+}
+
+// stopReceiver closes the receiver of the partition, if it is running, and notifies the partition close handlers with
+// the reason and the error which caused the partition to close
+func (s *scheduler) stopReceiver(ctx context.Context, lease LeaseMarker, reason CloseReason, cause error) error {
 	s.receiverMu.Lock()
 	defer s.receiverMu.Unlock()
 
@@ -219,12 +348,21 @@ func (s *scheduler) stopReceiver(ctx context.Context, lease LeaseMarker) error {
 	span.SetTag(epochTag, lease.GetEpoch())
 	s.dlog(ctx, fmt.Sprintf("stopping receiver for partitionID %q", lease.GetPartitionID()))
 	if receiver, ok := s.receivers[lease.GetPartitionID()]; ok {
+		// persist any coalesced checkpoint while the lease is still held
+		if batcher := s.processor.persister.batcher; batcher != nil {
+			if err := batcher.flushPartition(ctx, lease.GetPartitionID()); err != nil {
+				s.processor.logFor(ctx).Error(err)
+			}
+		}
+
 		// try to release the lease if possible
 		_, _ = s.processor.leaser.ReleaseLease(ctx, lease.GetPartitionID())
 		err := receiver.Close(ctx)
 		delete(s.receivers, lease.GetPartitionID())
+		s.processor.notifyLeaseChange(lease.GetPartitionID(), false, s.processor.name)
+		s.processor.notifyPartitionClose(lease.GetPartitionID(), reason, cause)
 		if err != nil {
-			log.For(ctx).Error(err)
+			s.processor.logFor(ctx).Error(err)
 			return err
 		}
 	}
@@ -241,6 +379,7 @@ func (s *scheduler) acquireExpiredLeases(ctx context.Context, leases []LeaseMark
 			if acquiredLease, ok, err := s.processor.leaser.AcquireLease(acquireCtx, lease.GetPartitionID()); ok {
 				cancel()
 				acquired = append(acquired, acquiredLease)
+				s.processor.notifyLeaseChange(acquiredLease.GetPartitionID(), true, lease.GetOwner())
 			} else {
 				cancel()
 				if err != nil {
@@ -258,24 +397,7 @@ func (s *scheduler) acquireExpiredLeases(ctx context.Context, leases []LeaseMark
 
 func (s *scheduler) dlog(ctx context.Context, msg string) {
 	name := s.processor.name
-	log.For(ctx).Debug(fmt.Sprintf("eph %q: "+msg, name))
-}
-
-func (s *scheduler) leaseToSteal(ctx context.Context, candidates []LeaseMarker, myLeaseCount int) (LeaseMarker, bool) {
-	span, ctx := s.startConsumerSpanFromContext(ctx, "eventhub.eph.scheduler.leaseToSteal")
-	defer span.Finish()
-
-	biggestOwner := ownerWithMostLeases(candidates)
-	if biggestOwner != nil && s.processor.GetName() != biggestOwner.Owner {
-		leasesByOwner := leasesByOwner(candidates)
-		log.For(ctx).Debug(fmt.Sprintf("i am %v, the biggest owner is %v and leases by owner: %v", s.processor.GetName(), biggestOwner.Owner, leasesByOwner))
-		if leasesByOwner[biggestOwner.Owner] != nil &&
-			(len(biggestOwner.Leases)-myLeaseCount) >= 2 && len(leasesByOwner[biggestOwner.Owner]) >= 1 {
-			selection := rand.Intn(len(leasesByOwner[biggestOwner.Owner]))
-			return leasesByOwner[biggestOwner.Owner][selection], true
-		}
-	}
-	return nil, false
+	s.processor.logFor(ctx).Debug(fmt.Sprintf("eph %q: "+msg, name))
 }
 
 func ownerWithMostLeases(candidates []LeaseMarker) *ownerCount {
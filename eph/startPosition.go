@@ -0,0 +1,75 @@
+package eph
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"time"
+
+	"github.com/Azure/azure-amqp-common-go/persist"
+)
+
+type (
+	// StartPosition is the position in the event stream of a partition where the EventProcessorHost starts receiving
+	// when no checkpoint has been recorded for the partition. The zero value starts from the beginning of the stream.
+	StartPosition struct {
+		latest       bool
+		enqueuedTime time.Time
+	}
+)
+
+// StartPositionBeginning starts receiving from the first event retained in the partition
+func StartPositionBeginning() StartPosition {
+	return StartPosition{}
+}
+
+// StartPositionLatest starts receiving with events enqueued after the receiver is opened
+func StartPositionLatest() StartPosition {
+	return StartPosition{latest: true}
+}
+
+// StartPositionFromEnqueuedTime starts receiving with events enqueued after the given time
+func StartPositionFromEnqueuedTime(t time.Time) StartPosition {
+	return StartPosition{enqueuedTime: t}
+}
+
+// WithStartPosition configures where the EventProcessorHost starts receiving a partition for which no checkpoint has
+// been recorded, such as the partitions of a new consumer group. A partition with a checkpoint always resumes from
+// the checkpoint. By default, partitions without a checkpoint are received from the beginning of the stream.
+func WithStartPosition(position StartPosition) EventProcessorHostOption {
+	return func(host *EventProcessorHost) error {
+		host.startPosition = position
+		return nil
+	}
+}
+
+// checkpoint returns the checkpoint which translates into the receiver filter for the start position
+func (p StartPosition) checkpoint() persist.Checkpoint {
+	switch {
+	case p.latest:
+		return persist.NewCheckpointFromEndOfStream()
+	case !p.enqueuedTime.IsZero():
+		return persist.NewCheckpoint("", 0, p.enqueuedTime)
+	default:
+		return persist.NewCheckpointFromStartOfStream()
+	}
+}
@@ -0,0 +1,80 @@
+package eph
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-amqp-common-go/persist"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckpointPersisterStartPosition(t *testing.T) {
+	enqueued := time.Date(2018, 6, 1, 0, 0, 0, 0, time.UTC)
+	tests := map[string]struct {
+		position   StartPosition
+		checkpoint persist.Checkpoint
+	}{
+		"Default":   {checkpoint: persist.NewCheckpointFromStartOfStream()},
+		"Beginning": {position: StartPositionBeginning(), checkpoint: persist.NewCheckpointFromStartOfStream()},
+		"Latest":    {position: StartPositionLatest(), checkpoint: persist.NewCheckpointFromEndOfStream()},
+		"Enqueued":  {position: StartPositionFromEnqueuedTime(enqueued), checkpoint: persist.NewCheckpoint("", 0, enqueued)},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			checkpointer := newMemoryLeaserCheckpointer(time.Minute, new(sharedStore))
+			host := &EventProcessorHost{}
+			require.NoError(t, WithStartPosition(tt.position)(host))
+			persister := &checkpointPersister{checkpointer: checkpointer, host: host}
+
+			checkpoint, err := persister.Read("ns", "hub", "$Default", "0")
+			require.NoError(t, err)
+			assert.Equal(t, tt.checkpoint, checkpoint)
+		})
+	}
+}
+
+func TestCheckpointPersisterStartPositionOverriddenByCheckpoint(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	host := &EventProcessorHost{name: "host", startPosition: StartPositionLatest()}
+	checkpointer := newMemoryLeaserCheckpointer(time.Minute, new(sharedStore))
+	checkpointer.SetEventHostProcessor(host)
+	require.NoError(t, checkpointer.EnsureStore(ctx))
+	_, err := checkpointer.EnsureLease(ctx, "0")
+	require.NoError(t, err)
+	_, ok, err := checkpointer.AcquireLease(ctx, "0")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.NoError(t, checkpointer.UpdateCheckpoint(ctx, "0", persist.NewCheckpoint("100", 10, time.Time{})))
+
+	persister := &checkpointPersister{checkpointer: checkpointer, host: host}
+	checkpoint, err := persister.Read("ns", "hub", "$Default", "0")
+	require.NoError(t, err)
+	assert.Equal(t, "100", checkpoint.Offset)
+}
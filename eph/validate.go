@@ -0,0 +1,74 @@
+package eph
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"context"
+
+	"github.com/Azure/azure-amqp-common-go/auth"
+	"github.com/Azure/azure-event-hubs-go"
+	"github.com/Azure/azure-event-hubs-go/mgmt"
+	"github.com/pkg/errors"
+)
+
+// Validate checks the configuration of an EventProcessorHost without starting it, such as for a preflight check
+// before deployment. The token provider must be able to authorize with the namespace, the Event Hub must exist and have
+// partitions, and the stores of the Leaser and Checkpointer must be reachable. Stores which don't exist yet are valid,
+// as they are created when the host starts. No leases are acquired and nothing is written.
+func Validate(ctx context.Context, namespace, hubName string, tokenProvider auth.TokenProvider, leaser Leaser, checkpointer Checkpointer) error {
+	span, ctx := startConsumerSpanFromContext(ctx, "eventhub.eph.Validate")
+	defer span.Finish()
+
+	if leaser == nil || checkpointer == nil {
+		return errors.New("leaser and checkpointer must not be nil")
+	}
+
+	client, err := eventhub.NewHub(namespace, hubName, tokenProvider)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = client.Close(ctx)
+	}()
+
+	return validate(ctx, client.GetRuntimeInformation, leaser, checkpointer)
+}
+
+// validate checks the runtime information of the Event Hub can be retrieved and the stores are reachable
+func validate(ctx context.Context, runtimeInformation func(ctx context.Context) (*mgmt.HubRuntimeInformation, error), leaser Leaser, checkpointer Checkpointer) error {
+	info, err := runtimeInformation(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to get the runtime information of the Event Hub; check the namespace, Event Hub name and credentials")
+	}
+	if len(info.PartitionIDs) == 0 {
+		return errors.Errorf("event hub %q has no partitions", info.Path)
+	}
+
+	if _, err := leaser.StoreExists(ctx); err != nil {
+		return errors.Wrap(err, "lease store is unreachable")
+	}
+	if _, err := checkpointer.StoreExists(ctx); err != nil {
+		return errors.Wrap(err, "checkpoint store is unreachable")
+	}
+	return nil
+}
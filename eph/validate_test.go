@@ -0,0 +1,65 @@
+package eph
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Azure/azure-event-hubs-go/mgmt"
+	"github.com/stretchr/testify/assert"
+)
+
+type (
+	unreachableStore struct {
+		*memoryLeaserCheckpointer
+	}
+)
+
+func (unreachableStore) StoreExists(ctx context.Context) (bool, error) {
+	return false, errors.New("no such host")
+}
+
+func TestValidate(t *testing.T) {
+	store := newMemoryLeaserCheckpointer(DefaultLeaseDuration, new(sharedStore))
+	info := &mgmt.HubRuntimeInformation{Path: "hub", PartitionIDs: []string{"0", "1"}}
+	var infoErr error
+	runtimeInformation := func(ctx context.Context) (*mgmt.HubRuntimeInformation, error) {
+		return info, infoErr
+	}
+
+	ctx := context.Background()
+	assert.NoError(t, validate(ctx, runtimeInformation, store, store), "stores which don't exist yet should be valid")
+
+	unreachable := unreachableStore{memoryLeaserCheckpointer: store}
+	assert.Error(t, validate(ctx, runtimeInformation, unreachable, store))
+	assert.Error(t, validate(ctx, runtimeInformation, store, unreachable))
+
+	info.PartitionIDs = nil
+	assert.Error(t, validate(ctx, runtimeInformation, store, store), "an Event Hub without partitions should be invalid")
+
+	infoErr = errors.New("unauthorized")
+	assert.Error(t, validate(ctx, runtimeInformation, store, store))
+	assert.False(t, store.store.exists(), "validation should not create the store")
+}
@@ -0,0 +1,101 @@
+package eph
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+)
+
+// WaitForPartition blocks until the host owns the lease of the partition and its receiver is running, returning nil,
+// or until the context is done, returning the context error. It returns straight away if the host is already
+// processing the partition. Waiting may begin before the host is started. An error is returned if the partition is not
+// a partition of the Event Hub or is excluded by the partition filter of the host.
+func (h *EventProcessorHost) WaitForPartition(ctx context.Context, partitionID string) error {
+	span, ctx := startConsumerSpanFromContext(ctx, "eventhub.eph.EventProcessorHost.WaitForPartition")
+	defer span.Finish()
+
+	span.SetTag(partitionIDTag, partitionID)
+	if !h.hasPartition(partitionID) {
+		return errors.Errorf("partition %q is not a partition of the Event Hub", partitionID)
+	}
+	if !h.leasable(partitionID) {
+		return errors.Errorf("partition %q is excluded by the partition filter of the host", partitionID)
+	}
+
+	for {
+		// the change is taken before checking ownership so a change in between is not missed
+		changed := h.ownershipChange()
+		if h.isProcessing(partitionID) {
+			return nil
+		}
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// isProcessing returns true if the current scheduler of the host has a running receiver for the partition
+func (h *EventProcessorHost) isProcessing(partitionID string) bool {
+	lr := h.currentReceiver(partitionID)
+	return lr != nil && lr.handle != nil
+}
+
+// currentReceiver returns the receiver of the partition of the current scheduler of the host, or nil if the host has
+// not been started or does not own the partition
+func (h *EventProcessorHost) currentReceiver(partitionID string) *leasedReceiver {
+	h.hostMu.Lock()
+	scheduler := h.scheduler
+	h.hostMu.Unlock()
+
+	if scheduler == nil {
+		return nil
+	}
+	return scheduler.receiver(partitionID)
+}
+
+// ownershipChange returns a channel which is closed the next time a receiver is started for a partition
+func (h *EventProcessorHost) ownershipChange() <-chan struct{} {
+	h.ownershipMu.Lock()
+	defer h.ownershipMu.Unlock()
+
+	if h.ownershipChanged == nil {
+		h.ownershipChanged = make(chan struct{})
+	}
+	return h.ownershipChanged
+}
+
+// notifyOwnershipChange wakes the callers of WaitForPartition to check whether their partition is now processed
+func (h *EventProcessorHost) notifyOwnershipChange() {
+	h.ownershipMu.Lock()
+	defer h.ownershipMu.Unlock()
+
+	if h.ownershipChanged != nil {
+		close(h.ownershipChanged)
+		h.ownershipChanged = nil
+	}
+}
@@ -0,0 +1,79 @@
+package eph
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-event-hubs-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWaitForPartition(t *testing.T) {
+	host, lease := newRestartTestHost(t)
+	waited := make(chan error, 1)
+	go func() {
+		waited <- host.WaitForPartition(context.Background(), "0")
+	}()
+
+	// the host is started while the caller waits
+	s := newScheduler(host)
+	host.hostMu.Lock()
+	host.scheduler = s
+	host.hostMu.Unlock()
+	select {
+	case err := <-waited:
+		assert.Fail(t, "wait returned before the partition was processed", "%v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	lr := newLeasedReceiver(host, lease)
+	lr.handle = new(eventhub.ListenerHandle)
+	s.receiverMu.Lock()
+	s.receivers["0"] = lr
+	s.receiverMu.Unlock()
+	host.notifyOwnershipChange()
+	select {
+	case err := <-waited:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		assert.Fail(t, "wait did not return once the partition was processed")
+	}
+
+	// a partition already processed returns straight away
+	assert.NoError(t, host.WaitForPartition(context.Background(), "0"))
+}
+
+func TestWaitForPartitionErrors(t *testing.T) {
+	host, _ := newRestartTestHost(t)
+	assert.Error(t, host.WaitForPartition(context.Background(), "2"), "the partition is not a partition of the Event Hub")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, host.WaitForPartition(ctx, "1"))
+
+	assert.NoError(t, WithPartitionFilter(func(partitionID string) bool { return partitionID == "0" })(host))
+	assert.Error(t, host.WaitForPartition(context.Background(), "1"), "the partition is excluded by the filter")
+}
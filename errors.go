@@ -0,0 +1,180 @@
+package eventhub
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"pack.ag/amqp"
+)
+
+const (
+	// linkStolenCondition is the condition of the error the broker detaches a receiver with when a receiver with a
+	// higher epoch is opened on the same partition and consumer group
+	linkStolenCondition = "amqp:link:stolen"
+)
+
+var (
+	// ErrHubNotFound is matched by errors.Is when the Event Hub or its namespace does not exist
+	ErrHubNotFound = errors.New("event hub not found")
+
+	// ErrConsumerGroupNotFound is matched by errors.Is when the consumer group of a receiver does not exist
+	ErrConsumerGroupNotFound = errors.New("consumer group not found")
+
+	// ErrUnauthorized is matched by errors.Is when the broker rejects the credentials of the Hub, or they do not grant
+	// the rights required by the operation
+	ErrUnauthorized = errors.New("unauthorized")
+
+	// ErrLeaseLost is matched by errors.Is when a partition is no longer owned, such as by a ReceiverDisconnectedError
+	ErrLeaseLost = errors.New("partition lease lost")
+
+	// ErrMessageTooLarge is matched by errors.Is when an event exceeds the max message size, such as by a
+	// MessageTooLargeError
+	ErrMessageTooLarge = errors.New("message too large")
+)
+
+type (
+	// kindError marks an error of the broker with the sentinel error its condition identifies, so errors.Is matches
+	// the sentinel while errors.Cause still returns the error of the broker
+	kindError struct {
+		kind error
+		err  error
+	}
+
+	// ReceiverDisconnectedError is returned when the broker disconnects a receiver because another receiver with a
+	// higher epoch was opened on the same partition and consumer group, which is expected when partition ownership
+	// moves to another process
+	ReceiverDisconnectedError struct {
+		PartitionID   string
+		ConsumerGroup string
+		Err           error
+	}
+
+	// MessageTooLargeError is returned before sending an event whose data can't fit within the max message size of
+	// the Hub
+	MessageTooLargeError struct {
+		Size    int64
+		MaxSize int64
+	}
+)
+
+func (e *ReceiverDisconnectedError) Error() string {
+	return fmt.Sprintf("receiver for partition %q of consumer group %q was disconnected by a receiver with a higher epoch: %v", e.PartitionID, e.ConsumerGroup, e.Err)
+}
+
+// Is returns true for ErrLeaseLost
+func (e *ReceiverDisconnectedError) Is(target error) bool {
+	return target == ErrLeaseLost
+}
+
+// Unwrap returns the error with which the broker detached the receiver
+func (e *ReceiverDisconnectedError) Unwrap() error {
+	return e.Err
+}
+
+func (e *MessageTooLargeError) Error() string {
+	return fmt.Sprintf("event data of %d bytes exceeds the max message size of %d bytes", e.Size, e.MaxSize)
+}
+
+// Is returns true for ErrMessageTooLarge
+func (e *MessageTooLargeError) Is(target error) bool {
+	return target == ErrMessageTooLarge
+}
+
+func (e *kindError) Error() string {
+	return e.err.Error()
+}
+
+func (e *kindError) Is(target error) bool {
+	return target == e.kind
+}
+
+func (e *kindError) Unwrap() error {
+	return e.err
+}
+
+func (e *kindError) Cause() error {
+	return e.err
+}
+
+// withErrorKind marks the error with the sentinel error identified by its AMQP condition, if any, so callers can
+// tell failures such as missing entities and rejected credentials from transient errors with errors.Is
+func withErrorKind(err error) error {
+	amqpErr := amqpError(err)
+	if amqpErr == nil {
+		return err
+	}
+
+	switch amqpErr.Condition {
+	case amqp.ErrorUnauthorizedAccess:
+		return &kindError{kind: ErrUnauthorized, err: err}
+	case amqp.ErrorMessageSizeExceeded:
+		return &kindError{kind: ErrMessageTooLarge, err: err}
+	case amqp.ErrorNotFound:
+		// the broker names the missing entity, which is a consumer group when a receiver's consumer group is missing
+		if strings.Contains(strings.ToLower(amqpErr.Description), "/consumergroups/") {
+			return &kindError{kind: ErrConsumerGroupNotFound, err: err}
+		}
+		return &kindError{kind: ErrHubNotFound, err: err}
+	}
+	return err
+}
+
+// amqpError returns the AMQP error of the broker which caused the error, or nil if it was not raised by the broker
+func amqpError(err error) *amqp.Error {
+	switch e := errors.Cause(err).(type) {
+	case *amqp.Error:
+		return e
+	case amqp.DetachError:
+		return e.RemoteError
+	case *amqp.DetachError:
+		return e.RemoteError
+	}
+	return nil
+}
+
+// IsReceiverDisconnected returns true if the error, or its cause, is a ReceiverDisconnectedError
+func IsReceiverDisconnected(err error) bool {
+	_, ok := errors.Cause(err).(*ReceiverDisconnectedError)
+	return ok
+}
+
+// isLinkStolen returns true if the error was raised because the link was stolen by a receiver with a higher epoch
+func isLinkStolen(err error) bool {
+	amqpErr := amqpError(err)
+	return amqpErr != nil && amqpErr.Condition == linkStolenCondition
+}
+
+// receiverDisconnectedError wraps the error in a ReceiverDisconnectedError if the receiver's link was stolen
+func (r *receiver) receiverDisconnectedError(err error) error {
+	if !isLinkStolen(err) {
+		return err
+	}
+	return &ReceiverDisconnectedError{
+		PartitionID:   r.partitionID,
+		ConsumerGroup: r.consumerGroup,
+		Err:           err,
+	}
+}
@@ -0,0 +1,109 @@
+package eventhub
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"errors"
+	"testing"
+
+	pkgerrors "github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"pack.ag/amqp"
+)
+
+func TestReceiverDisconnectedError(t *testing.T) {
+	stolen := &amqp.Error{Condition: linkStolenCondition, Description: "New receiver with higher epoch of '1' is created"}
+	tests := map[string]struct {
+		err          error
+		disconnected bool
+	}{
+		"Nil":             {err: nil},
+		"Other":           {err: errors.New("boom")},
+		"OtherCondition":  {err: &amqp.Error{Condition: amqp.ErrorInternalError}},
+		"GracefulDetach":  {err: amqp.DetachError{}},
+		"LinkStolen":      {err: stolen, disconnected: true},
+		"DetachedStolen":  {err: amqp.DetachError{RemoteError: stolen}, disconnected: true},
+		"DetachedPointer": {err: &amqp.DetachError{RemoteError: stolen}, disconnected: true},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			r := &receiver{partitionID: "1", consumerGroup: DefaultConsumerGroup}
+			err := r.receiverDisconnectedError(tt.err)
+			assert.Equal(t, tt.disconnected, IsReceiverDisconnected(err))
+			if !tt.disconnected {
+				assert.Equal(t, tt.err, err)
+				return
+			}
+
+			disconnected := err.(*ReceiverDisconnectedError)
+			assert.Equal(t, "1", disconnected.PartitionID)
+			assert.Equal(t, DefaultConsumerGroup, disconnected.ConsumerGroup)
+			assert.Equal(t, tt.err, disconnected.Err)
+			assert.False(t, IsTransient(err))
+			assert.False(t, IsTransient(tt.err))
+		})
+	}
+}
+
+func TestWithErrorKind(t *testing.T) {
+	unauthorized := &amqp.Error{Condition: amqp.ErrorUnauthorizedAccess}
+	tests := map[string]struct {
+		err  error
+		kind error
+	}{
+		"Nil":              {err: nil},
+		"Other":            {err: errors.New("boom")},
+		"Internal":         {err: &amqp.Error{Condition: amqp.ErrorInternalError}},
+		"Unauthorized":     {err: unauthorized, kind: ErrUnauthorized},
+		"DetachedUnauthed": {err: amqp.DetachError{RemoteError: unauthorized}, kind: ErrUnauthorized},
+		"HubNotFound":      {err: &amqp.Error{Condition: amqp.ErrorNotFound, Description: "The messaging entity 'sb://ns.servicebus.windows.net/hub' could not be found."}, kind: ErrHubNotFound},
+		"GroupNotFound":    {err: &amqp.Error{Condition: amqp.ErrorNotFound, Description: "The messaging entity 'sb://ns.servicebus.windows.net/hub/ConsumerGroups/group' could not be found."}, kind: ErrConsumerGroupNotFound},
+		"TooLarge":         {err: &amqp.Error{Condition: amqp.ErrorMessageSizeExceeded}, kind: ErrMessageTooLarge},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := withErrorKind(tt.err)
+			if tt.kind == nil {
+				assert.Equal(t, tt.err, err)
+				return
+			}
+
+			if kindErr, ok := err.(*kindError); assert.True(t, ok) {
+				assert.True(t, kindErr.Is(tt.kind))
+				assert.Equal(t, tt.err, kindErr.Unwrap())
+			}
+			assert.Equal(t, tt.err, pkgerrors.Cause(err), "the error of the broker should remain the cause")
+		})
+	}
+}
+
+func TestTypedErrorsMatchSentinels(t *testing.T) {
+	boom := errors.New("boom")
+	disconnected := &ReceiverDisconnectedError{Err: boom}
+	assert.True(t, disconnected.Is(ErrLeaseLost))
+	assert.Equal(t, boom, disconnected.Unwrap())
+	assert.True(t, (&MessageTooLargeError{Size: 2, MaxSize: 1}).Is(ErrMessageTooLarge))
+	assert.False(t, (&MessageTooLargeError{Size: 2, MaxSize: 1}).Is(ErrLeaseLost))
+}
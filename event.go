@@ -26,6 +26,7 @@ import (
 	"time"
 
 	"github.com/Azure/azure-amqp-common-go/persist"
+	"github.com/Azure/azure-event-hubs-go/mgmt"
 	"pack.ag/amqp"
 )
 
@@ -34,18 +35,63 @@ const (
 	partitionKeyAnnotationName string = "x-opt-partition-key"
 	sequenceNumberName         string = "x-opt-sequence-number"
 	enqueueTimeName            string = "x-opt-enqueued-time"
+	// scheduledEnqueueTimeName is the annotation holding the time the broker should enqueue the event
+	scheduledEnqueueTimeName string = "x-opt-scheduled-enqueue-time"
+)
+
+const (
+	// BodyTypeData is the body of an event sent as binary data sections, held by Event.Data
+	BodyTypeData BodyType = iota
+	// BodyTypeValue is the body of an event sent as an amqp-value section, returned by Event.Value
+	BodyTypeValue
 )
 
 type (
 	// Event is an Event Hubs message to be sent or received
 	Event struct {
-		Data         []byte
+		Data []byte
+		// PartitionKey is used by the broker to route the event to a partition. It is sent as the x-opt-partition-key
+		// message annotation and is populated from that annotation on received events.
 		PartitionKey *string
 		Properties   map[string]interface{}
 		ID           string
-		message      *amqp.Message
+		// PartitionInformation is the runtime information of the partition the event was received from. It is only
+		// populated by receivers configured to retrieve it, such as an EventProcessorHost created with
+		// eph.WithPartitionInformation.
+		PartitionInformation *mgmt.HubPartitionRuntimeInformation
+		// SystemProperties are the properties assigned by the broker to a received event. SystemProperties is nil for
+		// events which have not been received from the broker.
+		SystemProperties *SystemProperties
+		message          *amqp.Message
+		// scheduledEnqueueTime is sent as the x-opt-scheduled-enqueue-time message annotation when set
+		scheduledEnqueueTime *time.Time
+		// annotations are the message annotations of the event outside of the reserved x-opt- space
+		annotations map[string]interface{}
+		// timeToLive is sent as the ttl of the AMQP message header when greater than 0
+		timeToLive time.Duration
+		// contentType is sent as the AMQP content-type property when set
+		contentType string
+		// correlationID is sent as the AMQP correlation-id property when set
+		correlationID string
+		// groupID and groupSequence are sent as the AMQP group-id and group-sequence properties when set
+		groupID       string
+		groupSequence uint32
+		// value is sent as the amqp-value body section in place of Data when set
+		value interface{}
 	}
 
+	// SystemProperties are the properties assigned by the broker to an event when it was enqueued
+	SystemProperties struct {
+		SequenceNumber int64
+		Offset         string
+		EnqueuedTime   time.Time
+		PartitionKey   *string
+	}
+
+	// BodyType is the section holding the body of an AMQP message. Bodies of amqp-sequence sections are not supported by
+	// the AMQP client and fail to be received.
+	BodyType int
+
 	// EventBatch is a batch of Event Hubs messages to be sent
 	EventBatch struct {
 		Events       []*Event
@@ -67,6 +113,14 @@ func NewEvent(data []byte) *Event {
 	}
 }
 
+// NewEventFromValue builds an Event with an amqp-value body holding v rather than binary data. The value must be of a
+// type the AMQP encoder supports, such as a string, number, []byte or map of those types.
+func NewEventFromValue(v interface{}) *Event {
+	return &Event{
+		value: v,
+	}
+}
+
 // NewEventBatch builds an EventBatch from an array of Events
 func NewEventBatch(events []*Event) *EventBatch {
 	return &EventBatch{
@@ -74,8 +128,13 @@ func NewEventBatch(events []*Event) *EventBatch {
 	}
 }
 
-// GetCheckpoint returns the checkpoint information on the Event
+// GetCheckpoint returns the checkpoint information on the Event. Events which have not been received from the broker
+// return a checkpoint at the start of the stream.
 func (e *Event) GetCheckpoint() persist.Checkpoint {
+	if e.message == nil {
+		return persist.NewCheckpointFromStartOfStream()
+	}
+
 	var offset string
 	var enqueueTime time.Time
 	var sequenceNumber int64
@@ -94,6 +153,20 @@ func (e *Event) GetCheckpoint() persist.Checkpoint {
 	return persist.NewCheckpoint(offset, sequenceNumber, enqueueTime)
 }
 
+// Value returns the body of an event sent as an amqp-value section, such as a map, or nil for events with a binary
+// data body
+func (e *Event) Value() interface{} {
+	return e.value
+}
+
+// BodyType returns the section holding the body of the event
+func (e *Event) BodyType() BodyType {
+	if e.value != nil {
+		return BodyTypeValue
+	}
+	return BodyTypeData
+}
+
 // Set implements opentracing.TextMapWriter and sets properties on the event to be propagated to the message broker
 func (e *Event) Set(key, value string) {
 	if e.Properties == nil {
@@ -116,7 +189,7 @@ func (e *Event) ForeachKey(handler func(key string, val interface{}) error) erro
 func (e *Event) toMsg() *amqp.Message {
 	msg := e.message
 	if msg == nil {
-		msg = amqp.NewMessage(e.Data)
+		msg = e.newMessage()
 	}
 
 	msg.Properties = &amqp.MessageProperties{
@@ -131,12 +204,36 @@ func (e *Event) toMsg() *amqp.Message {
 	}
 
 	if e.PartitionKey != nil {
-		msg.Annotations = make(amqp.Annotations)
-		msg.Annotations[partitionKeyAnnotationName] = e.PartitionKey
+		if msg.Annotations == nil {
+			msg.Annotations = make(amqp.Annotations)
+		}
+		msg.Annotations[partitionKeyAnnotationName] = *e.PartitionKey
+	}
+
+	if e.scheduledEnqueueTime != nil {
+		if msg.Annotations == nil {
+			msg.Annotations = make(amqp.Annotations)
+		}
+		msg.Annotations[scheduledEnqueueTimeName] = *e.scheduledEnqueueTime
 	}
+
+	e.applyAnnotations(msg)
+	e.applyTimeToLive(msg)
+	e.applyProperties(msg)
 	return msg
 }
 
+// newMessage builds an AMQP message holding the body of the event as an amqp-value section when a value has been set,
+// or as a data section otherwise
+func (e *Event) newMessage() *amqp.Message {
+	if e.value != nil {
+		return &amqp.Message{
+			Value: e.value,
+		}
+	}
+	return amqp.NewMessage(e.Data)
+}
+
 func (b *EventBatch) toEvent() (*Event, error) {
 	msg := &amqp.Message{
 		Data: make([][]byte, len(b.Events)),
@@ -147,19 +244,30 @@ func (b *EventBatch) toEvent() (*Event, error) {
 	}
 
 	for idx, event := range b.Events {
-		innerMsg := amqp.NewMessage(event.Data)
-		bin, err := innerMsg.MarshalBinary()
+		bin, err := encodeBatchItem(event)
 		if err != nil {
 			return nil, err
 		}
 		msg.Data[idx] = bin
 	}
 
-	return eventFromMsg(msg), nil
+	event := eventFromMsg(msg)
+	event.PartitionKey = b.PartitionKey
+	scheduled, err := b.scheduledEnqueueTime()
+	if err != nil {
+		return nil, err
+	}
+	event.scheduledEnqueueTime = scheduled
+	event.timeToLive = b.timeToLive()
+	return event, nil
 }
 
 func eventFromMsg(msg *amqp.Message) *Event {
-	return newEvent(msg.Data[0], msg)
+	var data []byte
+	if len(msg.Data) > 0 {
+		data = msg.Data[0]
+	}
+	return newEvent(data, msg)
 }
 
 func newEvent(data []byte, msg *amqp.Message) *Event {
@@ -169,13 +277,58 @@ func newEvent(data []byte, msg *amqp.Message) *Event {
 	}
 
 	if msg.Properties != nil {
-		if id, ok := msg.Properties.MessageID.(string); ok {
-			event.ID = id
-		}
+		event.ID = correlationIDString(msg.Properties.MessageID)
+		event.contentType = msg.Properties.ContentType
+		event.correlationID = correlationIDString(msg.Properties.CorrelationID)
+		event.groupID = msg.Properties.GroupID
+		event.groupSequence = msg.Properties.GroupSequence
 	}
 
 	if msg != nil {
+		event.value = msg.Value
 		event.Properties = msg.ApplicationProperties
+		event.PartitionKey = partitionKeyFromAnnotations(msg.Annotations)
+		event.SystemProperties = newSystemProperties(msg.Annotations)
+		if scheduled, ok := msg.Annotations[scheduledEnqueueTimeName].(time.Time); ok {
+			event.scheduledEnqueueTime = &scheduled
+		}
+		event.annotations = annotationsFromMsg(msg)
+		if msg.Header != nil {
+			event.timeToLive = msg.Header.TTL
+		}
 	}
 	return event
 }
+
+// newSystemProperties reads the broker assigned properties from the message annotations. If the message has not been
+// assigned a sequence number by the broker, nil is returned.
+func newSystemProperties(annotations amqp.Annotations) *SystemProperties {
+	sequenceNumber, ok := annotations[sequenceNumberName].(int64)
+	if !ok {
+		return nil
+	}
+
+	props := &SystemProperties{
+		SequenceNumber: sequenceNumber,
+		PartitionKey:   partitionKeyFromAnnotations(annotations),
+	}
+	if offset, ok := annotations[offsetAnnotationName].(string); ok {
+		props.Offset = offset
+	}
+	if enqueuedTime, ok := annotations[enqueueTimeName].(time.Time); ok {
+		props.EnqueuedTime = enqueuedTime
+	}
+	return props
+}
+
+// partitionKeyFromAnnotations reads the partition key the message was routed with from the message annotations
+func partitionKeyFromAnnotations(annotations amqp.Annotations) *string {
+	switch key := annotations[partitionKeyAnnotationName].(type) {
+	case string:
+		return &key
+	case *string:
+		return key
+	default:
+		return nil
+	}
+}
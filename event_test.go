@@ -0,0 +1,140 @@
+package eventhub
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"pack.ag/amqp"
+)
+
+// amqpValueDescriptor is the encoded descriptor of an amqp-value body section
+var amqpValueDescriptor = []byte{0x00, 0x53, 0x77}
+
+func TestEventPartitionKeyRoundTrip(t *testing.T) {
+	key := "foo"
+	event := NewEventFromString("bar")
+	event.PartitionKey = &key
+
+	msg := event.toMsg()
+	assert.Equal(t, "foo", msg.Annotations[partitionKeyAnnotationName], "the annotation should hold the key rather than a pointer")
+
+	received := eventFromMsg(msg)
+	if assert.NotNil(t, received.PartitionKey) {
+		assert.Equal(t, "foo", *received.PartitionKey)
+	}
+}
+
+func TestEventPartitionKeyPreservesAnnotations(t *testing.T) {
+	key := "foo"
+	msg := amqp.NewMessage([]byte("bar"))
+	msg.Annotations = amqp.Annotations{"x-opt-custom": "value"}
+	event := eventFromMsg(msg)
+	event.PartitionKey = &key
+
+	msg = event.toMsg()
+	assert.Equal(t, "value", msg.Annotations["x-opt-custom"])
+	assert.Equal(t, "foo", msg.Annotations[partitionKeyAnnotationName])
+}
+
+func TestEventBatchPartitionKey(t *testing.T) {
+	key := "foo"
+	batch := NewEventBatch([]*Event{NewEventFromString("bar")})
+	batch.PartitionKey = &key
+
+	event, err := batch.toEvent()
+	if assert.NoError(t, err) {
+		assert.Equal(t, "foo", event.toMsg().Annotations[partitionKeyAnnotationName])
+	}
+}
+
+func TestEventWithoutPartitionKey(t *testing.T) {
+	assert.Nil(t, eventFromMsg(NewEventFromString("bar").toMsg()).PartitionKey)
+}
+
+func TestEventSystemProperties(t *testing.T) {
+	enqueued := time.Date(2018, 6, 1, 0, 0, 0, 0, time.UTC)
+	msg := amqp.NewMessage([]byte("bar"))
+	msg.Annotations = amqp.Annotations{
+		sequenceNumberName:         int64(42),
+		offsetAnnotationName:       "4096",
+		enqueueTimeName:            enqueued,
+		partitionKeyAnnotationName: "foo",
+	}
+
+	props := eventFromMsg(msg).SystemProperties
+	if assert.NotNil(t, props) {
+		assert.Equal(t, int64(42), props.SequenceNumber)
+		assert.Equal(t, "4096", props.Offset)
+		assert.Equal(t, enqueued, props.EnqueuedTime)
+		if assert.NotNil(t, props.PartitionKey) {
+			assert.Equal(t, "foo", *props.PartitionKey)
+		}
+	}
+}
+
+func TestEventSystemPropertiesNilForLocalEvents(t *testing.T) {
+	assert.Nil(t, NewEventFromString("bar").SystemProperties)
+	assert.Nil(t, eventFromMsg(NewEventFromString("bar").toMsg()).SystemProperties)
+}
+
+func TestEventFromValueRoundTrip(t *testing.T) {
+	event := NewEventFromValue(map[string]interface{}{"foo": "bar"})
+	assert.Equal(t, BodyTypeValue, event.BodyType())
+
+	msg := event.toMsg()
+	assert.Empty(t, msg.Data, "the value should not be sent as a data section")
+	bin, err := msg.MarshalBinary()
+	if assert.NoError(t, err) {
+		assert.True(t, bytes.Contains(bin, amqpValueDescriptor))
+	}
+
+	received := eventFromMsg(msg)
+	assert.Equal(t, BodyTypeValue, received.BodyType())
+	assert.Nil(t, received.Data)
+	assert.Equal(t, map[string]interface{}{"foo": "bar"}, received.Value())
+}
+
+func TestEventBodyTypeData(t *testing.T) {
+	received := eventFromMsg(NewEventFromString("bar").toMsg())
+	assert.Equal(t, BodyTypeData, received.BodyType())
+	assert.Equal(t, "bar", string(received.Data))
+	assert.Nil(t, received.Value())
+}
+
+func TestEventBatchWithValue(t *testing.T) {
+	batch := NewEventBatch([]*Event{NewEventFromValue("foo"), NewEventFromString("bar")})
+	event, err := batch.toEvent()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	msg := event.toMsg()
+	if assert.Len(t, msg.Data, 2) {
+		assert.True(t, bytes.Contains(msg.Data[0], amqpValueDescriptor))
+		assert.False(t, bytes.Contains(msg.Data[1], amqpValueDescriptor))
+	}
+}
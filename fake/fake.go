@@ -0,0 +1,160 @@
+// Package fake provides an in-memory implementation of the eventhub.Sender interface for unit testing code which
+// sends events, without an Event Hubs namespace.
+package fake
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"context"
+	"sync"
+
+	"github.com/Azure/azure-event-hubs-go"
+)
+
+type (
+	// Sender records the events sent to it so tests can assert on their data, partition keys and properties. Events
+	// sent within a batch are recorded individually, with the partition key of the batch if they have none of their
+	// own.
+	Sender struct {
+		mu      sync.Mutex
+		events  []*eventhub.Event
+		sendErr error
+	}
+)
+
+var _ eventhub.Sender = (*Sender)(nil)
+
+// NewSender creates a Sender which has recorded no events
+func NewSender() *Sender {
+	return new(Sender)
+}
+
+// Send applies the send options to the event and records it
+func (s *Sender) Send(ctx context.Context, event *eventhub.Event, opts ...eventhub.SendOption) error {
+	if err := s.checkSend(ctx); err != nil {
+		return err
+	}
+
+	for _, opt := range opts {
+		if err := opt(event); err != nil {
+			return err
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, copyEvent(event, nil))
+	return nil
+}
+
+// SendBatch records each event of the batch. The send options are applied to each event.
+func (s *Sender) SendBatch(ctx context.Context, batch *eventhub.EventBatch, opts ...eventhub.SendOption) error {
+	if err := s.checkSend(ctx); err != nil {
+		return err
+	}
+
+	events := make([]*eventhub.Event, len(batch.Events))
+	for idx, event := range batch.Events {
+		for _, opt := range opts {
+			if err := opt(event); err != nil {
+				return err
+			}
+		}
+		events[idx] = copyEvent(event, batch.PartitionKey)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, events...)
+	return nil
+}
+
+// Events returns the events recorded by the Sender in the order they were sent
+func (s *Sender) Events() []*eventhub.Event {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	events := make([]*eventhub.Event, len(s.events))
+	copy(events, s.events)
+	return events
+}
+
+// EventsWithPartitionKey returns the recorded events which were sent with the partition key
+func (s *Sender) EventsWithPartitionKey(partitionKey string) []*eventhub.Event {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var events []*eventhub.Event
+	for _, event := range s.events {
+		if event.PartitionKey != nil && *event.PartitionKey == partitionKey {
+			events = append(events, event)
+		}
+	}
+	return events
+}
+
+// FailWith configures the Sender to return the error from subsequent sends without recording the events. A nil
+// error makes sends succeed again.
+func (s *Sender) FailWith(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sendErr = err
+}
+
+// Reset discards the recorded events
+func (s *Sender) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = nil
+}
+
+func (s *Sender) checkSend(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sendErr
+}
+
+// copyEvent copies the event so later changes by the caller do not alter the recorded event
+func copyEvent(event *eventhub.Event, partitionKey *string) *eventhub.Event {
+	recorded := *event
+	recorded.Data = append([]byte(nil), event.Data...)
+	if event.Properties != nil {
+		recorded.Properties = make(map[string]interface{}, len(event.Properties))
+		for key, value := range event.Properties {
+			recorded.Properties[key] = value
+		}
+	}
+
+	if recorded.PartitionKey == nil {
+		recorded.PartitionKey = partitionKey
+	}
+	if recorded.PartitionKey != nil {
+		key := *recorded.PartitionKey
+		recorded.PartitionKey = &key
+	}
+	return &recorded
+}
@@ -0,0 +1,80 @@
+package fake
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Azure/azure-event-hubs-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSenderRecordsEvents(t *testing.T) {
+	ctx := context.Background()
+	sender := NewSender()
+
+	key := "foo"
+	event := eventhub.NewEventFromString("hello")
+	event.PartitionKey = &key
+	event.Set("color", "blue")
+	assert.NoError(t, sender.Send(ctx, event, eventhub.SendWithMessageID("1")))
+
+	batchKey := "bar"
+	batch := eventhub.NewEventBatch([]*eventhub.Event{eventhub.NewEventFromString("a"), eventhub.NewEventFromString("b")})
+	batch.PartitionKey = &batchKey
+	assert.NoError(t, sender.SendBatch(ctx, batch))
+
+	// changes made after sending are not recorded
+	event.Set("color", "red")
+	key = "changed"
+
+	events := sender.Events()
+	if assert.Len(t, events, 3) {
+		assert.Equal(t, "hello", string(events[0].Data))
+		assert.Equal(t, "1", events[0].ID)
+		assert.Equal(t, "blue", events[0].Properties["color"])
+		assert.Equal(t, "foo", *events[0].PartitionKey)
+	}
+	assert.Len(t, sender.EventsWithPartitionKey("foo"), 1)
+	assert.Len(t, sender.EventsWithPartitionKey("bar"), 2)
+
+	sender.Reset()
+	assert.Empty(t, sender.Events())
+}
+
+func TestSenderFailWith(t *testing.T) {
+	ctx := context.Background()
+	sender := NewSender()
+	boom := errors.New("boom")
+
+	sender.FailWith(boom)
+	assert.Equal(t, boom, sender.Send(ctx, eventhub.NewEventFromString("hello")))
+	assert.Equal(t, boom, sender.SendBatch(ctx, eventhub.NewEventBatch([]*eventhub.Event{eventhub.NewEventFromString("a")})))
+	assert.Empty(t, sender.Events())
+
+	sender.FailWith(nil)
+	assert.NoError(t, sender.Send(ctx, eventhub.NewEventFromString("hello")))
+	assert.Len(t, sender.Events(), 1)
+}
@@ -0,0 +1,142 @@
+package eventhub
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// DefaultSendWindow is the default number of sends started by SendAsync which may be awaiting acknowledgement
+	// from the broker at once
+	DefaultSendWindow = 64
+)
+
+type (
+	// SendFuture represents the outcome of an event sent with SendAsync
+	SendFuture struct {
+		done chan struct{}
+		err  error
+	}
+)
+
+// HubWithSendWindow configures the number of sends started by SendAsync which may be awaiting acknowledgement from
+// the broker at once
+func HubWithSendWindow(window int) HubOption {
+	return func(h *Hub) error {
+		if window < 1 {
+			return errors.New("send window must be at least 1")
+		}
+		h.sendWindow = window
+		return nil
+	}
+}
+
+// SendAsync sends an event to the Event Hub without waiting for the broker to acknowledge it. The returned SendFuture
+// completes once the broker has settled the transfer carrying the event, or the send has failed.
+//
+// Transfers are pipelined over the sender link, with each acknowledgement matched to its transfer by delivery tag.
+// At most the send window of transfers are unsettled at once; when the window is full, SendAsync blocks until a slot
+// frees or the context is done. Events sent concurrently are not guaranteed to be enqueued in the order SendAsync
+// was called, unless the Hub is configured with HubWithOrderedSend. The sender is opened, if needed, before SendAsync returns, so CloseSender waits for the send.
+func (h *Hub) SendAsync(ctx context.Context, event *Event, opts ...SendOption) *SendFuture {
+	future := &SendFuture{done: make(chan struct{})}
+	window := h.sendSlots()
+
+	select {
+	case window <- struct{}{}:
+	case <-ctx.Done():
+		future.complete(ctx.Err())
+		return future
+	}
+
+	sender, err := h.reserveSender(ctx)
+	if err != nil {
+		<-window
+		future.complete(err)
+		return future
+	}
+
+	previous := h.chainOrderedSend(future)
+	go func() {
+		defer func() { <-window }()
+		defer sender.endSend()
+
+		span, ctx := h.startSpanFromContext(ctx, "eventhub.Hub.SendAsync")
+		defer span.Finish()
+
+		if previous != nil {
+			select {
+			case <-previous.done:
+			case <-ctx.Done():
+				future.complete(ctx.Err())
+				return
+			}
+		}
+		future.complete(h.send(ctx, sender, event, opts...))
+	}()
+	return future
+}
+
+// Done returns a channel which is closed once the send has completed
+func (f *SendFuture) Done() <-chan struct{} {
+	return f.done
+}
+
+// Err returns the error of the send once it has completed. Err returns nil while the send is in flight.
+func (f *SendFuture) Err() error {
+	select {
+	case <-f.done:
+		return f.err
+	default:
+		return nil
+	}
+}
+
+// Wait blocks until the send has completed or the context is done and returns the error of the send
+func (f *SendFuture) Wait(ctx context.Context) error {
+	select {
+	case <-f.done:
+		return f.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (f *SendFuture) complete(err error) {
+	f.err = err
+	close(f.done)
+}
+
+func (h *Hub) sendSlots() chan struct{} {
+	h.sendSlotsOnce.Do(func() {
+		window := h.sendWindow
+		if window < 1 {
+			window = DefaultSendWindow
+		}
+		h.sendSlotsCh = make(chan struct{}, window)
+	})
+	return h.sendSlotsCh
+}
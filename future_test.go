@@ -0,0 +1,68 @@
+package eventhub
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSendAsyncWindowFull(t *testing.T) {
+	hub := &Hub{}
+	if !assert.NoError(t, HubWithSendWindow(1)(hub)) {
+		return
+	}
+
+	// occupy the only slot in the window
+	hub.sendSlots() <- struct{}{}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	future := hub.SendAsync(ctx, NewEventFromString("foo"))
+	select {
+	case <-future.Done():
+		assert.Equal(t, context.Canceled, future.Err())
+	default:
+		assert.Fail(t, "future should complete when the context is done before a slot frees")
+	}
+}
+
+func TestSendFutureErrWhileInFlight(t *testing.T) {
+	future := &SendFuture{done: make(chan struct{})}
+	assert.NoError(t, future.Err())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.Equal(t, context.Canceled, future.Wait(ctx))
+
+	future.complete(context.DeadlineExceeded)
+	assert.Equal(t, context.DeadlineExceeded, future.Err())
+	assert.Equal(t, context.DeadlineExceeded, future.Wait(context.Background()))
+}
+
+func TestHubWithSendWindow(t *testing.T) {
+	assert.Error(t, HubWithSendWindow(0)(&Hub{}))
+	assert.Equal(t, DefaultSendWindow, cap((&Hub{}).sendSlots()))
+}
@@ -31,7 +31,6 @@ import (
 
 	"github.com/Azure/azure-amqp-common-go/aad"
 	"github.com/Azure/azure-amqp-common-go/auth"
-	"github.com/Azure/azure-amqp-common-go/log"
 	"github.com/Azure/azure-amqp-common-go/persist"
 	"github.com/Azure/azure-amqp-common-go/sas"
 	"github.com/Azure/azure-event-hubs-go/mgmt"
@@ -59,6 +58,37 @@ type (
 		senderMu          sync.Mutex
 		offsetPersister   persist.CheckpointPersister
 		userAgent         string
+		maxMessageSize    uint64
+		compressionCodec  Codec
+		encryptor         Encryptor
+		retryPolicy       RetryPolicy
+		sendWindow        int
+		sendSlotsCh       chan struct{}
+		sendSlotsOnce     sync.Once
+		readers           map[*PartitionReader]struct{}
+		consumerGroups    consumerGroupsClient
+		resourceGroup     string
+		// linkRecoveryHandler is notified as sender and receiver links fail and are recovered
+		linkRecoveryHandler LinkRecoveryHandler
+		// sendMetrics records each completed send
+		sendMetrics SendMetricsRecorder
+		// sendConfirmation is invoked with each event once its transfer has settled
+		sendConfirmation func(event *Event, err error)
+		// sendInterceptors are invoked in order with each event before it is sent
+		sendInterceptors []func(event *Event)
+		// receiveInterceptors are invoked in order with each received event before it is handed to the handler
+		receiveInterceptors []ReceiveInterceptor
+		// receiverPool is the connection shared by receivers opened with ReceiveForConsumerGroup
+		receiverPool     *ConnectionPool
+		receiverPoolOnce sync.Once
+		// connections is the pool from which a Hub created by ConnectionPool.NewHub draws its connections
+		connections *ConnectionPool
+		// logger receives the log messages of the Hub, its sender and receivers, if set
+		logger Logger
+		// orderedSend keeps at most one transfer unsettled, and lastOrderedSend is the last send started by SendAsync
+		orderedSend     bool
+		orderedMu       sync.Mutex
+		lastOrderedSend *SendFuture
 	}
 
 	// Handler is the function signature for any receiver of events
@@ -72,7 +102,7 @@ type (
 
 	// PartitionedReceiver provides the ability to receive messages from a given partition
 	PartitionedReceiver interface {
-		Receive(ctx context.Context, partitionID string, handler Handler, opts ...ReceiveOption) (ListenerHandle, error)
+		Receive(ctx context.Context, partitionID string, handler Handler, opts ...ReceiveOption) (*ListenerHandle, error)
 	}
 
 	// Manager provides the ability to query management node information about a node
@@ -85,6 +115,12 @@ type (
 	HubOption func(h *Hub) error
 )
 
+var (
+	_ Sender              = (*Hub)(nil)
+	_ PartitionedReceiver = (*Hub)(nil)
+	_ Manager             = (*Hub)(nil)
+)
+
 // NewHub creates a new Event Hub client for sending and receiving messages
 func NewHub(namespace, name string, tokenProvider auth.TokenProvider, opts ...HubOption) (*Hub, error) {
 	ns := newNamespace(namespace, tokenProvider, azure.PublicCloud)
@@ -211,45 +247,122 @@ func NewHubFromEnvironment(opts ...HubOption) (*Hub, error) {
 func (h *Hub) GetRuntimeInformation(ctx context.Context) (*mgmt.HubRuntimeInformation, error) {
 	span, ctx := h.startSpanFromContext(ctx, "eventhub.Hub.GetRuntimeInformation")
 	defer span.Finish()
-	client := mgmt.NewClient(h.namespace.name, h.name, h.namespace.tokenProvider, h.namespace.environment)
+	client := h.newManagementClient()
 	conn, err := h.namespace.newConnection()
 	if err != nil {
-		log.For(ctx).Error(err)
+		h.logFor(ctx).Error(err)
 		return nil, err
 	}
 	info, err := client.GetHubRuntimeInformation(ctx, conn)
 	if err != nil {
-		log.For(ctx).Error(err)
-		return nil, err
+		h.logFor(ctx).Error(err)
+		if busy := managementServerBusyError(err); busy != nil {
+			return nil, busy
+		}
+		return nil, withErrorKind(err)
 	}
 	return info, nil
 }
 
-// GetPartitionInformation fetches runtime information about a specific partition from the Event Hub management node
+// GetPartitionInformation fetches runtime information about a specific partition from the Event Hub management node,
+// including the beginning sequence number and the sequence number, offset and time of the last enqueued event
 func (h *Hub) GetPartitionInformation(ctx context.Context, partitionID string) (*mgmt.HubPartitionRuntimeInformation, error) {
 	span, ctx := h.startSpanFromContext(ctx, "eventhub.Hub.GetPartitionInformation")
 	defer span.Finish()
-	client := mgmt.NewClient(h.namespace.name, h.name, h.namespace.tokenProvider, h.namespace.environment)
+	client := h.newManagementClient()
 	conn, err := h.namespace.newConnection()
 	if err != nil {
 		return nil, err
 	}
 	info, err := client.GetHubPartitionRuntimeInformation(ctx, conn, partitionID)
 	if err != nil {
-		return nil, err
+		if busy := managementServerBusyError(err); busy != nil {
+			return nil, busy
+		}
+		return nil, withErrorKind(err)
 	}
 	return info, nil
 }
 
+func (h *Hub) newManagementClient() *mgmt.Client {
+	var opts []mgmt.ClientOption
+	if h.retryPolicy != nil {
+		opts = append(opts, mgmt.ClientWithRetryPolicy(h.retryPolicy))
+	}
+	return mgmt.NewClient(h.namespace.name, h.name, h.namespace.tokenProvider, h.namespace.environment, opts...)
+}
+
 // Close drains and closes all of the existing senders, receivers and connections
 func (h *Hub) Close(ctx context.Context) error {
 	span, ctx := h.startSpanFromContext(ctx, "eventhub.Hub.Close")
 	defer span.Finish()
 
+	lastErr := h.closeReceivers(ctx, func(r *receiver) bool { return true })
+	if err := h.CloseSender(ctx); err != nil {
+		h.logFor(ctx).Error(err)
+		lastErr = err
+	}
+	return lastErr
+}
+
+// CloseSender stops the Hub from starting sends on its sender, waits for the sends in flight to be settled by the
+// broker or fail, then closes the sender link. If the context is done before the sends in flight complete, the link is
+// closed regardless and those sends fail. Receivers are left open and a later send opens a new sender.
+func (h *Hub) CloseSender(ctx context.Context) error {
+	span, ctx := h.startSpanFromContext(ctx, "eventhub.Hub.CloseSender")
+	defer span.Finish()
+
+	h.senderMu.Lock()
+	s := h.sender
+	h.sender = nil
+	h.senderMu.Unlock()
+
+	if s == nil {
+		return nil
+	}
+
+	drainErr := s.drain(ctx)
+	if err := s.Close(ctx); err != nil {
+		return err
+	}
+	return drainErr
+}
+
+// CloseReceiver closes the receivers and PartitionReaders of the Hub reading from the partition, leaving the sender
+// and other receivers open
+func (h *Hub) CloseReceiver(ctx context.Context, partitionID string) error {
+	span, ctx := h.startSpanFromContext(ctx, "eventhub.Hub.CloseReceiver")
+	defer span.Finish()
+
+	return h.closeReceivers(ctx, func(r *receiver) bool { return r.partitionID == partitionID })
+}
+
+// closeReceivers closes and removes the receivers and PartitionReaders which match
+func (h *Hub) closeReceivers(ctx context.Context, match func(r *receiver) bool) error {
+	h.receiverMu.Lock()
+	var closing []*receiver
+	for id, r := range h.receivers {
+		if match(r) {
+			closing = append(closing, r)
+			delete(h.receivers, id)
+		}
+	}
+	h.receiverMu.Unlock()
+
 	var lastErr error
-	for _, r := range h.receivers {
+	for _, r := range closing {
 		if err := r.Close(ctx); err != nil {
-			log.For(ctx).Error(err)
+			h.logFor(ctx).Error(err)
+			lastErr = err
+		}
+	}
+
+	for _, pr := range h.partitionReaders() {
+		if !match(pr.r) {
+			continue
+		}
+		if err := pr.Close(ctx); err != nil {
+			h.logFor(ctx).Error(err)
 			lastErr = err
 		}
 	}
@@ -272,7 +385,7 @@ func (h *Hub) Receive(ctx context.Context, partitionID string, handler Handler,
 	// Todo: change this to use name rather than identifier
 	if r, ok := h.receivers[receiver.getIdentifier()]; ok {
 		if err := r.Close(ctx); err != nil {
-			log.For(ctx).Error(err)
+			h.logFor(ctx).Error(err)
 		}
 	}
 
@@ -287,12 +400,32 @@ func (h *Hub) Send(ctx context.Context, event *Event, opts ...SendOption) error
 	span, ctx := h.startSpanFromContext(ctx, "eventhub.Hub.Send")
 	defer span.Finish()
 
-	sender, err := h.getSender(ctx)
+	sender, err := h.reserveSender(ctx)
+	if err != nil {
+		return err
+	}
+	defer sender.endSend()
+
+	return h.send(ctx, sender, event, opts...)
+}
+
+// send compresses and sends the event on a sender reserved with reserveSender
+func (h *Hub) send(ctx context.Context, sender *sender, original *Event, opts ...SendOption) error {
+	h.interceptSend(original)
+	event, err := h.compressEvent(original)
+	if err != nil {
+		return err
+	}
+	event, err = h.encryptEvent(event)
 	if err != nil {
 		return err
 	}
 
-	return sender.Send(ctx, event, opts...)
+	err = sender.Send(ctx, event, opts...)
+	h.recordSend(sender, len(event.Data), err)
+	err = event.scheduledSendError(err)
+	h.confirmSend(err, original)
+	return err
 }
 
 // SendBatch sends an EventBatch to the Event Hub
@@ -300,7 +433,19 @@ func (h *Hub) SendBatch(ctx context.Context, batch *EventBatch, opts ...SendOpti
 	span, ctx := h.startSpanFromContext(ctx, "eventhub.Hub.SendBatch")
 	defer span.Finish()
 
-	sender, err := h.getSender(ctx)
+	sender, err := h.reserveSender(ctx)
+	if err != nil {
+		return err
+	}
+	defer sender.endSend()
+
+	originals := batch.Events
+	h.interceptSend(originals...)
+	batch, err = h.compressBatch(batch)
+	if err != nil {
+		return err
+	}
+	batch, err = h.encryptBatch(batch)
 	if err != nil {
 		return err
 	}
@@ -310,10 +455,16 @@ func (h *Hub) SendBatch(ctx context.Context, batch *EventBatch, opts ...SendOpti
 		return err
 	}
 
-	return sender.Send(ctx, event, opts...)
+	err = sender.Send(ctx, event, opts...)
+	h.recordSend(sender, batch.size(), err)
+	err = event.scheduledSendError(err)
+	h.confirmSend(err, originals...)
+	return err
 }
 
-// HubWithPartitionedSender configures the Hub instance to send to a specific event Hub partition
+// HubWithPartitionedSender configures the Hub instance to send to a specific event Hub partition. The sender link is
+// opened to the partition directly, so events are not routed by the broker and sending an event or batch with a
+// partition key returns an error.
 func HubWithPartitionedSender(partitionID string) HubOption {
 	return func(h *Hub) error {
 		h.senderPartitionID = &partitionID
@@ -330,6 +481,19 @@ func HubWithOffsetPersistence(offsetPersister persist.CheckpointPersister) HubOp
 	}
 }
 
+// HubWithMaxMessageSize limits the largest message, in bytes, the Hub will send. The sender link negotiates the smaller
+// of this size and the max message size of the broker, which is used to size batches with SendBatchChunked and
+// BatchBuilder. Without it, the size negotiated on the sender link is the max message size offered by the broker.
+func HubWithMaxMessageSize(size uint64) HubOption {
+	return func(h *Hub) error {
+		if size == 0 {
+			return errors.New("max message size must be greater than 0")
+		}
+		h.maxMessageSize = size
+		return nil
+	}
+}
+
 // HubWithUserAgent configures the Hub to append the given string to the user agent sent to the server
 //
 // This option can be specified multiple times to add additional segments.
@@ -370,10 +534,24 @@ func (h *Hub) getSender(ctx context.Context) (*sender, error) {
 	if h.sender == nil {
 		s, err := h.newSender(ctx)
 		if err != nil {
-			log.For(ctx).Error(err)
+			h.logFor(ctx).Error(err)
 			return nil, err
 		}
 		h.sender = s
 	}
 	return h.sender, nil
 }
+
+// reserveSender returns the sender of the Hub with a send counted in flight, so CloseSender waits for it. The caller
+// must call endSend once the send completes.
+func (h *Hub) reserveSender(ctx context.Context) (*sender, error) {
+	sender, err := h.getSender(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := sender.startSend(); err != nil {
+		return nil, err
+	}
+	return sender, nil
+}
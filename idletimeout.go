@@ -0,0 +1,69 @@
+package eventhub
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"runtime"
+	"time"
+
+	"github.com/pkg/errors"
+	"pack.ag/amqp"
+)
+
+const (
+	// MinIdleTimeout is the shortest AMQP connection idle timeout accepted by HubWithIdleTimeout, below which the
+	// broker would be asked to send heartbeats more often than it supports
+	MinIdleTimeout = 5 * time.Second
+)
+
+// HubWithIdleTimeout sets the idle timeout the Hub's AMQP connections announce when they are opened. The broker sends
+// an empty frame whenever the connection would otherwise be silent for half of the idle timeout, which keeps the
+// connection alive through load balancers and proxies that drop idle connections sooner than the default of one
+// minute. An error is returned if the idle timeout is less than MinIdleTimeout. The option can't be used with
+// ConnectionPool.NewHub, as the connections of the pool are opened with the default idle timeout.
+func HubWithIdleTimeout(idleTimeout time.Duration) HubOption {
+	return func(h *Hub) error {
+		if idleTimeout < MinIdleTimeout {
+			return errors.Errorf("idle timeout %v is less than the minimum of %v", idleTimeout, MinIdleTimeout)
+		}
+		h.namespace.idleTimeout = idleTimeout
+		return nil
+	}
+}
+
+// connectionOptions returns the options with which the connections of the namespace are opened
+func (ns *namespace) connectionOptions() []amqp.ConnOption {
+	opts := []amqp.ConnOption{
+		amqp.ConnSASLAnonymous(),
+		amqp.ConnMaxSessions(65535),
+		amqp.ConnProperty("product", "MSGolangClient"),
+		amqp.ConnProperty("version", Version),
+		amqp.ConnProperty("platform", runtime.GOOS),
+		amqp.ConnProperty("framework", runtime.Version()),
+		amqp.ConnProperty("user-agent", rootUserAgent),
+	}
+	if ns.idleTimeout > 0 {
+		opts = append(opts, amqp.ConnIdleTimeout(ns.idleTimeout))
+	}
+	return opts
+}
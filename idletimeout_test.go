@@ -0,0 +1,44 @@
+package eventhub
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHubWithIdleTimeout(t *testing.T) {
+	_, err := NewHub("namespace", "hub", nil, HubWithIdleTimeout(time.Second))
+	assert.Error(t, err, "idle timeouts below the minimum should be rejected")
+
+	hub, err := NewHub("namespace", "hub", nil)
+	if assert.NoError(t, err) {
+		defaults := len(hub.namespace.connectionOptions())
+
+		assert.NoError(t, HubWithIdleTimeout(20*time.Second)(hub))
+		assert.Equal(t, 20*time.Second, hub.namespace.idleTimeout)
+		assert.Len(t, hub.namespace.connectionOptions(), defaults+1, "the idle timeout should be set on new connections")
+	}
+}
@@ -0,0 +1,54 @@
+package eventhub
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"github.com/Azure/azure-event-hubs-go/mgmt"
+	"github.com/pkg/errors"
+)
+
+const (
+	// MaxLinkNameLength is the longest link name accepted by ReceiveWithLinkName
+	MaxLinkNameLength = 64
+
+	// receiverNameKey is the link property naming a receiver, which the broker reports in its diagnostics
+	receiverNameKey = mgmt.MsftVendor + ":receiver-name"
+)
+
+// ReceiveWithLinkName names the receiver link so it can be found in broker-side diagnostics, such as when working with
+// support. The name is sent as the com.microsoft:receiver-name property of the link, as the AMQP client generates the
+// name of the link itself, and is kept when the link is recovered. It is also set on the log messages and spans of the
+// receiver. Names should be unique among the receivers of a partition and consumer group, and at most
+// MaxLinkNameLength characters long.
+func ReceiveWithLinkName(name string) ReceiveOption {
+	return func(receiver *receiver) error {
+		if name == "" {
+			return errors.New("link name must not be empty")
+		}
+		if len(name) > MaxLinkNameLength {
+			return errors.Errorf("link name %q must be at most %d characters long", name, MaxLinkNameLength)
+		}
+		receiver.linkName = name
+		return nil
+	}
+}
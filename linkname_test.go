@@ -0,0 +1,51 @@
+package eventhub
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReceiveWithLinkName(t *testing.T) {
+	r, err := newTestReceiver(ReceiveWithLinkName("orders-host-1-partition-0"))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "orders-host-1-partition-0", r.linkName)
+
+	logger := new(recordingLogger)
+	r.hub.logger = logger
+	r.logFor(context.Background()).Info("opened")
+	if assert.Len(t, logger.entries, 1) {
+		assert.Contains(t, logger.entries[0], "linkName orders-host-1-partition-0")
+	}
+
+	_, err = newTestReceiver(ReceiveWithLinkName(""))
+	assert.Error(t, err)
+	_, err = newTestReceiver(ReceiveWithLinkName(strings.Repeat("a", MaxLinkNameLength+1)))
+	assert.Error(t, err)
+}
@@ -0,0 +1,94 @@
+package eventhub
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// SenderLinkDetached is reported when a send fails and the sender link is about to be recovered
+	SenderLinkDetached = "sender-detached"
+	// SenderLinkRecovering is reported before each attempt to recover the sender link
+	SenderLinkRecovering = "sender-recovering"
+	// SenderLinkRecovered is reported once the sender link has been recovered
+	SenderLinkRecovered = "sender-recovered"
+	// ReceiverLinkDetached is reported when receiving fails and the receiver link is about to be recovered
+	ReceiverLinkDetached = "receiver-detached"
+	// ReceiverLinkRecovering is reported before each attempt to recover the receiver link
+	ReceiverLinkRecovering = "receiver-recovering"
+	// ReceiverLinkRecovered is reported once the receiver link has been recovered
+	ReceiverLinkRecovered = "receiver-recovered"
+)
+
+type (
+	// LinkRecoveryHandler is notified as sender and receiver links fail and are recovered. kind is one of the
+	// SenderLink and ReceiverLink constants and err is the error which triggered the recovery.
+	LinkRecoveryHandler func(kind string, err error)
+)
+
+// HubWithLinkRecoveryHandler configures the Hub to notify the handler when its sender and receiver links fail, before
+// each recovery attempt and when a link has been recovered. The handler is invoked synchronously on the send and
+// receive paths, so it should return quickly, such as after recording a metric.
+func HubWithLinkRecoveryHandler(handler LinkRecoveryHandler) HubOption {
+	return func(h *Hub) error {
+		if handler == nil {
+			return errors.New("link recovery handler must not be nil")
+		}
+		h.linkRecoveryHandler = handler
+		return nil
+	}
+}
+
+func (h *Hub) notifyLinkRecovery(kind string, err error) {
+	if h.linkRecoveryHandler != nil {
+		h.linkRecoveryHandler(kind, err)
+	}
+}
+
+// recoverLink rebuilds the sender link after the error and notifies the link recovery handler of the attempt and its
+// success
+func (s *sender) recoverLink(ctx context.Context, cause error) error {
+	s.hub.notifyLinkRecovery(SenderLinkRecovering, cause)
+	if err := s.Recover(ctx); err != nil {
+		return err
+	}
+	s.hub.notifyLinkRecovery(SenderLinkRecovered, cause)
+	s.logFor(ctx).Info(fmt.Sprintf("sender link recovered after: %v", cause))
+	return nil
+}
+
+// recoverLink rebuilds the receiver link after the error and notifies the link recovery handler of the attempt and
+// its success
+func (r *receiver) recoverLink(ctx context.Context, cause error) error {
+	r.hub.notifyLinkRecovery(ReceiverLinkRecovering, cause)
+	if err := r.Recover(ctx); err != nil {
+		return err
+	}
+	r.hub.notifyLinkRecovery(ReceiverLinkRecovered, cause)
+	r.logFor(ctx).Info(fmt.Sprintf("receiver link recovered after: %v", cause))
+	return nil
+}
@@ -0,0 +1,57 @@
+package eventhub
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHubWithLinkRecoveryHandler(t *testing.T) {
+	hub := new(Hub)
+	assert.Error(t, HubWithLinkRecoveryHandler(nil)(hub))
+
+	// notifying without a handler is a no-op
+	hub.notifyLinkRecovery(SenderLinkDetached, errors.New("detached"))
+
+	type recovery struct {
+		kind string
+		err  error
+	}
+	var recoveries []recovery
+	assert.NoError(t, HubWithLinkRecoveryHandler(func(kind string, err error) {
+		recoveries = append(recoveries, recovery{kind: kind, err: err})
+	})(hub))
+
+	cause := errors.New("detached")
+	hub.notifyLinkRecovery(ReceiverLinkDetached, cause)
+	hub.notifyLinkRecovery(ReceiverLinkRecovering, cause)
+	hub.notifyLinkRecovery(ReceiverLinkRecovered, cause)
+	assert.Equal(t, []recovery{
+		{kind: "receiver-detached", err: cause},
+		{kind: "receiver-recovering", err: cause},
+		{kind: "receiver-recovered", err: cause},
+	}, recoveries)
+}
@@ -0,0 +1,109 @@
+package eventhub
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"context"
+
+	"github.com/Azure/azure-amqp-common-go/log"
+	"github.com/pkg/errors"
+)
+
+type (
+	// Logger is a minimal leveled logger to which a Hub writes alongside its tracing spans, so the internals of the
+	// library can be routed to a structured logger such as zap or zerolog with a small adapter. Each message is
+	// followed by alternating keys and values, such as "hub" and the name of the Event Hub.
+	Logger interface {
+		Debug(msg string, keysAndValues ...interface{})
+		Info(msg string, keysAndValues ...interface{})
+		Error(msg string, keysAndValues ...interface{})
+	}
+
+	// contextLogger writes to the span of a context, as log.For does, and to a Logger with the keys and values
+	// identifying the writer
+	contextLogger struct {
+		ctx           context.Context
+		logger        Logger
+		keysAndValues []interface{}
+	}
+)
+
+// HubWithLogger configures the Hub to write to the logger as it creates links, sends, receives and recovers, in
+// addition to logging to its tracing spans
+func HubWithLogger(logger Logger) HubOption {
+	return func(h *Hub) error {
+		if logger == nil {
+			return errors.New("logger must not be nil")
+		}
+		h.logger = logger
+		h.namespace.logger = logger
+		return nil
+	}
+}
+
+func newContextLogger(ctx context.Context, logger Logger, keysAndValues ...interface{}) *contextLogger {
+	return &contextLogger{ctx: ctx, logger: logger, keysAndValues: keysAndValues}
+}
+
+// Debug writes a debug message
+func (l *contextLogger) Debug(msg string) {
+	log.For(l.ctx).Debug(msg)
+	if l.logger != nil {
+		l.logger.Debug(msg, l.keysAndValues...)
+	}
+}
+
+// Info writes an informational message
+func (l *contextLogger) Info(msg string) {
+	log.For(l.ctx).Info(msg)
+	if l.logger != nil {
+		l.logger.Info(msg, l.keysAndValues...)
+	}
+}
+
+// Error writes an error
+func (l *contextLogger) Error(err error) {
+	log.For(l.ctx).Error(err)
+	if l.logger != nil {
+		l.logger.Error(err.Error(), l.keysAndValues...)
+	}
+}
+
+func (h *Hub) logFor(ctx context.Context) *contextLogger {
+	return newContextLogger(ctx, h.logger, "hub", h.name)
+}
+
+func (s *sender) logFor(ctx context.Context) *contextLogger {
+	if s.partitionID != nil {
+		return newContextLogger(ctx, s.hub.logger, "hub", s.hub.name, "partitionID", *s.partitionID)
+	}
+	return s.hub.logFor(ctx)
+}
+
+func (r *receiver) logFor(ctx context.Context) *contextLogger {
+	keysAndValues := []interface{}{"hub", r.hub.name, "consumerGroup", r.consumerGroup, "partitionID", r.partitionID}
+	if r.linkName != "" {
+		keysAndValues = append(keysAndValues, "linkName", r.linkName)
+	}
+	return newContextLogger(ctx, r.hub.logger, keysAndValues...)
+}
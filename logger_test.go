@@ -0,0 +1,85 @@
+package eventhub
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type recordingLogger struct {
+	entries []string
+}
+
+func (l *recordingLogger) record(level, msg string, keysAndValues []interface{}) {
+	l.entries = append(l.entries, fmt.Sprint(level, " ", msg, " ", keysAndValues))
+}
+
+func (l *recordingLogger) Debug(msg string, keysAndValues ...interface{}) {
+	l.record("debug", msg, keysAndValues)
+}
+
+func (l *recordingLogger) Info(msg string, keysAndValues ...interface{}) {
+	l.record("info", msg, keysAndValues)
+}
+
+func (l *recordingLogger) Error(msg string, keysAndValues ...interface{}) {
+	l.record("error", msg, keysAndValues)
+}
+
+func TestHubWithLogger(t *testing.T) {
+	_, err := NewHub("namespace", "hub", nil, HubWithLogger(nil))
+	assert.Error(t, err)
+
+	logger := new(recordingLogger)
+	hub, err := NewHub("namespace", "hub", nil, HubWithLogger(logger), HubWithPartitionedSender("1"))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	ctx := context.Background()
+	hub.logFor(ctx).Debug("hello")
+	s := &sender{hub: hub, partitionID: hub.senderPartitionID}
+	s.logFor(ctx).Error(errors.New("boom"))
+	r := &receiver{hub: hub, consumerGroup: DefaultConsumerGroup, partitionID: "0"}
+	r.logFor(ctx).Info("received")
+
+	assert.Equal(t, []string{
+		"debug hello [hub hub]",
+		"error boom [hub hub partitionID 1]",
+		"info received [hub hub consumerGroup $Default partitionID 0]",
+	}, logger.entries)
+}
+
+func TestContextLoggerWithoutLogger(t *testing.T) {
+	hub, err := NewHub("namespace", "hub", nil)
+	if assert.NoError(t, err) {
+		assert.NotPanics(t, func() {
+			hub.logFor(context.Background()).Error(errors.New("boom"))
+		})
+	}
+}
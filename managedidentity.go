@@ -0,0 +1,133 @@
+package eventhub
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"sync"
+
+	"github.com/Azure/azure-amqp-common-go/auth"
+	"github.com/Azure/go-autorest/autorest/adal"
+	"github.com/pkg/errors"
+)
+
+const (
+	// eventHubsResourceURI is the Azure Active Directory resource tokens for Event Hubs are issued for
+	eventHubsResourceURI = "https://eventhubs.azure.net/"
+)
+
+type (
+	// ManagedIdentityTokenProvider provides Azure Active Directory tokens for the managed identity of the Azure
+	// resource, such as a VM, the process runs on. Tokens are fetched from the Azure Instance Metadata Service on first
+	// use and refreshed as they near expiry.
+	ManagedIdentityTokenProvider struct {
+		clientID string
+		endpoint string
+		token    *adal.ServicePrincipalToken
+		tokenMu  sync.Mutex
+	}
+
+	// ManagedIdentityOption provides a way to configure a ManagedIdentityTokenProvider
+	ManagedIdentityOption func(provider *ManagedIdentityTokenProvider) error
+)
+
+var (
+	_ auth.TokenProvider = (*ManagedIdentityTokenProvider)(nil)
+)
+
+// ManagedIdentityWithClientID configures the provider to use the user assigned managed identity with the client ID,
+// rather than the system assigned identity of the resource
+func ManagedIdentityWithClientID(clientID string) ManagedIdentityOption {
+	return func(provider *ManagedIdentityTokenProvider) error {
+		if clientID == "" {
+			return errors.New("client ID of a user assigned identity must not be empty")
+		}
+		provider.clientID = clientID
+		return nil
+	}
+}
+
+// NewManagedIdentityTokenProvider creates a token provider for the managed identity of the Azure resource the process
+// runs on. By default the system assigned identity is used.
+func NewManagedIdentityTokenProvider(opts ...ManagedIdentityOption) (*ManagedIdentityTokenProvider, error) {
+	endpoint, err := adal.GetMSIVMEndpoint()
+	if err != nil {
+		return nil, err
+	}
+
+	provider := &ManagedIdentityTokenProvider{endpoint: endpoint}
+	for _, opt := range opts {
+		if err := opt(provider); err != nil {
+			return nil, err
+		}
+	}
+	return provider, nil
+}
+
+// NewHubWithManagedIdentity creates a new Event Hub client authenticated with the managed identity of the Azure
+// resource the process runs on. The system assigned identity is used unless HubWithManagedIdentityClientID is given.
+func NewHubWithManagedIdentity(namespace, name string, opts ...HubOption) (*Hub, error) {
+	provider, err := NewManagedIdentityTokenProvider()
+	if err != nil {
+		return nil, err
+	}
+	return NewHub(namespace, name, provider, opts...)
+}
+
+// HubWithManagedIdentityClientID configures a Hub created with NewHubWithManagedIdentity to use the user assigned
+// managed identity with the client ID
+func HubWithManagedIdentityClientID(clientID string) HubOption {
+	return func(h *Hub) error {
+		provider, ok := h.namespace.tokenProvider.(*ManagedIdentityTokenProvider)
+		if !ok {
+			return errors.New("a managed identity client ID can only be set on a Hub created with NewHubWithManagedIdentity")
+		}
+		return ManagedIdentityWithClientID(clientID)(provider)
+	}
+}
+
+// GetToken returns an Azure Active Directory token for Event Hubs issued to the managed identity
+func (p *ManagedIdentityTokenProvider) GetToken(audience string) (*auth.Token, error) {
+	p.tokenMu.Lock()
+	defer p.tokenMu.Unlock()
+
+	if p.token == nil {
+		var token *adal.ServicePrincipalToken
+		var err error
+		if p.clientID != "" {
+			token, err = adal.NewServicePrincipalTokenFromMSIWithUserAssignedID(p.endpoint, eventHubsResourceURI, p.clientID)
+		} else {
+			token, err = adal.NewServicePrincipalTokenFromMSI(p.endpoint, eventHubsResourceURI)
+		}
+		if err != nil {
+			return nil, err
+		}
+		p.token = token
+	}
+
+	if err := p.token.EnsureFresh(); err != nil {
+		return nil, err
+	}
+
+	token := p.token.Token()
+	return auth.NewToken(auth.CBSTokenTypeJWT, token.AccessToken, token.ExpiresOn), nil
+}
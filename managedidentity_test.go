@@ -0,0 +1,48 @@
+package eventhub
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewHubWithManagedIdentity(t *testing.T) {
+	hub, err := NewHubWithManagedIdentity("namespace", "hub", HubWithManagedIdentityClientID("client"))
+	if assert.NoError(t, err) {
+		provider, ok := hub.namespace.tokenProvider.(*ManagedIdentityTokenProvider)
+		if assert.True(t, ok) {
+			assert.Equal(t, "client", provider.clientID)
+			assert.NotEmpty(t, provider.endpoint)
+		}
+	}
+
+	_, err = NewHubWithManagedIdentity("namespace", "hub", HubWithManagedIdentityClientID(""))
+	assert.Error(t, err)
+
+	_, err = NewHub("namespace", "hub", new(ManagedIdentityTokenProvider), HubWithManagedIdentityClientID("client"))
+	assert.NoError(t, err)
+	_, err = NewHub("namespace", "hub", nil, HubWithManagedIdentityClientID("client"))
+	assert.Error(t, err, "a client ID requires a managed identity token provider")
+}
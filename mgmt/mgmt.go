@@ -26,6 +26,7 @@ package mgmt
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"time"
 
 	"github.com/Azure/azure-amqp-common-go/auth"
@@ -49,6 +50,7 @@ const (
 	operationKey        = "operation"
 	readOperationKey    = "READ"
 	address             = "$management"
+	serverBusyCondition = MsftVendor + ":server-busy"
 )
 
 type (
@@ -58,6 +60,15 @@ type (
 		hubName       string
 		tokenProvider auth.TokenProvider
 		env           azure.Environment
+		retryPolicy   RetryPolicy
+	}
+
+	// ClientOption provides structure for configuring a new management Client
+	ClientOption func(c *Client)
+
+	// RetryPolicy decides whether a failed management operation should be attempted again, and after what delay
+	RetryPolicy interface {
+		ShouldRetry(err error, attempt int) (bool, time.Duration)
 	}
 
 	// HubRuntimeInformation provides management node information about a given Event Hub instance
@@ -80,13 +91,26 @@ type (
 )
 
 // NewClient constructs a new AMQP management client
-func NewClient(namespace, hubName string, provider auth.TokenProvider, env azure.Environment) *Client {
-	return &Client{
+func NewClient(namespace, hubName string, provider auth.TokenProvider, env azure.Environment, opts ...ClientOption) *Client {
+	c := &Client{
 		namespace:     namespace,
 		hubName:       hubName,
 		tokenProvider: provider,
 		env:           env,
 	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// ClientWithRetryPolicy configures the Client to retry failed management operations according to the policy rather
+// than the default of 3 attempts 1 second apart
+func ClientWithRetryPolicy(policy RetryPolicy) ClientOption {
+	return func(c *Client) {
+		c.retryPolicy = policy
+	}
 }
 
 // GetHubRuntimeInformation requests runtime information for an Event Hub
@@ -111,7 +135,7 @@ func (c *Client) GetHubRuntimeInformation(ctx context.Context, conn *amqp.Client
 		return nil, err
 	}
 
-	res, err := rpcLink.RetryableRPC(ctx, 3, 1*time.Second, msg)
+	res, err := c.rpc(ctx, rpcLink, msg)
 	if err != nil {
 		return nil, err
 	}
@@ -146,7 +170,7 @@ func (c *Client) GetHubPartitionRuntimeInformation(ctx context.Context, conn *am
 		return nil, err
 	}
 
-	res, err := rpcLink.RetryableRPC(ctx, 3, 1*time.Second, msg)
+	res, err := c.rpc(ctx, rpcLink, msg)
 	if err != nil {
 		return nil, err
 	}
@@ -158,6 +182,68 @@ func (c *Client) GetHubPartitionRuntimeInformation(ctx context.Context, conn *am
 	return hubPartitionRuntimeInfo, nil
 }
 
+func (c *Client) rpc(ctx context.Context, rpcLink *rpc.Link, msg *amqp.Message) (*rpc.Response, error) {
+	if c.retryPolicy == nil {
+		res, err := rpcLink.RetryableRPC(ctx, 3, 1*time.Second, msg)
+		if failed := responseError(res); failed != nil {
+			return nil, failed
+		}
+		return res, err
+	}
+
+	for attempt := 1; ; attempt++ {
+		res, err := rpcLink.RPC(ctx, msg)
+		if err == nil {
+			err = responseError(res)
+		}
+		if err == nil {
+			return res, nil
+		}
+
+		retry, delay := c.retryPolicy.ShouldRetry(err, attempt)
+		if !retry {
+			return nil, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, err
+		case <-time.After(delay):
+		}
+	}
+}
+
+// throttledError returns the server busy error of a response throttled by the management node, which describes how
+// long to wait before trying again, or nil if the response was not throttled
+func throttledError(res *rpc.Response) error {
+	if res == nil || (res.Code != http.StatusServiceUnavailable && res.Code != http.StatusTooManyRequests) {
+		return nil
+	}
+	return &amqp.Error{
+		Condition:   serverBusyCondition,
+		Description: res.Description,
+	}
+}
+
+// responseError returns an AMQP error with the condition matching the status of a failed response, which is either
+// throttled or rejected because the caller is unauthorized or the Event Hub does not exist, or nil otherwise
+func responseError(res *rpc.Response) error {
+	if busy := throttledError(res); busy != nil {
+		return busy
+	}
+	if res == nil {
+		return nil
+	}
+
+	switch res.Code {
+	case http.StatusUnauthorized:
+		return &amqp.Error{Condition: amqp.ErrorUnauthorizedAccess, Description: res.Description}
+	case http.StatusNotFound:
+		return &amqp.Error{Condition: amqp.ErrorNotFound, Description: res.Description}
+	}
+	return nil
+}
+
 func (c *Client) addSecurityToken(msg *amqp.Message) (*amqp.Message, error) {
 	token, err := c.tokenProvider.GetToken(c.getTokenAudience())
 	if err != nil {
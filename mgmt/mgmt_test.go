@@ -0,0 +1,88 @@
+package mgmt
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-amqp-common-go/rpc"
+	"github.com/stretchr/testify/assert"
+	"pack.ag/amqp"
+)
+
+func TestThrottledError(t *testing.T) {
+	assert.Nil(t, throttledError(nil))
+	assert.Nil(t, throttledError(&rpc.Response{Code: http.StatusOK}))
+
+	err := throttledError(&rpc.Response{Code: http.StatusServiceUnavailable, Description: "Please wait 4 seconds and try again."})
+	if amqpErr, ok := err.(*amqp.Error); assert.True(t, ok) {
+		assert.Equal(t, amqp.ErrorCondition(serverBusyCondition), amqpErr.Condition)
+		assert.Equal(t, "Please wait 4 seconds and try again.", amqpErr.Description)
+	}
+}
+
+func TestResponseError(t *testing.T) {
+	assert.Nil(t, responseError(nil))
+	assert.Nil(t, responseError(&rpc.Response{Code: http.StatusOK}))
+
+	tests := map[int]amqp.ErrorCondition{
+		http.StatusTooManyRequests: serverBusyCondition,
+		http.StatusUnauthorized:    amqp.ErrorUnauthorizedAccess,
+		http.StatusNotFound:        amqp.ErrorNotFound,
+	}
+	for code, condition := range tests {
+		err := responseError(&rpc.Response{Code: code, Description: "description"})
+		if amqpErr, ok := err.(*amqp.Error); assert.True(t, ok, "status %d", code) {
+			assert.Equal(t, condition, amqpErr.Condition)
+			assert.Equal(t, "description", amqpErr.Description)
+		}
+	}
+}
+
+func TestNewHubPartitionRuntimeInformation(t *testing.T) {
+	enqueued := time.Date(2018, 6, 1, 12, 0, 0, 0, time.UTC)
+	msg := &amqp.Message{
+		Value: map[string]interface{}{
+			"name":                          "hub",
+			"partition":                     "1",
+			"begin_sequence_number":         int64(10),
+			"last_enqueued_sequence_number": int64(42),
+			"last_enqueued_offset":          "4096",
+			"last_enqueued_time_utc":        enqueued,
+		},
+	}
+
+	info, err := newHubPartitionRuntimeInformation(msg)
+	if assert.NoError(t, err) {
+		assert.Equal(t, &HubPartitionRuntimeInformation{
+			HubPath:                 "hub",
+			PartitionID:             "1",
+			BeginningSequenceNumber: 10,
+			LastSequenceNumber:      42,
+			LastEnqueuedOffset:      "4096",
+			LastEnqueuedTimeUtc:     enqueued,
+		}, info)
+	}
+}
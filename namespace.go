@@ -23,12 +23,14 @@ package eventhub
 //	SOFTWARE
 
 import (
-	"context"
-	"runtime"
+	"crypto/tls"
+	"net/url"
+	"strings"
+	"time"
 
 	"github.com/Azure/azure-amqp-common-go/auth"
-	"github.com/Azure/azure-amqp-common-go/cbs"
 	"github.com/Azure/go-autorest/autorest/azure"
+	"github.com/pkg/errors"
 	"pack.ag/amqp"
 )
 
@@ -37,6 +39,21 @@ type (
 		name          string
 		tokenProvider auth.TokenProvider
 		environment   azure.Environment
+		// tokenRefreshBuffer is how long before expiry the claim of a link is renegotiated with a fresh token
+		tokenRefreshBuffer time.Duration
+		// logger receives the errors of background claim renegotiation, if set
+		logger Logger
+		// idleTimeout when greater than 0 is the idle timeout announced when connections are opened
+		idleTimeout time.Duration
+		// tlsConfig and proxyURL when set configure how connections are secured and routed
+		tlsConfig *tls.Config
+		proxyURL  *url.URL
+		// webSocket tunnels connections over WebSockets on port 443 rather than connecting to the AMQP port
+		webSocket bool
+		// endpoint when set is connected to in place of the host of the namespace, and audience when set is the base of
+		// the audiences of claims in place of the URI of the namespace
+		endpoint *url.URL
+		audience string
 	}
 )
 
@@ -51,30 +68,49 @@ func newNamespace(name string, tokenProvider auth.TokenProvider, env azure.Envir
 }
 
 func (ns *namespace) newConnection() (*amqp.Client, error) {
+	if ns.endpoint != nil && ns.endpoint.Scheme == amqpScheme && (ns.webSocket || ns.proxyURL != nil) {
+		return nil, errors.Errorf("endpoint %q without TLS can't be used with WebSocket connections or a proxy", ns.endpoint)
+	}
+	if ns.webSocket {
+		return ns.newWebSocketConnection()
+	}
+	if ns.proxyURL != nil {
+		return ns.newProxiedConnection()
+	}
+
 	host := ns.getAmqpHostURI()
-	return amqp.Dial(host,
-		amqp.ConnSASLAnonymous(),
-		amqp.ConnMaxSessions(65535),
-		amqp.ConnProperty("product", "MSGolangClient"),
-		amqp.ConnProperty("version", Version),
-		amqp.ConnProperty("platform", runtime.GOOS),
-		amqp.ConnProperty("framework", runtime.Version()),
-		amqp.ConnProperty("user-agent", rootUserAgent),
-	)
+	opts := ns.connectionOptions()
+	if ns.tlsConfig != nil {
+		opts = append(opts, amqp.ConnTLSConfig(ns.clientTLSConfig(ns.getHostname())))
+	}
+	return amqp.Dial(host, opts...)
 }
 
-func (ns *namespace) negotiateClaim(ctx context.Context, conn *amqp.Client, entityPath string) error {
-	span, ctx := ns.startSpanFromContext(ctx, "eventhub.namespace.negotiateClaim")
-	defer span.Finish()
+// getHostname returns the host connections are made to, which is the host of the namespace unless the endpoint has
+// been overridden
+func (ns *namespace) getHostname() string {
+	if ns.endpoint != nil {
+		return ns.endpoint.Hostname()
+	}
+	return ns.getNamespaceHostname()
+}
 
-	audience := ns.getEntityAudience(entityPath)
-	return cbs.NegotiateClaim(ctx, audience, conn, ns.tokenProvider)
+func (ns *namespace) getNamespaceHostname() string {
+	return ns.name + "." + ns.environment.ServiceBusEndpointSuffix
 }
 
 func (ns *namespace) getAmqpHostURI() string {
-	return "amqps://" + ns.name + "." + ns.environment.ServiceBusEndpointSuffix + "/"
+	if ns.endpoint != nil {
+		return ns.endpoint.Scheme + "://" + ns.endpoint.Host + "/"
+	}
+	return "amqps://" + ns.getHostname() + "/"
 }
 
+// getEntityAudience returns the audience of claims for the entity, which is computed from the namespace rather than
+// an overridden endpoint unless the audience has been overridden too
 func (ns *namespace) getEntityAudience(entityPath string) string {
-	return ns.getAmqpHostURI() + entityPath
+	if ns.audience != "" {
+		return strings.TrimSuffix(ns.audience, "/") + "/" + entityPath
+	}
+	return "amqps://" + ns.getNamespaceHostname() + "/" + entityPath
 }
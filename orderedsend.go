@@ -0,0 +1,64 @@
+package eventhub
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"github.com/pkg/errors"
+)
+
+// HubWithOrderedSend configures a Hub sending to a single partition with HubWithPartitionedSender to keep at most one
+// transfer unsettled at a time, so the broker enqueues events in the order they were sent even when sends are
+// retried. Each Send and SendBatch holds the sender until its transfer is settled or its retries are exhausted, and
+// SendAsync sends each event only once the send started before it has completed. A failed send is not retried after
+// later events, so stop sending on the first error if the order must hold. Throughput is limited to one round trip to
+// the broker per send.
+func HubWithOrderedSend() HubOption {
+	return func(h *Hub) error {
+		h.orderedSend = true
+		return nil
+	}
+}
+
+// validateOrderedSend returns an error if the Hub sends in order without sending to a single partition, as the broker
+// distributes the events of other senders across partitions
+func (h *Hub) validateOrderedSend() error {
+	if h.orderedSend && h.senderPartitionID == nil {
+		return errors.New("ordered sends require a sender to a single partition configured with HubWithPartitionedSender")
+	}
+	return nil
+}
+
+// chainOrderedSend records the future as the last send started by SendAsync and returns the future of the send
+// started before it, or nil if the Hub does not send in order
+func (h *Hub) chainOrderedSend(future *SendFuture) *SendFuture {
+	if !h.orderedSend {
+		return nil
+	}
+
+	h.orderedMu.Lock()
+	defer h.orderedMu.Unlock()
+
+	previous := h.lastOrderedSend
+	h.lastOrderedSend = future
+	return previous
+}
@@ -0,0 +1,57 @@
+package eventhub
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHubWithOrderedSendRequiresPartition(t *testing.T) {
+	hub, err := NewHub("namespace", "hub", nil, HubWithOrderedSend())
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.True(t, hub.orderedSend)
+
+	_, err = hub.newSender(context.Background())
+	assert.Error(t, err, "ordered sends without a partition should be rejected before the link is opened")
+
+	assert.NoError(t, HubWithPartitionedSender("0")(hub))
+	assert.NoError(t, hub.validateOrderedSend())
+}
+
+func TestChainOrderedSend(t *testing.T) {
+	hub, err := NewHub("namespace", "hub", nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Nil(t, hub.chainOrderedSend(new(SendFuture)), "sends should not be chained unless ordered")
+
+	hub.orderedSend = true
+	first, second := new(SendFuture), new(SendFuture)
+	assert.Nil(t, hub.chainOrderedSend(first))
+	assert.Equal(t, first, hub.chainOrderedSend(second), "each send should wait for the send started before it")
+}
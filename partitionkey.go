@@ -0,0 +1,110 @@
+package eventhub
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"encoding/binary"
+	"strconv"
+)
+
+// PartitionForKey returns the ID of the partition to which the broker assigns events with the partition key, for an
+// Event Hub with partitionCount partitions numbered from "0". It mirrors the partition resolution of the broker,
+// which hashes the UTF-8 bytes of the key with Jenkins' lookup3, so it agrees with how Send routes keyed events. An
+// empty string is returned if partitionCount is less than 1.
+func PartitionForKey(key string, partitionCount int) string {
+	if partitionCount < 1 {
+		return ""
+	}
+
+	primary, secondary := lookup3([]byte(key))
+	hash := int(int16(primary ^ secondary))
+	idx := hash % partitionCount
+	if idx < 0 {
+		idx = -idx
+	}
+	return strconv.Itoa(idx)
+}
+
+// lookup3 is hashlittle2 of Bob Jenkins' lookup3 with zero seeds, returning the primary and secondary hashes of the data
+func lookup3(data []byte) (uint32, uint32) {
+	a := 0xdeadbeef + uint32(len(data))
+	b, c := a, a
+
+	for len(data) > 12 {
+		a += binary.LittleEndian.Uint32(data)
+		b += binary.LittleEndian.Uint32(data[4:])
+		c += binary.LittleEndian.Uint32(data[8:])
+
+		a -= c
+		a ^= rotl(c, 4)
+		c += b
+		b -= a
+		b ^= rotl(a, 6)
+		a += c
+		c -= b
+		c ^= rotl(b, 8)
+		b += a
+		a -= c
+		a ^= rotl(c, 16)
+		c += b
+		b -= a
+		b ^= rotl(a, 19)
+		a += c
+		c -= b
+		c ^= rotl(b, 4)
+		b += a
+
+		data = data[12:]
+	}
+
+	if len(data) == 0 {
+		return c, b
+	}
+
+	// the remaining 1 to 12 bytes are added as little endian words, zero padded
+	var tail [12]byte
+	copy(tail[:], data)
+	a += binary.LittleEndian.Uint32(tail[:])
+	b += binary.LittleEndian.Uint32(tail[4:])
+	c += binary.LittleEndian.Uint32(tail[8:])
+
+	c ^= b
+	c -= rotl(b, 14)
+	a ^= c
+	a -= rotl(c, 11)
+	b ^= a
+	b -= rotl(a, 25)
+	c ^= b
+	c -= rotl(b, 16)
+	a ^= c
+	a -= rotl(c, 4)
+	b ^= a
+	b -= rotl(a, 14)
+	c ^= b
+	c -= rotl(b, 24)
+	return c, b
+}
+
+func rotl(x uint32, k uint) uint32 {
+	return (x << k) | (x >> (32 - k))
+}
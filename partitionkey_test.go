@@ -0,0 +1,56 @@
+package eventhub
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLookup3(t *testing.T) {
+	// expected hashes are those printed by the driver of the lookup3 reference implementation
+	primary, secondary := lookup3([]byte("Four score and seven years ago"))
+	assert.Equal(t, uint32(0x17770551), primary)
+	assert.Equal(t, uint32(0xce7226e6), secondary)
+
+	primary, secondary = lookup3(nil)
+	assert.Equal(t, uint32(0xdeadbeef), primary)
+	assert.Equal(t, uint32(0xdeadbeef), secondary)
+}
+
+func TestPartitionForKey(t *testing.T) {
+	assert.Equal(t, "", PartitionForKey("key", 0))
+	assert.Equal(t, "0", PartitionForKey("", 4), "an empty key hashes to 0")
+
+	for i := 0; i < 1000; i++ {
+		key := strconv.Itoa(i)
+		id := PartitionForKey(key, 32)
+		assert.Equal(t, id, PartitionForKey(key, 32), "partition assignment should be deterministic")
+		idx, err := strconv.Atoi(id)
+		if assert.NoError(t, err) {
+			assert.True(t, idx >= 0 && idx < 32, id)
+		}
+	}
+}
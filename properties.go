@@ -0,0 +1,117 @@
+package eventhub
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"fmt"
+
+	"pack.ag/amqp"
+)
+
+// SetMessageID sets the AMQP message-id property of the event, such as for deduplication downstream. It is the same
+// as setting ID, and events sent without a message ID are assigned a random one.
+func (e *Event) SetMessageID(messageID string) {
+	e.ID = messageID
+}
+
+// MessageID returns the AMQP message-id property of the event, or an empty string if it has none. Message IDs set by
+// other AMQP clients as a UUID, ulong or binary value are returned in their string form.
+func (e *Event) MessageID() string {
+	return e.ID
+}
+
+// SetContentType sets the AMQP content-type property of the event
+func (e *Event) SetContentType(contentType string) {
+	e.contentType = contentType
+}
+
+// ContentType returns the AMQP content-type property of the event, or an empty string if it has none
+func (e *Event) ContentType() string {
+	return e.contentType
+}
+
+// SetCorrelationID sets the AMQP correlation-id property of the event, such as to relate a reply to its request
+func (e *Event) SetCorrelationID(correlationID string) {
+	e.correlationID = correlationID
+}
+
+// CorrelationID returns the AMQP correlation-id property of the event, or an empty string if it has none. Correlation
+// IDs set by other AMQP clients as a UUID, ulong or binary value are returned in their string form.
+func (e *Event) CorrelationID() string {
+	return e.correlationID
+}
+
+// SetGroupID sets the AMQP group-id property of the event, identifying the message group, such as an ordering key,
+// to which the event belongs. Event Hubs does not enforce message groups, but the property is delivered with the event.
+func (e *Event) SetGroupID(groupID string) {
+	e.groupID = groupID
+}
+
+// GroupID returns the AMQP group-id property of the event, or an empty string if it has none
+func (e *Event) GroupID() string {
+	return e.groupID
+}
+
+// SetGroupSequence sets the AMQP group-sequence property of the event, the position of the event within its group
+func (e *Event) SetGroupSequence(groupSequence uint32) {
+	e.groupSequence = groupSequence
+}
+
+// GroupSequence returns the AMQP group-sequence property of the event, or 0 if it has none
+func (e *Event) GroupSequence() uint32 {
+	return e.groupSequence
+}
+
+// applyProperties sets the AMQP properties of the message held by the event
+func (e *Event) applyProperties(msg *amqp.Message) {
+	if e.ID == "" && e.contentType == "" && e.correlationID == "" && e.groupID == "" && e.groupSequence == 0 {
+		return
+	}
+
+	if msg.Properties == nil {
+		msg.Properties = new(amqp.MessageProperties)
+	}
+	if e.ID != "" {
+		msg.Properties.MessageID = e.ID
+	}
+	msg.Properties.ContentType = e.contentType
+	if e.correlationID != "" {
+		msg.Properties.CorrelationID = e.correlationID
+	}
+	msg.Properties.GroupID = e.groupID
+	msg.Properties.GroupSequence = e.groupSequence
+}
+
+// correlationIDString returns the correlation-id or message-id property in its string form
+func correlationIDString(correlationID interface{}) string {
+	switch id := correlationID.(type) {
+	case nil:
+		return ""
+	case string:
+		return id
+	case []byte:
+		return string(id)
+	default:
+		return fmt.Sprint(id)
+	}
+}
@@ -0,0 +1,93 @@
+package eventhub
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"pack.ag/amqp"
+)
+
+func TestEventProperties(t *testing.T) {
+	event := NewEventFromString("foo")
+	event.SetContentType("text/plain")
+	event.SetCorrelationID("request-1")
+
+	msg := event.toMsg()
+	assert.Equal(t, "text/plain", msg.Properties.ContentType)
+	assert.Equal(t, "request-1", msg.Properties.CorrelationID)
+	assert.Empty(t, msg.ApplicationProperties, "AMQP properties should not be sent as application properties")
+
+	received := eventFromMsg(msg)
+	assert.Equal(t, "text/plain", received.ContentType())
+	assert.Equal(t, "request-1", received.CorrelationID())
+
+	assert.Nil(t, NewEventFromString("foo").toMsg().Properties.CorrelationID)
+}
+
+func TestCorrelationIDString(t *testing.T) {
+	assert.Equal(t, "", correlationIDString(nil))
+	assert.Equal(t, "id", correlationIDString("id"))
+	assert.Equal(t, "id", correlationIDString([]byte("id")))
+	assert.Equal(t, "42", correlationIDString(uint64(42)))
+	assert.Equal(t, "00010203-0405-0607-0809-0a0b0c0d0e0f", correlationIDString(amqp.UUID{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}))
+}
+
+func TestEventGroup(t *testing.T) {
+	event := NewEventFromString("foo")
+	event.SetGroupID("order-1")
+	event.SetGroupSequence(3)
+
+	msg := event.toMsg()
+	assert.Equal(t, "order-1", msg.Properties.GroupID)
+	assert.Equal(t, uint32(3), msg.Properties.GroupSequence)
+
+	received := eventFromMsg(msg)
+	assert.Equal(t, "order-1", received.GroupID())
+	assert.Equal(t, uint32(3), received.GroupSequence())
+
+	bin, err := encodeBatchItem(event)
+	if assert.NoError(t, err) {
+		assert.True(t, bytes.Contains(bin, []byte("order-1")), "the group ID of each event of a batch should be sent")
+	}
+}
+
+func TestEventMessageID(t *testing.T) {
+	event := NewEventFromString("foo")
+	event.SetMessageID("dedupe-1")
+	assert.Equal(t, "dedupe-1", event.ID)
+
+	msg := event.toMsg()
+	assert.Equal(t, "dedupe-1", msg.Properties.MessageID)
+	assert.Equal(t, "dedupe-1", eventFromMsg(msg).MessageID())
+
+	msg.Properties.MessageID = uint64(7)
+	assert.Equal(t, "7", eventFromMsg(msg).MessageID(), "message IDs of other types should be returned in their string form")
+
+	bin, err := encodeBatchItem(event)
+	if assert.NoError(t, err) {
+		assert.True(t, bytes.Contains(bin, []byte("dedupe-1")), "the message ID of each event of a batch should be sent")
+	}
+}
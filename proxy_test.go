@@ -0,0 +1,120 @@
+package eventhub
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"bufio"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestProxy starts a proxy which answers each CONNECT request with the status and then echoes the tunnel, sending
+// each request it receives on the returned channel
+func newTestProxy(t *testing.T, status int) (*url.URL, <-chan *http.Request) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	requests := make(chan *http.Request, 1)
+
+	go func() {
+		defer listener.Close()
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		reader := bufio.NewReader(conn)
+		req, err := http.ReadRequest(reader)
+		if err != nil {
+			return
+		}
+		requests <- req
+		res := &http.Response{StatusCode: status, ProtoMajor: 1, ProtoMinor: 1}
+		if err := res.Write(conn); err != nil || status != http.StatusOK {
+			return
+		}
+
+		buf := make([]byte, 4)
+		if n, err := reader.Read(buf); err == nil {
+			_, _ = conn.Write(buf[:n])
+		}
+	}()
+	return &url.URL{Scheme: "http", Host: listener.Addr().String(), User: url.UserPassword("user", "secret")}, requests
+}
+
+func TestDialProxy(t *testing.T) {
+	proxyURL, requests := newTestProxy(t, http.StatusOK)
+	conn, err := dialProxy(proxyURL, "namespace.servicebus.windows.net:5671")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	req := <-requests
+	assert.Equal(t, http.MethodConnect, req.Method)
+	assert.Equal(t, "namespace.servicebus.windows.net:5671", req.Host)
+	assert.Equal(t, "Basic dXNlcjpzZWNyZXQ=", req.Header.Get("Proxy-Authorization"))
+
+	_, err = conn.Write([]byte("amqp"))
+	require.NoError(t, err)
+	buf := make([]byte, 4)
+	_, err = conn.Read(buf)
+	require.NoError(t, err)
+	assert.Equal(t, "amqp", string(buf), "data should be tunneled once the proxy accepts the CONNECT request")
+}
+
+func TestDialProxyRefused(t *testing.T) {
+	proxyURL, _ := newTestProxy(t, http.StatusProxyAuthRequired)
+	_, err := dialProxy(proxyURL, "namespace.servicebus.windows.net:5671")
+	assert.Error(t, err)
+}
+
+func TestHubWithWebSocketProxy(t *testing.T) {
+	for _, proxyURL := range []string{"", "proxy:8080", "socks5://proxy:1080", "http://"} {
+		_, err := NewHub("namespace", "hub", nil, HubWithWebSocketProxy(proxyURL))
+		assert.Error(t, err, proxyURL)
+	}
+
+	hub, err := NewHub("namespace", "hub", nil, HubWithWebSocketProxy("http://proxy:8080"))
+	if assert.NoError(t, err) {
+		assert.Equal(t, "proxy:8080", hub.namespace.proxyURL.Host)
+	}
+}
+
+func TestHubWithTLSConfig(t *testing.T) {
+	_, err := NewHub("namespace", "hub", nil, HubWithTLSConfig(nil))
+	assert.Error(t, err)
+
+	config := &tls.Config{MinVersion: tls.VersionTLS12}
+	hub, err := NewHub("namespace", "hub", nil, HubWithTLSConfig(config))
+	require.NoError(t, err)
+
+	clientConfig := hub.namespace.clientTLSConfig(hub.namespace.getHostname())
+	assert.Equal(t, "namespace.servicebus.windows.net", clientConfig.ServerName)
+	assert.Equal(t, uint16(tls.VersionTLS12), clientConfig.MinVersion)
+	assert.Empty(t, config.ServerName, "the configured TLS config should not be modified")
+}
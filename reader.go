@@ -0,0 +1,163 @@
+package eventhub
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+
+	"pack.ag/amqp"
+)
+
+type (
+	// PartitionReader reads events from a partition one at a time, as an alternative to the handler based Receive
+	PartitionReader struct {
+		r         *receiver
+		nextMu    sync.Mutex
+		closed    chan struct{}
+		closeOnce sync.Once
+	}
+)
+
+// NewPartitionReader opens a receiver on the partition and returns a PartitionReader which pulls events from it with
+// Next. The receive options are the same as for Receive.
+func (h *Hub) NewPartitionReader(ctx context.Context, partitionID string, opts ...ReceiveOption) (*PartitionReader, error) {
+	span, ctx := h.startSpanFromContext(ctx, "eventhub.Hub.NewPartitionReader")
+	defer span.Finish()
+
+	receiver, err := h.newReceiver(ctx, partitionID, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	pr := &PartitionReader{
+		r:      receiver,
+		closed: make(chan struct{}),
+	}
+
+	h.receiverMu.Lock()
+	defer h.receiverMu.Unlock()
+	if h.readers == nil {
+		h.readers = make(map[*PartitionReader]struct{})
+	}
+	h.readers[pr] = struct{}{}
+	return pr, nil
+}
+
+// Next blocks until the next event of the partition is available and returns it, or returns the context error if the
+// context is done first. Each event is settled once it is returned and the reader resumes after it if the link must
+// be recovered, so Next recovers from link failures without returning or repeating events. Next returns io.EOF once
+// the reader has been closed.
+func (pr *PartitionReader) Next(ctx context.Context) (*Event, error) {
+	pr.nextMu.Lock()
+	defer pr.nextMu.Unlock()
+
+	span, ctx := pr.r.startConsumerSpanFromContext(ctx, "eventhub.PartitionReader.Next")
+	defer span.Finish()
+
+	for {
+		if pr.isClosed() {
+			return nil, io.EOF
+		}
+
+		msg, err := pr.r.listenForMessage(ctx)
+		if err == nil {
+			return pr.settle(ctx, msg)
+		}
+
+		switch {
+		case pr.isClosed():
+			return nil, io.EOF
+		case ctx.Err() != nil:
+			return nil, ctx.Err()
+		case isLinkStolen(err):
+			return nil, pr.r.receiverDisconnectedError(err)
+		}
+
+		if err := pr.r.recoverWithRetry(ctx, err); err != nil {
+			if pr.isClosed() {
+				return nil, io.EOF
+			}
+			return nil, err
+		}
+	}
+}
+
+// Close closes the receiver of the PartitionReader. Any blocked or subsequent call to Next returns io.EOF.
+func (pr *PartitionReader) Close(ctx context.Context) error {
+	var err error
+	pr.closeOnce.Do(func() {
+		close(pr.closed)
+		err = pr.r.Close(ctx)
+
+		h := pr.r.hub
+		h.receiverMu.Lock()
+		delete(h.readers, pr)
+		h.receiverMu.Unlock()
+	})
+	return err
+}
+
+func (pr *PartitionReader) isClosed() bool {
+	select {
+	case <-pr.closed:
+		return true
+	default:
+		return false
+	}
+}
+
+// settle accepts the message and records its position so a recovered link resumes after it
+func (pr *PartitionReader) settle(ctx context.Context, msg *amqp.Message) (*Event, error) {
+	event := eventFromMsg(msg)
+	if err := pr.r.hub.decodeEvent(event); err != nil {
+		msg.Reject()
+		return nil, fmt.Errorf("message rejected: id: %v: %v", messageID(msg), err)
+	}
+	checkpoint := event.GetCheckpoint()
+	event, err := pr.r.hub.interceptReceive(ctx, event)
+	if err != nil {
+		msg.Reject()
+		return nil, fmt.Errorf("message rejected: id: %v: %v", messageID(msg), err)
+	}
+
+	msg.Accept()
+	if err := pr.r.storeLastReceivedOffset(checkpoint); err != nil {
+		pr.r.logFor(ctx).Error(err)
+	}
+	return event, nil
+}
+
+// partitionReaders returns the open PartitionReaders of the Hub
+func (h *Hub) partitionReaders() []*PartitionReader {
+	h.receiverMu.Lock()
+	defer h.receiverMu.Unlock()
+
+	readers := make([]*PartitionReader, 0, len(h.readers))
+	for pr := range h.readers {
+		readers = append(readers, pr)
+	}
+	return readers
+}
@@ -0,0 +1,74 @@
+package eventhub
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"fmt"
+)
+
+const (
+	// BufferPolicyBlock buffers received events in memory ahead of the handler, in addition to those held by the link
+	// credit. Once the buffer is full, events are no longer taken from the link until the handler catches up.
+	BufferPolicyBlock BufferPolicy = iota
+)
+
+type (
+	// BufferPolicy controls how a receiver bounds the events it holds in memory ahead of the handler
+	BufferPolicy int
+)
+
+// ReceiveWithBuffer bounds the events received ahead of the handler by size, following the policy. With
+// BufferPolicyBlock, at most size events are buffered in addition to the prefetch count. When not set, events are held
+// only by the link credit of the prefetch count; to hold fewer events in memory in total, lower the prefetch count
+// with ReceiveWithPrefetchCount, so the broker holds the rest until credit is granted again.
+func ReceiveWithBuffer(size int, policy BufferPolicy) ReceiveOption {
+	return func(receiver *receiver) error {
+		if size < 1 {
+			return fmt.Errorf("receive buffer size must be greater than 0")
+		}
+		if policy != BufferPolicyBlock {
+			return fmt.Errorf("unknown receive buffer policy %d", policy)
+		}
+		receiver.bufferSize = size
+		receiver.bufferPolicy = policy
+		return nil
+	}
+}
+
+// messageBufferSize returns the number of messages buffered between the link and the handler
+func (r *receiver) messageBufferSize() int {
+	if r.bufferPolicy == BufferPolicyBlock {
+		return r.bufferSize
+	}
+	return 0
+}
+
+// String returns the name of the buffer policy
+func (p BufferPolicy) String() string {
+	switch p {
+	case BufferPolicyBlock:
+		return "block"
+	default:
+		return fmt.Sprintf("BufferPolicy(%d)", int(p))
+	}
+}
@@ -0,0 +1,82 @@
+package eventhub
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// ReceiveChan opens a receiver on the partition and returns a channel of its events and a channel for the error which
+// stops receiving, if any. The receive options are the same as for Receive.
+//
+// Events are pulled from the link only as they are taken from the channel, so a slow consumer reduces the link credit
+// rather than buffering events in memory. Both channels are closed once the context is done, the Hub is closed or an
+// unrecoverable error, such as a ReceiverDisconnectedError, is sent on the error channel. Each event is settled before
+// it is sent on the channel, so an event pulled as the context is done is dropped.
+func (h *Hub) ReceiveChan(ctx context.Context, partitionID string, opts ...ReceiveOption) (<-chan *Event, <-chan error, error) {
+	span, ctx := h.startSpanFromContext(ctx, "eventhub.Hub.ReceiveChan")
+	defer span.Finish()
+
+	pr, err := h.NewPartitionReader(ctx, partitionID, opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	events := make(chan *Event)
+	errs := make(chan error, 1)
+	go func() {
+		pumpEvents(ctx, pr.Next, events, errs)
+
+		closeCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		if err := pr.Close(closeCtx); err != nil {
+			h.logFor(ctx).Error(err)
+		}
+	}()
+	return events, errs, nil
+}
+
+// pumpEvents sends the events returned by next on the events channel until the context is done or next fails, then
+// sends the error of next, unless it is the end of the events or the context error, and closes both channels
+func pumpEvents(ctx context.Context, next func(ctx context.Context) (*Event, error), events chan<- *Event, errs chan<- error) {
+	defer close(errs)
+	defer close(events)
+
+	for {
+		event, err := next(ctx)
+		if err != nil {
+			if err != io.EOF && ctx.Err() == nil {
+				errs <- err
+			}
+			return
+		}
+
+		select {
+		case events <- event:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
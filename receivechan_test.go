@@ -0,0 +1,98 @@
+package eventhub
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// nextFrom returns a next function returning the events in order, followed by err
+func nextFrom(err error, events ...*Event) func(ctx context.Context) (*Event, error) {
+	return func(ctx context.Context) (*Event, error) {
+		if len(events) == 0 {
+			return nil, err
+		}
+		event := events[0]
+		events = events[1:]
+		return event, nil
+	}
+}
+
+func TestPumpEventsUntilError(t *testing.T) {
+	boom := errors.New("boom")
+	events := make(chan *Event)
+	errs := make(chan error, 1)
+	go pumpEvents(context.Background(), nextFrom(boom, NewEventFromString("1"), NewEventFromString("2")), events, errs)
+
+	var received []string
+	for event := range events {
+		received = append(received, string(event.Data))
+	}
+	assert.Equal(t, []string{"1", "2"}, received)
+	assert.Equal(t, boom, <-errs)
+	_, ok := <-errs
+	assert.False(t, ok, "the error channel should be closed")
+}
+
+func TestPumpEventsClosesAtEOF(t *testing.T) {
+	events := make(chan *Event)
+	errs := make(chan error, 1)
+	go pumpEvents(context.Background(), nextFrom(io.EOF), events, errs)
+
+	_, ok := <-events
+	assert.False(t, ok)
+	_, ok = <-errs
+	assert.False(t, ok, "no error should be sent once the reader is closed")
+}
+
+func TestPumpEventsBlocksUntilCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	events := make(chan *Event)
+	errs := make(chan error, 1)
+	done := make(chan struct{})
+	go func() {
+		pumpEvents(ctx, nextFrom(io.EOF, NewEventFromString("1")), events, errs)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		assert.FailNow(t, "the pump should block until the event is taken")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		assert.FailNow(t, "the pump should stop once the context is cancelled")
+	}
+	_, ok := <-errs
+	assert.False(t, ok, "no error should be sent once the context is cancelled")
+}
@@ -0,0 +1,71 @@
+package eventhub
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+)
+
+type (
+	// ReceiveInterceptor is invoked with each received event before it is handed to the handler, such as to decrypt
+	// the event or record metrics. The returned event, which may be the event passed in or a replacement, is handed to
+	// the next interceptor and then the handler. If an error is returned, the handler is skipped and the event is
+	// rejected as if the handler had failed.
+	ReceiveInterceptor func(ctx context.Context, event *Event) (*Event, error)
+)
+
+// HubWithReceiveInterceptor configures the Hub to invoke the interceptor with every event received by the Hub, whether
+// by a handler or a PartitionReader, after the event has been decompressed. The option may be given more than once, in
+// which case the interceptors are invoked in the order they were given.
+func HubWithReceiveInterceptor(interceptor ReceiveInterceptor) HubOption {
+	return func(h *Hub) error {
+		if interceptor == nil {
+			return errors.New("receive interceptor must not be nil")
+		}
+		h.receiveInterceptors = append(h.receiveInterceptors, interceptor)
+		return nil
+	}
+}
+
+// interceptReceive passes the event through the receive interceptors of the Hub, returning the event to handle
+func (h *Hub) interceptReceive(ctx context.Context, event *Event) (*Event, error) {
+	return InterceptReceive(ctx, event, h.receiveInterceptors...)
+}
+
+// InterceptReceive passes the event through each of the interceptors in order, returning the event returned by the
+// last of them, or the first error returned
+func InterceptReceive(ctx context.Context, event *Event, interceptors ...ReceiveInterceptor) (*Event, error) {
+	for _, intercept := range interceptors {
+		intercepted, err := intercept(ctx, event)
+		if err != nil {
+			return nil, err
+		}
+		if intercepted == nil {
+			return nil, errors.New("receive interceptor returned a nil event")
+		}
+		event = intercepted
+	}
+	return event, nil
+}
@@ -0,0 +1,74 @@
+package eventhub
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHubWithReceiveInterceptor(t *testing.T) {
+	hub := new(Hub)
+	assert.Error(t, HubWithReceiveInterceptor(nil)(hub))
+
+	event := NewEventFromString("foo")
+	intercepted, err := hub.interceptReceive(context.Background(), event)
+	assert.NoError(t, err)
+	assert.Equal(t, event, intercepted, "events should pass through a Hub without interceptors")
+
+	assert.NoError(t, HubWithReceiveInterceptor(func(ctx context.Context, event *Event) (*Event, error) {
+		return NewEventFromString(string(event.Data) + "bar"), nil
+	})(hub))
+	assert.NoError(t, HubWithReceiveInterceptor(func(ctx context.Context, event *Event) (*Event, error) {
+		event.Set("seen", "true")
+		return event, nil
+	})(hub))
+
+	intercepted, err = hub.interceptReceive(context.Background(), event)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "foobar", string(intercepted.Data), "interceptors should run in the order they were given")
+		assert.Equal(t, "true", intercepted.Properties["seen"])
+	}
+}
+
+func TestInterceptReceiveErrors(t *testing.T) {
+	cause := errors.New("can't decrypt")
+	failing := func(ctx context.Context, event *Event) (*Event, error) {
+		return nil, cause
+	}
+	skipped := func(ctx context.Context, event *Event) (*Event, error) {
+		assert.Fail(t, "interceptors after a failed interceptor should be skipped")
+		return event, nil
+	}
+	_, err := InterceptReceive(context.Background(), NewEventFromString("foo"), failing, skipped)
+	assert.Equal(t, cause, err)
+
+	dropping := func(ctx context.Context, event *Event) (*Event, error) {
+		return nil, nil
+	}
+	_, err = InterceptReceive(context.Background(), NewEventFromString("foo"), dropping)
+	assert.Error(t, err)
+}
@@ -25,10 +25,10 @@ package eventhub
 import (
 	"context"
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/Azure/azure-amqp-common-go"
-	"github.com/Azure/azure-amqp-common-go/log"
 	"github.com/Azure/azure-amqp-common-go/persist"
 	"github.com/Azure/azure-event-hubs-go/mgmt"
 	"github.com/opentracing/opentracing-go"
@@ -39,7 +39,9 @@ const (
 	// DefaultConsumerGroup is the default name for a event stream consumer group
 	DefaultConsumerGroup = "$Default"
 
-	offsetAnnotationName = "x-opt-offset"
+	offsetAnnotationName         = "x-opt-offset"
+	enqueuedTimeAnnotationName   = "x-opt-enqueued-time"
+	sequenceNumberAnnotationName = "x-opt-sequence-number"
 
 	amqpAnnotationFormat = "amqp.annotation.%s >%s '%s'"
 
@@ -61,6 +63,25 @@ type (
 		done          func()
 		epoch         *int64
 		lastError     error
+		// startingPosition is the name of the option which set the starting position of the receiver, if any
+		startingPosition string
+		// startingCheckpoint is the checkpoint written by the starting position option
+		startingCheckpoint persist.Checkpoint
+		// inclusiveStart includes the event at the starting position while nothing has been received
+		inclusiveStart bool
+		// handlerConcurrency is the number of events which may be handled at once, where 0 means one at a time
+		handlerConcurrency int
+		// bufferSize bounds the events held ahead of the handler following bufferPolicy, where 0 means unbounded by
+		// anything other than the link credit
+		bufferSize   int
+		bufferPolicy BufferPolicy
+		// pool shares the connection of the receiver with other links when set, and pooled is the connection in use
+		pool   *ConnectionPool
+		pooled *pooledConnection
+		// stopClaimRefresh stops renegotiating the claim of the link before its token expires
+		stopClaimRefresh func()
+		// linkName identifies the link to the broker when set, and is kept when the link is recovered
+		linkName string
 	}
 
 	// ReceiveOption provides a structure for configuring receivers
@@ -81,25 +102,67 @@ func ReceiveWithConsumerGroup(consumerGroup string) ReceiveOption {
 	}
 }
 
-// ReceiveWithStartingOffset configures the receiver to start at a given position in the event stream
+// ReceiveWithStartingOffset configures the receiver to start after a given offset in the event stream. The offset is
+// translated into the filter `amqp.annotation.x-opt-offset > 'offset'`, or `>=` with ReceiveWithInclusiveStart.
+//
+// Only one starting position option may be supplied to a receiver.
 func ReceiveWithStartingOffset(offset string) ReceiveOption {
 	return func(receiver *receiver) error {
-		receiver.storeLastReceivedOffset(persist.NewCheckpoint(offset, 0, time.Time{}))
-		return nil
+		return receiver.setStartingPosition("ReceiveWithStartingOffset", persist.NewCheckpoint(offset, 0, time.Time{}))
+	}
+}
+
+// ReceiveWithStartingSequenceNumber configures the receiver to start after a given sequence number in the event
+// stream. The sequence number is translated into the filter `amqp.annotation.x-opt-sequence-number > 'seq'`, or `>=`
+// with ReceiveWithInclusiveStart.
+//
+// Only one starting position option may be supplied to a receiver.
+func ReceiveWithStartingSequenceNumber(seq int64) ReceiveOption {
+	return func(receiver *receiver) error {
+		return receiver.setStartingPosition("ReceiveWithStartingSequenceNumber", persist.NewCheckpoint("", seq, time.Time{}))
 	}
 }
 
 // ReceiveWithLatestOffset configures the receiver to start at a given position in the event stream
+//
+// Only one starting position option may be supplied to a receiver.
 func ReceiveWithLatestOffset() ReceiveOption {
 	return func(receiver *receiver) error {
-		receiver.storeLastReceivedOffset(persist.NewCheckpointFromEndOfStream())
+		return receiver.setStartingPosition("ReceiveWithLatestOffset", persist.NewCheckpointFromEndOfStream())
+	}
+}
+
+// ReceiveFromTimestamp configures the receiver to start with events enqueued after the given time. The time is
+// translated into the filter `amqp.annotation.x-opt-enqueued-time > 'milliseconds since epoch'`.
+//
+// Only one starting position option may be supplied to a receiver.
+func ReceiveFromTimestamp(t time.Time) ReceiveOption {
+	return func(receiver *receiver) error {
+		return receiver.setStartingPosition("ReceiveFromTimestamp", persist.NewCheckpoint("", 0, t))
+	}
+}
+
+// ReceiveWithInclusiveStart configures the receiver to include the event at its starting offset, sequence number or
+// enqueued time, so the filter of the starting position becomes `>=` rather than `>`. The filter is only inclusive
+// until an event is received, so recovering the link does not receive the last event again. It has no effect on
+// ReceiveWithLatestOffset.
+func ReceiveWithInclusiveStart() ReceiveOption {
+	return func(receiver *receiver) error {
+		receiver.inclusiveStart = true
 		return nil
 	}
 }
 
-// ReceiveWithPrefetchCount configures the receiver to attempt to fetch as many messages as the prefetch amount
+// ReceiveWithPrefetchCount configures the AMQP link credit of the receiver, which is the number of unsettled events
+// the broker may push to the receiver ahead of the handler. Events are handed to the handler one at a time and each
+// event is settled only after the handler returns, so a smaller prefetch bounds the events buffered in memory while a
+// larger prefetch absorbs bursts. Credit is granted again once half of it has been delivered. When not set, the
+// prefetch count defaults to 100.
 func ReceiveWithPrefetchCount(prefetch uint32) ReceiveOption {
 	return func(receiver *receiver) error {
+		if prefetch == 0 {
+			return fmt.Errorf("prefetch count must be greater than 0")
+		}
 		receiver.prefetchCount = prefetch
 		return nil
 	}
@@ -123,6 +186,7 @@ func (h *Hub) newReceiver(ctx context.Context, partitionID string, opts ...Recei
 		consumerGroup: DefaultConsumerGroup,
 		prefetchCount: defaultPrefetchCount,
 		partitionID:   partitionID,
+		pool:          h.connections,
 	}
 
 	for _, opt := range opts {
@@ -131,21 +195,21 @@ func (h *Hub) newReceiver(ctx context.Context, partitionID string, opts ...Recei
 		}
 	}
 
-	log.For(ctx).Debug("creating a new receiver")
+	if err := h.validateConsumerGroup(ctx, receiver.consumerGroup); err != nil {
+		return nil, err
+	}
+
+	receiver.logFor(ctx).Debug("creating a new receiver")
 	err := receiver.newSessionAndLink(ctx)
-	return receiver, err
+	return receiver, withErrorKind(receiver.receiverDisconnectedError(err))
 }
 
 // Close will close the AMQP session and link of the receiver
 func (r *receiver) Close(ctx context.Context) error {
-	span, _ := r.startConsumerSpanFromContext(ctx, "eventhub.receiver.Close")
+	span, ctx := r.startConsumerSpanFromContext(ctx, "eventhub.receiver.Close")
 	defer span.Finish()
 
-	if r.done != nil {
-		r.done()
-	}
-
-	return r.connection.Close()
+	return r.close(ctx, false)
 }
 
 // Recover will attempt to close the current session and link, then rebuild them
@@ -153,10 +217,58 @@ func (r *receiver) Recover(ctx context.Context) error {
 	span, ctx := r.startConsumerSpanFromContext(ctx, "eventhub.receiver.Recover")
 	defer span.Finish()
 
-	_ = r.Close(ctx) // we expect the receiver is in an error state
+	_ = r.close(ctx, true) // we expect the receiver is in an error state
 	return r.newSessionAndLink(ctx)
 }
 
+// close closes the link and session of the receiver and its connection, or returns a pooled connection to its pool.
+// A pooled connection is discarded from the pool if the receiver failed, as the connection may have failed with it.
+func (r *receiver) close(ctx context.Context, failed bool) error {
+	if r.done != nil {
+		r.done()
+	}
+
+	if r.stopClaimRefresh != nil {
+		r.stopClaimRefresh()
+	}
+
+	if r.pooled == nil {
+		return r.connection.Close()
+	}
+
+	if r.receiver != nil {
+		if err := r.receiver.Close(ctx); err != nil {
+			r.logFor(ctx).Debug(err.Error())
+		}
+	}
+	if r.session != nil {
+		if err := r.session.Close(ctx); err != nil {
+			r.logFor(ctx).Debug(err.Error())
+		}
+	}
+
+	pooled := r.pooled
+	r.pooled = nil
+	if failed {
+		return r.pool.discard(pooled)
+	}
+	return r.pool.release(pooled)
+}
+
+// openConnection opens a connection for the receiver, or acquires one from its pool
+func (r *receiver) openConnection() (*amqp.Client, error) {
+	if r.pool == nil {
+		return r.hub.namespace.newConnection()
+	}
+
+	pooled, err := r.pool.acquire()
+	if err != nil {
+		return nil, err
+	}
+	r.pooled = pooled
+	return pooled.client, nil
+}
+
 // Listen start a listener for messages sent to the entity path
 func (r *receiver) Listen(handler Handler) *ListenerHandle {
 	ctx, done := context.WithCancel(context.Background())
@@ -165,7 +277,7 @@ func (r *receiver) Listen(handler Handler) *ListenerHandle {
 	span, ctx := r.startConsumerSpanFromContext(ctx, "eventhub.receiver.Listen")
 	defer span.Finish()
 
-	messages := make(chan *amqp.Message)
+	messages := make(chan *amqp.Message, r.messageBufferSize())
 	go r.listenForMessages(ctx, messages)
 	go r.handleMessages(ctx, messages, handler)
 
@@ -178,6 +290,12 @@ func (r *receiver) Listen(handler Handler) *ListenerHandle {
 func (r *receiver) handleMessages(ctx context.Context, messages chan *amqp.Message, handler Handler) {
 	span, ctx := r.startConsumerSpanFromContext(ctx, "eventhub.receiver.handleMessages")
 	defer span.Finish()
+
+	if r.handlerConcurrency > 1 {
+		r.handleMessagesConcurrently(ctx, messages, handler)
+		return
+	}
+
 	for {
 		select {
 		case <-ctx.Done():
@@ -190,6 +308,17 @@ func (r *receiver) handleMessages(ctx context.Context, messages chan *amqp.Messa
 
 func (r *receiver) handleMessage(ctx context.Context, msg *amqp.Message, handler Handler) {
 	event := eventFromMsg(msg)
+	if !r.handleEvent(ctx, msg, event, handler) {
+		return
+	}
+	if err := r.storeLastReceivedOffset(event.GetCheckpoint()); err != nil {
+		r.logFor(ctx).Error(err)
+	}
+}
+
+// handleEvent hands the event of the message to the handler and settles the message, returning true if the message
+// was accepted
+func (r *receiver) handleEvent(ctx context.Context, msg *amqp.Message, event *Event, handler Handler) bool {
 	var span opentracing.Span
 	wireContext, err := opentracing.GlobalTracer().Extract(opentracing.TextMap, event)
 	if err == nil {
@@ -201,15 +330,29 @@ func (r *receiver) handleMessage(ctx context.Context, msg *amqp.Message, handler
 
 	id := messageID(msg)
 	span.SetTag("eventhub.message-id", id)
+	ctx = event.contextWithTraceContext(ctx)
+
+	if err := r.hub.decodeEvent(event); err != nil {
+		msg.Reject()
+		r.logFor(ctx).Error(fmt.Errorf("message rejected: id: %v: %v", id, err))
+		return false
+	}
+
+	event, err = r.hub.interceptReceive(ctx, event)
+	if err != nil {
+		msg.Reject()
+		r.logFor(ctx).Error(fmt.Errorf("message rejected: id: %v: %v", id, err))
+		return false
+	}
 
 	err = handler(ctx, event)
 	if err != nil {
 		msg.Reject()
-		log.For(ctx).Error(fmt.Errorf("message rejected: id: %v", id))
-		return
+		r.logFor(ctx).Error(fmt.Errorf("message rejected: id: %v", id))
+		return false
 	}
 	msg.Accept()
-	r.storeLastReceivedOffset(event.GetCheckpoint())
+	return true
 }
 
 func (r *receiver) listenForMessages(ctx context.Context, msgChan chan *amqp.Message) {
@@ -222,27 +365,15 @@ func (r *receiver) listenForMessages(ctx context.Context, msgChan chan *amqp.Mes
 			return
 		}
 
-		if amqpErr, ok := err.(*amqp.Error); ok && amqpErr.Condition == "amqp:link:stolen" {
+		if isLinkStolen(err) {
 			// exit since the link has been stolen by a higher epoch
+			r.lastError = r.receiverDisconnectedError(err)
+			r.Close(ctx)
 			return
 		}
 
 		if err != nil {
-			_, retryErr := common.Retry(5, 10*time.Second, func() (interface{}, error) {
-				sp, ctx := r.startConsumerSpanFromContext(ctx, "eventhub.receiver.listenForMessages.tryRecover")
-				defer sp.Finish()
-
-				err := r.Recover(ctx)
-				if ctx.Err() != nil && ctx.Err() == context.DeadlineExceeded {
-					return nil, ctx.Err()
-				}
-
-				if err != nil {
-					log.For(ctx).Error(err)
-					return nil, common.Retryable(err.Error())
-				}
-				return nil, nil
-			})
+			retryErr := r.recoverWithRetry(ctx, err)
 
 			if retryErr != nil {
 				r.lastError = retryErr
@@ -259,13 +390,46 @@ func (r *receiver) listenForMessages(ctx context.Context, msgChan chan *amqp.Mes
 	}
 }
 
+// recoverWithRetry attempts to recover the receiver after the error according to the retry policy of the Hub, or 5
+// attempts 10 seconds apart if the Hub has no retry policy
+func (r *receiver) recoverWithRetry(ctx context.Context, cause error) error {
+	r.hub.notifyLinkRecovery(ReceiverLinkDetached, cause)
+	tryRecover := func(ctx context.Context) error {
+		sp, ctx := r.startConsumerSpanFromContext(ctx, "eventhub.receiver.listenForMessages.tryRecover")
+		defer sp.Finish()
+
+		err := r.recoverLink(ctx, cause)
+		if ctx.Err() != nil && ctx.Err() == context.DeadlineExceeded {
+			return ctx.Err()
+		}
+
+		if err != nil {
+			r.logFor(ctx).Error(err)
+		}
+		return err
+	}
+
+	if r.hub.retryPolicy != nil {
+		return retry(ctx, r.hub.retryPolicy, tryRecover)
+	}
+
+	_, err := common.Retry(5, 10*time.Second, func() (interface{}, error) {
+		err := tryRecover(ctx)
+		if err != nil && err != ctx.Err() {
+			return nil, common.Retryable(err.Error())
+		}
+		return nil, err
+	})
+	return err
+}
+
 func (r *receiver) listenForMessage(ctx context.Context) (*amqp.Message, error) {
 	span, ctx := r.startConsumerSpanFromContext(ctx, "eventhub.receiver.listenForMessage")
 	defer span.Finish()
 
 	msg, err := r.receiver.Receive(ctx)
 	if err != nil {
-		log.For(ctx).Debug(err.Error())
+		r.logFor(ctx).Debug(err.Error())
 		return nil, err
 	}
 
@@ -279,34 +443,34 @@ func (r *receiver) newSessionAndLink(ctx context.Context) error {
 	span, ctx := r.startConsumerSpanFromContext(ctx, "eventhub.receiver.newSessionAndLink")
 	defer span.Finish()
 
-	connection, err := r.hub.namespace.newConnection()
+	connection, err := r.openConnection()
 	if err != nil {
 		return err
 	}
 	r.connection = connection
 
 	address := r.getAddress()
-	err = r.hub.namespace.negotiateClaim(ctx, connection, address)
+	r.stopClaimRefresh, err = r.hub.namespace.negotiateClaimAndRefresh(ctx, connection, address)
 	if err != nil {
-		log.For(ctx).Error(err)
+		r.logFor(ctx).Error(err)
 		return err
 	}
 
 	amqpSession, err := connection.NewSession()
 	if err != nil {
-		log.For(ctx).Error(err)
+		r.logFor(ctx).Error(err)
 		return err
 	}
 
 	offsetExpression, err := r.getOffsetExpression()
 	if err != nil {
-		log.For(ctx).Error(err)
+		r.logFor(ctx).Error(err)
 		return err
 	}
 
 	r.session, err = newSession(amqpSession)
 	if err != nil {
-		log.For(ctx).Error(err)
+		r.logFor(ctx).Error(err)
 		return err
 	}
 
@@ -323,9 +487,13 @@ func (r *receiver) newSessionAndLink(ctx context.Context) error {
 		opts = append(opts, amqp.LinkPropertyInt64(epochKey, *r.epoch))
 	}
 
+	if r.linkName != "" {
+		opts = append(opts, amqp.LinkProperty(receiverNameKey, r.linkName))
+	}
+
 	amqpReceiver, err := amqpSession.NewReceiver(opts...)
 	if err != nil {
-		log.For(ctx).Error(err)
+		r.logFor(ctx).Error(err)
 		return err
 	}
 
@@ -333,9 +501,8 @@ func (r *receiver) newSessionAndLink(ctx context.Context) error {
 	return nil
 }
 
-func (r *receiver) getLastReceivedOffset() (string, error) {
-	checkpoint, err := r.offsetPersister().Read(r.namespaceName(), r.hubName(), r.consumerGroup, r.partitionID)
-	return checkpoint.Offset, err
+func (r *receiver) getLastReceivedCheckpoint() (persist.Checkpoint, error) {
+	return r.offsetPersister().Read(r.namespaceName(), r.hubName(), r.consumerGroup, r.partitionID)
 }
 
 func (r *receiver) storeLastReceivedOffset(checkpoint persist.Checkpoint) error {
@@ -343,12 +510,46 @@ func (r *receiver) storeLastReceivedOffset(checkpoint persist.Checkpoint) error
 }
 
 func (r *receiver) getOffsetExpression() (string, error) {
-	offset, err := r.getLastReceivedOffset()
+	checkpoint, err := r.getLastReceivedCheckpoint()
 	if err != nil {
 		// assume err read is due to not having an offset -- probably want to change this as it's ambiguous
 		return fmt.Sprintf(amqpAnnotationFormat, offsetAnnotationName, "=", persist.StartOfStream), nil
 	}
-	return fmt.Sprintf(amqpAnnotationFormat, offsetAnnotationName, "", offset), nil
+
+	var inclusive string
+	if r.inclusiveStart && checkpoint.Offset != persist.EndOfStream && r.isStartingCheckpoint(checkpoint) {
+		inclusive = "="
+	}
+
+	// checkpoints without an offset are written by the timestamp and sequence number starting position options
+	if checkpoint.Offset == "" {
+		if !checkpoint.EnqueueTime.IsZero() {
+			millis := checkpoint.EnqueueTime.UnixNano() / int64(time.Millisecond)
+			return fmt.Sprintf(amqpAnnotationFormat, enqueuedTimeAnnotationName, inclusive, strconv.FormatInt(millis, 10)), nil
+		}
+		return fmt.Sprintf(amqpAnnotationFormat, sequenceNumberAnnotationName, inclusive, strconv.FormatInt(checkpoint.SequenceNumber, 10)), nil
+	}
+	return fmt.Sprintf(amqpAnnotationFormat, offsetAnnotationName, inclusive, checkpoint.Offset), nil
+}
+
+// isStartingCheckpoint returns true if the checkpoint is the one written by the starting position option, rather than
+// the checkpoint of a received event
+func (r *receiver) isStartingCheckpoint(checkpoint persist.Checkpoint) bool {
+	return r.startingPosition != "" &&
+		checkpoint.Offset == r.startingCheckpoint.Offset &&
+		checkpoint.SequenceNumber == r.startingCheckpoint.SequenceNumber &&
+		checkpoint.EnqueueTime.Equal(r.startingCheckpoint.EnqueueTime)
+}
+
+// setStartingPosition records the option setting the starting position of the receiver and writes its checkpoint,
+// returning an error if a starting position has already been set by another option
+func (r *receiver) setStartingPosition(option string, checkpoint persist.Checkpoint) error {
+	if r.startingPosition != "" {
+		return fmt.Errorf("%s cannot be combined with %s: only one starting position may be specified", option, r.startingPosition)
+	}
+	r.startingPosition = option
+	r.startingCheckpoint = checkpoint
+	return r.storeLastReceivedOffset(checkpoint)
 }
 
 func (r *receiver) getAddress() string {
@@ -396,7 +597,8 @@ func (lc *ListenerHandle) Done() <-chan struct{} {
 	return lc.ctx.Done()
 }
 
-// Err will return the last error encountered
+// Err will return the last error encountered. If the receiver was disconnected because a receiver with a higher epoch
+// was opened on the partition, the error is a *ReceiverDisconnectedError.
 func (lc *ListenerHandle) Err() error {
 	if lc.r.lastError != nil {
 		return lc.r.lastError
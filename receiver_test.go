@@ -0,0 +1,131 @@
+package eventhub
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-amqp-common-go/persist"
+	"github.com/Azure/go-autorest/autorest/azure"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestReceiver(opts ...ReceiveOption) (*receiver, error) {
+	hub := &Hub{
+		name:            "hub",
+		namespace:       newNamespace("ns", nil, azure.PublicCloud),
+		offsetPersister: persist.NewMemoryPersister(),
+	}
+	r := &receiver{
+		hub:           hub,
+		consumerGroup: DefaultConsumerGroup,
+		partitionID:   "0",
+	}
+	for _, opt := range opts {
+		if err := opt(r); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+func TestReceiverOffsetExpression(t *testing.T) {
+	enqueued := time.Date(2018, 6, 1, 0, 0, 0, 0, time.UTC)
+	tests := map[string]struct {
+		opts       []ReceiveOption
+		expression string
+	}{
+		"Default":                {expression: "amqp.annotation.x-opt-offset >= '-1'"},
+		"StartingOffset":         {opts: []ReceiveOption{ReceiveWithStartingOffset("1234")}, expression: "amqp.annotation.x-opt-offset > '1234'"},
+		"LatestOffset":           {opts: []ReceiveOption{ReceiveWithLatestOffset()}, expression: "amqp.annotation.x-opt-offset > '@latest'"},
+		"FromTimestamp":          {opts: []ReceiveOption{ReceiveFromTimestamp(enqueued)}, expression: "amqp.annotation.x-opt-enqueued-time > '1527811200000'"},
+		"StartingSequenceNumber": {opts: []ReceiveOption{ReceiveWithStartingSequenceNumber(42)}, expression: "amqp.annotation.x-opt-sequence-number > '42'"},
+		"InclusiveOffset":        {opts: []ReceiveOption{ReceiveWithInclusiveStart(), ReceiveWithStartingOffset("1234")}, expression: "amqp.annotation.x-opt-offset >= '1234'"},
+		"InclusiveSequence":      {opts: []ReceiveOption{ReceiveWithStartingSequenceNumber(42), ReceiveWithInclusiveStart()}, expression: "amqp.annotation.x-opt-sequence-number >= '42'"},
+		"InclusiveTimestamp":     {opts: []ReceiveOption{ReceiveFromTimestamp(enqueued), ReceiveWithInclusiveStart()}, expression: "amqp.annotation.x-opt-enqueued-time >= '1527811200000'"},
+		"InclusiveLatest":        {opts: []ReceiveOption{ReceiveWithLatestOffset(), ReceiveWithInclusiveStart()}, expression: "amqp.annotation.x-opt-offset > '@latest'"},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			r, err := newTestReceiver(tt.opts...)
+			if !assert.NoError(t, err) {
+				return
+			}
+			expression, err := r.getOffsetExpression()
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expression, expression)
+		})
+	}
+}
+
+func TestReceiverInclusiveStartEndsOnceReceived(t *testing.T) {
+	r, err := newTestReceiver(ReceiveWithStartingSequenceNumber(42), ReceiveWithInclusiveStart())
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// once the event at the starting position is received, recovering the link must not receive it again
+	assert.NoError(t, r.storeLastReceivedOffset(persist.NewCheckpoint("1234", 42, time.Now())))
+	expression, err := r.getOffsetExpression()
+	assert.NoError(t, err)
+	assert.Equal(t, "amqp.annotation.x-opt-offset > '1234'", expression)
+}
+
+func TestReceiverConflictingStartingPositions(t *testing.T) {
+	_, err := newTestReceiver(ReceiveWithStartingOffset("1234"), ReceiveFromTimestamp(time.Now()))
+	assert.EqualError(t, err, "ReceiveFromTimestamp cannot be combined with ReceiveWithStartingOffset: only one starting position may be specified")
+}
+
+func TestReceiverSequenceNumberConflictsWithOffset(t *testing.T) {
+	_, err := newTestReceiver(ReceiveWithStartingSequenceNumber(42), ReceiveWithStartingOffset("1234"))
+	assert.EqualError(t, err, "ReceiveWithStartingOffset cannot be combined with ReceiveWithStartingSequenceNumber: only one starting position may be specified")
+}
+
+func TestReceiverPrefetchCount(t *testing.T) {
+	r, err := newTestReceiver(ReceiveWithPrefetchCount(10))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, uint32(10), r.prefetchCount)
+
+	_, err = newTestReceiver(ReceiveWithPrefetchCount(0))
+	assert.EqualError(t, err, "prefetch count must be greater than 0")
+}
+
+func TestReceiverBuffer(t *testing.T) {
+	r, err := newTestReceiver()
+	if !assert.NoError(t, err) {
+		return
+	}
+	r.prefetchCount = defaultPrefetchCount
+	assert.Equal(t, 0, r.messageBufferSize(), "events should only be held by the link credit by default")
+
+	assert.NoError(t, ReceiveWithBuffer(10, BufferPolicyBlock)(r))
+	assert.Equal(t, 10, r.messageBufferSize())
+	assert.Equal(t, uint32(defaultPrefetchCount), r.prefetchCount, "the buffer should not change the link credit")
+
+	assert.Error(t, ReceiveWithBuffer(0, BufferPolicyBlock)(r))
+	assert.Error(t, ReceiveWithBuffer(10, BufferPolicy(42))(r))
+}
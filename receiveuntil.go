@@ -0,0 +1,80 @@
+package eventhub
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ReceiveUntil opens a receiver on the partition and hands the handler each event until pred returns true for an
+// event, which is handed to the handler and returned once the receiver is closed. The handler may be nil to only find
+// the event. Receiving stops with the error of the handler if it returns one, or with the context error if the context
+// is done before a matching event is received. The receive options are the same as for Receive.
+func (h *Hub) ReceiveUntil(ctx context.Context, partitionID string, pred func(*Event) bool, handler Handler, opts ...ReceiveOption) (*Event, error) {
+	span, ctx := h.startSpanFromContext(ctx, "eventhub.Hub.ReceiveUntil")
+	defer span.Finish()
+
+	if pred == nil {
+		return nil, errors.New("predicate must not be nil")
+	}
+
+	pr, err := h.NewPartitionReader(ctx, partitionID, opts...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		closeCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		if err := pr.Close(closeCtx); err != nil {
+			h.logFor(ctx).Error(err)
+		}
+	}()
+
+	return receiveUntil(ctx, pr.Next, pred, handler)
+}
+
+// receiveUntil hands the handler the events returned by next until pred returns true for one, which is returned
+func receiveUntil(ctx context.Context, next func(ctx context.Context) (*Event, error), pred func(*Event) bool, handler Handler) (*Event, error) {
+	for {
+		event, err := next(ctx)
+		if err != nil {
+			if err == io.EOF {
+				return nil, errors.New("receiver closed before an event matched the predicate")
+			}
+			return nil, err
+		}
+
+		if handler != nil {
+			if err := handler(ctx, event); err != nil {
+				return nil, err
+			}
+		}
+		if pred(event) {
+			return event, nil
+		}
+	}
+}
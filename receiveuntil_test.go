@@ -0,0 +1,71 @@
+package eventhub
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReceiveUntilReturnsMatchingEvent(t *testing.T) {
+	var handled []string
+	handler := func(ctx context.Context, event *Event) error {
+		handled = append(handled, string(event.Data))
+		return nil
+	}
+	pred := func(event *Event) bool { return string(event.Data) == "2" }
+
+	next := nextFrom(io.EOF, NewEventFromString("1"), NewEventFromString("2"), NewEventFromString("3"))
+	event, err := receiveUntil(context.Background(), next, pred, handler)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "2", string(event.Data))
+	}
+	assert.Equal(t, []string{"1", "2"}, handled, "events after the matching event should not be handled")
+}
+
+func TestReceiveUntilErrors(t *testing.T) {
+	never := func(event *Event) bool { return false }
+	boom := errors.New("boom")
+
+	_, err := receiveUntil(context.Background(), nextFrom(boom, NewEventFromString("1")), never, nil)
+	assert.Equal(t, boom, err)
+
+	_, err = receiveUntil(context.Background(), nextFrom(io.EOF, NewEventFromString("1")), never, nil)
+	assert.Error(t, err, "closing the receiver before a match should be an error")
+
+	failing := func(ctx context.Context, event *Event) error { return boom }
+	_, err = receiveUntil(context.Background(), nextFrom(io.EOF, NewEventFromString("1")), never, failing)
+	assert.Equal(t, boom, err)
+}
+
+func TestReceiveUntilRequiresPredicate(t *testing.T) {
+	hub, err := NewHub("namespace", "hub", nil)
+	if assert.NoError(t, err) {
+		_, err = hub.ReceiveUntil(context.Background(), "0", nil, nil)
+		assert.Error(t, err)
+	}
+}
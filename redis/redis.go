@@ -0,0 +1,627 @@
+// Package redis provides implementations for Checkpointer and Leaser from package eph for persisting leases and
+// checkpoints for the Event Processor Host using Redis as a durable store.
+package redis
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-amqp-common-go/log"
+	"github.com/Azure/azure-amqp-common-go/persist"
+	"github.com/Azure/azure-amqp-common-go/uuid"
+	"github.com/Azure/azure-event-hubs-go"
+	"github.com/Azure/azure-event-hubs-go/eph"
+	goredis "github.com/go-redis/redis"
+	"github.com/opentracing/opentracing-go"
+	tag "github.com/opentracing/opentracing-go/ext"
+	"github.com/pkg/errors"
+)
+
+const (
+	// DefaultKeyPrefix is the prefix applied to all keys written by the LeaserCheckpointer if none is provided
+	DefaultKeyPrefix = "eph"
+
+	ownerField          = "owner"
+	epochField          = "epoch"
+	offsetField         = "offset"
+	sequenceNumberField = "sequenceNumber"
+	enqueueTimeField    = "enqueueTime"
+)
+
+var (
+	// changeLeaseScript swaps the lease token if the caller presents the current token (or the lease has expired)
+	changeLeaseScript = goredis.NewScript(`
+local current = redis.call("GET", KEYS[1])
+if current == false or current == ARGV[1] then
+	return redis.call("SET", KEYS[1], ARGV[2], "PX", ARGV[3])
+end
+return false`)
+
+	// renewLeaseScript extends the TTL of the lease only if it is still held by the caller
+	renewLeaseScript = goredis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0`)
+
+	// releaseLeaseScript deletes the lease only if it is still held by the caller
+	releaseLeaseScript = goredis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+
+	// updateCheckpointScript writes the checkpoint fields to the partition hash only if the lease is still held by the
+	// caller
+	updateCheckpointScript = goredis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	redis.call("HMSET", KEYS[2], "offset", ARGV[2], "sequenceNumber", ARGV[3], "enqueueTime", ARGV[4])
+	return 1
+end
+return 0`)
+)
+
+type (
+	// LeaserCheckpointer implements the eph.Leaser and eph.Checkpointer interfaces for Redis
+	LeaserCheckpointer struct {
+		client        *goredis.Client
+		processor     processor
+		leaseDuration time.Duration
+		keyPrefix     string
+		leases        map[string]*redisLease
+		leasesMu      sync.Mutex
+	}
+
+	// LeaserCheckpointerOption provides configuration options for a Redis LeaserCheckpointer
+	LeaserCheckpointerOption func(*LeaserCheckpointer) error
+
+	// processor is the part of the EventProcessorHost the LeaserCheckpointer depends on
+	processor interface {
+		GetName() string
+		GetPartitionIDs() []string
+	}
+
+	redisLease struct {
+		*eph.Lease
+		leaser     *LeaserCheckpointer
+		Checkpoint *persist.Checkpoint `json:"checkpoint"`
+		Token      string              `json:"token"`
+	}
+)
+
+// WithKeyPrefix configures the prefix under which all lease and checkpoint keys are stored. Use distinct prefixes to
+// isolate multiple Event Hubs or consumer groups sharing the same Redis instance.
+func WithKeyPrefix(prefix string) LeaserCheckpointerOption {
+	return func(rl *LeaserCheckpointer) error {
+		if prefix == "" {
+			return errors.New("key prefix must not be empty")
+		}
+		rl.keyPrefix = prefix
+		return nil
+	}
+}
+
+// WithLeaseDuration configures the amount of time a lease is held before it expires in Redis
+func WithLeaseDuration(duration time.Duration) LeaserCheckpointerOption {
+	return func(rl *LeaserCheckpointer) error {
+		if duration <= 0 {
+			return errors.New("lease duration must be greater than 0")
+		}
+		rl.leaseDuration = duration
+		return nil
+	}
+}
+
+// NewLeaserCheckpointer builds a Redis Leaser Checkpointer which handles leasing and checkpointing for the
+// EventProcessorHost. Leases are held as keys with a TTL of the lease duration and checkpoints are stored in a hash
+// per partition.
+func NewLeaserCheckpointer(client *goredis.Client, opts ...LeaserCheckpointerOption) (*LeaserCheckpointer, error) {
+	if client == nil {
+		return nil, errors.New("redis client must not be nil")
+	}
+
+	rl := &LeaserCheckpointer{
+		client:        client,
+		leaseDuration: eph.DefaultLeaseDuration,
+		keyPrefix:     DefaultKeyPrefix,
+		leases:        make(map[string]*redisLease),
+	}
+
+	for _, opt := range opts {
+		if err := opt(rl); err != nil {
+			return nil, err
+		}
+	}
+	return rl, nil
+}
+
+// SetEventHostProcessor sets the EventHostProcessor on the instance of the LeaserCheckpointer
+func (rl *LeaserCheckpointer) SetEventHostProcessor(eph *eph.EventProcessorHost) {
+	rl.processor = eph
+}
+
+// StoreExists returns true if the store marker key exists in Redis
+func (rl *LeaserCheckpointer) StoreExists(ctx context.Context) (bool, error) {
+	span, ctx := startConsumerSpanFromContext(ctx, "eventhub.redis.LeaserCheckpointer.StoreExists")
+	defer span.Finish()
+
+	count, err := rl.client.WithContext(ctx).Exists(rl.storeKey()).Result()
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// EnsureStore writes the store marker key if it does not exist
+func (rl *LeaserCheckpointer) EnsureStore(ctx context.Context) error {
+	span, ctx := startConsumerSpanFromContext(ctx, "eventhub.redis.LeaserCheckpointer.EnsureStore")
+	defer span.Finish()
+
+	return rl.client.WithContext(ctx).SetNX(rl.storeKey(), time.Now().UTC().Format(time.RFC3339), 0).Err()
+}
+
+// DeleteStore deletes all of the keys under the configured prefix
+func (rl *LeaserCheckpointer) DeleteStore(ctx context.Context) error {
+	rl.leasesMu.Lock()
+	defer rl.leasesMu.Unlock()
+
+	span, ctx := startConsumerSpanFromContext(ctx, "eventhub.redis.LeaserCheckpointer.DeleteStore")
+	defer span.Finish()
+
+	client := rl.client.WithContext(ctx)
+	iter := client.Scan(0, rl.keyPrefix+":*", 100).Iterator()
+	var keys []string
+	for iter.Next() {
+		keys = append(keys, iter.Val())
+	}
+	if err := iter.Err(); err != nil {
+		return err
+	}
+
+	if len(keys) > 0 {
+		if err := client.Del(keys...).Err(); err != nil {
+			return err
+		}
+	}
+	rl.leases = make(map[string]*redisLease)
+	return nil
+}
+
+// GetLeases gets all of the partition leases
+func (rl *LeaserCheckpointer) GetLeases(ctx context.Context) ([]eph.LeaseMarker, error) {
+	rl.leasesMu.Lock()
+	defer rl.leasesMu.Unlock()
+
+	span, ctx := startConsumerSpanFromContext(ctx, "eventhub.redis.LeaserCheckpointer.GetLeases")
+	defer span.Finish()
+
+	partitionIDs := rl.processor.GetPartitionIDs()
+	leases := make([]eph.LeaseMarker, len(partitionIDs))
+	for idx, partitionID := range partitionIDs {
+		lease, err := rl.getLease(ctx, partitionID)
+		if err != nil {
+			return nil, err
+		}
+		leases[idx] = lease
+	}
+	return leases, nil
+}
+
+// EnsureLease creates a lease hash for the partition if it doesn't exist
+func (rl *LeaserCheckpointer) EnsureLease(ctx context.Context, partitionID string) (eph.LeaseMarker, error) {
+	rl.leasesMu.Lock()
+	defer rl.leasesMu.Unlock()
+
+	span, ctx := startConsumerSpanFromContext(ctx, "eventhub.redis.LeaserCheckpointer.EnsureLease")
+	defer span.Finish()
+
+	if err := rl.client.WithContext(ctx).HSetNX(rl.partitionKey(partitionID), epochField, 0).Err(); err != nil {
+		return nil, err
+	}
+	return rl.getLease(ctx, partitionID)
+}
+
+// DeleteLease deletes the lease and checkpoint for a partition
+func (rl *LeaserCheckpointer) DeleteLease(ctx context.Context, partitionID string) error {
+	rl.leasesMu.Lock()
+	defer rl.leasesMu.Unlock()
+
+	span, ctx := startConsumerSpanFromContext(ctx, "eventhub.redis.LeaserCheckpointer.DeleteLease")
+	defer span.Finish()
+
+	err := rl.client.WithContext(ctx).Del(rl.leaseKey(partitionID), rl.partitionKey(partitionID)).Err()
+	delete(rl.leases, partitionID)
+	return err
+}
+
+// AcquireLease acquires the lease for the partition by setting the lease key with a TTL of the lease duration
+func (rl *LeaserCheckpointer) AcquireLease(ctx context.Context, partitionID string) (eph.LeaseMarker, bool, error) {
+	rl.leasesMu.Lock()
+	defer rl.leasesMu.Unlock()
+
+	span, ctx := startConsumerSpanFromContext(ctx, "eventhub.redis.LeaserCheckpointer.AcquireLease")
+	defer span.Finish()
+
+	lease, err := rl.getLease(ctx, partitionID)
+	if err != nil {
+		log.For(ctx).Error(err)
+		return nil, false, err
+	}
+
+	uuidToken, err := uuid.NewV4()
+	if err != nil {
+		log.For(ctx).Error(err)
+		return nil, false, err
+	}
+
+	newToken := uuidToken.String()
+	client := rl.client.WithContext(ctx)
+	acquired, err := client.SetNX(rl.leaseKey(partitionID), newToken, rl.leaseDuration).Result()
+	if err != nil {
+		log.For(ctx).Error(err)
+		return nil, false, err
+	}
+
+	if !acquired {
+		// is leased by someone else due to a race to acquire
+		res, err := changeLeaseScript.Run(client, []string{rl.leaseKey(partitionID)}, lease.Token, newToken, durationMillis(rl.leaseDuration)).Result()
+		if err != nil && err != goredis.Nil {
+			log.For(ctx).Error(err)
+			return nil, false, err
+		}
+		if res == nil {
+			return nil, false, errors.New("failed to change lease")
+		}
+	}
+
+	lease.Token = newToken
+	lease.Owner = rl.processor.GetName()
+	lease.IncrementEpoch()
+	if err := rl.storeLease(ctx, lease); err != nil {
+		return nil, false, err
+	}
+	rl.leases[partitionID] = lease
+	return lease, true, nil
+}
+
+// RenewLease extends the TTL of the lease for the partition
+func (rl *LeaserCheckpointer) RenewLease(ctx context.Context, partitionID string) (eph.LeaseMarker, bool, error) {
+	rl.leasesMu.Lock()
+	defer rl.leasesMu.Unlock()
+
+	span, ctx := startConsumerSpanFromContext(ctx, "eventhub.redis.LeaserCheckpointer.RenewLease")
+	defer span.Finish()
+
+	lease, ok, err := rl.renewLease(ctx, partitionID)
+	if err != nil {
+		return nil, false, err
+	}
+	return lease, ok, nil
+}
+
+// ReleaseLease removes the lease key for the partition so other hosts may acquire it
+func (rl *LeaserCheckpointer) ReleaseLease(ctx context.Context, partitionID string) (bool, error) {
+	rl.leasesMu.Lock()
+	defer rl.leasesMu.Unlock()
+
+	span, ctx := startConsumerSpanFromContext(ctx, "eventhub.redis.LeaserCheckpointer.ReleaseLease")
+	defer span.Finish()
+
+	lease, ok := rl.leases[partitionID]
+	if !ok {
+		return false, errors.New("lease was not found")
+	}
+
+	client := rl.client.WithContext(ctx)
+	released, err := resultInt64(releaseLeaseScript.Run(client, []string{rl.leaseKey(partitionID)}, lease.Token))
+	if err != nil {
+		log.For(ctx).Error(err)
+		return false, err
+	}
+
+	if released == 0 {
+		return false, errors.New("could not release the lease")
+	}
+
+	if err := client.HSet(rl.partitionKey(partitionID), ownerField, "").Err(); err != nil {
+		log.For(ctx).Error(err)
+	}
+	delete(rl.leases, partitionID)
+	return true, nil
+}
+
+// UpdateLease renews the lease and writes the latest lease and checkpoint to the partition hash
+func (rl *LeaserCheckpointer) UpdateLease(ctx context.Context, partitionID string) (eph.LeaseMarker, bool, error) {
+	rl.leasesMu.Lock()
+	defer rl.leasesMu.Unlock()
+
+	span, ctx := startConsumerSpanFromContext(ctx, "eventhub.redis.LeaserCheckpointer.UpdateLease")
+	defer span.Finish()
+
+	lease, ok, err := rl.renewLease(ctx, partitionID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if !ok {
+		return nil, false, errors.New("could not renew lease when updating lease")
+	}
+
+	if err := rl.storeLease(ctx, lease); err != nil {
+		log.For(ctx).Error(err)
+		return nil, false, err
+	}
+	return lease, true, nil
+}
+
+// GetCheckpoint returns the latest checkpoint for the partitionID
+func (rl *LeaserCheckpointer) GetCheckpoint(ctx context.Context, partitionID string) (persist.Checkpoint, bool) {
+	rl.leasesMu.Lock()
+	defer rl.leasesMu.Unlock()
+
+	span, ctx := startConsumerSpanFromContext(ctx, "eventhub.redis.LeaserCheckpointer.GetCheckpoint")
+	defer span.Finish()
+
+	lease, ok := rl.leases[partitionID]
+	if ok && lease.Checkpoint != nil {
+		return *lease.Checkpoint, ok
+	}
+	return persist.NewCheckpointFromStartOfStream(), ok
+}
+
+// EnsureCheckpoint ensures a checkpoint exists for the lease
+func (rl *LeaserCheckpointer) EnsureCheckpoint(ctx context.Context, partitionID string) (persist.Checkpoint, error) {
+	rl.leasesMu.Lock()
+	defer rl.leasesMu.Unlock()
+
+	span, ctx := startConsumerSpanFromContext(ctx, "eventhub.redis.LeaserCheckpointer.EnsureCheckpoint")
+	defer span.Finish()
+
+	lease, ok := rl.leases[partitionID]
+	if ok {
+		if lease.Checkpoint == nil {
+			checkpoint := persist.NewCheckpointFromStartOfStream()
+			lease.Checkpoint = &checkpoint
+		}
+		return *lease.Checkpoint, nil
+	}
+	return persist.NewCheckpointFromStartOfStream(), nil
+}
+
+// UpdateCheckpoint writes the checkpoint to the partition hash if the lease is still held by this host
+func (rl *LeaserCheckpointer) UpdateCheckpoint(ctx context.Context, partitionID string, checkpoint persist.Checkpoint) error {
+	rl.leasesMu.Lock()
+	defer rl.leasesMu.Unlock()
+
+	span, ctx := startConsumerSpanFromContext(ctx, "eventhub.redis.LeaserCheckpointer.UpdateCheckpoint")
+	defer span.Finish()
+
+	lease, ok := rl.leases[partitionID]
+	if !ok {
+		return errors.New("lease for partition isn't owned by this EventProcessorHost")
+	}
+
+	if err := rl.writeCheckpoint(ctx, lease, checkpoint); err != nil {
+		return err
+	}
+	lease.Checkpoint = &checkpoint
+	return nil
+}
+
+// DeleteCheckpoint resets the checkpoint for the partition to the start of the stream
+func (rl *LeaserCheckpointer) DeleteCheckpoint(ctx context.Context, partitionID string) error {
+	rl.leasesMu.Lock()
+	defer rl.leasesMu.Unlock()
+
+	span, ctx := startConsumerSpanFromContext(ctx, "eventhub.redis.LeaserCheckpointer.DeleteCheckpoint")
+	defer span.Finish()
+
+	lease, ok := rl.leases[partitionID]
+	if !ok {
+		return errors.New("lease for partition isn't owned by this EventProcessorHost")
+	}
+
+	checkpoint := persist.NewCheckpointFromStartOfStream()
+	if err := rl.writeCheckpoint(ctx, lease, checkpoint); err != nil {
+		return err
+	}
+	lease.Checkpoint = &checkpoint
+	return nil
+}
+
+// Close is a no-op; the Redis client is owned by the caller and is not closed
+func (rl *LeaserCheckpointer) Close() error {
+	return nil
+}
+
+func (rl *LeaserCheckpointer) renewLease(ctx context.Context, partitionID string) (*redisLease, bool, error) {
+	lease, ok := rl.leases[partitionID]
+	if !ok {
+		return nil, false, errors.New("lease was not found")
+	}
+
+	renewed, err := resultInt64(renewLeaseScript.Run(rl.client.WithContext(ctx), []string{rl.leaseKey(partitionID)}, lease.Token, durationMillis(rl.leaseDuration)))
+	if err != nil {
+		log.For(ctx).Error(err)
+		return nil, false, err
+	}
+
+	if renewed == 0 {
+		return nil, false, errors.New("unable to renew lease")
+	}
+	return lease, true, nil
+}
+
+func (rl *LeaserCheckpointer) writeCheckpoint(ctx context.Context, lease *redisLease, checkpoint persist.Checkpoint) error {
+	keys := []string{rl.leaseKey(lease.PartitionID), rl.partitionKey(lease.PartitionID)}
+	written, err := resultInt64(updateCheckpointScript.Run(rl.client.WithContext(ctx), keys,
+		lease.Token,
+		checkpoint.Offset,
+		checkpoint.SequenceNumber,
+		checkpoint.EnqueueTime.Format(time.RFC3339Nano)))
+	if err != nil {
+		log.For(ctx).Error(err)
+		return err
+	}
+
+	if written == 0 {
+		return errors.New("lease for partition is no longer owned by this EventProcessorHost")
+	}
+	return nil
+}
+
+func (rl *LeaserCheckpointer) storeLease(ctx context.Context, lease *redisLease) error {
+	span, ctx := startConsumerSpanFromContext(ctx, "eventhub.redis.LeaserCheckpointer.storeLease")
+	defer span.Finish()
+
+	fields := map[string]interface{}{
+		ownerField: lease.Owner,
+		epochField: lease.Epoch,
+	}
+	if lease.Checkpoint != nil {
+		fields[offsetField] = lease.Checkpoint.Offset
+		fields[sequenceNumberField] = lease.Checkpoint.SequenceNumber
+		fields[enqueueTimeField] = lease.Checkpoint.EnqueueTime.Format(time.RFC3339Nano)
+	}
+	return rl.client.WithContext(ctx).HMSet(rl.partitionKey(lease.PartitionID), fields).Err()
+}
+
+func (rl *LeaserCheckpointer) getLease(ctx context.Context, partitionID string) (*redisLease, error) {
+	span, ctx := startConsumerSpanFromContext(ctx, "eventhub.redis.LeaserCheckpointer.getLease")
+	defer span.Finish()
+
+	client := rl.client.WithContext(ctx)
+	values, err := client.HGetAll(rl.partitionKey(partitionID)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := client.Get(rl.leaseKey(partitionID)).Result()
+	if err != nil && err != goredis.Nil {
+		return nil, err
+	}
+
+	return rl.leaseFromValues(partitionID, token, values)
+}
+
+func (rl *LeaserCheckpointer) leaseFromValues(partitionID, token string, values map[string]string) (*redisLease, error) {
+	lease := &redisLease{
+		Lease: &eph.Lease{
+			PartitionID: partitionID,
+			Owner:       values[ownerField],
+		},
+		leaser: rl,
+		Token:  token,
+	}
+
+	if epoch, ok := values[epochField]; ok {
+		parsed, err := strconv.ParseInt(epoch, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		lease.Epoch = parsed
+	}
+
+	if offset, ok := values[offsetField]; ok {
+		checkpoint := persist.NewCheckpointFromStartOfStream()
+		checkpoint.Offset = offset
+		if seq, ok := values[sequenceNumberField]; ok {
+			parsed, err := strconv.ParseInt(seq, 10, 64)
+			if err != nil {
+				return nil, err
+			}
+			checkpoint.SequenceNumber = parsed
+		}
+		if enqueueTime, ok := values[enqueueTimeField]; ok {
+			parsed, err := time.Parse(time.RFC3339Nano, enqueueTime)
+			if err != nil {
+				return nil, err
+			}
+			checkpoint.EnqueueTime = parsed
+		}
+		lease.Checkpoint = &checkpoint
+	}
+	return lease, nil
+}
+
+func (rl *LeaserCheckpointer) storeKey() string {
+	return rl.keyPrefix + ":store"
+}
+
+func (rl *LeaserCheckpointer) leaseKey(partitionID string) string {
+	return rl.keyPrefix + ":lease:" + partitionID
+}
+
+func (rl *LeaserCheckpointer) partitionKey(partitionID string) string {
+	return rl.keyPrefix + ":partition:" + partitionID
+}
+
+// IsExpired checks to see if the lease key has expired in Redis
+func (l *redisLease) IsExpired(ctx context.Context) bool {
+	span, ctx := startConsumerSpanFromContext(ctx, "eventhub.redis.redisLease.IsExpired")
+	defer span.Finish()
+
+	count, err := l.leaser.client.WithContext(ctx).Exists(l.leaser.leaseKey(l.PartitionID)).Result()
+	if err != nil {
+		return false
+	}
+	return count == 0
+}
+
+func (l *redisLease) String() string {
+	bits, err := json.Marshal(l)
+	if err != nil {
+		return ""
+	}
+	return string(bits)
+}
+
+func resultInt64(cmd *goredis.Cmd) (int64, error) {
+	res, err := cmd.Result()
+	if err != nil {
+		return 0, err
+	}
+
+	val, ok := res.(int64)
+	if !ok {
+		return 0, errors.Errorf("unexpected script result type %T", res)
+	}
+	return val, nil
+}
+
+func durationMillis(d time.Duration) int64 {
+	return int64(d / time.Millisecond)
+}
+
+func startConsumerSpanFromContext(ctx context.Context, operationName string, opts ...opentracing.StartSpanOption) (opentracing.Span, context.Context) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, operationName, opts...)
+	eventhub.ApplyComponentInfo(span)
+	tag.SpanKindRPCClient.Set(span)
+	span.SetTag("eventhub.eventprocessorhost.kind", "redis")
+	return span, ctx
+}
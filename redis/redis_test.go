@@ -0,0 +1,476 @@
+package redis
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-amqp-common-go/persist"
+	goredis "github.com/go-redis/redis"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type (
+	// fakeRedis serves the subset of the Redis protocol used by the LeaserCheckpointer from memory, running the Lua
+	// scripts of the LeaserCheckpointer as their Go equivalents
+	fakeRedis struct {
+		mu      sync.Mutex
+		strings map[string]fakeString
+		hashes  map[string]map[string]string
+	}
+
+	fakeString struct {
+		value   string
+		expires time.Time
+	}
+
+	fakeHost struct {
+		name         string
+		partitionIDs []string
+	}
+)
+
+func newFakeRedis() *fakeRedis {
+	return &fakeRedis{
+		strings: make(map[string]fakeString),
+		hashes:  make(map[string]map[string]string),
+	}
+}
+
+func (h fakeHost) GetName() string {
+	return h.name
+}
+
+func (h fakeHost) GetPartitionIDs() []string {
+	return h.partitionIDs
+}
+
+// client returns a Redis client connected to the fake over in-memory connections
+func (f *fakeRedis) client() *goredis.Client {
+	return goredis.NewClient(&goredis.Options{
+		Dialer: func() (net.Conn, error) {
+			client, server := net.Pipe()
+			go f.serve(server)
+			return client, nil
+		},
+	})
+}
+
+func (f *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			return
+		}
+		if _, err := io.WriteString(conn, f.do(args)); err != nil {
+			return
+		}
+	}
+}
+
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	count, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "*")))
+	if err != nil {
+		return nil, err
+	}
+
+	args := make([]string, count)
+	for i := range args {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "$")))
+		if err != nil {
+			return nil, err
+		}
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		args[i] = string(buf[:size])
+	}
+	return args, nil
+}
+
+func (f *fakeRedis) do(args []string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch strings.ToLower(args[0]) {
+	case "exists":
+		count := 0
+		for _, key := range args[1:] {
+			if _, ok := f.get(key); ok {
+				count++
+			} else if _, ok := f.hashes[key]; ok {
+				count++
+			}
+		}
+		return integerReply(int64(count))
+	case "get":
+		if value, ok := f.get(args[1]); ok {
+			return bulkReply(value)
+		}
+		return nilReply
+	case "setnx":
+		if _, ok := f.get(args[1]); ok {
+			return integerReply(0)
+		}
+		f.strings[args[1]] = fakeString{value: args[2]}
+		return integerReply(1)
+	case "set":
+		var expires time.Time
+		for i := 3; i < len(args); i++ {
+			switch strings.ToLower(args[i]) {
+			case "px":
+				ms, _ := strconv.ParseInt(args[i+1], 10, 64)
+				expires = time.Now().Add(time.Duration(ms) * time.Millisecond)
+				i++
+			case "nx":
+				if _, ok := f.get(args[1]); ok {
+					return nilReply
+				}
+			}
+		}
+		f.strings[args[1]] = fakeString{value: args[2], expires: expires}
+		return "+OK\r\n"
+	case "del":
+		return integerReply(f.del(args[1:]...))
+	case "hsetnx":
+		hash := f.hash(args[1])
+		if _, ok := hash[args[2]]; ok {
+			return integerReply(0)
+		}
+		hash[args[2]] = args[3]
+		return integerReply(1)
+	case "hset":
+		hash := f.hash(args[1])
+		_, existed := hash[args[2]]
+		hash[args[2]] = args[3]
+		if existed {
+			return integerReply(0)
+		}
+		return integerReply(1)
+	case "hmset":
+		f.hmset(args[1], args[2:]...)
+		return "+OK\r\n"
+	case "hgetall":
+		var values []string
+		for field, value := range f.hashes[args[1]] {
+			values = append(values, field, value)
+		}
+		return arrayReply(values)
+	case "scan":
+		var keys []string
+		for key := range f.strings {
+			if ok, _ := filepath.Match(args[3], key); ok {
+				keys = append(keys, key)
+			}
+		}
+		for key := range f.hashes {
+			if ok, _ := filepath.Match(args[3], key); ok {
+				keys = append(keys, key)
+			}
+		}
+		return "*2\r\n" + bulkReply("0") + arrayReply(keys)
+	case "evalsha":
+		return f.runScript(args[1], args[3:])
+	default:
+		return fmt.Sprintf("-ERR unknown command '%s'\r\n", args[0])
+	}
+}
+
+// runScript runs the Go equivalent of the LeaserCheckpointer script with the hash
+func (f *fakeRedis) runScript(sha string, keysAndArgs []string) string {
+	switch sha {
+	case changeLeaseScript.Hash():
+		if current, ok := f.get(keysAndArgs[0]); ok && current != keysAndArgs[1] {
+			return nilReply
+		}
+		ms, _ := strconv.ParseInt(keysAndArgs[3], 10, 64)
+		f.strings[keysAndArgs[0]] = fakeString{value: keysAndArgs[2], expires: time.Now().Add(time.Duration(ms) * time.Millisecond)}
+		return "+OK\r\n"
+	case renewLeaseScript.Hash():
+		if current, ok := f.get(keysAndArgs[0]); !ok || current != keysAndArgs[1] {
+			return integerReply(0)
+		}
+		ms, _ := strconv.ParseInt(keysAndArgs[2], 10, 64)
+		f.strings[keysAndArgs[0]] = fakeString{value: keysAndArgs[1], expires: time.Now().Add(time.Duration(ms) * time.Millisecond)}
+		return integerReply(1)
+	case releaseLeaseScript.Hash():
+		if current, ok := f.get(keysAndArgs[0]); !ok || current != keysAndArgs[1] {
+			return integerReply(0)
+		}
+		return integerReply(f.del(keysAndArgs[0]))
+	case updateCheckpointScript.Hash():
+		if current, ok := f.get(keysAndArgs[0]); !ok || current != keysAndArgs[2] {
+			return integerReply(0)
+		}
+		f.hmset(keysAndArgs[1], offsetField, keysAndArgs[3], sequenceNumberField, keysAndArgs[4], enqueueTimeField, keysAndArgs[5])
+		return integerReply(1)
+	default:
+		return "-NOSCRIPT No matching script.\r\n"
+	}
+}
+
+func (f *fakeRedis) get(key string) (string, bool) {
+	value, ok := f.strings[key]
+	if ok && !value.expires.IsZero() && time.Now().After(value.expires) {
+		delete(f.strings, key)
+		return "", false
+	}
+	return value.value, ok
+}
+
+func (f *fakeRedis) del(keys ...string) int64 {
+	var count int64
+	for _, key := range keys {
+		if _, ok := f.get(key); ok {
+			delete(f.strings, key)
+			count++
+		} else if _, ok := f.hashes[key]; ok {
+			delete(f.hashes, key)
+			count++
+		}
+	}
+	return count
+}
+
+func (f *fakeRedis) hash(key string) map[string]string {
+	hash, ok := f.hashes[key]
+	if !ok {
+		hash = make(map[string]string)
+		f.hashes[key] = hash
+	}
+	return hash
+}
+
+func (f *fakeRedis) hmset(key string, fieldsAndValues ...string) {
+	hash := f.hash(key)
+	for i := 0; i+1 < len(fieldsAndValues); i += 2 {
+		hash[fieldsAndValues[i]] = fieldsAndValues[i+1]
+	}
+}
+
+const nilReply = "$-1\r\n"
+
+func integerReply(n int64) string {
+	return fmt.Sprintf(":%d\r\n", n)
+}
+
+func bulkReply(s string) string {
+	return fmt.Sprintf("$%d\r\n%s\r\n", len(s), s)
+}
+
+func arrayReply(values []string) string {
+	reply := fmt.Sprintf("*%d\r\n", len(values))
+	for _, value := range values {
+		reply += bulkReply(value)
+	}
+	return reply
+}
+
+func newTestLeaserCheckpointer(t *testing.T, store *fakeRedis, name string, opts ...LeaserCheckpointerOption) *LeaserCheckpointer {
+	rl, err := NewLeaserCheckpointer(store.client(), opts...)
+	require.NoError(t, err)
+	rl.processor = fakeHost{name: name, partitionIDs: []string{"0", "1"}}
+	return rl
+}
+
+func TestNewLeaserCheckpointerValidation(t *testing.T) {
+	_, err := NewLeaserCheckpointer(nil)
+	assert.Error(t, err)
+
+	client := newFakeRedis().client()
+	_, err = NewLeaserCheckpointer(client, WithKeyPrefix(""))
+	assert.Error(t, err)
+	_, err = NewLeaserCheckpointer(client, WithLeaseDuration(0))
+	assert.Error(t, err)
+
+	rl, err := NewLeaserCheckpointer(client, WithKeyPrefix("hub"), WithLeaseDuration(time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, "hub:lease:0", rl.leaseKey("0"))
+	assert.Equal(t, time.Minute, rl.leaseDuration)
+}
+
+func TestLeaserCheckpointerStore(t *testing.T) {
+	ctx := context.Background()
+	rl := newTestLeaserCheckpointer(t, newFakeRedis(), "host")
+
+	exists, err := rl.StoreExists(ctx)
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	require.NoError(t, rl.EnsureStore(ctx))
+	exists, err = rl.StoreExists(ctx)
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	_, err = rl.EnsureLease(ctx, "0")
+	require.NoError(t, err)
+	require.NoError(t, rl.DeleteStore(ctx))
+	exists, err = rl.StoreExists(ctx)
+	require.NoError(t, err)
+	assert.False(t, exists, "deleting the store should remove every key under the prefix")
+}
+
+func TestLeaserCheckpointerAcquireRenewRelease(t *testing.T) {
+	ctx := context.Background()
+	rl := newTestLeaserCheckpointer(t, newFakeRedis(), "host")
+	require.NoError(t, rl.EnsureStore(ctx))
+	for _, partitionID := range []string{"0", "1"} {
+		_, err := rl.EnsureLease(ctx, partitionID)
+		require.NoError(t, err)
+	}
+
+	leases, err := rl.GetLeases(ctx)
+	require.NoError(t, err)
+	require.Len(t, leases, 2)
+	assert.True(t, leases[0].IsExpired(ctx), "a lease which was never acquired is expired")
+
+	lease, ok, err := rl.AcquireLease(ctx, "0")
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, "host", lease.GetOwner())
+	assert.Equal(t, int64(1), lease.GetEpoch())
+	assert.False(t, lease.IsExpired(ctx))
+
+	renewed, ok, err := rl.RenewLease(ctx, "0")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, int64(1), renewed.GetEpoch(), "renewing should not change the epoch")
+
+	released, err := rl.ReleaseLease(ctx, "0")
+	require.NoError(t, err)
+	assert.True(t, released)
+	assert.True(t, lease.IsExpired(ctx))
+	leases, err = rl.GetLeases(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "", leases[0].GetOwner(), "the owner should be cleared on release")
+
+	_, _, err = rl.RenewLease(ctx, "0")
+	assert.Error(t, err, "a released lease can't be renewed")
+	_, err = rl.ReleaseLease(ctx, "0")
+	assert.Error(t, err)
+}
+
+func TestLeaserCheckpointerStealLease(t *testing.T) {
+	ctx := context.Background()
+	store := newFakeRedis()
+	first := newTestLeaserCheckpointer(t, store, "first")
+	second := newTestLeaserCheckpointer(t, store, "second")
+	_, err := first.EnsureLease(ctx, "0")
+	require.NoError(t, err)
+
+	_, ok, err := first.AcquireLease(ctx, "0")
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	stolen, ok, err := second.AcquireLease(ctx, "0")
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, "second", stolen.GetOwner())
+	assert.Equal(t, int64(2), stolen.GetEpoch(), "stealing a lease should increment its epoch")
+
+	_, _, err = first.RenewLease(ctx, "0")
+	assert.Error(t, err, "the previous owner should no longer be able to renew")
+	assert.Error(t, first.UpdateCheckpoint(ctx, "0", persist.NewCheckpoint("10", 10, time.Now())), "the previous owner should no longer be able to checkpoint")
+	released, err := first.ReleaseLease(ctx, "0")
+	assert.Error(t, err)
+	assert.False(t, released)
+	assert.False(t, stolen.IsExpired(ctx), "a failed release should leave the new owner's lease alone")
+}
+
+func TestLeaserCheckpointerLeaseExpires(t *testing.T) {
+	ctx := context.Background()
+	rl := newTestLeaserCheckpointer(t, newFakeRedis(), "host", WithLeaseDuration(20*time.Millisecond))
+	_, err := rl.EnsureLease(ctx, "0")
+	require.NoError(t, err)
+
+	lease, ok, err := rl.AcquireLease(ctx, "0")
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.False(t, lease.IsExpired(ctx))
+
+	time.Sleep(40 * time.Millisecond)
+	assert.True(t, lease.IsExpired(ctx))
+	_, _, err = rl.RenewLease(ctx, "0")
+	assert.Error(t, err, "an expired lease can't be renewed")
+}
+
+func TestLeaserCheckpointerUpdateCheckpoint(t *testing.T) {
+	ctx := context.Background()
+	store := newFakeRedis()
+	rl := newTestLeaserCheckpointer(t, store, "host")
+	_, err := rl.EnsureLease(ctx, "0")
+	require.NoError(t, err)
+
+	checkpoint := persist.NewCheckpoint("10", 10, time.Date(2018, 6, 1, 12, 0, 0, 500, time.UTC))
+	assert.Error(t, rl.UpdateCheckpoint(ctx, "0", checkpoint), "checkpoints can't be written without the lease")
+
+	_, ok, err := rl.AcquireLease(ctx, "0")
+	require.NoError(t, err)
+	require.True(t, ok)
+	initial, err := rl.EnsureCheckpoint(ctx, "0")
+	require.NoError(t, err)
+	assert.Equal(t, persist.NewCheckpointFromStartOfStream(), initial)
+
+	require.NoError(t, rl.UpdateCheckpoint(ctx, "0", checkpoint))
+	got, ok := rl.GetCheckpoint(ctx, "0")
+	assert.True(t, ok)
+	assert.Equal(t, checkpoint, got)
+
+	// the next owner of the partition resumes from the checkpoint stored in Redis
+	released, err := rl.ReleaseLease(ctx, "0")
+	require.NoError(t, err)
+	require.True(t, released)
+	next := newTestLeaserCheckpointer(t, store, "next")
+	_, ok, err = next.AcquireLease(ctx, "0")
+	require.NoError(t, err)
+	require.True(t, ok)
+	got, ok = next.GetCheckpoint(ctx, "0")
+	assert.True(t, ok)
+	assert.Equal(t, checkpoint, got)
+
+	require.NoError(t, next.DeleteCheckpoint(ctx, "0"))
+	got, _ = next.GetCheckpoint(ctx, "0")
+	assert.Equal(t, persist.NewCheckpointFromStartOfStream(), got)
+}
@@ -0,0 +1,112 @@
+package eventhub
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"context"
+	"time"
+
+	"github.com/Azure/azure-event-hubs-go/mgmt"
+	"github.com/pkg/errors"
+)
+
+type (
+	// replayRange decides which events of a partition are replayed and when the replay is complete
+	replayRange struct {
+		to time.Time
+		// lastSequenceNumber is the sequence number of the last event enqueued when the replay started
+		lastSequenceNumber int64
+	}
+)
+
+// ReplayPartition hands the handler each event of the partition enqueued from the from time through the to time,
+// inclusive, and returns nil once the range is exhausted. The range is exhausted when an event enqueued after the to
+// time is read, or once the last event enqueued in the partition when ReplayPartition was called has been handled, so
+// events enqueued after the call are not replayed. Replay stops with the error of the handler if it returns one, or
+// with the context error if the context is done first. The receive options may set the consumer group, but not a
+// starting position.
+func (h *Hub) ReplayPartition(ctx context.Context, partitionID string, from, to time.Time, handler Handler, opts ...ReceiveOption) error {
+	span, ctx := h.startSpanFromContext(ctx, "eventhub.Hub.ReplayPartition")
+	defer span.Finish()
+
+	if to.Before(from) {
+		return errors.Errorf("replay range is empty: %v is before %v", to, from)
+	}
+
+	info, err := h.GetPartitionInformation(ctx, partitionID)
+	if err != nil {
+		return err
+	}
+	if isReplayRangeEmpty(info, from) {
+		return nil
+	}
+
+	opts = append(opts, ReceiveFromTimestamp(from), ReceiveWithInclusiveStart())
+	reader, err := h.NewPartitionReader(ctx, partitionID, opts...)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := reader.Close(ctx); err != nil {
+			h.logFor(ctx).Error(err)
+		}
+	}()
+
+	rng := replayRange{to: to, lastSequenceNumber: info.LastSequenceNumber}
+	for {
+		event, err := reader.Next(ctx)
+		if err != nil {
+			return err
+		}
+
+		handle, done := rng.next(event)
+		if handle {
+			if err := handler(ctx, event); err != nil {
+				return err
+			}
+		}
+		if done {
+			return nil
+		}
+	}
+}
+
+// isReplayRangeEmpty returns true if the partition has no events enqueued at or after the from time
+func isReplayRangeEmpty(info *mgmt.HubPartitionRuntimeInformation, from time.Time) bool {
+	if info.LastSequenceNumber < 0 || info.LastSequenceNumber < info.BeginningSequenceNumber {
+		return true
+	}
+	return info.LastEnqueuedTimeUtc.Before(from)
+}
+
+// next returns whether the event is within the range and should be handled, and whether the replay is complete
+func (r replayRange) next(event *Event) (handle bool, done bool) {
+	props := event.SystemProperties
+	if props == nil {
+		return true, false
+	}
+	if props.EnqueuedTime.After(r.to) {
+		return false, true
+	}
+	return true, props.SequenceNumber >= r.lastSequenceNumber
+}
@@ -0,0 +1,63 @@
+package eventhub
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-event-hubs-go/mgmt"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReplayRange(t *testing.T) {
+	to := time.Date(2018, 6, 1, 0, 0, 0, 0, time.UTC)
+	rng := replayRange{to: to, lastSequenceNumber: 10}
+	event := func(seq int64, enqueued time.Time) *Event {
+		return &Event{SystemProperties: &SystemProperties{SequenceNumber: seq, EnqueuedTime: enqueued}}
+	}
+
+	handle, done := rng.next(event(1, to.Add(-time.Minute)))
+	assert.True(t, handle)
+	assert.False(t, done)
+
+	handle, done = rng.next(event(2, to))
+	assert.True(t, handle, "the to time is inclusive")
+	assert.False(t, done)
+
+	handle, done = rng.next(event(3, to.Add(time.Millisecond)))
+	assert.False(t, handle, "events enqueued after the to time are not replayed")
+	assert.True(t, done)
+
+	handle, done = rng.next(event(10, to.Add(-time.Minute)))
+	assert.True(t, handle)
+	assert.True(t, done, "the replay completes with the last event enqueued when it started")
+}
+
+func TestIsReplayRangeEmpty(t *testing.T) {
+	from := time.Date(2018, 6, 1, 0, 0, 0, 0, time.UTC)
+	assert.True(t, isReplayRangeEmpty(&mgmt.HubPartitionRuntimeInformation{LastSequenceNumber: -1}, from))
+	assert.True(t, isReplayRangeEmpty(&mgmt.HubPartitionRuntimeInformation{BeginningSequenceNumber: 5, LastSequenceNumber: 4, LastEnqueuedTimeUtc: from}, from))
+	assert.True(t, isReplayRangeEmpty(&mgmt.HubPartitionRuntimeInformation{LastSequenceNumber: 4, LastEnqueuedTimeUtc: from.Add(-time.Second)}, from))
+	assert.False(t, isReplayRangeEmpty(&mgmt.HubPartitionRuntimeInformation{LastSequenceNumber: 4, LastEnqueuedTimeUtc: from}, from))
+}
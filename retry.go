@@ -0,0 +1,154 @@
+package eventhub
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"context"
+	"math/rand"
+	"net"
+	"time"
+
+	"github.com/Azure/azure-amqp-common-go"
+	"github.com/pkg/errors"
+	"pack.ag/amqp"
+)
+
+const (
+	// DefaultRetryMaxAttempts is the number of attempts made by the default ExponentialBackoffRetryPolicy
+	DefaultRetryMaxAttempts = 5
+
+	// DefaultRetryMinDelay is the delay before the first retry of the default ExponentialBackoffRetryPolicy
+	DefaultRetryMinDelay = 1 * time.Second
+
+	// DefaultRetryMaxDelay is the longest delay between retries of the default ExponentialBackoffRetryPolicy
+	DefaultRetryMaxDelay = 30 * time.Second
+)
+
+type (
+	// RetryPolicy decides whether a failed operation should be attempted again, and after what delay. attempt is the
+	// number of attempts which have failed so far, starting at 1.
+	RetryPolicy interface {
+		ShouldRetry(err error, attempt int) (bool, time.Duration)
+	}
+
+	// ExponentialBackoffRetryPolicy retries transient errors up to MaxAttempts times, doubling the delay after each
+	// attempt from MinDelay up to MaxDelay. Each delay is jittered between half and the full computed delay.
+	ExponentialBackoffRetryPolicy struct {
+		MaxAttempts int
+		MinDelay    time.Duration
+		MaxDelay    time.Duration
+	}
+)
+
+// NewExponentialBackoffRetryPolicy creates an ExponentialBackoffRetryPolicy with the default attempts and delays
+func NewExponentialBackoffRetryPolicy() *ExponentialBackoffRetryPolicy {
+	return &ExponentialBackoffRetryPolicy{
+		MaxAttempts: DefaultRetryMaxAttempts,
+		MinDelay:    DefaultRetryMinDelay,
+		MaxDelay:    DefaultRetryMaxDelay,
+	}
+}
+
+// HubWithRetryPolicy configures the Hub to retry sends, management operations and link recovery according to the
+// policy rather than the built in fixed retries
+func HubWithRetryPolicy(policy RetryPolicy) HubOption {
+	return func(h *Hub) error {
+		if policy == nil {
+			return errors.New("retry policy must not be nil")
+		}
+		h.retryPolicy = policy
+		return nil
+	}
+}
+
+// ShouldRetry returns true with a jittered exponential delay if the error is transient and fewer than MaxAttempts
+// attempts have been made
+func (p *ExponentialBackoffRetryPolicy) ShouldRetry(err error, attempt int) (bool, time.Duration) {
+	if attempt >= p.MaxAttempts || !IsTransient(err) {
+		return false, 0
+	}
+
+	delay := p.MinDelay
+	for i := 1; i < attempt && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+
+	half := delay / 2
+	if half > 0 {
+		delay = half + time.Duration(rand.Int63n(int64(half)+1))
+	}
+	return true, delay
+}
+
+// IsTransient returns true if the error is likely to be resolved by retrying the operation, such as the broker being
+// busy or a connection, session or link having been closed
+func IsTransient(err error) bool {
+	if err == nil || isLinkStolen(err) || IsReceiverDisconnected(err) {
+		return false
+	}
+
+	switch e := errors.Cause(err).(type) {
+	case common.Retryable, *ServerBusyError:
+		return true
+	case *amqp.DetachError:
+		return true
+	case *amqp.Error:
+		switch e.Condition {
+		case "com.microsoft:server-busy", "com.microsoft:timeout", amqp.ErrorInternalError, amqp.ErrorConnectionForced, amqp.ErrorDetachForced:
+			return true
+		}
+		return false
+	case net.Error:
+		return e.Temporary() || e.Timeout()
+	}
+
+	switch errors.Cause(err) {
+	case amqp.ErrConnClosed, amqp.ErrSessionClosed, amqp.ErrLinkClosed, amqp.ErrTimeout, context.DeadlineExceeded:
+		return true
+	}
+	return false
+}
+
+// retry invokes the operation until it succeeds, the policy declines to retry it, or the context is done
+func retry(ctx context.Context, policy RetryPolicy, operation func(ctx context.Context) error) error {
+	for attempt := 1; ; attempt++ {
+		err := operation(ctx)
+		if err == nil {
+			return nil
+		}
+
+		retry, delay := policy.ShouldRetry(err, attempt)
+		if !retry {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+	}
+}
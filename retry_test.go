@@ -0,0 +1,94 @@
+package eventhub
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"pack.ag/amqp"
+)
+
+func TestIsTransient(t *testing.T) {
+	tests := map[string]struct {
+		err       error
+		transient bool
+	}{
+		"Nil":          {err: nil},
+		"ServerBusy":   {err: &amqp.Error{Condition: "com.microsoft:server-busy"}, transient: true},
+		"Unauthorized": {err: &amqp.Error{Condition: amqp.ErrorUnauthorizedAccess}},
+		"LinkClosed":   {err: amqp.ErrLinkClosed, transient: true},
+		"Detached":     {err: &amqp.DetachError{}, transient: true},
+		"Deadline":     {err: context.DeadlineExceeded, transient: true},
+		"Other":        {err: errors.New("boom")},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tt.transient, IsTransient(tt.err))
+		})
+	}
+}
+
+func TestExponentialBackoffRetryPolicy(t *testing.T) {
+	policy := &ExponentialBackoffRetryPolicy{MaxAttempts: 5, MinDelay: 100 * time.Millisecond, MaxDelay: 300 * time.Millisecond}
+	busy := &amqp.Error{Condition: "com.microsoft:server-busy"}
+
+	for attempt, max := range map[int]time.Duration{1: 100 * time.Millisecond, 2: 200 * time.Millisecond, 3: 300 * time.Millisecond, 4: 300 * time.Millisecond} {
+		retry, delay := policy.ShouldRetry(busy, attempt)
+		assert.True(t, retry)
+		assert.True(t, delay >= max/2 && delay <= max, "attempt %d delay %v should be between %v and %v", attempt, delay, max/2, max)
+	}
+
+	retry, _ := policy.ShouldRetry(busy, 5)
+	assert.False(t, retry, "should not retry once max attempts have been made")
+
+	retry, _ = policy.ShouldRetry(errors.New("boom"), 1)
+	assert.False(t, retry, "should not retry errors which are not transient")
+}
+
+func TestRetry(t *testing.T) {
+	policy := &ExponentialBackoffRetryPolicy{MaxAttempts: 3, MinDelay: time.Millisecond, MaxDelay: time.Millisecond}
+
+	var calls int
+	err := retry(context.Background(), policy, func(ctx context.Context) error {
+		calls++
+		if calls < 3 {
+			return amqp.ErrLinkClosed
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, calls)
+
+	calls = 0
+	err = retry(context.Background(), policy, func(ctx context.Context) error {
+		calls++
+		return amqp.ErrLinkClosed
+	})
+	assert.Equal(t, amqp.ErrLinkClosed, err)
+	assert.Equal(t, 3, calls)
+}
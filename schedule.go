@@ -0,0 +1,79 @@
+package eventhub
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+	"pack.ag/amqp"
+)
+
+// SetScheduledEnqueueTime sets the x-opt-scheduled-enqueue-time message annotation of the event to the given time.
+// Event Hubs does not support scheduled enqueue: it does not honor the annotation, so the event is enqueued when it is
+// sent, or the broker rejects the send. The annotation is only acted upon by brokers which support scheduling, such as
+// Service Bus.
+func (e *Event) SetScheduledEnqueueTime(t time.Time) {
+	e.scheduledEnqueueTime = &t
+}
+
+// ScheduledEnqueueTime returns the time the event is scheduled to be enqueued and true, or false if the event is not
+// scheduled
+func (e *Event) ScheduledEnqueueTime() (time.Time, bool) {
+	if e.scheduledEnqueueTime == nil {
+		return time.Time{}, false
+	}
+	return *e.scheduledEnqueueTime, true
+}
+
+// scheduledEnqueueTime returns the scheduled enqueue time shared by the events of the batch. The annotation is set on
+// the batch as a whole, so an error is returned if the events are scheduled at different times.
+func (b *EventBatch) scheduledEnqueueTime() (*time.Time, error) {
+	var scheduled *time.Time
+	for idx, event := range b.Events {
+		if idx > 0 && !sameScheduledEnqueueTime(scheduled, event.scheduledEnqueueTime) {
+			return nil, errors.New("all events of a batch must have the same scheduled enqueue time")
+		}
+		scheduled = event.scheduledEnqueueTime
+	}
+	return scheduled, nil
+}
+
+// scheduledSendError adds context to an error returned by the broker for a scheduled event, as Event Hubs may reject
+// the x-opt-scheduled-enqueue-time annotation since it does not support scheduling
+func (e *Event) scheduledSendError(err error) error {
+	if err == nil || e.scheduledEnqueueTime == nil || IsTransient(err) {
+		return err
+	}
+	if _, ok := errors.Cause(err).(*amqp.Error); !ok {
+		return err
+	}
+	return errors.Wrap(err, "failed to send event with a scheduled enqueue time; Event Hubs does not support scheduled enqueue")
+}
+
+func sameScheduledEnqueueTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
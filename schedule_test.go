@@ -0,0 +1,84 @@
+package eventhub
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"pack.ag/amqp"
+)
+
+func TestEventScheduledEnqueueTimeRoundTrip(t *testing.T) {
+	scheduled := time.Date(2018, 6, 1, 0, 0, 0, 0, time.UTC)
+	event := NewEventFromString("foo")
+	_, ok := event.ScheduledEnqueueTime()
+	assert.False(t, ok)
+	assert.Nil(t, event.toMsg().Annotations[scheduledEnqueueTimeName])
+
+	event.SetScheduledEnqueueTime(scheduled)
+	msg := event.toMsg()
+	assert.Equal(t, scheduled, msg.Annotations[scheduledEnqueueTimeName])
+
+	received, ok := eventFromMsg(msg).ScheduledEnqueueTime()
+	assert.True(t, ok)
+	assert.Equal(t, scheduled, received)
+}
+
+func TestEventBatchScheduledEnqueueTime(t *testing.T) {
+	scheduled := time.Date(2018, 6, 1, 0, 0, 0, 0, time.UTC)
+	first, second := NewEventFromString("foo"), NewEventFromString("bar")
+	first.SetScheduledEnqueueTime(scheduled)
+	second.SetScheduledEnqueueTime(scheduled)
+
+	event, err := NewEventBatch([]*Event{first, second}).toEvent()
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, scheduled, event.toMsg().Annotations[scheduledEnqueueTimeName])
+
+	second.SetScheduledEnqueueTime(scheduled.Add(time.Minute))
+	_, err = NewEventBatch([]*Event{first, second}).toEvent()
+	assert.EqualError(t, err, "all events of a batch must have the same scheduled enqueue time")
+
+	_, err = NewEventBatch([]*Event{first, NewEventFromString("baz")}).toEvent()
+	assert.Error(t, err)
+}
+
+func TestEventScheduledSendError(t *testing.T) {
+	rejected := &amqp.Error{Condition: amqp.ErrorNotImplemented}
+	event := NewEventFromString("foo")
+	assert.Equal(t, rejected, event.scheduledSendError(rejected), "unscheduled events should not wrap errors")
+
+	event.SetScheduledEnqueueTime(time.Now().Add(time.Minute))
+	assert.Nil(t, event.scheduledSendError(nil))
+	other := errors.New("boom")
+	assert.Equal(t, other, event.scheduledSendError(other))
+
+	err := event.scheduledSendError(rejected)
+	assert.Contains(t, err.Error(), "scheduled enqueue")
+	busy := &amqp.Error{Condition: "com.microsoft:server-busy"}
+	assert.Equal(t, busy, event.scheduledSendError(busy), "transient errors should not be attributed to scheduling")
+}
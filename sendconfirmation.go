@@ -0,0 +1,55 @@
+package eventhub
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"github.com/pkg/errors"
+)
+
+// HubWithSendConfirmation configures the Hub to invoke confirm with each event sent by Send, SendAsync and SendBatch
+// once its transfer has settled, with the error of the send or nil if the broker accepted it. Each event of a batch is
+// confirmed with the outcome of the batch. Sends which fail before a transfer is attempted, such as when the sender
+// link can't be opened, are not confirmed and return their error as usual.
+//
+// The event is the one passed to the Hub, before any compression. confirm is called on the goroutine completing the
+// send, which for SendAsync is not the caller's, so it should return quickly, such as after appending to a ledger.
+func HubWithSendConfirmation(confirm func(event *Event, err error)) HubOption {
+	return func(h *Hub) error {
+		if confirm == nil {
+			return errors.New("send confirmation must not be nil")
+		}
+		h.sendConfirmation = confirm
+		return nil
+	}
+}
+
+// confirmSend invokes the send confirmation of the Hub, if configured, for each of the events with the error
+func (h *Hub) confirmSend(err error, events ...*Event) {
+	if h.sendConfirmation == nil {
+		return
+	}
+
+	for _, event := range events {
+		h.sendConfirmation(event, err)
+	}
+}
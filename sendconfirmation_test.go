@@ -0,0 +1,58 @@
+package eventhub
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHubWithSendConfirmation(t *testing.T) {
+	hub := new(Hub)
+	assert.Error(t, HubWithSendConfirmation(nil)(hub))
+
+	// confirming without a confirmation is a no-op
+	hub.confirmSend(nil, NewEventFromString("foo"))
+
+	type confirmed struct {
+		event *Event
+		err   error
+	}
+	var confirmations []confirmed
+	assert.NoError(t, HubWithSendConfirmation(func(event *Event, err error) {
+		confirmations = append(confirmations, confirmed{event: event, err: err})
+	})(hub))
+
+	single := NewEventFromString("foo")
+	batch := []*Event{NewEventFromString("bar"), NewEventFromString("baz")}
+	cause := errors.New("boom")
+	hub.confirmSend(nil, single)
+	hub.confirmSend(cause, batch...)
+	assert.Equal(t, []confirmed{
+		{event: single},
+		{event: batch[0], err: cause},
+		{event: batch[1], err: cause},
+	}, confirmations)
+}
@@ -25,13 +25,15 @@ package eventhub
 import (
 	"context"
 	"fmt"
+	"reflect"
+	"sync"
 	"time"
 
 	"github.com/Azure/azure-amqp-common-go"
-	"github.com/Azure/azure-amqp-common-go/log"
 	"github.com/Azure/azure-amqp-common-go/uuid"
 	"github.com/Azure/azure-event-hubs-go/internal"
 	"github.com/opentracing/opentracing-go"
+	"github.com/pkg/errors"
 	"pack.ag/amqp"
 )
 
@@ -44,6 +46,20 @@ type (
 		sender      *amqp.Sender
 		partitionID *string
 		Name        string
+		// busyUntil is when the sender may send again after the broker reported it is busy
+		busyUntil time.Time
+		busyMu    sync.Mutex
+		// inflight counts the sends in progress so they can be drained before the sender is closed
+		inflight sync.WaitGroup
+		closing  bool
+		closeMu  sync.Mutex
+		// stopClaimRefresh stops renegotiating the claim of the link before its token expires
+		stopClaimRefresh func()
+		// pool shares the connection of the sender with other links when set, and pooled is the connection in use
+		pool   *ConnectionPool
+		pooled *pooledConnection
+		// orderMu is held by each send of a Hub configured with HubWithOrderedSend until its transfer is settled
+		orderMu sync.Mutex
 	}
 
 	// SendOption provides a way to customize a message on sending
@@ -60,11 +76,16 @@ func (h *Hub) newSender(ctx context.Context) (*sender, error) {
 	span, ctx := h.startSpanFromContext(ctx, "eventhub.sender.newSender")
 	defer span.Finish()
 
+	if err := h.validateOrderedSend(); err != nil {
+		return nil, err
+	}
+
 	s := &sender{
 		hub:         h,
 		partitionID: h.senderPartitionID,
+		pool:        h.connections,
 	}
-	log.For(ctx).Debug(fmt.Sprintf("creating a new sender for entity path %s", s.getAddress()))
+	s.logFor(ctx).Debug(fmt.Sprintf("creating a new sender for entity path %s", s.getAddress()))
 	err := s.newSessionAndLink(ctx)
 	return s, err
 }
@@ -74,16 +95,97 @@ func (s *sender) Recover(ctx context.Context) error {
 	span, ctx := s.startProducerSpanFromContext(ctx, "eventhub.sender.Recover")
 	defer span.Finish()
 
-	_ = s.Close(ctx) // we expect the sender is in an error state
+	_ = s.close(ctx, true) // we expect the sender is in an error state
 	return s.newSessionAndLink(ctx)
 }
 
 // Close will close the AMQP connection, session and link of the sender
 func (s *sender) Close(ctx context.Context) error {
-	span, _ := s.startProducerSpanFromContext(ctx, "eventhub.sender.Close")
+	span, ctx := s.startProducerSpanFromContext(ctx, "eventhub.sender.Close")
 	defer span.Finish()
 
-	return s.connection.Close()
+	return s.close(ctx, false)
+}
+
+// close closes the link and session of the sender and its connection, or returns a pooled connection to its pool.
+// A pooled connection is discarded from the pool if the sender failed, as the connection may have failed with it.
+func (s *sender) close(ctx context.Context, failed bool) error {
+	if s.stopClaimRefresh != nil {
+		s.stopClaimRefresh()
+	}
+
+	if s.pooled == nil {
+		return s.connection.Close()
+	}
+
+	if s.sender != nil {
+		if err := s.sender.Close(ctx); err != nil {
+			s.logFor(ctx).Debug(err.Error())
+		}
+	}
+	if s.session != nil {
+		if err := s.session.Close(ctx); err != nil {
+			s.logFor(ctx).Debug(err.Error())
+		}
+	}
+
+	pooled := s.pooled
+	s.pooled = nil
+	if failed {
+		return s.pool.discard(pooled)
+	}
+	return s.pool.release(pooled)
+}
+
+// openConnection opens a connection for the sender, or acquires one from its pool
+func (s *sender) openConnection() (*amqp.Client, error) {
+	if s.pool == nil {
+		return s.hub.namespace.newConnection()
+	}
+
+	pooled, err := s.pool.acquire()
+	if err != nil {
+		return nil, err
+	}
+	s.pooled = pooled
+	return pooled.client, nil
+}
+
+// startSend counts a send in flight, or returns an error if the sender is being closed
+func (s *sender) startSend() error {
+	s.closeMu.Lock()
+	defer s.closeMu.Unlock()
+
+	if s.closing {
+		return errors.New("sender has been closed")
+	}
+	s.inflight.Add(1)
+	return nil
+}
+
+// endSend marks a send started with startSend as complete
+func (s *sender) endSend() {
+	s.inflight.Done()
+}
+
+// drain stops the sender from starting sends and waits for the sends in flight to complete, or the context to be done
+func (s *sender) drain(ctx context.Context) error {
+	s.closeMu.Lock()
+	s.closing = true
+	s.closeMu.Unlock()
+
+	drained := make(chan struct{})
+	go func() {
+		s.inflight.Wait()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
 
 // Send will send a message to the entity path with options
@@ -100,6 +202,14 @@ func (s *sender) Send(ctx context.Context, event *Event, opts ...SendOption) err
 		}
 	}
 
+	if s.partitionID != nil && event.PartitionKey != nil {
+		return fmt.Errorf("event has partition key %q, which can't be combined with sending to partition %q", *event.PartitionKey, *s.partitionID)
+	}
+
+	if _, ok := event.Properties[TraceParentPropertyName]; !ok {
+		event.SetTraceContext(ctx)
+	}
+
 	if event.ID == "" {
 		id, err := uuid.NewV4()
 		if err != nil {
@@ -115,49 +225,75 @@ func (s *sender) trySend(ctx context.Context, evt eventer) error {
 	sp, ctx := s.startProducerSpanFromContext(ctx, "eventhub.sender.trySend")
 	defer sp.Finish()
 
-	times := 3
-	delay := 10 * time.Second
-	durationOfSend := 3 * time.Second
-	if deadline, ok := ctx.Deadline(); ok {
-		times = int(time.Until(deadline) / (delay + durationOfSend))
-		times = ehmath.Max(times, 1) // give at least one chance at sending
+	if s.hub.orderedSend {
+		// retries happen while holding the sender, so no later transfer overtakes a failed one
+		s.orderMu.Lock()
+		defer s.orderMu.Unlock()
 	}
-	_, err := common.Retry(times, delay, func() (interface{}, error) {
+
+	durationOfSend := 3 * time.Second
+	transmit := func(ctx context.Context) error {
 		sp, ctx := s.startProducerSpanFromContext(ctx, "eventhub.sender.trySend.transmit")
 		defer sp.Finish()
 
+		if err := s.waitUntilNotBusy(ctx); err != nil {
+			return err
+		}
+
 		select {
 		case <-ctx.Done():
-			return nil, ctx.Err()
+			return ctx.Err()
 		default:
 			innerCtx, cancel := context.WithTimeout(ctx, durationOfSend)
 			defer cancel()
 
 			err := opentracing.GlobalTracer().Inject(sp.Context(), opentracing.TextMap, evt)
 			if err != nil {
-				log.For(ctx).Error(err)
-				return nil, err
+				s.logFor(ctx).Error(err)
+				return err
 			}
 
 			msg := evt.toMsg()
 			sp.SetTag("eventhub.message-id", msg.Properties.MessageID)
 			err = s.sender.Send(innerCtx, msg)
+			if busy := newServerBusyError(err); busy != nil {
+				// the link is healthy, so rather than being recovered it is paused for the delay suggested by the broker
+				s.pause(busy.RetryAfter)
+				return busy
+			}
 			if err != nil {
-				recoverErr := s.Recover(ctx)
+				s.hub.notifyLinkRecovery(SenderLinkDetached, err)
+				recoverErr := s.recoverLink(ctx, err)
 				if recoverErr != nil {
-					log.For(ctx).Error(recoverErr)
+					s.logFor(ctx).Error(recoverErr)
 				}
 			}
+			return withErrorKind(err)
+		}
+	}
 
-			if amqpErr, ok := err.(*amqp.Error); ok {
-				if amqpErr.Condition == "com.microsoft:server-busy" {
-					return nil, common.Retryable(amqpErr.Condition)
-				}
-			}
+	if s.hub.retryPolicy != nil {
+		return retry(ctx, s.hub.retryPolicy, transmit)
+	}
 
-			return nil, err
+	times := 3
+	delay := 10 * time.Second
+	if deadline, ok := ctx.Deadline(); ok {
+		times = int(time.Until(deadline) / (delay + durationOfSend))
+		times = ehmath.Max(times, 1) // give at least one chance at sending
+	}
+	var busy *ServerBusyError
+	_, err := common.Retry(times, delay, func() (interface{}, error) {
+		err := transmit(ctx)
+		if busyErr, ok := err.(*ServerBusyError); ok {
+			busy = busyErr
+			return nil, common.Retryable(busyErr.Error())
 		}
+		return nil, err
 	})
+	if _, ok := err.(common.Retryable); ok && busy != nil {
+		return busy
+	}
 	return err
 }
 
@@ -176,39 +312,71 @@ func (s *sender) getFullIdentifier() string {
 	return s.hub.namespace.getEntityAudience(s.getAddress())
 }
 
+// maxMessageSize returns the max message size negotiated on the sender link, or 0 if the link is not open or has no
+// limit
+func (s *sender) maxMessageSize() uint64 {
+	return linkMaxMessageSize(s.sender)
+}
+
+// linkMaxMessageSize returns the max message size negotiated when the link was attached. The pinned AMQP library keeps
+// the size in an unexported field of the link rather than exposing it, so it is read by reflection, and
+// TestLinkMaxMessageSize fails if the library changes the field.
+func linkMaxMessageSize(amqpSender *amqp.Sender) uint64 {
+	if amqpSender == nil {
+		return 0
+	}
+
+	link := reflect.ValueOf(amqpSender).Elem().FieldByName("link")
+	if link.Kind() != reflect.Ptr || link.IsNil() {
+		return 0
+	}
+
+	size := link.Elem().FieldByName("maxMessageSize")
+	if size.Kind() != reflect.Uint64 {
+		return 0
+	}
+	return size.Uint()
+}
+
 // newSessionAndLink will replace the existing session and link
 func (s *sender) newSessionAndLink(ctx context.Context) error {
 	span, ctx := s.startProducerSpanFromContext(ctx, "eventhub.sender.newSessionAndLink")
 	defer span.Finish()
 
-	connection, err := s.hub.namespace.newConnection()
+	connection, err := s.openConnection()
 	if err != nil {
-		log.For(ctx).Error(err)
+		s.logFor(ctx).Error(err)
 		return err
 	}
 	s.connection = connection
 
-	err = s.hub.namespace.negotiateClaim(ctx, connection, s.getAddress())
+	s.stopClaimRefresh, err = s.hub.namespace.negotiateClaimAndRefresh(ctx, connection, s.getAddress())
 	if err != nil {
-		log.For(ctx).Error(err)
+		s.logFor(ctx).Error(err)
 		return err
 	}
 
 	amqpSession, err := connection.NewSession()
 	if err != nil {
-		log.For(ctx).Error(err)
+		s.logFor(ctx).Error(err)
 		return err
 	}
 
-	amqpSender, err := amqpSession.NewSender(amqp.LinkTargetAddress(s.getAddress()))
+	opts := []amqp.LinkOption{amqp.LinkTargetAddress(s.getAddress())}
+	if s.hub.maxMessageSize > 0 {
+		// the link negotiates the smaller of the size requested here and the size offered by the broker
+		opts = append(opts, amqp.LinkMaxMessageSize(s.hub.maxMessageSize))
+	}
+
+	amqpSender, err := amqpSession.NewSender(opts...)
 	if err != nil {
-		log.For(ctx).Error(err)
+		s.logFor(ctx).Error(err)
 		return err
 	}
 
 	s.session, err = newSession(amqpSession)
 	if err != nil {
-		log.For(ctx).Error(err)
+		s.logFor(ctx).Error(err)
 		return err
 	}
 
@@ -0,0 +1,79 @@
+package eventhub
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"unsafe"
+
+	"github.com/Azure/go-autorest/autorest/azure"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"pack.ag/amqp"
+)
+
+func TestSenderPartitionKeyConflictsWithPartitionedSender(t *testing.T) {
+	partitionID := "1"
+	hub := &Hub{name: "hub", namespace: newNamespace("ns", nil, azure.PublicCloud), senderPartitionID: &partitionID}
+	s := &sender{hub: hub, partitionID: hub.senderPartitionID}
+	assert.Equal(t, "hub/Partitions/1", s.getAddress())
+
+	key := "foo"
+	event := NewEventFromString("bar")
+	event.PartitionKey = &key
+	assert.EqualError(t, s.Send(context.Background(), event), `event has partition key "foo", which can't be combined with sending to partition "1"`)
+
+	batch := NewEventBatch([]*Event{NewEventFromString("bar")})
+	batch.PartitionKey = &key
+	batchEvent, err := batch.toEvent()
+	if assert.NoError(t, err) {
+		assert.Error(t, s.Send(context.Background(), batchEvent))
+	}
+}
+
+func TestLinkMaxMessageSize(t *testing.T) {
+	assert.Equal(t, uint64(0), linkMaxMessageSize(nil))
+	assert.Equal(t, uint64(0), linkMaxMessageSize(new(amqp.Sender)), "a sender without a link has no max message size")
+	assert.Equal(t, uint64(256*1024), linkMaxMessageSize(newNegotiatedSender(t, 256*1024)))
+
+	s := &sender{sender: newNegotiatedSender(t, 100*DefaultMaxMessageSize)}
+	assert.Equal(t, uint64(100*DefaultMaxMessageSize), s.maxMessageSize())
+}
+
+// newNegotiatedSender returns an AMQP sender whose link negotiated the max message size upon attach, failing the test
+// if the AMQP library no longer keeps the size where linkMaxMessageSize reads it
+func newNegotiatedSender(t *testing.T, size uint64) *amqp.Sender {
+	amqpSender := new(amqp.Sender)
+	link := reflect.ValueOf(amqpSender).Elem().FieldByName("link")
+	require.Equal(t, reflect.Ptr, link.Kind(), "amqp.Sender should reference its link")
+
+	attached := reflect.New(link.Type().Elem())
+	maxSize := attached.Elem().FieldByName("maxMessageSize")
+	require.Equal(t, reflect.Uint64, maxSize.Kind(), "the link should hold its max message size")
+
+	reflect.NewAt(maxSize.Type(), unsafe.Pointer(maxSize.UnsafeAddr())).Elem().SetUint(size)
+	reflect.NewAt(link.Type(), unsafe.Pointer(link.UnsafeAddr())).Elem().Set(attached)
+	return amqpSender
+}
@@ -0,0 +1,51 @@
+package eventhub
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"github.com/pkg/errors"
+)
+
+// HubWithSendInterceptor configures the Hub to invoke intercept with every event sent by Send, SendAsync and
+// SendBatch, including each event of a batch, before the event is compressed and serialized. The interceptor may
+// modify the event, such as to set properties or annotations common to every event, and is called with the event
+// passed to the Hub, so its changes are visible to the caller. The option may be given more than once, in which case
+// the interceptors are invoked in the order they were given.
+func HubWithSendInterceptor(intercept func(event *Event)) HubOption {
+	return func(h *Hub) error {
+		if intercept == nil {
+			return errors.New("send interceptor must not be nil")
+		}
+		h.sendInterceptors = append(h.sendInterceptors, intercept)
+		return nil
+	}
+}
+
+// interceptSend invokes the send interceptors of the Hub with each of the events
+func (h *Hub) interceptSend(events ...*Event) {
+	for _, event := range events {
+		for _, intercept := range h.sendInterceptors {
+			intercept(event)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package eventhub
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHubWithSendInterceptor(t *testing.T) {
+	hub := new(Hub)
+	assert.Error(t, HubWithSendInterceptor(nil)(hub))
+
+	var calls []string
+	assert.NoError(t, HubWithSendInterceptor(func(event *Event) {
+		calls = append(calls, "service")
+		event.Set("service", "orders")
+	})(hub))
+	assert.NoError(t, HubWithSendInterceptor(func(event *Event) {
+		calls = append(calls, "annotation")
+		assert.Equal(t, "orders", event.Properties["service"], "interceptors should run in the order they were given")
+		assert.NoError(t, event.SetAnnotation("version", "1.2.3"))
+	})(hub))
+
+	events := []*Event{NewEventFromString("foo"), NewEventFromString("bar")}
+	hub.interceptSend(events...)
+	assert.Equal(t, []string{"service", "annotation", "service", "annotation"}, calls)
+	for _, event := range events {
+		assert.Equal(t, "orders", event.Properties["service"])
+		assert.Equal(t, "1.2.3", event.Annotations()["version"])
+	}
+}
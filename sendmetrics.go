@@ -0,0 +1,72 @@
+package eventhub
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"github.com/pkg/errors"
+)
+
+type (
+	// SendMetricsRecorder records the outcome of each send of a Hub, such as to count the events and bytes sent to
+	// each partition
+	SendMetricsRecorder interface {
+		// RecordSend is called as each send completes with the partition sent to, the number of bytes of event data
+		// sent and the error of the send, if any. The partition ID is empty unless the Hub was configured with
+		// HubWithPartitionedSender, as the broker chooses the partition of other sends.
+		RecordSend(partitionID string, bytes int, err error)
+	}
+)
+
+// HubWithSendMetrics configures the Hub to record each completed Send, SendAsync and SendBatch with the recorder. The
+// recorder is called synchronously on the send path, so it should return quickly, such as after updating a metric.
+func HubWithSendMetrics(recorder SendMetricsRecorder) HubOption {
+	return func(h *Hub) error {
+		if recorder == nil {
+			return errors.New("send metrics recorder must not be nil")
+		}
+		h.sendMetrics = recorder
+		return nil
+	}
+}
+
+// recordSend records a completed send of a number of bytes of event data by the sender
+func (h *Hub) recordSend(sender *sender, bytes int, err error) {
+	if h.sendMetrics == nil {
+		return
+	}
+
+	var partitionID string
+	if sender.partitionID != nil {
+		partitionID = *sender.partitionID
+	}
+	h.sendMetrics.RecordSend(partitionID, bytes, err)
+}
+
+// size returns the number of bytes of event data in the batch
+func (b *EventBatch) size() int {
+	var bytes int
+	for _, event := range b.Events {
+		bytes += len(event.Data)
+	}
+	return bytes
+}
@@ -0,0 +1,71 @@
+package eventhub
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type (
+	recordedSend struct {
+		partitionID string
+		bytes       int
+		err         error
+	}
+
+	sendRecorder struct {
+		sends []recordedSend
+	}
+)
+
+func (r *sendRecorder) RecordSend(partitionID string, bytes int, err error) {
+	r.sends = append(r.sends, recordedSend{partitionID: partitionID, bytes: bytes, err: err})
+}
+
+func TestHubWithSendMetrics(t *testing.T) {
+	hub := new(Hub)
+	assert.Error(t, HubWithSendMetrics(nil)(hub))
+
+	// recording without a recorder is a no-op
+	hub.recordSend(new(sender), 1, nil)
+
+	recorder := new(sendRecorder)
+	assert.NoError(t, HubWithSendMetrics(recorder)(hub))
+
+	partitionID := "1"
+	cause := errors.New("boom")
+	hub.recordSend(new(sender), 5, nil)
+	hub.recordSend(&sender{partitionID: &partitionID}, 7, cause)
+	assert.Equal(t, []recordedSend{
+		{partitionID: "", bytes: 5},
+		{partitionID: "1", bytes: 7, err: cause},
+	}, recorder.sends)
+}
+
+func TestEventBatchSize(t *testing.T) {
+	batch := NewEventBatch([]*Event{NewEventFromString("foo"), NewEventFromString("quux")})
+	assert.Equal(t, 7, batch.size())
+}
@@ -0,0 +1,74 @@
+package eventhub
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"context"
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+// SendReader sends an event whose data is read from the reader, which must supply exactly size bytes. If the data
+// can't fit within the max message size negotiated on the sender link, a MessageTooLargeError is returned before
+// anything is read. The AMQP library encodes messages from memory, so the data is read into a single buffer of exactly
+// size bytes rather than a growing one, and the event shares that buffer rather than copying it.
+func (h *Hub) SendReader(ctx context.Context, r io.Reader, size int64, opts ...SendOption) error {
+	span, ctx := h.startSpanFromContext(ctx, "eventhub.Hub.SendReader")
+	defer span.Finish()
+
+	if size < 0 {
+		return errors.New("size must not be negative")
+	}
+
+	// compressed data may fit even if the data read does not, so only uncompressed sends are rejected early
+	if h.compressionCodec == nil {
+		maxSize, err := h.sendMaxMessageSize(ctx)
+		if err != nil {
+			return err
+		}
+		if uint64(size) > maxSize {
+			return &MessageTooLargeError{Size: size, MaxSize: int64(maxSize)}
+		}
+	}
+
+	data, err := readExactly(r, size)
+	if err != nil {
+		return err
+	}
+	return h.Send(ctx, NewEvent(data), opts...)
+}
+
+// readExactly reads size bytes from the reader, returning an error if it supplies fewer or more
+func readExactly(r io.Reader, size int64) ([]byte, error) {
+	data := make([]byte, size)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, errors.Wrapf(err, "reader supplied fewer than %d bytes", size)
+	}
+
+	var extra [1]byte
+	if n, _ := r.Read(extra[:]); n > 0 {
+		return nil, errors.Errorf("reader supplied more than %d bytes", size)
+	}
+	return data, nil
+}
@@ -0,0 +1,68 @@
+package eventhub
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type unreadableReader struct {
+	t *testing.T
+}
+
+func (r unreadableReader) Read(p []byte) (int, error) {
+	assert.Fail(r.t, "the reader should not be read")
+	return 0, nil
+}
+
+func TestSendReaderTooLarge(t *testing.T) {
+	hub, err := NewHub("namespace", "hub", nil, HubWithMaxMessageSize(10))
+	if !assert.NoError(t, err) {
+		return
+	}
+	// an open sender link is reused rather than opened, and the size configured is used as the link has no limit
+	hub.sender = &sender{hub: hub}
+
+	err = hub.SendReader(context.Background(), unreadableReader{t: t}, 11)
+	if assert.IsType(t, &MessageTooLargeError{}, err) {
+		assert.Equal(t, &MessageTooLargeError{Size: 11, MaxSize: 10}, err)
+	}
+	assert.Error(t, hub.SendReader(context.Background(), unreadableReader{t: t}, -1))
+}
+
+func TestReadExactly(t *testing.T) {
+	data, err := readExactly(bytes.NewReader([]byte("hello")), 5)
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", string(data))
+	assert.Equal(t, 5, cap(data), "the data should be read into a buffer of exactly the size")
+
+	_, err = readExactly(bytes.NewReader([]byte("hell")), 5)
+	assert.Error(t, err, "a short reader should be an error")
+
+	_, err = readExactly(bytes.NewReader([]byte("hello!")), 5)
+	assert.Error(t, err, "a reader with more than size bytes should be an error")
+}
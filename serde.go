@@ -0,0 +1,86 @@
+package eventhub
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// JSONContentType is the content type of events serialized by JSONSerde
+	JSONContentType = "application/json"
+)
+
+type (
+	// Serde serializes typed values to event data and back, such as with a schema registry. The content type returned
+	// by Serialize is sent as the AMQP content-type property of the event and passed back to Deserialize, so it can
+	// identify the encoding or schema of the data.
+	Serde interface {
+		Serialize(v interface{}) (data []byte, contentType string, err error)
+		Deserialize(data []byte, contentType string, v interface{}) error
+	}
+
+	// JSONSerde serializes values as JSON
+	JSONSerde struct{}
+)
+
+// NewEventFromTyped creates a new event with the data and content type of the value serialized by the serde
+func NewEventFromTyped(v interface{}, serde Serde) (*Event, error) {
+	if serde == nil {
+		return nil, errors.New("serde must not be nil")
+	}
+
+	data, contentType, err := serde.Serialize(v)
+	if err != nil {
+		return nil, err
+	}
+
+	event := NewEvent(data)
+	event.contentType = contentType
+	return event, nil
+}
+
+// Into deserializes the data of the event into the value with the serde, passing the content type of the event
+func (e *Event) Into(v interface{}, serde Serde) error {
+	if serde == nil {
+		return errors.New("serde must not be nil")
+	}
+	return serde.Deserialize(e.Data, e.contentType, v)
+}
+
+// Serialize marshals the value as JSON
+func (JSONSerde) Serialize(v interface{}) ([]byte, string, error) {
+	data, err := json.Marshal(v)
+	return data, JSONContentType, err
+}
+
+// Deserialize unmarshals JSON data into the value, returning an error if the content type is not JSON
+func (JSONSerde) Deserialize(data []byte, contentType string, v interface{}) error {
+	if contentType != "" && !strings.HasPrefix(contentType, JSONContentType) {
+		return errors.Errorf("cannot deserialize content type %q as JSON", contentType)
+	}
+	return json.Unmarshal(data, v)
+}
@@ -0,0 +1,70 @@
+package eventhub
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type serdeTestValue struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+func TestEventSerde(t *testing.T) {
+	event, err := NewEventFromTyped(serdeTestValue{Name: "foo", Count: 2}, JSONSerde{})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, JSONContentType, event.ContentType())
+	assert.Equal(t, `{"name":"foo","count":2}`, string(event.Data))
+
+	msg := event.toMsg()
+	assert.Equal(t, JSONContentType, msg.Properties.ContentType)
+
+	received := eventFromMsg(msg)
+	var value serdeTestValue
+	if assert.NoError(t, received.Into(&value, JSONSerde{})) {
+		assert.Equal(t, serdeTestValue{Name: "foo", Count: 2}, value)
+	}
+
+	received.SetContentType("avro/binary+schema")
+	assert.Error(t, received.Into(&value, JSONSerde{}), "avro content should not be deserialized as JSON")
+
+	_, err = NewEventFromTyped(value, nil)
+	assert.Error(t, err)
+}
+
+func TestBatchItemContentType(t *testing.T) {
+	event := NewEventFromString("foo")
+	event.SetContentType(JSONContentType)
+	bin, err := encodeBatchItem(event)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.True(t, bytes.Contains(bin, []byte(JSONContentType)), "the content type should be encoded with the batch item")
+}
@@ -0,0 +1,136 @@
+package eventhub
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/pkg/errors"
+	"pack.ag/amqp"
+)
+
+const (
+	// DefaultServerBusyRetryAfter is how long a sender link is paused after the broker reports it is busy without
+	// suggesting a delay
+	DefaultServerBusyRetryAfter = 10 * time.Second
+
+	serverBusyCondition = "com.microsoft:server-busy"
+)
+
+var (
+	// serverBusyDelayPattern matches the delay suggested in the description of a server busy error, such as
+	// "... Please wait 4 seconds and try again."
+	serverBusyDelayPattern = regexp.MustCompile(`(?i)wait (\d+) seconds`)
+)
+
+type (
+	// ServerBusyError is returned when the broker throttles a send or a management operation. The sender link is paused
+	// for RetryAfter, the delay suggested by the broker, before it sends again, and callers of throttled management
+	// operations should wait as long before trying again.
+	ServerBusyError struct {
+		RetryAfter time.Duration
+		Err        error
+	}
+)
+
+func (e *ServerBusyError) Error() string {
+	return fmt.Sprintf("server busy, retry after %v: %v", e.RetryAfter, e.Err)
+}
+
+// newServerBusyError returns a ServerBusyError if the error is the broker's server busy condition, otherwise nil
+func newServerBusyError(err error) *ServerBusyError {
+	amqpErr, ok := errors.Cause(err).(*amqp.Error)
+	if !ok || amqpErr.Condition != serverBusyCondition {
+		return nil
+	}
+
+	retryAfter := DefaultServerBusyRetryAfter
+	if match := serverBusyDelayPattern.FindStringSubmatch(amqpErr.Description); match != nil {
+		if seconds, err := strconv.Atoi(match[1]); err == nil {
+			retryAfter = time.Duration(seconds) * time.Second
+		}
+	}
+	return &ServerBusyError{RetryAfter: retryAfter, Err: err}
+}
+
+// managementServerBusyError returns a ServerBusyError if a management operation was throttled, either by the AMQP
+// management node or by the Azure Resource Manager with a 429 response, otherwise nil
+func managementServerBusyError(err error) *ServerBusyError {
+	if busy := newServerBusyError(err); busy != nil {
+		return busy
+	}
+
+	var detailed autorest.DetailedError
+	switch e := errors.Cause(err).(type) {
+	case autorest.DetailedError:
+		detailed = e
+	case *autorest.DetailedError:
+		detailed = *e
+	default:
+		return nil
+	}
+	if code, ok := detailed.StatusCode.(int); !ok || code != http.StatusTooManyRequests {
+		return nil
+	}
+
+	retryAfter := DefaultServerBusyRetryAfter
+	if detailed.Response != nil {
+		if seconds, err := strconv.Atoi(detailed.Response.Header.Get("Retry-After")); err == nil {
+			retryAfter = time.Duration(seconds) * time.Second
+		}
+	}
+	return &ServerBusyError{RetryAfter: retryAfter, Err: err}
+}
+
+// pause stops the sender from sending for the duration, unless it is already paused for longer
+func (s *sender) pause(d time.Duration) {
+	s.busyMu.Lock()
+	defer s.busyMu.Unlock()
+
+	if until := time.Now().Add(d); until.After(s.busyUntil) {
+		s.busyUntil = until
+	}
+}
+
+// waitUntilNotBusy blocks while the sender is paused by a server busy error, or until the context is done
+func (s *sender) waitUntilNotBusy(ctx context.Context) error {
+	s.busyMu.Lock()
+	wait := time.Until(s.busyUntil)
+	s.busyMu.Unlock()
+	if wait <= 0 {
+		return nil
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(wait):
+		return nil
+	}
+}
@@ -0,0 +1,91 @@
+package eventhub
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/stretchr/testify/assert"
+	"pack.ag/amqp"
+)
+
+func TestNewServerBusyError(t *testing.T) {
+	assert.Nil(t, newServerBusyError(nil))
+	assert.Nil(t, newServerBusyError(errors.New("boom")))
+	assert.Nil(t, newServerBusyError(&amqp.Error{Condition: amqp.ErrorInternalError}))
+
+	busy := newServerBusyError(&amqp.Error{Condition: serverBusyCondition})
+	if assert.NotNil(t, busy) {
+		assert.Equal(t, DefaultServerBusyRetryAfter, busy.RetryAfter)
+		assert.True(t, IsTransient(busy))
+	}
+
+	busy = newServerBusyError(&amqp.Error{
+		Condition:   serverBusyCondition,
+		Description: "The request was terminated because the entity is being throttled. Please wait 4 seconds and try again.",
+	})
+	if assert.NotNil(t, busy) {
+		assert.Equal(t, 4*time.Second, busy.RetryAfter)
+	}
+}
+
+func TestManagementServerBusyError(t *testing.T) {
+	assert.Nil(t, managementServerBusyError(errors.New("boom")))
+
+	busy := managementServerBusyError(&amqp.Error{Condition: serverBusyCondition, Description: "Please wait 4 seconds and try again."})
+	if assert.NotNil(t, busy, "throttled management node responses should be server busy errors") {
+		assert.Equal(t, 4*time.Second, busy.RetryAfter)
+	}
+
+	res := &http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{"Retry-After": []string{"7"}}}
+	throttled := autorest.NewErrorWithResponse("eventhub.ConsumerGroupsClient", "Get", res, "throttled")
+	busy = managementServerBusyError(throttled)
+	if assert.NotNil(t, busy, "throttled resource manager responses should be server busy errors") {
+		assert.Equal(t, 7*time.Second, busy.RetryAfter)
+	}
+
+	res.StatusCode = http.StatusInternalServerError
+	assert.Nil(t, managementServerBusyError(autorest.NewErrorWithResponse("eventhub.ConsumerGroupsClient", "Get", res, "failed")))
+}
+
+func TestSenderPausesWhileServerBusy(t *testing.T) {
+	s := new(sender)
+	assert.NoError(t, s.waitUntilNotBusy(context.Background()))
+
+	s.pause(time.Hour)
+	s.pause(time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, s.waitUntilNotBusy(ctx), "a shorter pause should not shorten a longer one")
+
+	s = new(sender)
+	s.pause(10 * time.Millisecond)
+	start := time.Now()
+	assert.NoError(t, s.waitUntilNotBusy(context.Background()))
+	assert.True(t, time.Since(start) >= 10*time.Millisecond)
+}
@@ -0,0 +1,258 @@
+package storage
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/url"
+	"sync"
+
+	"github.com/Azure/azure-amqp-common-go/persist"
+	"github.com/Azure/azure-event-hubs-go/eph"
+	"github.com/Azure/azure-storage-blob-go/2016-05-31/azblob"
+	"github.com/Azure/go-autorest/autorest/azure"
+	"github.com/pkg/errors"
+)
+
+const (
+	// CheckpointBlobPrefix is the prefix of the names of the blobs holding the checkpoints written by a Checkpointer
+	CheckpointBlobPrefix = "checkpoint/"
+)
+
+type (
+	// Checkpointer implements the eph.Checkpointer interface for Azure Storage without taking blob leases, so it can be
+	// paired with any eph.Leaser, such as the Redis LeaserCheckpointer. Each partition's checkpoint is stored in a blob
+	// named with CheckpointBlobPrefix and the partition ID, and is updated with optimistic concurrency using the ETag
+	// of the blob.
+	Checkpointer struct {
+		processor     *eph.EventProcessorHost
+		containerURL  *azblob.ContainerURL
+		serviceURL    *azblob.ServiceURL
+		containerName string
+		etags         map[string]azblob.ETag
+		etagsMu       sync.Mutex
+	}
+)
+
+// NewStorageCheckpointer builds an Azure Storage Checkpointer which only handles checkpointing for the
+// EventProcessorHost, leaving leasing to another eph.Leaser
+func NewStorageCheckpointer(credential Credential, accountName, containerName string, env azure.Environment) (*Checkpointer, error) {
+	storageURL, err := url.Parse("https://" + accountName + ".blob." + env.StorageEndpointSuffix)
+	if err != nil {
+		return nil, err
+	}
+
+	svURL := azblob.NewServiceURL(*storageURL, azblob.NewPipeline(credential, azblob.PipelineOptions{}))
+	containerURL := svURL.NewContainerURL(containerName)
+
+	return &Checkpointer{
+		containerName: containerName,
+		serviceURL:    &svURL,
+		containerURL:  &containerURL,
+		etags:         make(map[string]azblob.ETag),
+	}, nil
+}
+
+// SetEventHostProcessor sets the EventHostProcessor on the instance of the Checkpointer
+func (c *Checkpointer) SetEventHostProcessor(eph *eph.EventProcessorHost) {
+	c.processor = eph
+}
+
+// StoreExists returns true if the storage container exists
+func (c *Checkpointer) StoreExists(ctx context.Context) (bool, error) {
+	span, ctx := startConsumerSpanFromContext(ctx, "eventhub.storage.Checkpointer.StoreExists")
+	defer span.Finish()
+
+	res, err := c.serviceURL.ListContainers(ctx, azblob.Marker{}, azblob.ListContainersOptions{Prefix: c.containerName})
+	if err != nil {
+		return false, err
+	}
+
+	for _, container := range res.Containers {
+		if container.Name == c.containerName {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// EnsureStore creates the container if it does not exist
+func (c *Checkpointer) EnsureStore(ctx context.Context) error {
+	span, ctx := startConsumerSpanFromContext(ctx, "eventhub.storage.Checkpointer.EnsureStore")
+	defer span.Finish()
+
+	ok, err := c.StoreExists(ctx)
+	if err != nil || ok {
+		return err
+	}
+
+	_, err = c.containerURL.Create(ctx, azblob.Metadata{}, azblob.PublicAccessNone)
+	if hasServiceCode(err, azblob.ServiceCodeContainerAlreadyExists) {
+		return nil
+	}
+	return err
+}
+
+// DeleteStore deletes the Azure Storage container
+func (c *Checkpointer) DeleteStore(ctx context.Context) error {
+	c.etagsMu.Lock()
+	defer c.etagsMu.Unlock()
+
+	span, ctx := startConsumerSpanFromContext(ctx, "eventhub.storage.Checkpointer.DeleteStore")
+	defer span.Finish()
+
+	if _, err := c.containerURL.Delete(ctx, azblob.ContainerAccessConditions{}); err != nil {
+		return err
+	}
+	c.etags = make(map[string]azblob.ETag)
+	return nil
+}
+
+// GetCheckpoint returns the latest checkpoint for the partitionID
+func (c *Checkpointer) GetCheckpoint(ctx context.Context, partitionID string) (persist.Checkpoint, bool) {
+	c.etagsMu.Lock()
+	defer c.etagsMu.Unlock()
+
+	span, ctx := startConsumerSpanFromContext(ctx, "eventhub.storage.Checkpointer.GetCheckpoint")
+	defer span.Finish()
+
+	checkpoint, err := c.read(ctx, partitionID)
+	if err != nil {
+		return persist.NewCheckpointFromStartOfStream(), false
+	}
+	return checkpoint, true
+}
+
+// EnsureCheckpoint ensures a checkpoint exists for the partition, creating one at the start of the stream if needed
+func (c *Checkpointer) EnsureCheckpoint(ctx context.Context, partitionID string) (persist.Checkpoint, error) {
+	c.etagsMu.Lock()
+	defer c.etagsMu.Unlock()
+
+	span, ctx := startConsumerSpanFromContext(ctx, "eventhub.storage.Checkpointer.EnsureCheckpoint")
+	defer span.Finish()
+
+	checkpoint, err := c.read(ctx, partitionID)
+	if !hasServiceCode(err, azblob.ServiceCodeBlobNotFound) {
+		return checkpoint, err
+	}
+
+	checkpoint = persist.NewCheckpointFromStartOfStream()
+	err = c.write(ctx, partitionID, checkpoint, azblob.HTTPAccessConditions{IfNoneMatch: azblob.ETagAny})
+	if hasServiceCode(err, azblob.ServiceCodeBlobAlreadyExists) || hasServiceCode(err, azblob.ServiceCodeConditionNotMet) {
+		// another host created the checkpoint first
+		return c.read(ctx, partitionID)
+	}
+	return checkpoint, err
+}
+
+// UpdateCheckpoint writes the checkpoint for the partition if it has not been changed since it was last read or
+// written by this Checkpointer
+func (c *Checkpointer) UpdateCheckpoint(ctx context.Context, partitionID string, checkpoint persist.Checkpoint) error {
+	c.etagsMu.Lock()
+	defer c.etagsMu.Unlock()
+
+	span, ctx := startConsumerSpanFromContext(ctx, "eventhub.storage.Checkpointer.UpdateCheckpoint")
+	defer span.Finish()
+
+	return c.replace(ctx, partitionID, checkpoint)
+}
+
+// DeleteCheckpoint resets the checkpoint for the partition to the start of the stream
+func (c *Checkpointer) DeleteCheckpoint(ctx context.Context, partitionID string) error {
+	c.etagsMu.Lock()
+	defer c.etagsMu.Unlock()
+
+	span, ctx := startConsumerSpanFromContext(ctx, "eventhub.storage.Checkpointer.DeleteCheckpoint")
+	defer span.Finish()
+
+	return c.replace(ctx, partitionID, persist.NewCheckpointFromStartOfStream())
+}
+
+// Close is a no-op; checkpoints are written as they are updated
+func (c *Checkpointer) Close() error {
+	return nil
+}
+
+// read fetches the checkpoint for the partition and records the ETag of its blob
+func (c *Checkpointer) read(ctx context.Context, partitionID string) (persist.Checkpoint, error) {
+	res, err := c.checkpointBlobURL(partitionID).GetBlob(ctx, azblob.BlobRange{}, azblob.BlobAccessConditions{}, false)
+	if err != nil {
+		return persist.NewCheckpointFromStartOfStream(), err
+	}
+	defer res.Response().Body.Close()
+
+	var checkpoint persist.Checkpoint
+	if err := json.NewDecoder(res.Response().Body).Decode(&checkpoint); err != nil {
+		return persist.NewCheckpointFromStartOfStream(), err
+	}
+	c.etags[partitionID] = res.ETag()
+	return checkpoint, nil
+}
+
+// replace writes the checkpoint for the partition using the recorded ETag as a precondition
+func (c *Checkpointer) replace(ctx context.Context, partitionID string, checkpoint persist.Checkpoint) error {
+	etag, ok := c.etags[partitionID]
+	if !ok {
+		if _, err := c.read(ctx, partitionID); err != nil {
+			return err
+		}
+		etag = c.etags[partitionID]
+	}
+
+	err := c.write(ctx, partitionID, checkpoint, azblob.HTTPAccessConditions{IfMatch: etag})
+	if hasServiceCode(err, azblob.ServiceCodeConditionNotMet) {
+		// the checkpoint was written elsewhere, so the next update must read the current ETag
+		delete(c.etags, partitionID)
+		return errors.Errorf("checkpoint for partition %q was modified by another writer", partitionID)
+	}
+	return err
+}
+
+// write uploads the checkpoint blob of the partition under the access conditions and records its new ETag
+func (c *Checkpointer) write(ctx context.Context, partitionID string, checkpoint persist.Checkpoint, conditions azblob.HTTPAccessConditions) error {
+	body, err := json.Marshal(checkpoint)
+	if err != nil {
+		return err
+	}
+
+	res, err := c.checkpointBlobURL(partitionID).PutBlob(ctx, bytes.NewReader(body), azblob.BlobHTTPHeaders{}, azblob.Metadata{}, azblob.BlobAccessConditions{
+		HTTPAccessConditions: conditions,
+	})
+	if err != nil {
+		return err
+	}
+	c.etags[partitionID] = res.ETag()
+	return nil
+}
+
+func (c *Checkpointer) checkpointBlobURL(partitionID string) azblob.BlockBlobURL {
+	return c.containerURL.NewBlockBlobURL(CheckpointBlobPrefix + partitionID)
+}
+
+// hasServiceCode returns true if the error is a storage error with the service code
+func hasServiceCode(err error, code azblob.ServiceCodeType) bool {
+	storageErr, ok := err.(azblob.StorageError)
+	return ok && storageErr.ServiceCode() == code
+}
@@ -26,6 +26,7 @@ import (
 	"context"
 	"fmt"
 	"net/url"
+	"os"
 	"strconv"
 	"strings"
 	"sync"
@@ -34,10 +35,13 @@ import (
 
 	"github.com/Azure/azure-amqp-common-go/aad"
 	"github.com/Azure/azure-amqp-common-go/auth"
+	"github.com/Azure/azure-amqp-common-go/persist"
 	"github.com/Azure/azure-event-hubs-go"
 	"github.com/Azure/azure-event-hubs-go/eph"
 	"github.com/Azure/azure-event-hubs-go/internal/test"
+	"github.com/Azure/azure-event-hubs-go/redis"
 	"github.com/Azure/azure-storage-blob-go/2016-05-31/azblob"
+	goredis "github.com/go-redis/redis"
 )
 
 func (ts *testSuite) TestSingle() {
@@ -179,6 +183,74 @@ func (ts *testSuite) TestMultiple() {
 	}
 }
 
+func (ts *testSuite) TestRedisLeaserWithStorageCheckpointer() {
+	redisAddr := os.Getenv("REDIS_ADDR")
+	if redisAddr == "" {
+		ts.T().Skip("REDIS_ADDR must be set to lease partitions in Redis")
+	}
+
+	randomName := strings.ToLower(ts.RandomName("gostoreph", 4))
+	hub, delHub := ts.ensureRandomHubByName(randomName)
+	delContainer := ts.newTestContainerByName(randomName)
+	defer delContainer()
+
+	client := goredis.NewClient(&goredis.Options{Addr: redisAddr})
+	defer client.Close()
+	leaser, err := redis.NewLeaserCheckpointer(client, redis.WithKeyPrefix(randomName))
+	if err != nil {
+		ts.T().Fatal(err)
+	}
+
+	cred, err := NewAADSASCredential(ts.SubscriptionID, test.ResourceGroupName, ts.AccountName, randomName, AADSASCredentialWithEnvironmentVars())
+	if err != nil {
+		ts.T().Fatal(err)
+	}
+	checkpointer, err := NewStorageCheckpointer(cred, ts.AccountName, randomName, ts.Env)
+	if err != nil {
+		ts.T().Fatal(err)
+	}
+
+	processor, err := ts.newStorageBackedEPHOptions(*hub.Name, leaser, checkpointer)
+	if err != nil {
+		ts.T().Fatal(err)
+	}
+	defer func() {
+		closeContext, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		processor.Close(closeContext)
+		cancel()
+		delHub()
+	}()
+
+	messages, err := ts.sendMessages(*hub.Name, 10)
+	if err != nil {
+		ts.T().Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(len(messages))
+	processor.Receive(func(c context.Context, event *eventhub.Event) error {
+		wg.Done()
+		return nil
+	})
+
+	processor.StartNonBlocking(context.Background())
+	waitUntil(ts.T(), &wg, 30*time.Second)
+
+	// leases are held in Redis while the checkpoints of the handled events are written to blobs
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	checkpointed := 0
+	for _, partitionID := range *hub.PartitionIds {
+		checkpoint, ok := checkpointer.GetCheckpoint(ctx, partitionID)
+		if ok && checkpoint.Offset != persist.StartOfStream {
+			checkpointed++
+		}
+	}
+	if checkpointed == 0 {
+		ts.T().Error("no checkpoints were written to the storage checkpointer")
+	}
+}
+
 func (ts *testSuite) newTestContainerByName(containerName string) func() {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
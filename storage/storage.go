@@ -47,7 +47,9 @@ import (
 )
 
 type (
-	// LeaserCheckpointer implements the eph.LeaserCheckpointer interface for Azure Storage
+	// LeaserCheckpointer implements the eph.LeaserCheckpointer interface for Azure Storage. Checkpoints are stored with
+	// the blob leases, so it only checkpoints partitions it has leased itself; use a Checkpointer to store checkpoints
+	// in Azure Storage with another eph.Leaser.
 	LeaserCheckpointer struct {
 		leases            map[string]*storageLease
 		processor         *eph.EventProcessorHost
@@ -251,6 +253,15 @@ func (sl *LeaserCheckpointer) AcquireLease(ctx context.Context, partitionID stri
 		}
 	}
 
+	// read the lease again now it is held, so the epoch is incremented from the last one written rather than from one
+	// which may have been superseded while acquiring. Writes to the blob require the lease, so no other host can
+	// write an epoch until this one is released or expires.
+	lease, err = sl.getLease(ctx, partitionID)
+	if err != nil {
+		log.For(ctx).Error(err)
+		return nil, false, err
+	}
+
 	lease.Token = newToken
 	lease.Owner = sl.processor.GetName()
 	lease.IncrementEpoch()
@@ -296,6 +307,12 @@ func (sl *LeaserCheckpointer) ReleaseLease(ctx context.Context, partitionID stri
 		return false, errors.New("lease was not found")
 	}
 
+	// clear the owner while the lease is still held so other hosts don't attribute the partition to this host
+	lease.Owner = ""
+	if err := sl.uploadLease(ctx, lease); err != nil {
+		log.For(ctx).Error(err)
+	}
+
 	_, err := blobURL.ReleaseLease(ctx, lease.Token, azblob.HTTPAccessConditions{})
 	if err != nil {
 		log.For(ctx).Error(err)
@@ -0,0 +1,170 @@
+package eventhub
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-amqp-common-go/auth"
+	"github.com/Azure/azure-amqp-common-go/cbs"
+	"pack.ag/amqp"
+)
+
+const (
+	// DefaultTokenRefreshBuffer is how long before a token expires the claim of a link is renegotiated by default
+	DefaultTokenRefreshBuffer = 5 * time.Minute
+
+	// MaxTokenRefreshBuffer is the largest refresh buffer, to which longer buffers given to HubWithTokenRefreshBuffer
+	// are clamped
+	MaxTokenRefreshBuffer = time.Hour
+
+	// minTokenRefreshInterval keeps claims from being renegotiated in a tight loop when tokens are very short lived
+	minTokenRefreshInterval = time.Second
+)
+
+type (
+	// recordingTokenProvider records the last token handed out by the token provider it wraps
+	recordingTokenProvider struct {
+		auth.TokenProvider
+		mu    sync.Mutex
+		token *auth.Token
+	}
+)
+
+// HubWithTokenRefreshBuffer configures how long before a token expires the claim of each sender and receiver link
+// is renegotiated with a fresh token from the TokenProvider. Buffers are clamped between one second and
+// MaxTokenRefreshBuffer.
+// If the buffer exceeds the lifetime of a token, the claim is refreshed once half of the lifetime has passed.
+func HubWithTokenRefreshBuffer(buffer time.Duration) HubOption {
+	return func(h *Hub) error {
+		switch {
+		case buffer < minTokenRefreshInterval:
+			buffer = minTokenRefreshInterval
+		case buffer > MaxTokenRefreshBuffer:
+			buffer = MaxTokenRefreshBuffer
+		}
+		h.namespace.tokenRefreshBuffer = buffer
+		return nil
+	}
+}
+
+// negotiateClaimAndRefresh negotiates the claim of an entity path and renegotiates it before the token expires, until
+// the returned func is called
+func (ns *namespace) negotiateClaimAndRefresh(ctx context.Context, conn *amqp.Client, entityPath string) (func(), error) {
+	expiry, err := ns.negotiateClaimForExpiry(ctx, conn, entityPath)
+	if err != nil {
+		return nil, err
+	}
+
+	done := make(chan struct{})
+	go func() {
+		issued := time.Now()
+		for !expiry.IsZero() {
+			timer := time.NewTimer(ns.tokenRefreshDelay(issued, expiry, time.Now()))
+			select {
+			case <-done:
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			next, err := ns.negotiateClaimForExpiry(ctx, conn, entityPath)
+			cancel()
+			if err != nil {
+				// keep the current expiry so the claim is retried after the minimum interval
+				newContextLogger(ctx, ns.logger, "entityPath", entityPath).Error(err)
+				continue
+			}
+			issued, expiry = time.Now(), next
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(done) })
+	}, nil
+}
+
+// negotiateClaimForExpiry negotiates the claim of an entity path and returns when the token used expires, or the
+// zero time if the expiry of the token is unknown
+func (ns *namespace) negotiateClaimForExpiry(ctx context.Context, conn *amqp.Client, entityPath string) (time.Time, error) {
+	span, ctx := ns.startSpanFromContext(ctx, "eventhub.namespace.negotiateClaim")
+	defer span.Finish()
+
+	provider := &recordingTokenProvider{TokenProvider: ns.tokenProvider}
+	audience := ns.getEntityAudience(entityPath)
+	if err := cbs.NegotiateClaim(ctx, audience, conn, provider); err != nil {
+		return time.Time{}, withErrorKind(err)
+	}
+	return provider.expiry(), nil
+}
+
+// tokenRefreshDelay returns how long to wait from now to renegotiate a claim with a token issued and expiring at the
+// given times
+func (ns *namespace) tokenRefreshDelay(issued, expiry, now time.Time) time.Duration {
+	buffer := ns.tokenRefreshBuffer
+	if buffer == 0 {
+		buffer = DefaultTokenRefreshBuffer
+	}
+
+	refreshAt := expiry.Add(-buffer)
+	if lifetime := expiry.Sub(issued); buffer >= lifetime {
+		refreshAt = issued.Add(lifetime / 2)
+	}
+
+	delay := refreshAt.Sub(now)
+	if delay < minTokenRefreshInterval {
+		delay = minTokenRefreshInterval
+	}
+	return delay
+}
+
+// GetToken gets a token from the wrapped provider and records it
+func (p *recordingTokenProvider) GetToken(uri string) (*auth.Token, error) {
+	token, err := p.TokenProvider.GetToken(uri)
+	if err == nil {
+		p.mu.Lock()
+		p.token = token
+		p.mu.Unlock()
+	}
+	return token, err
+}
+
+// expiry returns when the recorded token expires, parsed from its expiry in seconds since the Unix epoch
+func (p *recordingTokenProvider) expiry() time.Time {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.token == nil {
+		return time.Time{}
+	}
+	seconds, err := strconv.ParseInt(p.token.Expiry, 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	return time.Unix(seconds, 0)
+}
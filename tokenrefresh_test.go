@@ -0,0 +1,87 @@
+package eventhub
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-amqp-common-go/auth"
+	"github.com/stretchr/testify/assert"
+)
+
+type staticTokenProvider struct {
+	token *auth.Token
+}
+
+func (p staticTokenProvider) GetToken(uri string) (*auth.Token, error) {
+	return p.token, nil
+}
+
+func TestHubWithTokenRefreshBuffer(t *testing.T) {
+	cases := map[time.Duration]time.Duration{
+		-time.Minute:     minTokenRefreshInterval,
+		0:                minTokenRefreshInterval,
+		10 * time.Second: 10 * time.Second,
+		24 * time.Hour:   MaxTokenRefreshBuffer,
+	}
+	for buffer, expected := range cases {
+		hub, err := NewHub("namespace", "hub", nil, HubWithTokenRefreshBuffer(buffer))
+		if assert.NoError(t, err) {
+			assert.Equal(t, expected, hub.namespace.tokenRefreshBuffer, buffer.String())
+		}
+	}
+}
+
+func TestTokenRefreshDelay(t *testing.T) {
+	now := time.Now()
+	ns := &namespace{}
+	assert.Equal(t, time.Hour-DefaultTokenRefreshBuffer, ns.tokenRefreshDelay(now, now.Add(time.Hour), now))
+
+	ns.tokenRefreshBuffer = 10 * time.Minute
+	assert.Equal(t, 50*time.Minute, ns.tokenRefreshDelay(now, now.Add(time.Hour), now))
+
+	// a buffer exceeding the token lifetime refreshes once half of the lifetime has passed
+	assert.Equal(t, 2*time.Minute, ns.tokenRefreshDelay(now, now.Add(4*time.Minute), now))
+
+	// refreshes past due are retried after the minimum interval rather than immediately
+	assert.Equal(t, minTokenRefreshInterval, ns.tokenRefreshDelay(now, now.Add(time.Hour), now.Add(2*time.Hour)))
+}
+
+func TestRecordingTokenProviderExpiry(t *testing.T) {
+	expiry := time.Now().Add(time.Hour).Truncate(time.Second)
+	provider := &recordingTokenProvider{TokenProvider: staticTokenProvider{
+		token: auth.NewToken(auth.CBSTokenTypeSAS, "token", strconv.FormatInt(expiry.Unix(), 10)),
+	}}
+	assert.True(t, provider.expiry().IsZero(), "no token has been recorded")
+
+	_, err := provider.GetToken("audience")
+	if assert.NoError(t, err) {
+		assert.True(t, expiry.Equal(provider.expiry()))
+	}
+
+	provider = &recordingTokenProvider{TokenProvider: staticTokenProvider{token: auth.NewToken(auth.CBSTokenTypeJWT, "token", "soon")}}
+	_, _ = provider.GetToken("audience")
+	assert.True(t, provider.expiry().IsZero(), "an unparseable expiry is unknown")
+}
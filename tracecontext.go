@@ -0,0 +1,130 @@
+package eventhub
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"context"
+	"encoding/hex"
+	"strings"
+)
+
+const (
+	// TraceParentPropertyName is the application property carrying the W3C Trace Context traceparent header
+	TraceParentPropertyName = "traceparent"
+
+	// TraceStatePropertyName is the application property carrying the W3C Trace Context tracestate header
+	TraceStatePropertyName = "tracestate"
+)
+
+type (
+	traceContextKey struct{}
+
+	traceContext struct {
+		traceParent string
+		traceState  string
+	}
+)
+
+// ContextWithTraceContext returns a copy of the context carrying the W3C Trace Context traceparent and tracestate
+// headers. The traceparent is ignored if it is not well formed.
+func ContextWithTraceContext(ctx context.Context, traceParent, traceState string) context.Context {
+	if !validTraceParent(traceParent) {
+		return ctx
+	}
+	return context.WithValue(ctx, traceContextKey{}, traceContext{traceParent: traceParent, traceState: traceState})
+}
+
+// TraceContextFromContext returns the W3C Trace Context traceparent and tracestate headers carried by the context
+func TraceContextFromContext(ctx context.Context) (traceParent, traceState string, ok bool) {
+	tc, ok := ctx.Value(traceContextKey{}).(traceContext)
+	if !ok {
+		return "", "", false
+	}
+	return tc.traceParent, tc.traceState, true
+}
+
+// SetTraceContext writes the W3C Trace Context carried by the context, if any, into the traceparent and tracestate
+// application properties of the event
+func (e *Event) SetTraceContext(ctx context.Context) {
+	traceParent, traceState, ok := TraceContextFromContext(ctx)
+	if !ok {
+		return
+	}
+
+	if e.Properties == nil {
+		e.Properties = make(map[string]interface{})
+	}
+	e.Properties[TraceParentPropertyName] = traceParent
+	if traceState != "" {
+		e.Properties[TraceStatePropertyName] = traceState
+	} else {
+		delete(e.Properties, TraceStatePropertyName)
+	}
+}
+
+// TraceContext returns a context carrying the W3C Trace Context read from the traceparent and tracestate application
+// properties of the event
+func (e *Event) TraceContext() context.Context {
+	return e.contextWithTraceContext(context.Background())
+}
+
+// contextWithTraceContext returns a copy of the context carrying the W3C Trace Context of the event, if any
+func (e *Event) contextWithTraceContext(ctx context.Context) context.Context {
+	traceParent, ok := e.Properties[TraceParentPropertyName].(string)
+	if !ok {
+		return ctx
+	}
+	traceState, _ := e.Properties[TraceStatePropertyName].(string)
+	return ContextWithTraceContext(ctx, traceParent, traceState)
+}
+
+// validTraceParent checks the traceparent is formatted as version-traceid-parentid-flags with non-zero ids
+func validTraceParent(traceParent string) bool {
+	parts := strings.Split(traceParent, "-")
+	if len(parts) < 4 || parts[0] == "ff" || (parts[0] == "00" && len(parts) != 4) {
+		return false
+	}
+
+	for idx, size := range []int{2, 32, 16, 2} {
+		if len(parts[idx]) != size || strings.ToLower(parts[idx]) != parts[idx] {
+			return false
+		}
+		decoded, err := hex.DecodeString(parts[idx])
+		if err != nil {
+			return false
+		}
+		if (idx == 1 || idx == 2) && allZero(decoded) {
+			return false
+		}
+	}
+	return true
+}
+
+func allZero(b []byte) bool {
+	for _, v := range b {
+		if v != 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,72 @@
+package eventhub
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testTraceParent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+func TestEventTraceContextRoundTrip(t *testing.T) {
+	ctx := ContextWithTraceContext(context.Background(), testTraceParent, "congo=t61rcWkgMzE")
+	event := NewEventFromString("foo")
+	event.SetTraceContext(ctx)
+	assert.Equal(t, testTraceParent, event.Properties[TraceParentPropertyName])
+	assert.Equal(t, "congo=t61rcWkgMzE", event.Properties[TraceStatePropertyName])
+
+	traceParent, traceState, ok := TraceContextFromContext(event.TraceContext())
+	assert.True(t, ok)
+	assert.Equal(t, testTraceParent, traceParent)
+	assert.Equal(t, "congo=t61rcWkgMzE", traceState)
+}
+
+func TestEventSetTraceContextWithoutTraceContext(t *testing.T) {
+	event := NewEventFromString("foo")
+	event.SetTraceContext(context.Background())
+	assert.Nil(t, event.Properties)
+
+	_, _, ok := TraceContextFromContext(event.TraceContext())
+	assert.False(t, ok)
+}
+
+func TestValidTraceParent(t *testing.T) {
+	tests := map[string]bool{
+		testTraceParent: true,
+		"01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-future": true,
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra":  false,
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01":        false,
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01":        false,
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01":        false,
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01":        false,
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7":           false,
+		"": false,
+	}
+
+	for traceParent, valid := range tests {
+		assert.Equal(t, valid, validTraceParent(traceParent), traceParent)
+	}
+}
@@ -33,6 +33,9 @@ func (r *receiver) startConsumerSpanFromContext(ctx context.Context, operationNa
 	ApplyComponentInfo(span)
 	tag.SpanKindConsumer.Set(span)
 	tag.MessageBusDestination.Set(span, r.getFullIdentifier())
+	if r.linkName != "" {
+		span.SetTag("eventhub.link-name", r.linkName)
+	}
 	return span, ctx
 }
 
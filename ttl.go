@@ -0,0 +1,92 @@
+package eventhub
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"time"
+
+	"pack.ag/amqp"
+)
+
+// SetTimeToLive sets the AMQP header ttl of the event, the duration after which the broker may drop the event if it
+// has not been consumed. Whether expired events are dropped is up to the broker, so consumers should not rely on it
+// and can check RemainingTimeToLive. A duration of 0 or less clears the time to live.
+func (e *Event) SetTimeToLive(d time.Duration) {
+	if d < 0 {
+		d = 0
+	}
+	e.timeToLive = d
+}
+
+// TimeToLive returns the time to live the event was sent with and true, or false if it has none
+func (e *Event) TimeToLive() (time.Duration, bool) {
+	return e.timeToLive, e.timeToLive > 0
+}
+
+// RemainingTimeToLive returns how long a received event has left to live and true, or false if the event has no time
+// to live or has not been received from the broker. The remaining time is measured from the absolute expiry time of
+// the message if the broker provides one, otherwise from the time the event was enqueued. Expired events return 0.
+func (e *Event) RemainingTimeToLive() (time.Duration, bool) {
+	if e.message == nil {
+		return 0, false
+	}
+
+	var remaining time.Duration
+	switch {
+	case e.message.Properties != nil && !e.message.Properties.AbsoluteExpiryTime.IsZero():
+		remaining = time.Until(e.message.Properties.AbsoluteExpiryTime)
+	case e.timeToLive > 0 && e.SystemProperties != nil && !e.SystemProperties.EnqueuedTime.IsZero():
+		remaining = e.timeToLive - time.Since(e.SystemProperties.EnqueuedTime)
+	default:
+		return 0, false
+	}
+
+	if remaining < 0 {
+		remaining = 0
+	}
+	return remaining, true
+}
+
+// applyTimeToLive sets the ttl of the message header if the event has a time to live
+func (e *Event) applyTimeToLive(msg *amqp.Message) {
+	if e.timeToLive <= 0 {
+		return
+	}
+
+	if msg.Header == nil {
+		msg.Header = new(amqp.MessageHeader)
+	}
+	msg.Header.TTL = e.timeToLive
+}
+
+// timeToLive returns the shortest time to live of the events of the batch. The broker drops a batch as a whole, so
+// the batch lives no longer than its shortest lived event.
+func (b *EventBatch) timeToLive() time.Duration {
+	var ttl time.Duration
+	for _, event := range b.Events {
+		if event.timeToLive > 0 && (ttl == 0 || event.timeToLive < ttl) {
+			ttl = event.timeToLive
+		}
+	}
+	return ttl
+}
@@ -0,0 +1,89 @@
+package eventhub
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"pack.ag/amqp"
+)
+
+func TestEventTimeToLive(t *testing.T) {
+	event := NewEventFromString("foo")
+	_, ok := event.TimeToLive()
+	assert.False(t, ok)
+	assert.Nil(t, event.toMsg().Header)
+
+	event.SetTimeToLive(5 * time.Second)
+	ttl, ok := event.TimeToLive()
+	assert.True(t, ok)
+	assert.Equal(t, 5*time.Second, ttl)
+	assert.Equal(t, 5*time.Second, event.toMsg().Header.TTL)
+
+	_, ok = event.RemainingTimeToLive()
+	assert.False(t, ok, "an event which has not been received has no remaining time to live")
+
+	event.SetTimeToLive(-time.Second)
+	_, ok = event.TimeToLive()
+	assert.False(t, ok)
+}
+
+func TestReceivedEventRemainingTimeToLive(t *testing.T) {
+	msg := amqp.NewMessage([]byte("foo"))
+	msg.Header = &amqp.MessageHeader{TTL: time.Minute}
+	msg.Annotations = amqp.Annotations{
+		sequenceNumberName: int64(1),
+		enqueueTimeName:    time.Now().Add(-20 * time.Second),
+	}
+
+	event := eventFromMsg(msg)
+	ttl, ok := event.TimeToLive()
+	assert.True(t, ok)
+	assert.Equal(t, time.Minute, ttl)
+	remaining, ok := event.RemainingTimeToLive()
+	assert.True(t, ok)
+	assert.InDelta(t, float64(40*time.Second), float64(remaining), float64(time.Second))
+
+	msg.Annotations[enqueueTimeName] = time.Now().Add(-time.Hour)
+	remaining, ok = eventFromMsg(msg).RemainingTimeToLive()
+	assert.True(t, ok)
+	assert.Equal(t, time.Duration(0), remaining, "an expired event should have no time left")
+
+	msg.Properties = &amqp.MessageProperties{AbsoluteExpiryTime: time.Now().Add(10 * time.Second)}
+	remaining, ok = eventFromMsg(msg).RemainingTimeToLive()
+	assert.True(t, ok)
+	assert.InDelta(t, float64(10*time.Second), float64(remaining), float64(time.Second), "the absolute expiry time should take precedence")
+}
+
+func TestEventBatchTimeToLive(t *testing.T) {
+	short, long, none := NewEventFromString("short"), NewEventFromString("long"), NewEventFromString("none")
+	short.SetTimeToLive(time.Second)
+	long.SetTimeToLive(time.Minute)
+
+	event, err := NewEventBatch([]*Event{none, long, short}).toEvent()
+	if assert.NoError(t, err) {
+		assert.Equal(t, time.Second, event.toMsg().Header.TTL, "a batch should live no longer than its shortest lived event")
+	}
+}
@@ -0,0 +1,90 @@
+package eventhub
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"net"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/net/websocket"
+	"pack.ag/amqp"
+)
+
+const (
+	webSocketPort     = "443"
+	webSocketPath     = "/$servicebus/websocket"
+	webSocketProtocol = "AMQPWSB10"
+)
+
+// HubWithWebSocketConnection tunnels the AMQP connections of the Hub, which carry its sender, receiver and management
+// links, over WebSockets on port 443 rather than connecting to the AMQP port 5671. This allows the Hub to be used
+// where only HTTPS traffic may leave the network. Combine with HubWithWebSocketProxy to also go through a proxy.
+func HubWithWebSocketConnection() HubOption {
+	return func(h *Hub) error {
+		h.namespace.webSocket = true
+		return nil
+	}
+}
+
+// newWebSocketConnection opens an AMQP connection to the namespace tunneled over a WebSocket
+func (ns *namespace) newWebSocketConnection() (*amqp.Client, error) {
+	hostname := ns.getHostname()
+	conn, err := ns.dialTLS(webSocketPort)
+	if err != nil {
+		return nil, err
+	}
+
+	ws, err := newWebSocketConn(conn, hostname)
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	opts := append([]amqp.ConnOption{amqp.ConnServerHostname(hostname)}, ns.connectionOptions()...)
+	client, err := amqp.New(ws, opts...)
+	if err != nil {
+		_ = ws.Close()
+		return nil, err
+	}
+	return client, nil
+}
+
+// newWebSocketConn performs the WebSocket handshake with the host over an established connection
+func newWebSocketConn(conn net.Conn, hostname string) (*websocket.Conn, error) {
+	config, err := websocket.NewConfig("wss://"+hostname+webSocketPath, "https://"+hostname)
+	if err != nil {
+		return nil, err
+	}
+	config.Protocol = []string{webSocketProtocol}
+
+	_ = conn.SetDeadline(time.Now().Add(dialTimeout))
+	ws, err := websocket.NewClient(config, conn)
+	if err != nil {
+		return nil, errors.Wrapf(err, "WebSocket handshake with %s failed", hostname)
+	}
+	_ = conn.SetDeadline(time.Time{})
+
+	ws.PayloadType = websocket.BinaryFrame
+	return ws, nil
+}
@@ -0,0 +1,79 @@
+package eventhub
+
+//	MIT License
+//
+//	Copyright (c) Microsoft Corporation. All rights reserved.
+//
+//	Permission is hereby granted, free of charge, to any person obtaining a copy
+//	of this software and associated documentation files (the "Software"), to deal
+//	in the Software without restriction, including without limitation the rights
+//	to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//	copies of the Software, and to permit persons to whom the Software is
+//	furnished to do so, subject to the following conditions:
+//
+//	The above copyright notice and this permission notice shall be included in all
+//	copies or substantial portions of the Software.
+//
+//	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//	IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//	FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//	AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//	LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//	SOFTWARE
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/websocket"
+)
+
+func TestNewWebSocketConn(t *testing.T) {
+	protocols := make(chan []string, 1)
+	server := httptest.NewServer(websocket.Server{Handler: func(ws *websocket.Conn) {
+		protocols <- ws.Config().Protocol
+		_, _ = io.Copy(ws, ws)
+	}})
+	defer server.Close()
+
+	conn, err := net.Dial("tcp", server.Listener.Addr().String())
+	require.NoError(t, err)
+	ws, err := newWebSocketConn(conn, "namespace.servicebus.windows.net")
+	require.NoError(t, err)
+	defer ws.Close()
+
+	assert.Equal(t, []string{webSocketProtocol}, <-protocols)
+	_, err = ws.Write([]byte("amqp"))
+	require.NoError(t, err)
+	buf := make([]byte, 4)
+	_, err = io.ReadFull(ws, buf)
+	require.NoError(t, err)
+	assert.Equal(t, "amqp", string(buf), "AMQP frames should be carried by the WebSocket")
+}
+
+func TestNewWebSocketConnHandshakeFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	conn, err := net.Dial("tcp", server.Listener.Addr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+	_, err = newWebSocketConn(conn, "namespace.servicebus.windows.net")
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "WebSocket handshake with namespace.servicebus.windows.net failed")
+	}
+}
+
+func TestHubWithWebSocketConnection(t *testing.T) {
+	hub, err := NewHub("namespace", "hub", nil, HubWithWebSocketConnection())
+	require.NoError(t, err)
+	assert.True(t, hub.namespace.webSocket)
+}